@@ -2,21 +2,30 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/specmint/specmint/internal/config"
 	"github.com/specmint/specmint/pkg/generator"
+	"github.com/specmint/specmint/pkg/llm"
 	"github.com/specmint/specmint/pkg/schema"
+	"github.com/specmint/specmint/pkg/stats"
 	"github.com/specmint/specmint/pkg/validator"
+	"github.com/specmint/specmint/pkg/writer"
 )
 
 func newGenerateCmd() *cobra.Command {
@@ -24,12 +33,30 @@ func newGenerateCmd() *cobra.Command {
 		schemaFile string
 		outputDir  string
 		count      int
+		countExpr  string
 		seed       int64
 		llmMode    string
 		workers    int
 		llmWorkers int
 		maxRPS     int
 		timeout    string
+		extendTo   int
+		format     string
+		compress   bool
+		refs       map[string]string
+		uniqueBy   string
+		dryRun     bool
+		sample     int
+		quiet      bool
+		resume     bool
+		shardRecs  int
+		shardBytes int64
+		table      string
+		xmlRoot    string
+		xmlRecord  string
+		xmlSplit   bool
+		strict     bool
+		retries    int
 	)
 
 	cmd := &cobra.Command{
@@ -39,8 +66,25 @@ func newGenerateCmd() *cobra.Command {
 
 Examples:
   specmint generate --schema schema.json --count 1000 --seed 12345 --out ./output
-  specmint generate --schema schema.json --count 100 --llm-mode fields --workers 4`,
+  specmint generate --schema schema.json --count 100 --llm-mode fields --workers 4
+  specmint generate --schema claims.json --count-expr "500 beds * 7.5" --out ./output
+  specmint generate --schema schema.json --out ./output --extend-to 5000
+  specmint generate --schema schema.json --out ./output --resume
+  specmint generate --schema schema.json --count 1000 --out ./output --format csv
+  specmint generate --schema schema.json --count 1000 --out ./output --format sql --table patients
+  specmint generate --schema schema.json --count 1000 --out ./output --format xml --xml-root patients --xml-record patient
+  specmint generate --schema schema.json --count 1000 --out ./output --format avro
+  specmint generate --schema schema.json --count 1000 --out ./output --format parquet
+  specmint generate --schema schema.json --count 10 --out - | jq .
+  specmint generate --schema schema.json --count 1000000 --out ./output --shard-max-records 100000 --compress
+  specmint generate --schema orders.json --out ./output/orders --ref products=./output/products/dataset.jsonl
+  specmint generate --schema patients.json --count 100000 --out ./output --unique-by patient.mrn
+  specmint generate --schema patients.json --count 1000 --out ./output --strict
+  specmint generate --schema schema.json --count 10000000 --out ./output --dry-run
+  specmint generate --schema schema.json --sample 5`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// Flags parsed, so failures from here on are not usage errors
+			cmd.SilenceUsage = true
 			cfg := config.FromContext(cmd.Context())
 
 			// Override config with CLI flags
@@ -50,8 +94,18 @@ Examples:
 			if outputDir != "" {
 				cfg.Output.Directory = outputDir
 			}
+			if count > 0 && countExpr != "" {
+				return fmt.Errorf("--count and --count-expr cannot be combined")
+			}
 			if count > 0 {
 				cfg.Generation.Count = count
+				cfg.Generation.CountExpr = ""
+			}
+			if countExpr != "" {
+				cfg.Generation.CountExpr = countExpr
+				if err := cfg.Generation.ResolveCount(); err != nil {
+					return fmt.Errorf("invalid --count-expr: %w", err)
+				}
 			}
 			if seed != 0 {
 				cfg.Generation.Seed = seed
@@ -68,49 +122,211 @@ Examples:
 			if maxRPS > 0 {
 				cfg.LLM.MaxRPS = maxRPS
 			}
+			if timeout != "" {
+				d, err := time.ParseDuration(timeout)
+				if err != nil || d < 0 {
+					return fmt.Errorf("invalid --timeout %q: expected a duration such as 5m or 30s", timeout)
+				}
+				cfg.Generation.Timeout = d
+			}
+			if format != "" {
+				cfg.Output.Format = format
+			}
+			if table != "" {
+				cfg.Output.Table = table
+			}
+			if cfg.Output.Format == "sql" && cfg.Output.Table == "" {
+				return fmt.Errorf("--format sql needs a --table name")
+			}
+			if xmlRoot != "" {
+				cfg.Output.XMLRoot = xmlRoot
+			}
+			if xmlRecord != "" {
+				cfg.Output.XMLRecord = xmlRecord
+			}
+			if xmlSplit {
+				cfg.Output.XMLFilePerRecord = true
+			}
+			if compress {
+				cfg.Output.Compress = true
+			}
+			if shardRecs > 0 {
+				cfg.Output.ShardMaxRecords = shardRecs
+			}
+			if shardBytes > 0 {
+				cfg.Output.ShardMaxBytes = shardBytes
+			}
+			if len(refs) > 0 {
+				if cfg.Generation.References == nil {
+					cfg.Generation.References = make(map[string]string)
+				}
+				for recordType, path := range refs {
+					cfg.Generation.References[recordType] = path
+				}
+			}
+			if uniqueBy != "" {
+				cfg.Generation.UniqueBy = uniqueBy
+			}
+			if strict {
+				cfg.Generation.Validation = "strict"
+			}
+			if retries > 0 {
+				cfg.Generation.ValidationRetries = retries
+			}
+			if sample > 0 {
+				if dryRun || extendTo > 0 || resume {
+					return fmt.Errorf("--sample cannot be combined with --dry-run, --extend-to or --resume")
+				}
+				return runSample(cmd.Context(), cfg, sample)
+			}
+			if outputDir == "" {
+				return fmt.Errorf("required flag \"out\" not set")
+			}
+			if extendTo > 0 && resume {
+				return fmt.Errorf("--extend-to and --resume cannot be combined")
+			}
+			if cfg.Output.Stdout() {
+				if extendTo > 0 || resume {
+					return fmt.Errorf("--extend-to and --resume need an output directory, not stdout")
+				}
+				if cfg.Generation.Validation == "strict" {
+					return fmt.Errorf("--strict writes rejects.jsonl, so it needs an output directory, not stdout")
+				}
+				if err := cfg.Output.ValidateStdout(); err != nil {
+					return err
+				}
+			}
+			// The config file was validated before the flags overrode it
+			if err := cfg.Validate(); err != nil {
+				return fmt.Errorf("invalid configuration: %w", err)
+			}
+			if extendTo > 0 {
+				if err := prepareExtend(cfg, extendTo, seed != 0); err != nil {
+					return err
+				}
+			}
+			var checkpoint *generator.Checkpoint
+			if resume {
+				var err error
+				if checkpoint, err = prepareResume(cfg, seed != 0, count > 0); err != nil {
+					return err
+				}
+			}
+
+			if dryRun {
+				return runDryRun(cmd.Context(), cfg)
+			}
 
 			// Create generator
 			gen, err := generator.New(cfg)
 			if err != nil {
 				return fmt.Errorf("failed to create generator: %w", err)
 			}
+			gen.SetQuiet(quiet)
+			gen.SetBuildInfo(generator.BuildInfo{Version: version, Commit: commit})
+			if checkpoint != nil {
+				gen.SetResume(checkpoint)
+			}
+
+			// Generate dataset, within the timeout when there is one
+			ctx := cmd.Context()
+			if cfg.Generation.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, cfg.Generation.Timeout)
+				defer cancel()
+			}
+			result, err := gen.Generate(ctx)
 
-			// Generate dataset
-			result, err := gen.Generate(cmd.Context())
+			// Keep stdout clean for the dataset when it is written there
+			out := io.Writer(os.Stdout)
+			if cfg.Output.Stdout() {
+				out = os.Stderr
+			}
+			// A timeout or Ctrl-C keeps the records done and a valid manifest
+			stopped := errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+			if result != nil && stopped {
+				kept := cfg.Generation.StartIndex + result.RecordCount
+				if errors.Is(err, context.DeadlineExceeded) {
+					fmt.Fprintf(out, "⏱️  Timed out after %v, kept the first %d records\n", cfg.Generation.Timeout, kept)
+				} else {
+					fmt.Fprintf(out, "⏹️  Interrupted, kept the first %d records\n", kept)
+				}
+				if !cfg.Output.Stdout() {
+					fmt.Fprintf(out, "📊 Partial manifest: %s\n", filepath.Join(result.OutputPath, "manifest.json"))
+				}
+				if _, err := os.Stat(generator.CheckpointPath(cfg.Output.Directory)); err == nil {
+					fmt.Fprintln(out, "⏯️  Rerun with --resume to continue")
+				}
+				return nil
+			}
 			if err != nil {
 				return fmt.Errorf("generation failed: %w", err)
 			}
-
-			fmt.Printf("✅ Generated %d records in %v\n", result.RecordCount, result.Duration)
-			fmt.Printf("📁 Output: %s\n", result.OutputPath)
-			fmt.Printf("📊 Manifest: %s\n", filepath.Join(result.OutputPath, "manifest.json"))
+			fmt.Fprintf(out, "✅ Generated %d records in %v\n", result.RecordCount, result.Duration)
+			if !cfg.Output.Stdout() {
+				fmt.Fprintf(out, "📁 Output: %s\n", result.DatasetFile)
+				fmt.Fprintf(out, "📊 Manifest: %s\n", filepath.Join(result.OutputPath, "manifest.json"))
+			}
+			if result.Regenerations > 0 {
+				fmt.Fprintf(out, "🔁 Regenerated %d duplicate records to keep them unique\n", result.Regenerations)
+			}
+			if cfg.Generation.Validation == "strict" {
+				fmt.Fprintf(out, "🚫 Rejected %d invalid records: %s\n", result.FailedRecords, filepath.Join(result.OutputPath, "rejects.jsonl"))
+			}
+			if result.Retries > 0 {
+				fmt.Fprintf(out, "🔁 Regenerated invalid records %d times, %d still invalid\n", result.Retries, result.FailedRecords)
+			}
 
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&schemaFile, "schema", "s", "", "JSON Schema file path (required)")
-	cmd.Flags().StringVarP(&outputDir, "out", "o", "", "Output directory (required)")
+	cmd.Flags().StringVarP(&schemaFile, "schema", "s", "", "JSON Schema file path, .json or .yaml (required)")
+	cmd.Flags().StringVarP(&outputDir, "out", "o", "", "Output directory, or - to write the dataset to stdout (required unless --sample)")
 	cmd.Flags().IntVarP(&count, "count", "c", 0, "Number of records to generate")
+	cmd.Flags().StringVar(&countExpr, "count-expr", "", "Number of records relative to a base unit, e.g. \"500 beds * 7.5\" or \"500 beds * claims\"")
 	cmd.Flags().Int64Var(&seed, "seed", 0, "Random seed for deterministic generation")
 	cmd.Flags().StringVar(&llmMode, "llm-mode", "", "LLM enrichment mode: off, fields, record")
 	cmd.Flags().IntVar(&workers, "workers", 0, "Number of generation workers")
 	cmd.Flags().IntVar(&llmWorkers, "llm-workers", 0, "Number of LLM workers")
 	cmd.Flags().IntVar(&maxRPS, "llm-max-rps", 0, "Maximum LLM requests per second")
 	cmd.Flags().StringVar(&timeout, "timeout", "", "Generation timeout (e.g., 5m, 30s)")
+	cmd.Flags().BoolVar(&resume, "resume", false, "Continue an interrupted run from the checkpoint in the output directory")
+	cmd.Flags().IntVar(&extendTo, "extend-to", 0, "Extend an existing dataset in the output directory to this many records")
+	cmd.Flags().StringVar(&format, "format", "", "Output format: jsonl, json, csv, sql, xml, avro, parquet")
+	cmd.Flags().StringVar(&table, "table", "", "Table name for INSERT statements with --format sql")
+	cmd.Flags().StringVar(&xmlRoot, "xml-root", "", "Element wrapping all records with --format xml")
+	cmd.Flags().StringVar(&xmlRecord, "xml-record", "", "Element for each record with --format xml")
+	cmd.Flags().BoolVar(&xmlSplit, "xml-file-per-record", false, "Write each record to its own file with --format xml")
+	cmd.Flags().BoolVar(&compress, "compress", false, "Gzip the dataset file")
+	cmd.Flags().IntVar(&shardRecs, "shard-max-records", 0, "Split the dataset into files of at most this many records")
+	cmd.Flags().Int64Var(&shardBytes, "shard-max-bytes", 0, "Split the dataset into files of at most this many uncompressed bytes")
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "Do not report progress during generation")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview one record and the estimated dataset size without writing anything")
+	cmd.Flags().IntVar(&sample, "sample", 0, "Print this many records as a JSON array instead of writing a dataset, ignoring --count")
+	cmd.Flags().StringVar(&uniqueBy, "unique-by", "", "Field path that must be unique across records, or \"record\" for whole records")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Write records still invalid after patching to rejects.jsonl instead of the dataset")
+	cmd.Flags().IntVar(&retries, "validation-retries", 0, "Regenerate a record still invalid after patching up to this many times")
+	cmd.Flags().StringToStringVar(&refs, "ref", nil, "Dataset file for a record type used by x-ref fields (e.g. products=./output/products/dataset.jsonl)")
 
 	_ = cmd.MarkFlagRequired("schema")
-	_ = cmd.MarkFlagRequired("out")
 
 	return cmd
 }
 
 func newValidateCmd() *cobra.Command {
 	var (
-		schemaFile  string
-		datasetFile string
-		verbose     bool
-		rulesFile   string
+		schemaFile       string
+		datasetFile      string
+		verbose          bool
+		rulesFile        string
+		fieldMappingFile string
+		reportFile       string
+		domain           string
+		failOnError      bool
+		showWarnings     bool
+		strict           bool
+		workers          int
 	)
 
 	cmd := &cobra.Command{
@@ -120,16 +336,29 @@ func newValidateCmd() *cobra.Command {
 
 Examples:
   specmint validate --schema schema.json --dataset output/dataset.jsonl
-  specmint validate --schema schema.json --dataset output/dataset.jsonl --rules rules.json --verbose`,
+  specmint validate --schema schema.json --dataset output/dataset.jsonl --rules rules.json --verbose
+  specmint validate --schema schema.json --dataset output/dataset.jsonl --field-mapping mapping.json
+  specmint validate --schema data.json --dataset output/dataset.jsonl --domain healthcare
+  specmint validate --schema schema.json --dataset output/dataset.jsonl --warnings --verbose
+  specmint validate --schema schema.json --dataset output/dataset.jsonl --report report.json --fail-on-error`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runValidate(datasetFile, schemaFile, rulesFile, verbose)
+			// A failed validation is a result, not a usage error
+			cmd.SilenceUsage = true
+			return runValidate(datasetFile, schemaFile, rulesFile, fieldMappingFile, reportFile, domain, workers, verbose, failOnError, showWarnings, strict)
 		},
 	}
 
-	cmd.Flags().StringVarP(&schemaFile, "schema", "s", "", "JSON Schema file path (required)")
+	cmd.Flags().StringVarP(&schemaFile, "schema", "s", "", "JSON Schema file path, .json or .yaml (required)")
 	cmd.Flags().StringVarP(&datasetFile, "dataset", "d", "", "Dataset file to validate (required)")
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
 	cmd.Flags().StringVar(&rulesFile, "rules", "", "Cross-field rules file")
+	cmd.Flags().StringVar(&fieldMappingFile, "field-mapping", "", "File mapping domain rule fields to JSON pointers in the dataset")
+	cmd.Flags().StringVar(&reportFile, "report", "", "Write a JSON report of every error to this file")
+	cmd.Flags().StringVar(&domain, "domain", "", "Domain rules to apply: healthcare, fintech, ecommerce, telecom or none (default detected from the schema)")
+	cmd.Flags().IntVar(&workers, "workers", runtime.NumCPU(), "Number of validation workers")
+	cmd.Flags().BoolVar(&failOnError, "fail-on-error", false, "Exit with a nonzero status if any record fails validation")
+	cmd.Flags().BoolVar(&showWarnings, "warnings", false, "Also check and report domain rules of warning severity, without failing on them")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Treat domain warnings as errors")
 
 	_ = cmd.MarkFlagRequired("schema")
 	_ = cmd.MarkFlagRequired("dataset")
@@ -167,10 +396,44 @@ Examples:
 	return cmd
 }
 
+func newInferCmd() *cobra.Command {
+	var (
+		datasetFile string
+		outputFile  string
+		maxEnum     int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "infer",
+		Short: "Infer a JSON Schema from a sample dataset",
+		Long: `Build a JSON Schema from a JSONL sample. Field types are merged across
+records, fields present in every record are required, string fields with few
+distinct values become enums, and uuid, email, date and date-time formats are
+detected. The schema can be passed straight to generate.
+
+Examples:
+  specmint infer --dataset sample.jsonl --out schema.json
+  specmint infer --dataset sample.jsonl --max-enum 5`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInfer(datasetFile, outputFile, maxEnum)
+		},
+	}
+
+	cmd.Flags().StringVarP(&datasetFile, "dataset", "d", "", "Sample JSONL file (required)")
+	cmd.Flags().StringVarP(&outputFile, "out", "o", "", "Schema file to write (default stdout)")
+	cmd.Flags().IntVar(&maxEnum, "max-enum", schema.DefaultMaxEnum, "Most distinct values a string field may have to become an enum (0 disables enums)")
+
+	_ = cmd.MarkFlagRequired("dataset")
+
+	return cmd
+}
+
 func newDoctorCmd() *cobra.Command {
 	var (
 		full       bool
 		ollamaOnly bool
+		provider   string
+		schemaFile string
 	)
 
 	cmd := &cobra.Command{
@@ -182,14 +445,18 @@ dependencies, and LLM provider connectivity.
 Examples:
   specmint doctor
   specmint doctor --full
-  specmint doctor --ollama-only`,
+  specmint doctor --ollama-only
+  specmint doctor --provider openai
+  specmint doctor --schema schema.json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDoctor(ollamaOnly)
+			return runDoctor(config.FromContext(cmd.Context()), schemaFile, provider, ollamaOnly)
 		},
 	}
 
 	cmd.Flags().BoolVar(&full, "full", false, "Run comprehensive diagnostics")
 	cmd.Flags().BoolVar(&ollamaOnly, "ollama-only", false, "Test only Ollama connectivity")
+	cmd.Flags().StringVarP(&schemaFile, "schema", "s", "", "Schema file to check (default from config)")
+	cmd.Flags().StringVar(&provider, "provider", "", "Test only this LLM provider: ollama, openai, anthropic")
 
 	return cmd
 }
@@ -216,7 +483,7 @@ Examples:
 		},
 	}
 
-	cmd.Flags().StringVarP(&schemaFile, "schema", "s", "", "JSON Schema file path (required)")
+	cmd.Flags().StringVarP(&schemaFile, "schema", "s", "", "JSON Schema file path, .json or .yaml (required)")
 	cmd.Flags().StringVar(&counts, "counts", "100,1000", "Comma-separated record counts")
 	cmd.Flags().StringVar(&seeds, "seeds", "1,2,3", "Comma-separated seeds")
 	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file for benchmark results")
@@ -226,9 +493,436 @@ Examples:
 	return cmd
 }
 
+func newVerifyCmd() *cobra.Command {
+	var (
+		manifestFile string
+		datasetFile  string
+		schemaFile   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify a dataset by regenerating it from its manifest",
+		Long: `Regenerate a dataset from the seed, record count, schema and config in its
+manifest and compare it byte for byte with the records on disk. The first
+record that differs is reported. Fields enriched by the LLM cannot be
+regenerated and are left out of the comparison.
+
+Examples:
+  specmint verify --manifest output/manifest.json
+  specmint verify --manifest output/manifest.json --dataset output/dataset.jsonl
+  specmint verify --manifest output/manifest.json --schema schemas/patients.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerify(cmd.Context(), manifestFile, datasetFile, schemaFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&manifestFile, "manifest", "m", "", "Manifest of the dataset to verify (required)")
+	cmd.Flags().StringVarP(&datasetFile, "dataset", "d", "", "JSONL dataset to verify (default: the dataset or shards named in the manifest)")
+	cmd.Flags().StringVarP(&schemaFile, "schema", "s", "", "Schema file (default: the schema file named in the manifest)")
+
+	_ = cmd.MarkFlagRequired("manifest")
+
+	return cmd
+}
+
 // Implementation functions for all commands
 
-func runValidate(datasetFile, schemaFile, rulesFile string, verbose bool) error {
+// prepareExtend configures an append-only run that grows the dataset in the
+// output directory to extendTo records, reusing the seed from its manifest
+func prepareExtend(cfg *config.Config, extendTo int, seedSet bool) error {
+	manifestPath := filepath.Join(cfg.Output.Directory, "manifest.json")
+	manifest, err := generator.ReadManifest(manifestPath)
+	if err != nil {
+		return fmt.Errorf("cannot extend dataset: %w", err)
+	}
+
+	if manifest.Config.Output.Format != "" && manifest.Config.Output.Format != "jsonl" {
+		return fmt.Errorf("cannot extend dataset: only jsonl datasets can be extended, found %s", manifest.Config.Output.Format)
+	}
+	if manifest.Config.Generation.Validation == "strict" || cfg.Generation.Validation == "strict" {
+		return fmt.Errorf("cannot extend dataset: strict validation leaves out rejected records, so record indexes no longer match dataset lines")
+	}
+	if manifest.Config.Output.Compress {
+		return fmt.Errorf("cannot extend dataset: compressed datasets cannot be extended")
+	}
+	if filepath.Clean(manifest.SchemaFile) != filepath.Clean(cfg.Schema) {
+		return fmt.Errorf("cannot extend dataset: schema %s does not match manifest schema %s", cfg.Schema, manifest.SchemaFile)
+	}
+	if manifest.SchemaHash != "" {
+		// The same path may hold an edited schema, whose records would not
+		// belong in the existing dataset
+		parser := schema.NewParser()
+		if err := parser.ParseFile(cfg.Schema); err != nil {
+			return fmt.Errorf("cannot extend dataset: failed to parse schema: %w", err)
+		}
+		hash, err := parser.CanonicalHash()
+		if err != nil {
+			return fmt.Errorf("cannot extend dataset: %w", err)
+		}
+		if hash != manifest.SchemaHash {
+			return fmt.Errorf("cannot extend dataset: schema %s has changed since the dataset was generated (sha256 %s, manifest records %s)", cfg.Schema, hash, manifest.SchemaHash)
+		}
+	}
+	if seedSet && cfg.Generation.Seed != manifest.Seed {
+		return fmt.Errorf("cannot extend dataset: seed %d does not match manifest seed %d", cfg.Generation.Seed, manifest.Seed)
+	}
+
+	// The new records must continue the existing shard layout
+	cfg.Output.ShardMaxRecords = manifest.Config.Output.ShardMaxRecords
+	cfg.Output.ShardMaxBytes = manifest.Config.Output.ShardMaxBytes
+	existing, err := writer.CountRecords(cfg.Output)
+	if err != nil {
+		return fmt.Errorf("cannot extend dataset: %w", err)
+	}
+	if manifest.RecordCount > 0 && manifest.RecordCount != existing {
+		return fmt.Errorf("cannot extend dataset: manifest records %d records but dataset has %d", manifest.RecordCount, existing)
+	}
+	if extendTo <= existing {
+		return fmt.Errorf("cannot extend dataset: it already has %d records (requested %d)", existing, extendTo)
+	}
+
+	cfg.Generation.Seed = manifest.Seed
+	if manifest.ReferenceDate != "" {
+		cfg.Generation.ReferenceDate = manifest.ReferenceDate
+	}
+	cfg.Generation.Count = extendTo
+	cfg.Generation.StartIndex = existing
+	cfg.Output.Format = "jsonl"
+	cfg.Output.Compress = false
+	cfg.Output.Append = true
+
+	fmt.Printf("➕ Extending dataset from %d to %d records (seed %d)\n", existing, extendTo, manifest.Seed)
+
+	return nil
+}
+
+// runDryRun generates and prints the first record of the configured run and
+// estimates the dataset size, without calling the LLM or writing output
+func runDryRun(ctx context.Context, cfg *config.Config) error {
+	gen, err := generator.NewPreview(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create generator: %w", err)
+	}
+
+	preview, err := gen.Preview(ctx)
+	if err != nil {
+		return fmt.Errorf("dry run failed: %w", err)
+	}
+
+	sample, err := json.MarshalIndent(preview.Record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sample record: %w", err)
+	}
+
+	fmt.Printf("🔍 Dry run: sample record %d\n", preview.RecordIndex)
+	fmt.Println(string(sample))
+	for _, validationErr := range preview.ValidationErrors {
+		fmt.Printf("⚠️  %s\n", validationErr)
+	}
+	fmt.Printf("📏 Record size: %s\n", formatBytes(int64(preview.RecordBytes)))
+	fmt.Printf("📦 Estimated dataset size: %s for %d records (uncompressed JSONL)\n", formatBytes(preview.EstimatedBytes), preview.RecordCount)
+	if cfg.LLM.Mode != "off" {
+		fmt.Printf("ℹ️  LLM enrichment (%s mode) was skipped\n", cfg.LLM.Mode)
+	}
+	fmt.Println("✅ Schema is valid; nothing was written")
+
+	return nil
+}
+
+// runSample prints the first n records of the configured run as a JSON
+// array. Records go through LLM enrichment like a real run, but no dataset
+// or manifest is written.
+func runSample(ctx context.Context, cfg *config.Config, n int) error {
+	gen, err := generator.NewSampler(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create generator: %w", err)
+	}
+
+	records, err := gen.Sample(ctx, n)
+	if err != nil {
+		return fmt.Errorf("sampling failed: %w", err)
+	}
+
+	encoded, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sample records: %w", err)
+	}
+	fmt.Println(string(encoded))
+
+	return nil
+}
+
+// runVerify regenerates the dataset described by a manifest and compares it
+// with the dataset on disk, failing at the first record that differs
+func runVerify(ctx context.Context, manifestFile, datasetFile, schemaFile string) error {
+	manifest, err := generator.ReadManifest(manifestFile)
+	if err != nil {
+		return err
+	}
+	cfg, err := generator.ReadManifestConfig(manifestFile)
+	if err != nil {
+		return err
+	}
+	manifestDir := filepath.Dir(manifestFile)
+
+	var datasetFiles []string
+	switch {
+	case datasetFile != "":
+		datasetFiles = []string{datasetFile}
+	case manifest.Config.Output.Format != "" && manifest.Config.Output.Format != "jsonl":
+		return fmt.Errorf("only jsonl datasets can be verified, found %s", manifest.Config.Output.Format)
+	case len(manifest.Shards) > 0:
+		for _, shard := range manifest.Shards {
+			datasetFiles = append(datasetFiles, filepath.Join(manifestDir, shard.Path))
+		}
+	case manifest.DatasetFile != "":
+		datasetFiles = []string{filepath.Join(manifestDir, manifest.DatasetFile)}
+	default:
+		return fmt.Errorf("manifest %s names no dataset; pass --dataset", manifestFile)
+	}
+
+	if schemaFile == "" {
+		schemaFile = manifest.SchemaFile
+	}
+	cfg.Schema = schemaFile
+	cfg.Generation.Seed = manifest.Seed
+	cfg.Generation.Count = manifest.RecordCount
+	cfg.Generation.StartIndex = 0
+	cfg.Output.Directory = manifestDir
+	cfg.Output.Append = false
+
+	if manifest.SchemaHash == "" {
+		fmt.Println("⚠️  The manifest has no schema hash, so the schema cannot be checked")
+	}
+	if manifest.ReferenceDate == "" {
+		fmt.Println("⚠️  The manifest has no reference date, so dates are regenerated from today")
+	}
+	if manifest.Specmint.Version != "" && manifest.Specmint.Version != version {
+		fmt.Printf("⚠️  The dataset was generated by specmint %s; this is %s\n", manifest.Specmint.Version, version)
+	}
+
+	gen, err := generator.NewVerifier(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create generator: %w", err)
+	}
+	result, err := gen.Verify(ctx, manifest, datasetFiles)
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+
+	if len(result.SkippedFields) > 0 {
+		fmt.Printf("ℹ️  Skipped LLM-enriched fields: %s\n", strings.Join(result.SkippedFields, ", "))
+	}
+	if mismatch := result.Mismatch; mismatch != nil {
+		fmt.Printf("❌ Record %d (line %d): %s\n", mismatch.Index, mismatch.Index+1, mismatch.Reason)
+		if mismatch.Field != "" {
+			fmt.Printf("   Field:    %s\n", mismatch.Field)
+		}
+		if mismatch.Expected != nil || mismatch.Actual != nil {
+			expected, _ := json.Marshal(mismatch.Expected)
+			actual, _ := json.Marshal(mismatch.Actual)
+			fmt.Printf("   Expected: %s\n", expected)
+			fmt.Printf("   Actual:   %s\n", actual)
+		}
+		return fmt.Errorf("dataset does not match its manifest after %d records", result.Records)
+	}
+
+	fmt.Printf("✅ All %d records match the manifest (seed %d)\n", result.Records, manifest.Seed)
+	return nil
+}
+
+// prepareResume configures a run that continues the interrupted run whose
+// checkpoint is in the output directory. Records after the checkpoint are
+// dropped from the dataset and generated again.
+func prepareResume(cfg *config.Config, seedSet, countSet bool) (*generator.Checkpoint, error) {
+	checkpoint, err := generator.ReadCheckpoint(cfg.Output.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resume: %w", err)
+	}
+
+	if filepath.Clean(checkpoint.SchemaFile) != filepath.Clean(cfg.Schema) {
+		return nil, fmt.Errorf("cannot resume: schema %s does not match checkpoint schema %s", cfg.Schema, checkpoint.SchemaFile)
+	}
+	hash, err := generator.SchemaHash(cfg.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resume: %w", err)
+	}
+	if hash != checkpoint.SchemaHash {
+		return nil, fmt.Errorf("cannot resume: schema %s has changed since the checkpoint", cfg.Schema)
+	}
+	if seedSet && cfg.Generation.Seed != checkpoint.Seed {
+		return nil, fmt.Errorf("cannot resume: seed %d does not match checkpoint seed %d", cfg.Generation.Seed, checkpoint.Seed)
+	}
+	if countSet && cfg.Generation.Count != checkpoint.Count {
+		return nil, fmt.Errorf("cannot resume: count %d does not match checkpoint count %d", cfg.Generation.Count, checkpoint.Count)
+	}
+	if cfg.Output.Compress || (cfg.Output.Format != "" && cfg.Output.Format != "jsonl") {
+		return nil, fmt.Errorf("cannot resume: only uncompressed jsonl datasets can be resumed")
+	}
+
+	// The remaining records must continue the checkpoint's shard layout
+	cfg.Output.ShardMaxRecords = checkpoint.ShardMaxRecords
+	cfg.Output.ShardMaxBytes = checkpoint.ShardMaxBytes
+	completed := checkpoint.LastIndex + 1
+	if err := writer.TruncateRecords(cfg.Output, completed); err != nil {
+		return nil, fmt.Errorf("cannot resume: %w", err)
+	}
+
+	cfg.Generation.Seed = checkpoint.Seed
+	if checkpoint.ReferenceDate != "" {
+		cfg.Generation.ReferenceDate = checkpoint.ReferenceDate
+	}
+	cfg.Generation.Count = checkpoint.Count
+	cfg.Generation.StartIndex = completed
+	cfg.Output.Format = "jsonl"
+	cfg.Output.Append = true
+
+	fmt.Printf("⏯️  Resuming from record %d of %d (seed %d)\n", completed, checkpoint.Count, checkpoint.Seed)
+
+	return checkpoint, nil
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. 1.5 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// validationReport is the JSON document written by validate --report
+type validationReport struct {
+	Dataset string                  `json:"dataset"`
+	Schema  string                  `json:"schema"`
+	Domain  string                  `json:"domain,omitempty"`
+	Passed  bool                    `json:"passed"`
+	Summary validationSummary       `json:"summary"`
+	Errors  []recordValidationError `json:"errors"`
+}
+
+type validationSummary struct {
+	Records        int            `json:"records"`
+	InvalidRecords int            `json:"invalid_records"`
+	Errors         int            `json:"errors"`
+	Warnings       int            `json:"warnings"`
+	ErrorsByRule   map[string]int `json:"errors_by_rule"`
+}
+
+// recordValidationError locates a validation error in the dataset. Record
+// indexes count from 0, lines from 1.
+type recordValidationError struct {
+	RecordIndex int `json:"record_index"`
+	Line        int `json:"line"`
+	validator.ValidationError
+}
+
+type validationJob struct {
+	index int
+	line  []byte
+}
+
+type validationResult struct {
+	index  int
+	errors []validator.ValidationError
+}
+
+// domainCheck is the domain validation applied to each record: the rules of
+// domain at least as severe as minSeverity, with warnings promoted to errors
+// when strict
+type domainCheck struct {
+	validator   *validator.DomainValidator
+	domain      string
+	minSeverity string
+	strict      bool
+}
+
+// validateLine runs schema and domain validation on one dataset line.
+// Malformed JSON is reported as an error rather than skipped.
+func validateLine(v *validator.Validator, check domainCheck, line []byte) []validator.ValidationError {
+	var record interface{}
+	if err := json.Unmarshal(line, &record); err != nil {
+		return []validator.ValidationError{{
+			Rule:    "json",
+			Source:  "parse",
+			Message: err.Error(),
+		}}
+	}
+
+	recordErrors := v.ValidateRecord(record)
+
+	if object, ok := record.(map[string]interface{}); ok && check.domain != "" {
+		for _, err := range check.validator.ValidateDomainSeverity(check.domain, object, check.minSeverity) {
+			validationErr := validator.ValidationError{
+				Rule:     check.domain,
+				Source:   "domain",
+				Message:  err.Error(),
+				Severity: validator.SeverityError,
+			}
+			var domainErr *validator.DomainError
+			if errors.As(err, &domainErr) {
+				validationErr.Message = fmt.Sprintf("%s: %v", domainErr.Rule, domainErr.Err)
+				if domainErr.Severity == validator.SeverityWarning && !check.strict {
+					validationErr.Severity = validator.SeverityWarning
+				}
+			}
+			recordErrors = append(recordErrors, validationErr)
+		}
+	}
+
+	return recordErrors
+}
+
+// add records the errors and warnings of the record at index, printing them
+// with their severity if verbose. Only errors make a record invalid.
+func (r *validationReport) add(index int, recordErrors []validator.ValidationError, verbose bool) {
+	line := index + 1
+	invalid := false
+
+	for _, validationErr := range recordErrors {
+		warning := validationErr.Severity == validator.SeverityWarning
+		if verbose {
+			switch {
+			case validationErr.Source == "parse":
+				fmt.Printf("❌ Record %d [error]: JSON parse error: %s\n", line, validationErr.Message)
+			case warning:
+				fmt.Printf("⚠️  Record %d [warning]: %s\n", line, validationErr.Message)
+			case validationErr.Source == "domain":
+				fmt.Printf("❌ Record %d [error]: %s\n", line, validationErr.Message)
+			default:
+				fmt.Printf("❌ Record %d [error]: %s\n", line, validationErr)
+			}
+		}
+
+		if warning {
+			r.Summary.Warnings++
+		} else {
+			r.Summary.Errors++
+			invalid = true
+		}
+		r.Summary.ErrorsByRule[validationErr.Rule]++
+		r.Errors = append(r.Errors, recordValidationError{
+			RecordIndex:     index,
+			Line:            line,
+			ValidationError: validationErr,
+		})
+	}
+	if invalid {
+		r.Summary.InvalidRecords++
+	}
+}
+
+func runValidate(datasetFile, schemaFile, rulesFile, fieldMappingFile, reportFile, domainOverride string, workers int, verbose, failOnError, showWarnings, strict bool) error {
+	if workers <= 0 {
+		return fmt.Errorf("workers must be positive")
+	}
+
 	fmt.Printf("🔍 Validating dataset: %s\n", datasetFile)
 	fmt.Printf("📋 Against schema: %s\n", schemaFile)
 
@@ -243,6 +937,41 @@ func runValidate(datasetFile, schemaFile, rulesFile string, verbose bool) error
 	v := validator.New(parser)
 	domainValidator := validator.NewDomainValidator()
 
+	if rulesFile != "" {
+		rules, err := validator.LoadRules(rulesFile)
+		if err != nil {
+			return err
+		}
+		v.AddRules(rules)
+		fmt.Printf("📐 Loaded %d cross-field rules from %s\n", len(rules), rulesFile)
+	}
+
+	if fieldMappingFile != "" {
+		mapping, err := validator.LoadFieldMapping(fieldMappingFile)
+		if err != nil {
+			return err
+		}
+		if err := domainValidator.SetFieldMapping(mapping); err != nil {
+			return fmt.Errorf("invalid field mapping: %w", err)
+		}
+	}
+
+	domain, domainSource, err := resolveDomain(parser, domainValidator, schemaFile, domainOverride)
+	if err != nil {
+		return err
+	}
+	if domain != "" {
+		fmt.Printf("🏷️  Domain: %s (%s)\n", domain, domainSource)
+	} else {
+		fmt.Printf("🏷️  Domain: none (%s), domain rules skipped\n", domainSource)
+	}
+
+	// Warnings are only checked to report them, or when strict fails on them
+	check := domainCheck{validator: domainValidator, domain: domain, minSeverity: validator.SeverityError, strict: strict}
+	if showWarnings || strict {
+		check.minSeverity = validator.SeverityWarning
+	}
+
 	// Read and validate dataset
 	file, err := os.Open(datasetFile)
 	if err != nil {
@@ -250,55 +979,80 @@ func runValidate(datasetFile, schemaFile, rulesFile string, verbose bool) error
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	recordCount := 0
-	errorCount := 0
-
-	for scanner.Scan() {
-		recordCount++
-		var record map[string]interface{}
-
-		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
-			errorCount++
-			if verbose {
-				fmt.Printf("❌ Record %d: JSON parse error: %v\n", recordCount, err)
-			}
-			continue
-		}
+	report := validationReport{
+		Dataset: datasetFile,
+		Schema:  schemaFile,
+		Domain:  domain,
+		Summary: validationSummary{ErrorsByRule: make(map[string]int)},
+		Errors:  []recordValidationError{},
+	}
 
-		// Schema validation
-		errors := v.ValidateRecord(record)
-		if len(errors) > 0 {
-			errorCount += len(errors)
-			if verbose {
-				for _, validationErr := range errors {
-					fmt.Printf("❌ Record %d: %s\n", recordCount, validationErr)
+	// The scanner feeds workers; results are collected back in record order
+	jobs := make(chan validationJob, workers*2)
+	results := make(chan validationResult, workers*2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results <- validationResult{
+					index:  job.index,
+					errors: validateLine(v, check, job.line),
 				}
 			}
-		}
+		}()
+	}
 
-		// Domain validation
-		domain := detectDomain(schemaFile)
-		if domain != "" {
-			domainErrors := domainValidator.ValidateDomain(domain, record)
-			if len(domainErrors) > 0 {
-				errorCount += len(domainErrors)
-				if verbose {
-					for _, err := range domainErrors {
-						fmt.Printf("⚠️  Record %d: %v\n", recordCount, err)
-					}
-				}
+	recordCount := 0
+	var scanErr error
+	go func() {
+		defer close(jobs)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			// The scanner reuses its buffer, so each job needs its own copy
+			line := append([]byte(nil), scanner.Bytes()...)
+			jobs <- validationJob{index: recordCount, line: line}
+			recordCount++
+		}
+		scanErr = scanner.Err()
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int][]validator.ValidationError)
+	next := 0
+	for result := range results {
+		pending[result.index] = result.errors
+		for {
+			recordErrors, ok := pending[next]
+			if !ok {
+				break
 			}
+			delete(pending, next)
+			report.add(next, recordErrors, verbose)
+			next++
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading dataset: %w", err)
+	if scanErr != nil {
+		return fmt.Errorf("error reading dataset: %w", scanErr)
 	}
 
+	errorCount := report.Summary.Errors
+	report.Summary.Records = recordCount
+	report.Passed = errorCount == 0
+
 	fmt.Printf("📊 Validation Results:\n")
 	fmt.Printf("   Records processed: %d\n", recordCount)
 	fmt.Printf("   Validation errors: %d\n", errorCount)
+	if showWarnings && !strict {
+		fmt.Printf("   Warnings: %d\n", report.Summary.Warnings)
+	}
 
 	if errorCount == 0 {
 		fmt.Println("✅ All records passed validation")
@@ -306,9 +1060,31 @@ func runValidate(datasetFile, schemaFile, rulesFile string, verbose bool) error
 		fmt.Printf("⚠️  %d validation issues found\n", errorCount)
 	}
 
+	if reportFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		if err := os.WriteFile(reportFile, append(data, '\n'), 0600); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		fmt.Printf("📝 Report: %s\n", reportFile)
+	}
+
+	if failOnError && errorCount > 0 {
+		return fmt.Errorf("validation failed: %d issues in %d of %d records", errorCount, report.Summary.InvalidRecords, recordCount)
+	}
+
 	return nil
 }
 
+// Limits for the distribution statistics of inspect --detailed
+const (
+	inspectReservoirSize = 10000
+	inspectMaxCategories = 1000
+	inspectTopValues     = 5
+)
+
 func runInspect(datasetFile, outputFormat string, detailed bool) error {
 	fmt.Printf("🔍 Inspecting dataset: %s\n", datasetFile)
 
@@ -321,6 +1097,10 @@ func runInspect(datasetFile, outputFormat string, detailed bool) error {
 	scanner := bufio.NewScanner(file)
 	recordCount := 0
 	fieldStats := make(map[string]int)
+	arrayItems := make(map[string]int)
+	arrayCounts := make(map[string]int)
+	numericStats := make(map[string]*stats.Numeric)
+	categoricalStats := make(map[string]*stats.Categorical)
 
 	for scanner.Scan() {
 		recordCount++
@@ -330,22 +1110,67 @@ func runInspect(datasetFile, outputFormat string, detailed bool) error {
 			continue
 		}
 
-		// Count fields
-		for field := range record {
-			fieldStats[field]++
-		}
+		// Count each leaf path once per record
+		seen := make(map[string]bool)
+		writer.Flatten(record, func(path string, value interface{}) {
+			switch v := value.(type) {
+			case []interface{}:
+				arrayItems[path] += len(v)
+				arrayCounts[path]++
+			case float64:
+				if detailed {
+					if numericStats[path] == nil {
+						numericStats[path] = stats.NewNumeric(inspectReservoirSize)
+					}
+					numericStats[path].Add(v)
+				}
+			case string, bool:
+				if detailed {
+					if categoricalStats[path] == nil {
+						categoricalStats[path] = stats.NewCategorical(inspectMaxCategories)
+					}
+					categoricalStats[path].Add(fmt.Sprint(v))
+				}
+			}
+			if !seen[path] {
+				seen[path] = true
+				fieldStats[path]++
+			}
+		})
 	}
 
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("error reading dataset: %w", err)
 	}
 
+	arrayLengths := make(map[string]float64, len(arrayCounts))
+	for path, count := range arrayCounts {
+		arrayLengths[path] = float64(arrayItems[path]) / float64(count)
+	}
+
 	// Output results
 	switch outputFormat {
 	case "json":
 		result := map[string]interface{}{
-			"record_count": recordCount,
-			"field_stats":  fieldStats,
+			"record_count":  recordCount,
+			"field_stats":   fieldStats,
+			"array_lengths": arrayLengths,
+		}
+		if detailed {
+			numeric := make(map[string]stats.NumericSummary, len(numericStats))
+			for path, acc := range numericStats {
+				numeric[path] = acc.Summary()
+			}
+			categorical := make(map[string]interface{}, len(categoricalStats))
+			for path, acc := range categoricalStats {
+				categorical[path] = map[string]interface{}{
+					"count":       acc.Total(),
+					"top_values":  acc.Top(inspectTopValues),
+					"approximate": acc.Approximate(),
+				}
+			}
+			result["numeric_stats"] = numeric
+			result["categorical_stats"] = categorical
 		}
 		jsonBytes, _ := json.MarshalIndent(result, "", "  ")
 		fmt.Println(string(jsonBytes))
@@ -355,11 +1180,25 @@ func runInspect(datasetFile, outputFormat string, detailed bool) error {
 		fmt.Printf("   Fields found: %d\n", len(fieldStats))
 
 		if detailed {
+			fields := make([]string, 0, len(fieldStats))
+			for field := range fieldStats {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+
 			fmt.Println("\n📋 Field Coverage:")
-			for field, count := range fieldStats {
+			for _, field := range fields {
+				count := fieldStats[field]
 				coverage := float64(count) / float64(recordCount) * 100
-				fmt.Printf("   %s: %d records (%.1f%%)\n", field, count, coverage)
+				if avgLen, ok := arrayLengths[field]; ok {
+					fmt.Printf("   %s: %d records (%.1f%%), avg length %.1f\n", field, count, coverage, avgLen)
+				} else {
+					fmt.Printf("   %s: %d records (%.1f%%)\n", field, count, coverage)
+				}
 			}
+
+			printNumericStats(numericStats)
+			printCategoricalStats(categoricalStats)
 		}
 	}
 
@@ -367,25 +1206,159 @@ func runInspect(datasetFile, outputFormat string, detailed bool) error {
 	return nil
 }
 
-func runDoctor(ollamaOnly bool) error {
+// printNumericStats prints the distribution of each numeric field
+func printNumericStats(numericStats map[string]*stats.Numeric) {
+	if len(numericStats) == 0 {
+		return
+	}
+
+	fmt.Println("\n📈 Numeric Fields:")
+	for _, field := range sortedKeys(numericStats) {
+		s := numericStats[field].Summary()
+		fmt.Printf("   %s: min %g, max %g, mean %.2f, stddev %.2f, p50 %.2f, p90 %.2f, p99 %.2f\n",
+			field, s.Min, s.Max, s.Mean, s.StdDev, s.P50, s.P90, s.P99)
+	}
+}
+
+// printCategoricalStats prints the most frequent values of each string or
+// boolean field
+func printCategoricalStats(categoricalStats map[string]*stats.Categorical) {
+	if len(categoricalStats) == 0 {
+		return
+	}
+
+	fmt.Println("\n🏷️  Top Values:")
+	for _, field := range sortedKeys(categoricalStats) {
+		acc := categoricalStats[field]
+		values := make([]string, 0, inspectTopValues)
+		for _, vc := range acc.Top(inspectTopValues) {
+			share := float64(vc.Count) / float64(acc.Total()) * 100
+			values = append(values, fmt.Sprintf("%q %.1f%%", vc.Value, share))
+		}
+		note := ""
+		if acc.Approximate() {
+			note = " (approximate)"
+		}
+		fmt.Printf("   %s%s: %s\n", field, note, strings.Join(values, ", "))
+	}
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runInfer infers a schema from every record of datasetFile
+func runInfer(datasetFile, outputFile string, maxEnum int) error {
+	file, err := os.Open(datasetFile)
+	if err != nil {
+		return fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer file.Close()
+
+	inferrer := schema.NewInferrer(maxEnum)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	records := 0
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return fmt.Errorf("invalid JSON on line %d: %w", lineNum, err)
+		}
+		inferrer.Add(record)
+		records++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading dataset: %w", err)
+	}
+	if records == 0 {
+		return fmt.Errorf("no records in %s", datasetFile)
+	}
+
+	data, err := json.MarshalIndent(inferrer.Schema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	data = append(data, '\n')
+
+	if outputFile == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(outputFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	fmt.Printf("📝 Inferred schema from %d records: %s\n", records, outputFile)
+	return nil
+}
+
+func runDoctor(cfg *config.Config, schemaFile, provider string, ollamaOnly bool) error {
+	switch provider {
+	case "", "ollama", "openai", "anthropic":
+	default:
+		return fmt.Errorf("unknown provider %q (use ollama, openai or anthropic)", provider)
+	}
+	if ollamaOnly {
+		provider = "ollama"
+	}
+
 	fmt.Println("🏥 Running system diagnostics...")
 
 	allGood := true
 
 	// Check Ollama connection
-	fmt.Print("🤖 Checking Ollama connection... ")
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get("http://localhost:11434/api/version")
-	if err != nil {
-		fmt.Println("❌ Failed")
-		fmt.Printf("   Error: %v\n", err)
-		allGood = false
-	} else {
-		_ = resp.Body.Close()
-		fmt.Println("✅ Connected")
+	if provider == "" || provider == "ollama" {
+		fmt.Print("🤖 Checking Ollama connection... ")
+		client := &http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Get("http://localhost:11434/api/version")
+		if err != nil {
+			fmt.Println("❌ Failed")
+			fmt.Printf("   Error: %v\n", err)
+			allGood = false
+		} else {
+			_ = resp.Body.Close()
+			fmt.Println("✅ Connected")
+		}
+	}
+
+	// Check cloud providers that are configured or have a key, or the one
+	// asked for
+	if provider == "openai" || (provider == "" && (cfg.LLM.Provider == "openai" || cfg.LLM.OpenAI.APIKey != "")) {
+		fmt.Print("☁️  Checking OpenAI API... ")
+		if !checkProvider("OPENAI_API_KEY", cfg.LLM.OpenAI.APIKey, func(ctx context.Context) error {
+			client, err := llm.NewOpenAIClient(llm.OpenAIConfig{
+				APIKey:  cfg.LLM.OpenAI.APIKey,
+				Model:   cfg.LLM.OpenAI.Model,
+				Timeout: doctorTimeout,
+			})
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			return client.HealthCheck(ctx)
+		}) {
+			allGood = false
+		}
+	}
+	if provider == "anthropic" || (provider == "" && (cfg.LLM.Provider == "anthropic" || cfg.LLM.Anthropic.APIKey != "")) {
+		fmt.Print("☁️  Checking Anthropic API... ")
+		if !checkProvider("ANTHROPIC_API_KEY", cfg.LLM.Anthropic.APIKey, func(ctx context.Context) error {
+			return llm.PingAnthropic(ctx, &http.Client{Timeout: doctorTimeout}, cfg.LLM.Anthropic.APIKey)
+		}) {
+			allGood = false
+		}
 	}
 
-	if !ollamaOnly {
+	if provider == "" {
 		// Check schema directory
 		fmt.Print("📋 Checking schema directory... ")
 		if _, err := os.Stat("test/schemas"); err != nil {
@@ -404,6 +1377,14 @@ func runDoctor(ollamaOnly bool) error {
 			fmt.Println("✅ Ready")
 		}
 
+		// Check the schema a run would use
+		if schemaFile == "" {
+			schemaFile = cfg.Schema
+		}
+		if schemaFile != "" && !checkSchema(schemaFile) {
+			allGood = false
+		}
+
 		// Check Go version
 		fmt.Print("🔧 Checking Go environment... ")
 		fmt.Println("✅ Go 1.21+")
@@ -418,6 +1399,121 @@ func runDoctor(ollamaOnly bool) error {
 	return nil
 }
 
+// checkSchema parses a schema as generate would and reports its shape and
+// anything generation will not honor. It returns false if the schema is
+// unusable or has such constructs.
+func checkSchema(schemaFile string) bool {
+	fmt.Printf("📄 Checking schema %s... ", schemaFile)
+	parser := schema.NewParser()
+	err := parser.ParseFile(schemaFile)
+	var root *schema.SchemaNode
+	if err == nil {
+		root, err = parser.GetRootNode()
+	}
+	if err != nil {
+		fmt.Println("❌ Invalid")
+		fmt.Printf("   Error: %v\n", err)
+		return false
+	}
+	fmt.Println("✅ Parsed")
+
+	rootType := root.Type
+	if record := root.RecordNode(); record != root {
+		rootType = "array of " + record.Type
+	}
+	if rootType == "" {
+		rootType = "none"
+	}
+	var summary schemaSummary
+	summary.walk(root)
+	fmt.Printf("   Root type: %s\n", rootType)
+	fmt.Printf("   Properties: %d (%d LLM fields)\n", summary.properties, summary.llmFields)
+
+	ok := true
+	for _, warning := range parser.Warnings() {
+		fmt.Printf("   ⚠️  %s: %s\n", displayPath(warning.Path), warning.Message)
+		ok = false
+	}
+	for _, path := range summary.untyped {
+		fmt.Printf("   ⚠️  %s: no type, enum or const; strings will be generated\n", displayPath(path))
+		ok = false
+	}
+	return ok
+}
+
+// displayPath names the root of a schema path, which is empty
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
+
+// schemaSummary counts what doctor reports about a schema
+type schemaSummary struct {
+	properties int
+	llmFields  int
+	untyped    []string // paths of nodes with nothing to say what to generate
+}
+
+func (s *schemaSummary) walk(node *schema.SchemaNode) {
+	if node == nil {
+		return
+	}
+	if node.LLMEnhanced {
+		s.llmFields++
+	}
+	if node.Type == "" && len(node.Types) == 0 && !node.HasConst && len(node.Enum) == 0 &&
+		len(node.Variants) == 0 && len(node.Examples) == 0 && node.Properties == nil && node.Items == nil {
+		s.untyped = append(s.untyped, node.Path)
+	}
+
+	names := make([]string, 0, len(node.Properties))
+	for name := range node.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s.properties += len(names)
+	for _, name := range names {
+		s.walk(node.Properties[name])
+	}
+	s.walk(node.Items)
+	for _, item := range node.TupleItems {
+		s.walk(item)
+	}
+	for _, variant := range node.Variants {
+		s.walk(variant)
+	}
+	for _, pp := range node.PatternProperties {
+		s.walk(pp.Node)
+	}
+	s.walk(node.AdditionalProperties)
+}
+
+// doctorTimeout bounds each provider check so an unreachable API cannot
+// hang doctor
+const doctorTimeout = 10 * time.Second
+
+// checkProvider reports on a cloud provider: a missing key is reported with
+// the variable to set, otherwise ping is called with a bounded context
+func checkProvider(envVar, apiKey string, ping func(ctx context.Context) error) bool {
+	if apiKey == "" {
+		fmt.Println("❌ No API key")
+		fmt.Printf("   Set %s to use this provider\n", envVar)
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	if err := ping(ctx); err != nil {
+		fmt.Println("❌ Failed")
+		fmt.Printf("   Error: %v\n", err)
+		return false
+	}
+	fmt.Println("✅ Connected")
+	return true
+}
+
 func runBenchmark(schemaFile, counts, seeds string) error {
 	fmt.Printf("🏃 Running benchmarks with schema: %s\n", schemaFile)
 
@@ -463,6 +1559,38 @@ func runBenchmark(schemaFile, counts, seeds string) error {
 	return nil
 }
 
+// resolveDomain picks the domain whose rules validate apply: the override
+// when given, else the one the schema's field names point to, else a guess
+// from the schema file name. It also says where the domain came from.
+func resolveDomain(parser *schema.Parser, dv *validator.DomainValidator, schemaFile, override string) (string, string, error) {
+	switch {
+	case override == "none":
+		return "", "turned off with --domain none", nil
+	case override != "":
+		if !dv.HasDomain(override) {
+			return "", "", fmt.Errorf("unknown domain %q: expected healthcare, fintech, ecommerce, telecom or none", override)
+		}
+		return override, "from --domain", nil
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get root schema node: %w", err)
+	}
+	if detection := validator.DetectDomain(root.RecordNode()); detection.Domain != "" {
+		fields := strings.Join(detection.Fields, ", ")
+		if len(detection.Fields) > 5 {
+			fields = fmt.Sprintf("%s and %d more", strings.Join(detection.Fields[:5], ", "), len(detection.Fields)-5)
+		}
+		return detection.Domain, fmt.Sprintf("detected from fields %s; override with --domain", fields), nil
+	}
+	if domain := detectDomain(schemaFile); domain != "" {
+		return domain, "guessed from the schema file name; override with --domain", nil
+	}
+	return "", "not detected; set one with --domain", nil
+}
+
+// detectDomain guesses a domain from words in the schema file name
 func detectDomain(schemaFile string) string {
 	schemaFile = strings.ToLower(schemaFile)
 	if strings.Contains(schemaFile, "healthcare") || strings.Contains(schemaFile, "patient") {
@@ -474,5 +1602,8 @@ func detectDomain(schemaFile string) string {
 	if strings.Contains(schemaFile, "ecommerce") || strings.Contains(schemaFile, "product") {
 		return "ecommerce"
 	}
+	if strings.Contains(schemaFile, "telecom") || strings.Contains(schemaFile, "call-detail") || strings.Contains(schemaFile, "subscriber") {
+		return "telecom"
+	}
 	return ""
 }
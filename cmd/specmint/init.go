@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/specmint/specmint/internal/config"
+)
+
+const (
+	initConfigFile = "specmint.yaml"
+	initSchemaFile = "schema.json"
+)
+
+func newInitCmd() *cobra.Command {
+	var (
+		domain string
+		force  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create a starter specmint.yaml and example schema",
+		Long: `Write a commented specmint.yaml with the default settings and an example
+JSON Schema into the current directory. Existing files are left alone
+unless --force is given.
+
+Examples:
+  specmint init
+  specmint init --domain healthcare
+  specmint init --domain fintech --force`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInit(domain, force)
+		},
+	}
+
+	cmd.Flags().StringVar(&domain, "domain", "", "Starter schema domain: healthcare, fintech, ecommerce")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite existing files")
+
+	return cmd
+}
+
+func runInit(domain string, force bool) error {
+	schemaJSON, ok := starterSchemas[domain]
+	if !ok {
+		return fmt.Errorf("unknown domain %q: expected healthcare, fintech or ecommerce", domain)
+	}
+
+	if !force {
+		for _, path := range []string{initConfigFile, initSchemaFile} {
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to check %s: %w", path, err)
+			}
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Schema = initSchemaFile
+	configYAML, err := commentedConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	if err := os.WriteFile(initConfigFile, configYAML, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", initConfigFile, err)
+	}
+	if err := os.WriteFile(initSchemaFile, []byte(schemaJSON), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", initSchemaFile, err)
+	}
+
+	fmt.Printf("📝 Wrote %s\n", initConfigFile)
+	fmt.Printf("📝 Wrote %s\n", initSchemaFile)
+	fmt.Printf("👉 Next: specmint generate --schema %s --out ./output\n", initSchemaFile)
+	return nil
+}
+
+// configComments documents the settings written by init, keyed by their
+// dotted YAML path
+var configComments = map[string]string{
+	"debug":                         "Enable debug logging",
+	"schema":                        "JSON Schema to generate records from",
+	"generation":                    "Deterministic record generation",
+	"generation.count":              "Number of records to generate",
+	"generation.count_expr":         "Count relative to a base unit instead of count, e.g. \"500 beds * 7.5\" or \"500 beds * claims\"",
+	"generation.seed":               "Random seed; the same seed and schema always give the same records",
+	"generation.workers":            "Number of generation workers",
+	"generation.timeout":            "Stop the run after this long, keeping the records done; 0 for no limit",
+	"generation.start_index":        "First record index to generate",
+	"generation.null_probability":   "Chance that a nullable field is null",
+	"generation.timezone":           "IANA timezone used to render dates, e.g. UTC or Europe/Berlin",
+	"generation.locale":             "Locale of x-generator names, addresses and phones: en-US, en-GB, de-DE or fr-FR",
+	"generation.reference_date":     "UTC day generated dates count back from, e.g. 2024-06-30; empty for the day the run starts",
+	"generation.unique_by":          "Field path that must be unique across records, or \"record\"",
+	"generation.checkpoint_every":   "Records written between checkpoints that --resume continues from; 0 disables them",
+	"generation.validation_retries": "Times a record still invalid after patching is regenerated from a new seed",
+	"generation.validation":         "Either keep, which writes every record, or strict, which writes records still invalid after patching to rejects.jsonl instead",
+	"generation.references":         "Dataset files for record types named in x-ref, e.g. products: ./output/products/dataset.jsonl",
+	"llm":                           "Optional LLM enrichment of x-llm fields",
+	"llm.mode":                      "off, fields or record",
+	"llm.provider":                  "auto, ollama, openai or anthropic",
+	"llm.max_rps":                   "Maximum LLM requests per second",
+	"llm.ollama.stream":             "Read responses as they are generated",
+	"llm.openai.api_key":            "Prefer the OPENAI_API_KEY environment variable",
+	"llm.anthropic.api_key":         "Prefer the ANTHROPIC_API_KEY environment variable",
+	"llm.budget.max_cost_usd":       "Stop enriching once the estimated spend reaches this amount",
+	"llm.budget.warn_threshold":     "Warn when this fraction of the budget is spent",
+	"llm.cache_enabled":             "Reuse responses to repeated prompts with the same seed",
+	"llm.cache_file":                "Keep the response cache in this file between runs",
+	"output":                        "Where and how datasets are written",
+	"output.format":                 "jsonl, json, csv, sql, xml, avro or parquet",
+	"output.manifest":               "Write manifest.json next to the dataset",
+	"output.compress":               "Gzip the dataset file",
+	"output.shard_max_records":      "Split the dataset into dataset-00001.jsonl, ... of at most this many records; 0 for one file",
+	"output.shard_max_bytes":        "Split the dataset into files of at most this many uncompressed bytes; 0 for no limit",
+	"output.append":                 "Append to an existing dataset (jsonl only)",
+	"output.table":                  "Table that sql output inserts into",
+	"output.xml_root":               "Element wrapping all records in xml output",
+	"output.xml_record":             "Element for each record in xml output",
+	"output.xml_file_per_record":    "Write each xml record to its own file instead of dataset.xml",
+	"logging.format":                "json or text; empty logs text to the console and JSON to a file",
+	"metrics":                       "Prometheus metrics endpoint, served while generating",
+}
+
+// commentedConfig encodes cfg as YAML with configComments attached to
+// their keys
+func commentedConfig(cfg *config.Config) ([]byte, error) {
+	var root yaml.Node
+	if err := root.Encode(cfg); err != nil {
+		return nil, err
+	}
+	root.HeadComment = "SpecMint configuration. Command-line flags override these settings."
+	annotateConfig(&root, "")
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// annotateConfig sets the comment of every mapping key under node that has
+// an entry in configComments
+func annotateConfig(node *yaml.Node, prefix string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := key.Value
+		if prefix != "" {
+			path = prefix + "." + key.Value
+		}
+		if comment := configComments[path]; comment != "" {
+			if value.Kind == yaml.MappingNode {
+				key.HeadComment = comment
+			} else {
+				key.LineComment = comment
+			}
+		}
+		annotateConfig(value, path)
+	}
+}
+
+// starterSchemas holds the example schema written by init for each domain.
+// Each shows an x-llm field and an x-cross-field-rules entry.
+var starterSchemas = map[string]string{
+	"": strings.TrimLeft(`
+{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Customer",
+  "type": "object",
+  "properties": {
+    "customer_id": {
+      "type": "string",
+      "format": "uuid"
+    },
+    "email": {
+      "type": "string",
+      "format": "email"
+    },
+    "signup_date": {
+      "type": "string",
+      "format": "date"
+    },
+    "last_order_date": {
+      "type": "string",
+      "format": "date"
+    },
+    "bio": {
+      "type": "string",
+      "maxLength": 200,
+      "x-llm": true,
+      "description": "llm: Write a one-sentence customer bio"
+    }
+  },
+  "required": ["customer_id", "email", "signup_date", "last_order_date"],
+  "x-cross-field-rules": [
+    {
+      "name": "order_after_signup",
+      "rule": "date_ordering",
+      "fields": ["signup_date", "last_order_date"],
+      "severity": "error"
+    }
+  ]
+}
+`, "\n"),
+
+	"healthcare": strings.TrimLeft(`
+{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Patient Encounter",
+  "type": "object",
+  "properties": {
+    "patient_id": {
+      "type": "string",
+      "pattern": "^PT[0-9]{8}$"
+    },
+    "date_of_birth": {
+      "type": "string",
+      "format": "date"
+    },
+    "admission_date": {
+      "type": "string",
+      "format": "date"
+    },
+    "discharge_date": {
+      "type": "string",
+      "format": "date"
+    },
+    "icd10_code": {
+      "type": "string",
+      "pattern": "^[A-Z][0-9]{2}\\.[0-9]{1,2}$"
+    },
+    "clinical_note": {
+      "type": "string",
+      "maxLength": 300,
+      "x-llm": true,
+      "description": "llm: Write a brief clinical note consistent with the diagnosis code"
+    }
+  },
+  "required": ["patient_id", "date_of_birth", "admission_date", "discharge_date", "icd10_code"],
+  "x-cross-field-rules": [
+    {
+      "name": "encounter_dates",
+      "rule": "date_ordering",
+      "fields": ["date_of_birth", "admission_date", "discharge_date"],
+      "severity": "error"
+    }
+  ]
+}
+`, "\n"),
+
+	"fintech": strings.TrimLeft(`
+{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Card Transaction",
+  "type": "object",
+  "properties": {
+    "transaction_id": {
+      "type": "string",
+      "pattern": "^TXN-[0-9]{10}$"
+    },
+    "amount": {
+      "type": "number",
+      "minimum": 1,
+      "maximum": 5000,
+      "multipleOf": 0.01
+    },
+    "fee": {
+      "type": "number",
+      "minimum": 0,
+      "maximum": 50,
+      "multipleOf": 0.01
+    },
+    "currency": {
+      "type": "string",
+      "enum": ["USD", "EUR", "GBP"]
+    },
+    "merchant_description": {
+      "type": "string",
+      "maxLength": 80,
+      "x-llm": true,
+      "description": "llm: Write a realistic merchant statement descriptor"
+    }
+  },
+  "required": ["transaction_id", "amount", "fee", "currency"],
+  "x-cross-field-rules": [
+    {
+      "name": "fee_below_amount",
+      "rule": "comparison",
+      "fields": ["fee", "amount"],
+      "constraint": "fee <= amount",
+      "severity": "error"
+    }
+  ]
+}
+`, "\n"),
+
+	"ecommerce": strings.TrimLeft(`
+{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Product",
+  "type": "object",
+  "properties": {
+    "sku": {
+      "type": "string",
+      "pattern": "^[A-Z]{2}[0-9]{6}$"
+    },
+    "name": {
+      "type": "string",
+      "maxLength": 60,
+      "x-llm": true,
+      "description": "llm: Write a realistic product name"
+    },
+    "base_price": {
+      "type": "number",
+      "minimum": 5,
+      "maximum": 500,
+      "multipleOf": 0.01
+    },
+    "sale_price": {
+      "type": "number",
+      "minimum": 1,
+      "maximum": 500,
+      "multipleOf": 0.01
+    },
+    "stock_quantity": {
+      "type": "integer",
+      "minimum": 0,
+      "maximum": 1000
+    }
+  },
+  "required": ["sku", "name", "base_price", "sale_price", "stock_quantity"],
+  "x-cross-field-rules": [
+    {
+      "name": "sale_below_base",
+      "rule": "comparison",
+      "fields": ["sale_price", "base_price"],
+      "constraint": "sale_price < base_price",
+      "severity": "error"
+    }
+  ]
+}
+`, "\n"),
+}
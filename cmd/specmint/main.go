@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
+	zlog "github.com/rs/zerolog/log"
 	"github.com/specmint/specmint/internal/config"
 	"github.com/specmint/specmint/internal/logger"
 	"github.com/spf13/cobra"
@@ -16,7 +20,7 @@ var (
 )
 
 func main() {
-	ctx := context.Background()
+	ctx := interruptContext()
 
 	// Initialize logger
 	log := logger.New()
@@ -31,14 +35,16 @@ seeded generation and optional LLM enrichment via local Ollama or cloud provider
 
 	// Add global flags
 	var configFile string
+	var profile string
 	var debug bool
 
-	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file (default is specmint.yaml)")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file, YAML or JSON (default is specmint.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "config profile to overlay on the base settings (default $SPECMINT_PROFILE)")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug logging")
 
 	// Initialize config
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		cfg, err := config.Load(configFile)
+		cfg, err := config.Load(configFile, profile)
 		if err != nil {
 			return err
 		}
@@ -47,10 +53,25 @@ seeded generation and optional LLM enrichment via local Ollama or cloud provider
 			cfg.Debug = true
 		}
 
-		// Update logger level
+		// Pick the logger for the configured file, format and level;
+		// --debug wins over the configured level
+		level := cfg.Logging.Level
 		if cfg.Debug {
-			log = logger.WithLevel("debug")
+			level = "debug"
 		}
+		switch {
+		case cfg.Logging.File != "":
+			fileLog, err := logger.WithFile(cfg.Logging.File, cfg.Logging.Format, level)
+			if err != nil {
+				return fmt.Errorf("failed to open log file %s: %w", cfg.Logging.File, err)
+			}
+			log = fileLog
+		case cfg.Logging.Format == "json":
+			log = logger.WithJSON(level)
+		default:
+			log = logger.WithLevel(level)
+		}
+		zlog.Logger = log
 
 		// Store config in context
 		ctx = config.WithContext(ctx, cfg)
@@ -64,9 +85,12 @@ seeded generation and optional LLM enrichment via local Ollama or cloud provider
 		newGenerateCmd(),
 		newValidateCmd(),
 		newInspectCmd(),
+		newInferCmd(),
 		newDoctorCmd(),
 		newBenchmarkCmd(),
+		newInitCmd(),
 		newSimulateCmd(),
+		newVerifyCmd(),
 	)
 
 	if err := rootCmd.ExecuteContext(ctx); err != nil {
@@ -74,3 +98,20 @@ seeded generation and optional LLM enrichment via local Ollama or cloud provider
 		os.Exit(1)
 	}
 }
+
+// interruptContext returns a context cancelled by the first SIGINT or
+// SIGTERM, so a run can finish its in-flight records and write what it has.
+// A second signal exits immediately.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		fmt.Fprintln(os.Stderr, "\n⏹️  Interrupted, finishing in-flight records (press Ctrl-C again to exit now)")
+		cancel()
+		<-signals
+		os.Exit(130)
+	}()
+	return ctx
+}
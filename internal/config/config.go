@@ -1,12 +1,20 @@
 package config
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/specmint/specmint/pkg/population"
 )
 
 type contextKey string
@@ -25,10 +33,24 @@ type Config struct {
 }
 
 type Generation struct {
-	Count   int           `yaml:"count" json:"count"`
-	Seed    int64         `yaml:"seed" json:"seed"`
-	Workers int           `yaml:"workers" json:"workers"`
-	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+	Count             int           `yaml:"count" json:"count"`
+	CountExpr         string        `yaml:"count_expr" json:"count_expr,omitempty"` // count relative to a base unit, e.g. "500 beds * 7.5"; overrides count
+	Seed              int64         `yaml:"seed" json:"seed"`
+	Workers           int           `yaml:"workers" json:"workers"`
+	Timeout           time.Duration `yaml:"timeout" json:"timeout"`
+	StartIndex        int           `yaml:"start_index" json:"start_index"`               // first record index to generate
+	NullProbability   float64       `yaml:"null_probability" json:"null_probability"`     // chance that a nullable field is null
+	Timezone          string        `yaml:"timezone" json:"timezone"`                     // IANA name used to render dates, e.g. UTC or Europe/Berlin
+	Locale            string        `yaml:"locale" json:"locale"`                         // locale of synthetic names, addresses and phones, e.g. en-US or de-DE
+	ReferenceDate     string        `yaml:"reference_date" json:"reference_date"`         // UTC day generated dates count back from, as YYYY-MM-DD; empty for the day the run starts
+	UniqueBy          string        `yaml:"unique_by" json:"unique_by"`                   // field path that must be unique across records, or "record"
+	CheckpointEvery   int           `yaml:"checkpoint_every" json:"checkpoint_every"`     // records written between checkpoints; 0 disables checkpoints
+	Validation        string        `yaml:"validation" json:"validation"`                 // keep writes every record; strict moves records still invalid after patching to rejects.jsonl
+	ValidationRetries int           `yaml:"validation_retries" json:"validation_retries"` // times a record still invalid after patching is regenerated from a new seed
+
+	// References maps record types named in x-ref to their dataset files.
+	// Types not listed are looked up in a sibling of the output directory.
+	References map[string]string `yaml:"references" json:"references"`
 }
 
 type LLM struct {
@@ -41,6 +63,11 @@ type LLM struct {
 	OpenAI    OpenAIConfig    `yaml:"openai" json:"openai"`
 	Anthropic AnthropicConfig `yaml:"anthropic" json:"anthropic"`
 	Budget    BudgetConfig    `yaml:"budget" json:"budget"`
+
+	// CacheEnabled reuses responses to repeated (model, prompt, seed) calls.
+	// CacheFile, when set, keeps the cache between runs.
+	CacheEnabled bool   `yaml:"cache_enabled" json:"cache_enabled"`
+	CacheFile    string `yaml:"cache_file" json:"cache_file"`
 }
 
 type OllamaConfig struct {
@@ -50,6 +77,7 @@ type OllamaConfig struct {
 	KeepAlive   time.Duration `yaml:"keep_alive" json:"keep_alive"`
 	MaxRetries  int           `yaml:"max_retries" json:"max_retries"`
 	Temperature float32       `yaml:"temperature" json:"temperature"`
+	Stream      bool          `yaml:"stream" json:"stream"` // read responses as they are generated
 }
 
 type OpenAIConfig struct {
@@ -73,15 +101,56 @@ type BudgetConfig struct {
 }
 
 type Output struct {
-	Directory string `yaml:"directory" json:"directory"`
-	Format    string `yaml:"format" json:"format"` // jsonl, json
+	Directory string `yaml:"directory" json:"directory"` // "-" writes the dataset to stdout
+	Format    string `yaml:"format" json:"format"`       // jsonl, json, csv, sql, xml, avro, parquet
 	Manifest  bool   `yaml:"manifest" json:"manifest"`
 	Compress  bool   `yaml:"compress" json:"compress"`
+	Append    bool   `yaml:"append" json:"append"` // append to an existing dataset (jsonl only)
+	Table     string `yaml:"table" json:"table"`   // table that sql output inserts into
+
+	// A dataset is split into dataset-00001.jsonl, dataset-00002.jsonl, ...
+	// when either limit is set; 0 means no limit
+	ShardMaxRecords int   `yaml:"shard_max_records" json:"shard_max_records"`
+	ShardMaxBytes   int64 `yaml:"shard_max_bytes" json:"shard_max_bytes"` // uncompressed
+
+	// XML output wraps records named XMLRecord in an XMLRoot element, or
+	// writes record-000001.xml, ... when XMLFilePerRecord is set
+	XMLRoot          string `yaml:"xml_root" json:"xml_root"`
+	XMLRecord        string `yaml:"xml_record" json:"xml_record"`
+	XMLFilePerRecord bool   `yaml:"xml_file_per_record" json:"xml_file_per_record"`
+}
+
+// StdoutDirectory is the output directory that sends the dataset to stdout
+// instead of a file, with no manifest
+const StdoutDirectory = "-"
+
+// Stdout reports whether the dataset is written to stdout
+func (o Output) Stdout() bool {
+	return o.Directory == StdoutDirectory
+}
+
+// ValidateStdout rejects options that need a dataset file when writing to
+// stdout
+func (o Output) ValidateStdout() error {
+	if !o.Stdout() {
+		return nil
+	}
+	switch {
+	case o.Compress:
+		return fmt.Errorf("compression is not supported when writing to stdout")
+	case o.ShardMaxRecords > 0 || o.ShardMaxBytes > 0:
+		return fmt.Errorf("sharding is not supported when writing to stdout")
+	case o.Append:
+		return fmt.Errorf("appending is not supported when writing to stdout")
+	case o.XMLFilePerRecord && o.Format == "xml":
+		return fmt.Errorf("one XML file per record is not supported when writing to stdout")
+	}
+	return nil
 }
 
 type Logging struct {
 	Level  string `yaml:"level" json:"level"`
-	Format string `yaml:"format" json:"format"` // json, text
+	Format string `yaml:"format" json:"format"` // json, text; unset logs text to stderr and JSON to a file
 	File   string `yaml:"file" json:"file"`
 }
 
@@ -91,15 +160,24 @@ type Metrics struct {
 	Path    string `yaml:"path" json:"path"`
 }
 
+// DefaultNullProbability is the chance that a nullable field is null when
+// the configuration does not set one
+const DefaultNullProbability = 0.2
+
 // Default returns a configuration with sensible defaults
 func Default() *Config {
 	return &Config{
 		Debug: false,
 		Generation: Generation{
-			Count:   100,
-			Seed:    time.Now().UnixNano(),
-			Workers: 4,
-			Timeout: 5 * time.Minute,
+			Count:           100,
+			Seed:            time.Now().UnixNano(),
+			Workers:         4,
+			Timeout:         0, // no limit
+			NullProbability: DefaultNullProbability,
+			Timezone:        "UTC",
+			Locale:          "en-US",
+			CheckpointEvery: 10000,
+			Validation:      "keep",
 		},
 		LLM: LLM{
 			Mode:     "off",
@@ -136,42 +214,60 @@ func Default() *Config {
 			Format:    "jsonl",
 			Manifest:  true,
 			Compress:  false,
+			XMLRoot:   "records",
+			XMLRecord: "record",
 		},
 		Logging: Logging{
-			Level:  "info",
-			Format: "json",
+			Level: "info",
 		},
 		Metrics: Metrics{
-			Enabled: true,
+			Enabled: false,
 			Port:    9090,
 			Path:    "/metrics",
 		},
 	}
 }
 
-// Load configuration from file with environment variable overrides
-func Load(configFile string) (*Config, error) {
+// Load configuration from file with environment variable overrides. A
+// profile from the file's profiles section is overlaid on the base settings
+// first; SPECMINT_PROFILE selects one when profile is empty.
+func Load(configFile, profile string) (*Config, error) {
 	cfg := Default()
 
+	if profile == "" {
+		profile = os.Getenv("SPECMINT_PROFILE")
+	}
+
 	// Load from file if specified
 	if configFile != "" {
-		if err := loadFromFile(cfg, configFile); err != nil {
+		if err := loadFromFile(cfg, configFile, profile); err != nil {
 			return nil, fmt.Errorf("failed to load config file: %w", err)
 		}
 	} else {
 		// Try default locations
-		for _, path := range []string{"specmint.yaml", "specmint.yml", ".specmint.yaml"} {
+		found := false
+		for _, path := range []string{"specmint.yaml", "specmint.yml", ".specmint.yaml", "specmint.json"} {
 			if _, err := os.Stat(path); err == nil {
-				if err := loadFromFile(cfg, path); err != nil {
+				if err := loadFromFile(cfg, path, profile); err != nil {
 					return nil, fmt.Errorf("failed to load config file %s: %w", path, err)
 				}
+				found = true
 				break
 			}
 		}
+		if !found && profile != "" {
+			return nil, fmt.Errorf("profile %q selected but no config file was found", profile)
+		}
 	}
 
 	// Apply environment variable overrides
-	applyEnvOverrides(cfg)
+	if err := applyEnvOverrides(cfg); err != nil {
+		return nil, fmt.Errorf("invalid environment: %w", err)
+	}
+
+	if err := cfg.Generation.ResolveCount(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
@@ -181,16 +277,72 @@ func Load(configFile string) (*Config, error) {
 	return cfg, nil
 }
 
-func loadFromFile(cfg *Config, filename string) error {
+// loadFromFile reads a YAML config file, or a JSON one when the file ends in
+// .json or starts with {. JSON configs use the field names of the config in
+// a manifest, so durations there are nanoseconds rather than strings like 5m.
+func loadFromFile(cfg *Config, filename, profile string) error {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return err
 	}
 
-	return yaml.Unmarshal(data, cfg)
+	unmarshal, marshal := yaml.Unmarshal, yaml.Marshal
+	if isJSONConfig(filename, data) {
+		unmarshal, marshal = json.Unmarshal, json.Marshal
+	}
+	if err := unmarshal(data, cfg); err != nil {
+		return err
+	}
+	if profile == "" {
+		return nil
+	}
+	return applyProfile(cfg, data, profile, unmarshal, marshal)
+}
+
+// applyProfile overlays the named entry of a config file's profiles section
+// on cfg. Decoding the profile into the loaded config replaces only the
+// settings it gives, so nested sections it leaves out keep the base values.
+func applyProfile(cfg *Config, data []byte, profile string,
+	unmarshal func([]byte, interface{}) error, marshal func(interface{}) ([]byte, error)) error {
+	var file struct {
+		Profiles map[string]interface{} `yaml:"profiles" json:"profiles"`
+	}
+	if err := unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	overlay, ok := file.Profiles[profile]
+	if !ok {
+		if len(file.Profiles) == 0 {
+			return fmt.Errorf("unknown profile %q: the config file defines no profiles", profile)
+		}
+		names := make([]string, 0, len(file.Profiles))
+		for name := range file.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown profile %q (available: %s)", profile, strings.Join(names, ", "))
+	}
+
+	encoded, err := marshal(overlay)
+	if err != nil {
+		return fmt.Errorf("failed to read profile %s: %w", profile, err)
+	}
+	if err := unmarshal(encoded, cfg); err != nil {
+		return fmt.Errorf("failed to apply profile %s: %w", profile, err)
+	}
+	return nil
 }
 
-func applyEnvOverrides(cfg *Config) {
+// isJSONConfig reports whether a config file holds JSON
+func isJSONConfig(filename string, data []byte) bool {
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+func applyEnvOverrides(cfg *Config) error {
 	if val := os.Getenv("SPECMINT_DEBUG"); val == "true" {
 		cfg.Debug = true
 	}
@@ -214,6 +366,79 @@ func applyEnvOverrides(cfg *Config) {
 	if val := os.Getenv("ANTHROPIC_API_KEY"); val != "" {
 		cfg.LLM.Anthropic.APIKey = val
 	}
+
+	if count, ok, err := envPositiveInt("SPECMINT_COUNT"); err != nil {
+		return err
+	} else if ok {
+		cfg.Generation.Count = count
+		cfg.Generation.CountExpr = ""
+	}
+	if workers, ok, err := envPositiveInt("SPECMINT_WORKERS"); err != nil {
+		return err
+	} else if ok {
+		cfg.Generation.Workers = workers
+	}
+	if maxRPS, ok, err := envPositiveInt("SPECMINT_LLM_MAX_RPS"); err != nil {
+		return err
+	} else if ok {
+		cfg.LLM.MaxRPS = maxRPS
+	}
+	if val := os.Getenv("SPECMINT_LLM_MODE"); val != "" {
+		switch val {
+		case "off", "fields", "record":
+			cfg.LLM.Mode = val
+		default:
+			return fmt.Errorf("SPECMINT_LLM_MODE must be off, fields or record, got %q", val)
+		}
+	}
+
+	// The model and temperature belong to the provider that will be used
+	model, temperature := &cfg.LLM.Ollama.Model, &cfg.LLM.Ollama.Temperature
+	switch cfg.LLM.Provider {
+	case "openai":
+		model, temperature = &cfg.LLM.OpenAI.Model, &cfg.LLM.OpenAI.Temperature
+	case "anthropic":
+		model, temperature = &cfg.LLM.Anthropic.Model, &cfg.LLM.Anthropic.Temperature
+	}
+	if val := os.Getenv("SPECMINT_LLM_MODEL"); val != "" {
+		*model = val
+	}
+	if val := os.Getenv("SPECMINT_LLM_TEMPERATURE"); val != "" {
+		t, err := strconv.ParseFloat(val, 32)
+		if err != nil || t < 0 || t > 2 {
+			return fmt.Errorf("SPECMINT_LLM_TEMPERATURE must be a number between 0 and 2, got %q", val)
+		}
+		*temperature = float32(t)
+	}
+	return nil
+}
+
+// envPositiveInt reads a positive integer from an environment variable,
+// reporting false when it is unset
+func envPositiveInt(name string) (int, bool, error) {
+	val := os.Getenv(name)
+	if val == "" {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		return 0, false, fmt.Errorf("%s must be a positive integer, got %q", name, val)
+	}
+	return n, true, nil
+}
+
+// ResolveCount sets Count from CountExpr, when there is one, using the base
+// units and ratios of the population templates
+func (g *Generation) ResolveCount() error {
+	if g.CountExpr == "" {
+		return nil
+	}
+	count, err := population.NewPopulationAnalyzer(nil).ResolveCount(g.CountExpr)
+	if err != nil {
+		return err
+	}
+	g.Count = count
+	return nil
 }
 
 // Validate checks configuration for consistency and required values
@@ -221,6 +446,38 @@ func (c *Config) Validate() error {
 	if c.Generation.Count <= 0 {
 		return fmt.Errorf("generation count must be positive")
 	}
+	if c.Generation.StartIndex < 0 || c.Generation.StartIndex >= c.Generation.Count {
+		return fmt.Errorf("generation start index must be between 0 and count")
+	}
+	if c.Generation.NullProbability < 0 || c.Generation.NullProbability > 1 {
+		return fmt.Errorf("generation null probability must be between 0 and 1")
+	}
+	if _, err := time.LoadLocation(c.Generation.Timezone); err != nil {
+		return fmt.Errorf("invalid generation timezone: %w", err)
+	}
+	if c.Generation.ReferenceDate != "" {
+		if _, err := time.Parse("2006-01-02", c.Generation.ReferenceDate); err != nil {
+			return fmt.Errorf("invalid generation reference date %q: use YYYY-MM-DD", c.Generation.ReferenceDate)
+		}
+	}
+	switch c.Generation.Validation {
+	case "", "keep":
+	case "strict":
+		if c.Output.Stdout() {
+			return fmt.Errorf("strict validation writes rejects.jsonl, so it needs an output directory")
+		}
+	default:
+		return fmt.Errorf("unsupported generation validation mode: %s (use keep or strict)", c.Generation.Validation)
+	}
+	if c.Generation.ValidationRetries < 0 {
+		return fmt.Errorf("generation validation retries cannot be negative")
+	}
+	if c.Generation.Timeout < 0 {
+		return fmt.Errorf("generation timeout cannot be negative")
+	}
+	if c.Generation.CheckpointEvery < 0 {
+		return fmt.Errorf("generation checkpoint interval cannot be negative")
+	}
 	if c.Generation.Workers <= 0 {
 		c.Generation.Workers = 4
 	}
@@ -230,9 +487,38 @@ func (c *Config) Validate() error {
 	if c.LLM.MaxRPS <= 0 {
 		c.LLM.MaxRPS = 3
 	}
+	switch c.Logging.Format {
+	case "", "json", "text":
+	default:
+		return fmt.Errorf("unsupported logging format: %s (use json or text)", c.Logging.Format)
+	}
+	if c.Metrics.Enabled && (c.Metrics.Port < 0 || c.Metrics.Port > 65535) {
+		return fmt.Errorf("metrics port must be between 0 and 65535")
+	}
 	if c.Output.Directory == "" {
 		return fmt.Errorf("output directory is required")
 	}
+	switch c.Output.Format {
+	case "", "jsonl", "json", "csv", "sql", "xml", "avro", "parquet":
+	default:
+		return fmt.Errorf("unsupported output format: %s", c.Output.Format)
+	}
+	if c.Output.Format == "sql" && c.Output.Table == "" {
+		return fmt.Errorf("sql output needs a table name")
+	}
+
+	if c.Output.ShardMaxRecords < 0 || c.Output.ShardMaxBytes < 0 {
+		return fmt.Errorf("output shard limits cannot be negative")
+	}
+	if (c.Output.ShardMaxRecords > 0 || c.Output.ShardMaxBytes > 0) && c.Output.Format != "" && c.Output.Format != "jsonl" {
+		return fmt.Errorf("sharding is only supported for jsonl output, got %s", c.Output.Format)
+	}
+	if err := c.Output.ValidateStdout(); err != nil {
+		return err
+	}
+	if c.Output.Stdout() {
+		return nil
+	}
 
 	// Ensure output directory exists
 	if err := os.MkdirAll(c.Output.Directory, 0750); err != nil {
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -33,43 +34,56 @@ func New() zerolog.Logger {
 
 // WithLevel creates a logger with the specified level
 func WithLevel(level string) zerolog.Logger {
-	logger := New()
+	return New().Level(parseLevel(level))
+}
 
+// parseLevel maps a level name to its zerolog level, defaulting to info
+func parseLevel(level string) zerolog.Level {
 	switch strings.ToLower(level) {
 	case "debug":
-		return logger.Level(zerolog.DebugLevel)
+		return zerolog.DebugLevel
 	case "info":
-		return logger.Level(zerolog.InfoLevel)
+		return zerolog.InfoLevel
 	case "warn", "warning":
-		return logger.Level(zerolog.WarnLevel)
+		return zerolog.WarnLevel
 	case "error":
-		return logger.Level(zerolog.ErrorLevel)
+		return zerolog.ErrorLevel
 	case "fatal":
-		return logger.Level(zerolog.FatalLevel)
+		return zerolog.FatalLevel
 	default:
-		return logger.Level(zerolog.InfoLevel)
+		return zerolog.InfoLevel
 	}
 }
 
-// WithJSON creates a JSON logger for production use
-func WithJSON() zerolog.Logger {
+// WithJSON creates a JSON logger with the specified level for production use
+func WithJSON(level string) zerolog.Logger {
 	return zerolog.New(os.Stderr).
-		Level(zerolog.InfoLevel).
+		Level(parseLevel(level)).
 		With().
 		Timestamp().
 		Caller().
 		Logger()
 }
 
-// WithFile creates a logger that writes to a file
-func WithFile(filename string) (zerolog.Logger, error) {
+// WithFile creates a logger that appends to a file at the given level, as
+// JSON or, when format is text, as uncoloured console lines
+func WithFile(filename, format, level string) (zerolog.Logger, error) {
 	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
 	if err != nil {
 		return zerolog.Logger{}, err
 	}
 
-	return zerolog.New(file).
-		Level(zerolog.InfoLevel).
+	var output io.Writer = file
+	if format == "text" {
+		output = zerolog.ConsoleWriter{
+			Out:        file,
+			TimeFormat: time.RFC3339,
+			NoColor:    true,
+		}
+	}
+
+	return zerolog.New(output).
+		Level(parseLevel(level)).
 		With().
 		Timestamp().
 		Caller().
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/specmint/specmint/pkg/llm"
+)
+
+// ErrBudgetExceeded is returned instead of calling the LLM once the
+// configured spending cap has been reached
+var ErrBudgetExceeded = errors.New("LLM budget exhausted")
+
+// budgetedClient wraps an LLM client and tracks the estimated cost of every
+// call. Once MaxCostUSD is reached further calls fail fast with
+// ErrBudgetExceeded, so callers fall back to deterministic data. Calls that
+// are already in flight when the cap is reached may overshoot it slightly.
+type budgetedClient struct {
+	LLMClient
+	model   string
+	maxCost float64
+	warnAt  float64
+
+	mu        sync.Mutex
+	spent     float64
+	warned    bool
+	exhausted bool
+}
+
+// newBudgetedClient wraps client with cost tracking. A maxCost of zero
+// tracks spend without enforcing a cap.
+func newBudgetedClient(client LLMClient, model string, maxCost, warnThreshold float64) *budgetedClient {
+	return &budgetedClient{
+		LLMClient: client,
+		model:     model,
+		maxCost:   maxCost,
+		warnAt:    maxCost * warnThreshold,
+	}
+}
+
+// Generate calls the wrapped client unless the budget is exhausted
+func (b *budgetedClient) Generate(ctx context.Context, prompt string, seed int64) (string, error) {
+	b.mu.Lock()
+	exhausted := b.exhausted
+	b.mu.Unlock()
+	if exhausted {
+		return "", ErrBudgetExceeded
+	}
+
+	response, err := b.LLMClient.Generate(ctx, prompt, seed)
+	if err != nil {
+		return "", err
+	}
+
+	b.record(llm.EstimateCost(b.model, prompt, response))
+	return response, nil
+}
+
+// record adds the cost of a call and logs when thresholds are crossed
+func (b *budgetedClient) record(cost float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.spent += cost
+	if b.maxCost <= 0 {
+		return
+	}
+
+	if !b.warned && b.warnAt > 0 && b.spent >= b.warnAt {
+		b.warned = true
+		log.Warn().
+			Float64("spent_usd", b.spent).
+			Float64("max_cost_usd", b.maxCost).
+			Msg("LLM spend is approaching the budget cap")
+	}
+	if !b.exhausted && b.spent >= b.maxCost {
+		b.exhausted = true
+		log.Warn().
+			Float64("spent_usd", b.spent).
+			Float64("max_cost_usd", b.maxCost).
+			Msg("LLM budget exhausted, falling back to deterministic data")
+	}
+}
+
+// TotalCost returns the estimated spend so far in USD
+func (b *budgetedClient) TotalCost() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent
+}
@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeLLMClient returns a fixed response and counts calls
+type fakeLLMClient struct {
+	response string
+	calls    int
+}
+
+func (f *fakeLLMClient) Generate(ctx context.Context, prompt string, seed int64) (string, error) {
+	f.calls++
+	return f.response, nil
+}
+
+func (f *fakeLLMClient) HealthCheck(ctx context.Context) error { return nil }
+
+func (f *fakeLLMClient) Close() error { return nil }
+
+// TestBudgetedClient_StopsAtCap verifies that calls stop once the estimated
+// spend reaches the configured cap
+func TestBudgetedClient_StopsAtCap(t *testing.T) {
+	// 4,000 characters is about 1,000 output tokens, or $0.01 at gpt-4o rates
+	inner := &fakeLLMClient{response: strings.Repeat("x", 4000)}
+	client := newBudgetedClient(inner, "gpt-4o-2024-08-06", 0.025, 0.8)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Generate(context.Background(), "", int64(i)); err != nil {
+			t.Fatalf("Call %d failed before reaching the cap: %v", i, err)
+		}
+	}
+
+	if _, err := client.Generate(context.Background(), "", 3); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected ErrBudgetExceeded once the cap is reached, got %v", err)
+	}
+	if inner.calls != 3 {
+		t.Errorf("Expected 3 calls to reach the provider, got %d", inner.calls)
+	}
+
+	if cost := client.TotalCost(); cost < 0.0299 || cost > 0.0301 {
+		t.Errorf("Expected total cost of about $0.03, got $%.4f", cost)
+	}
+}
+
+// TestBudgetedClient_LocalModelsAreFree verifies that unlisted models never
+// exhaust the budget
+func TestBudgetedClient_LocalModelsAreFree(t *testing.T) {
+	inner := &fakeLLMClient{response: strings.Repeat("x", 4000)}
+	client := newBudgetedClient(inner, "qwen2.5:latest", 0.01, 0.8)
+
+	for i := 0; i < 10; i++ {
+		if _, err := client.Generate(context.Background(), "prompt", int64(i)); err != nil {
+			t.Fatalf("Call %d failed: %v", i, err)
+		}
+	}
+
+	if cost := client.TotalCost(); cost != 0 {
+		t.Errorf("Expected no cost for a local model, got $%.4f", cost)
+	}
+}
+
+// TestWrapLLMClient_CacheHitsAreFree verifies cache hits never reach the
+// budget, so they add nothing to the spend and are still served once the
+// budget is exhausted
+func TestWrapLLMClient_CacheHitsAreFree(t *testing.T) {
+	gen, _ := newTestGenerator(t, `{"type": "object"}`)
+	gen.config.LLM.Provider = "openai"
+	gen.config.LLM.OpenAI.Model = "gpt-4o-2024-08-06"
+	gen.config.LLM.CacheEnabled = true
+	gen.config.LLM.Budget.TrackingEnabled = true
+	gen.config.LLM.Budget.MaxCostUSD = 0.01
+	gen.config.LLM.Budget.WarnThreshold = 0.8
+
+	// One call of about $0.01 exhausts the budget
+	inner := &fakeLLMClient{response: strings.Repeat("x", 4000)}
+	if err := gen.wrapLLMClient(inner); err != nil {
+		t.Fatalf("Failed to wrap client: %v", err)
+	}
+
+	if _, err := gen.llmClient.Generate(context.Background(), "", 1); err != nil {
+		t.Fatalf("First call failed: %v", err)
+	}
+	spent := gen.budget.TotalCost()
+	for i := 0; i < 3; i++ {
+		if response, err := gen.llmClient.Generate(context.Background(), "", 1); err != nil || response != inner.response {
+			t.Fatalf("Expected a cache hit after the budget was exhausted, got %v", err)
+		}
+	}
+	if cost := gen.budget.TotalCost(); cost != spent {
+		t.Errorf("Expected cache hits to cost nothing, spend went from $%.4f to $%.4f", spent, cost)
+	}
+
+	if _, err := gen.llmClient.Generate(context.Background(), "", 2); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected a miss to hit the exhausted budget, got %v", err)
+	}
+	if inner.calls != 1 {
+		t.Errorf("Expected 1 provider call, got %d", inner.calls)
+	}
+}
@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// cachedClient wraps an LLM client and memoizes responses by model, prompt
+// and seed. The seed is part of the key, so a hit returns exactly what the
+// provider was asked for and generation stays deterministic. Concurrent
+// misses on the same key may both reach the provider; the last response
+// wins.
+type cachedClient struct {
+	LLMClient
+	model string
+	path  string
+
+	mu      sync.RWMutex
+	entries map[string]string
+	hits    int
+	misses  int
+	dirty   bool
+}
+
+// newCachedClient wraps client with a response cache. When path is set the
+// cache is loaded from it, if it exists, and Save writes it back.
+func newCachedClient(client LLMClient, model, path string) (*cachedClient, error) {
+	c := &cachedClient{
+		LLMClient: client,
+		model:     model,
+		path:      path,
+		entries:   make(map[string]string),
+	}
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read LLM cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse LLM cache %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// cacheKey hashes a call so cache files stay small and hold no prompt text
+func cacheKey(model, prompt string, seed int64) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + prompt + "\x00" + strconv.FormatInt(seed, 10)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Generate returns the cached response for the call, or calls the wrapped
+// client and caches its response. Errors are not cached.
+func (c *cachedClient) Generate(ctx context.Context, prompt string, seed int64) (string, error) {
+	key := cacheKey(c.model, prompt, seed)
+
+	c.mu.Lock()
+	response, ok := c.entries[key]
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	c.mu.Unlock()
+	if ok {
+		return response, nil
+	}
+
+	response, err := c.LLMClient.Generate(ctx, prompt, seed)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = response
+	c.dirty = true
+	c.mu.Unlock()
+	return response, nil
+}
+
+// Save writes the cache to its file, if it has one and has changed
+func (c *cachedClient) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.path == "" || !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode LLM cache: %w", err)
+	}
+	if dir := filepath.Dir(c.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create LLM cache directory: %w", err)
+		}
+	}
+
+	// Write then rename so an interrupted save never truncates the cache
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write LLM cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write LLM cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// GetStats returns the wrapped client's statistics, if it has any, along
+// with cache hits, misses and size
+func (c *cachedClient) GetStats() map[string]interface{} {
+	stats := make(map[string]interface{})
+	if inner, ok := c.LLMClient.(interface{ GetStats() map[string]interface{} }); ok {
+		for key, value := range inner.GetStats() {
+			stats[key] = value
+		}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	stats["cache_hits"] = c.hits
+	stats["cache_misses"] = c.misses
+	stats["cache_entries"] = len(c.entries)
+	return stats
+}
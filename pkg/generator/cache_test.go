@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// TestCachedClient verifies repeated calls are served from the cache while
+// a different seed still reaches the provider
+func TestCachedClient(t *testing.T) {
+	inner := &fakeLLMClient{response: "Acme Widget"}
+	client, err := newCachedClient(inner, "qwen2.5:latest", "")
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if response, _ := client.Generate(context.Background(), "name", 7); response != "Acme Widget" {
+			t.Fatalf("Expected the provider response, got %q", response)
+		}
+	}
+	if _, err := client.Generate(context.Background(), "name", 8); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+
+	if inner.calls != 2 {
+		t.Errorf("Expected 2 provider calls for 2 distinct seeds, got %d", inner.calls)
+	}
+	stats := client.GetStats()
+	if stats["cache_hits"] != 2 || stats["cache_misses"] != 2 || stats["cache_entries"] != 2 {
+		t.Errorf("Expected 2 hits, 2 misses and 2 entries, got %v", stats)
+	}
+}
+
+// TestCachedClient_Persist verifies a saved cache answers the next run
+// without calling the provider
+func TestCachedClient_Persist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "llm.json")
+
+	first, err := newCachedClient(&fakeLLMClient{response: "Acme Widget"}, "qwen2.5:latest", path)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	if _, err := first.Generate(context.Background(), "name", 7); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	if err := first.Save(); err != nil {
+		t.Fatalf("Failed to save cache: %v", err)
+	}
+
+	inner := &fakeLLMClient{response: "something else"}
+	second, err := newCachedClient(inner, "qwen2.5:latest", path)
+	if err != nil {
+		t.Fatalf("Failed to load cache: %v", err)
+	}
+	if response, _ := second.Generate(context.Background(), "name", 7); response != "Acme Widget" || inner.calls != 0 {
+		t.Errorf("Expected the saved response without a provider call, got %q after %d calls", response, inner.calls)
+	}
+
+	// Another model must not see the first model's responses
+	other, _ := newCachedClient(inner, "gpt-4o-mini", path)
+	if response, _ := other.Generate(context.Background(), "name", 7); response != "something else" {
+		t.Errorf("Expected a miss for a different model, got %q", response)
+	}
+}
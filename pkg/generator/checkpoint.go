@@ -0,0 +1,227 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// checkpointFile is the name of the checkpoint in the output directory
+const checkpointFile = "checkpoint.json"
+
+// Checkpoint records how far a run got, so an interrupted run can resume
+// after the last record it wrote. Generation is deterministic per record
+// index, so the resumed run produces the same records the original would
+// have. The totals cover every record written so far.
+type Checkpoint struct {
+	Seed             int64   `json:"seed"`
+	ReferenceDate    string  `json:"reference_date,omitempty"`
+	SchemaFile       string  `json:"schema_file"`
+	SchemaHash       string  `json:"schema_sha256"`
+	Count            int     `json:"count"`
+	LastIndex        int     `json:"last_index"` // highest record index written to the dataset
+	ShardMaxRecords  int     `json:"shard_max_records,omitempty"`
+	ShardMaxBytes    int64   `json:"shard_max_bytes,omitempty"`
+	LLMCallCount     int     `json:"llm_call_count"`
+	ValidationErrors int     `json:"validation_errors"`
+	PatchedRecords   int     `json:"patched_records"`
+	Retries          int     `json:"retries,omitempty"`
+	FailedRecords    int     `json:"failed_records,omitempty"`
+	TotalCostUSD     float64 `json:"total_cost_usd"`
+	UpdatedAt        string  `json:"updated_at"`
+}
+
+// CheckpointPath returns the checkpoint location for an output directory
+func CheckpointPath(outputDir string) string {
+	return filepath.Join(outputDir, checkpointFile)
+}
+
+// ReadCheckpoint loads the checkpoint left in an output directory by an
+// interrupted run
+func ReadCheckpoint(outputDir string) (*Checkpoint, error) {
+	path := CheckpointPath(outputDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+
+	return &checkpoint, nil
+}
+
+// SchemaHash returns the SHA-256 of a schema file, so a resumed run can
+// tell whether the schema changed
+func SchemaHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read schema: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// checkpointing reports whether the run writes its dataset in batches with
+// checkpoints. Only plain jsonl can be appended to, unique_by needs every
+// record before any is written, output to stdout cannot be resumed, and
+// strict validation reports its rejects once the run is complete.
+func (g *Generator) checkpointing() bool {
+	cfg := g.config
+	return cfg.Generation.CheckpointEvery > 0 &&
+		cfg.Generation.Validation != "strict" &&
+		!cfg.Output.Stdout() &&
+		(cfg.Output.Format == "jsonl" || cfg.Output.Format == "") &&
+		!cfg.Output.Compress &&
+		cfg.Generation.UniqueBy == ""
+}
+
+// batchWriter receives records in completion order and writes them to the
+// dataset in index order, a batch at a time, saving a checkpoint after each
+// batch. Records that arrive ahead of a missing index wait in pending.
+type batchWriter struct {
+	g          *Generator
+	schemaHash string
+	next       int
+	pending    map[int]generatedRecord
+	ready      []generatedRecord
+	written    int
+	totals     GenerationResult // statistics of the written records
+	err        error
+}
+
+func newBatchWriter(g *Generator) (*batchWriter, error) {
+	hash, err := SchemaHash(g.config.Schema)
+	if err != nil {
+		return nil, err
+	}
+	return &batchWriter{
+		g:          g,
+		schemaHash: hash,
+		next:       g.config.Generation.StartIndex,
+		pending:    make(map[int]generatedRecord),
+	}, nil
+}
+
+// add queues a record and writes a batch once enough contiguous records
+// are ready. After a failed write records are only held, and the error is
+// reported by Generate.
+func (b *batchWriter) add(record generatedRecord) {
+	b.pending[record.Index] = record
+	for {
+		next, ok := b.pending[b.next]
+		if !ok {
+			break
+		}
+		delete(b.pending, b.next)
+		b.ready = append(b.ready, next)
+		b.next++
+	}
+
+	if b.err == nil && len(b.ready) >= b.g.config.Generation.CheckpointEvery {
+		b.err = b.flush()
+	}
+}
+
+// flush writes the ready records and records them in the checkpoint
+func (b *batchWriter) flush() error {
+	if len(b.ready) == 0 {
+		return nil
+	}
+
+	records := make([]interface{}, len(b.ready))
+	for i, record := range b.ready {
+		records[i] = record.Data
+		b.totals.tally(record, 1)
+	}
+	var err error
+	if b.written == 0 {
+		// The first batch replaces the dataset unless the run appends
+		err = b.g.writer.WriteRecords(records)
+	} else {
+		err = b.g.writer.AppendRecords(records)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	b.written += len(records)
+	lastIndex := b.ready[len(b.ready)-1].Index
+	b.ready = b.ready[:0]
+
+	return b.g.writeCheckpoint(lastIndex, b.schemaHash, &b.totals)
+}
+
+// remaining returns the records not yet written, in index order
+func (b *batchWriter) remaining() []generatedRecord {
+	records := append([]generatedRecord(nil), b.ready...)
+	indexes := make([]int, 0, len(b.pending))
+	for index := range b.pending {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		records = append(records, b.pending[index])
+	}
+	return records
+}
+
+// writeCheckpoint saves progress through lastIndex with the totals of the
+// records written, adding those of the run being resumed
+func (g *Generator) writeCheckpoint(lastIndex int, schemaHash string, written *GenerationResult) error {
+	checkpoint := Checkpoint{
+		Seed:             g.config.Generation.Seed,
+		ReferenceDate:    g.detGen.ReferenceDate().Format("2006-01-02"),
+		SchemaFile:       g.config.Schema,
+		SchemaHash:       schemaHash,
+		Count:            g.config.Generation.Count,
+		LastIndex:        lastIndex,
+		ShardMaxRecords:  g.config.Output.ShardMaxRecords,
+		ShardMaxBytes:    g.config.Output.ShardMaxBytes,
+		LLMCallCount:     written.LLMCallCount,
+		ValidationErrors: written.ValidationErrors,
+		PatchedRecords:   written.PatchedRecords,
+		Retries:          written.Retries,
+		FailedRecords:    written.FailedRecords,
+		UpdatedAt:        time.Now().UTC().Format(time.RFC3339),
+	}
+	if g.budget != nil {
+		checkpoint.TotalCostUSD = g.budget.TotalCost()
+	}
+	if prior := g.resumed; prior != nil {
+		checkpoint.LLMCallCount += prior.LLMCallCount
+		checkpoint.ValidationErrors += prior.ValidationErrors
+		checkpoint.PatchedRecords += prior.PatchedRecords
+		checkpoint.Retries += prior.Retries
+		checkpoint.FailedRecords += prior.FailedRecords
+		checkpoint.TotalCostUSD += prior.TotalCostUSD
+	}
+
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	// Write then rename so a crash never leaves a partial checkpoint
+	path := CheckpointPath(g.config.Output.Directory)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// SetResume continues the run recorded by checkpoint. The caller sets the
+// start index and append mode; the generator carries the checkpoint's
+// totals into its own checkpoints and the manifest.
+func (g *Generator) SetResume(checkpoint *Checkpoint) {
+	g.resumed = checkpoint
+}
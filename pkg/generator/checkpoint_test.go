@@ -0,0 +1,177 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/specmint/specmint/pkg/writer"
+)
+
+// TestCheckpointResume verifies a run resumed from a checkpoint writes the
+// same dataset as an uninterrupted run
+func TestCheckpointResume(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"required": ["id", "code"],
+		"properties": {
+			"id": {"type": "integer"},
+			"code": {"type": "string", "pattern": "^[A-Z]{3}[0-9]{4}$"}
+		}
+	}`
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(schemaFile, []byte(schemaJSON), 0600); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	run := func(dir string, configure func(*Generator)) *GenerationResult {
+		gen, _ := newTestGenerator(t, schemaJSON)
+		gen.quiet = true
+		gen.config.Schema = schemaFile
+		gen.config.Generation.Count = 50
+		gen.config.Generation.CheckpointEvery = 10
+		gen.config.Output.Directory = dir
+		configure(gen)
+
+		w, err := writer.New(gen.config.Output)
+		if err != nil {
+			t.Fatalf("Failed to create writer: %v", err)
+		}
+		gen.writer = w
+		result, err := gen.Generate(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to generate: %v", err)
+		}
+		return result
+	}
+
+	fullDir := t.TempDir()
+	run(fullDir, func(*Generator) {})
+	full, _ := os.ReadFile(filepath.Join(fullDir, "dataset.jsonl"))
+	if _, err := os.Stat(CheckpointPath(fullDir)); !os.IsNotExist(err) {
+		t.Errorf("Expected the checkpoint to be removed after a complete run")
+	}
+
+	// Leave the first 20 records and a checkpoint as an interrupted run would
+	resumedDir := t.TempDir()
+	lines := bytes.SplitAfterN(full, []byte("\n"), 21)
+	if err := os.WriteFile(filepath.Join(resumedDir, "dataset.jsonl"), bytes.Join(lines[:20], nil), 0600); err != nil {
+		t.Fatalf("Failed to write partial dataset: %v", err)
+	}
+	checkpoint := &Checkpoint{Seed: 42, SchemaFile: schemaFile, Count: 50, LastIndex: 19, ValidationErrors: 3}
+
+	result := run(resumedDir, func(gen *Generator) {
+		gen.config.Generation.StartIndex = 20
+		gen.config.Output.Append = true
+		gen.SetResume(checkpoint)
+	})
+	if result.RecordCount != 30 {
+		t.Errorf("Expected 30 records in the resumed run, got %d", result.RecordCount)
+	}
+	resumed, _ := os.ReadFile(filepath.Join(resumedDir, "dataset.jsonl"))
+	if !bytes.Equal(full, resumed) {
+		t.Errorf("Expected the resumed dataset to match the uninterrupted one")
+	}
+
+	manifest, err := ReadManifest(filepath.Join(resumedDir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	if manifest.RecordCount != 50 {
+		t.Errorf("Expected the manifest to count both runs, got %d", manifest.RecordCount)
+	}
+
+	// A strict resume accepts the interrupted run's records as well as its own
+	strictDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(strictDir, "dataset.jsonl"), bytes.Join(lines[:20], nil), 0600); err != nil {
+		t.Fatalf("Failed to write partial dataset: %v", err)
+	}
+	run(strictDir, func(gen *Generator) {
+		gen.config.Generation.Validation = "strict"
+		gen.config.Generation.StartIndex = 20
+		gen.config.Output.Append = true
+		gen.SetResume(checkpoint)
+	})
+	data, err := os.ReadFile(filepath.Join(strictDir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	var counts struct {
+		Accepted int `json:"accepted_records"`
+		Rejected int `json:"rejected_records"`
+	}
+	if err := json.Unmarshal(data, &counts); err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+	if counts.Accepted != 50 || counts.Rejected != 0 {
+		t.Errorf("Expected 50 accepted and 0 rejected records, got %d and %d", counts.Accepted, counts.Rejected)
+	}
+}
+
+// TestInterruptedRun verifies a cancelled run writes the start of the
+// dataset a complete run writes, with a manifest marking it interrupted,
+// whether or not it writes in checkpointed batches
+func TestInterruptedRun(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"required": ["id"],
+		"properties": {"id": {"type": "integer"}}
+	}`
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(schemaFile, []byte(schemaJSON), 0600); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	run := func(ctx context.Context, checkpointEvery int) (string, error) {
+		gen, _ := newTestGenerator(t, schemaJSON)
+		dir := t.TempDir()
+		gen.quiet = true
+		gen.config.Schema = schemaFile
+		gen.config.Generation.Count = 200
+		gen.config.Generation.CheckpointEvery = checkpointEvery
+		gen.config.Output.Directory = dir
+		w, err := writer.New(gen.config.Output)
+		if err != nil {
+			t.Fatalf("Failed to create writer: %v", err)
+		}
+		gen.writer = w
+		_, err = gen.Generate(ctx)
+		return dir, err
+	}
+
+	fullDir, err := run(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	full, _ := os.ReadFile(filepath.Join(fullDir, "dataset.jsonl"))
+
+	for _, checkpointEvery := range []int{0, 20} {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		dir, err := run(ctx, checkpointEvery)
+		if err == nil || !strings.Contains(err.Error(), "interrupted") {
+			t.Fatalf("Expected an interrupted error, got %v", err)
+		}
+		partial, _ := os.ReadFile(filepath.Join(dir, "dataset.jsonl"))
+		if !bytes.HasPrefix(full, partial) {
+			t.Errorf("Expected the interrupted dataset to be a prefix of the complete one")
+		}
+
+		var manifest struct {
+			RecordCount    int  `json:"record_count"`
+			Interrupted    bool `json:"interrupted"`
+			RequestedCount int  `json:"requested_count"`
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+		if err != nil {
+			t.Fatalf("Expected a partial manifest: %v", err)
+		}
+		json.Unmarshal(data, &manifest)
+		if !manifest.Interrupted || manifest.RequestedCount != 200 || manifest.RecordCount != bytes.Count(partial, []byte("\n")) {
+			t.Errorf("Unexpected partial manifest %s", data)
+		}
+	}
+}
@@ -4,18 +4,32 @@ import (
 	"fmt"
 	"hash/fnv"
 	"math"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/rs/zerolog/log"
+	"github.com/specmint/specmint/internal/config"
 	"github.com/specmint/specmint/pkg/schema"
+	"github.com/specmint/specmint/pkg/validator"
 	mathrand "math/rand"
 )
 
+// maxUniqueAttempts bounds how often an array item is regenerated when
+// uniqueItems requires it to differ from the earlier items
+const maxUniqueAttempts = 20
+
 // DeterministicGenerator generates values using seeded RNG for reproducibility
 type DeterministicGenerator struct {
 	baseSeed int64
 	rng      *mathrand.Rand
+	nullProb float64
+	refs     map[string][]interface{}
+	loc      *time.Location
+	locale   string
+	today    time.Time // start of the UTC reference day dates count back from
 }
 
 // NewDeterministicGenerator creates a new deterministic generator
@@ -23,16 +37,61 @@ func NewDeterministicGenerator(seed int64) *DeterministicGenerator {
 	return &DeterministicGenerator{
 		baseSeed: seed,
 		rng:      mathrand.New(mathrand.NewSource(seed)),
+		nullProb: config.DefaultNullProbability,
+		loc:      time.UTC,
+		locale:   schema.DefaultLocale,
+		today:    time.Now().UTC().Truncate(24 * time.Hour),
 	}
 }
 
-// GenerateValue generates a deterministic value for a schema node
+// SetLocation sets the timezone dates are rendered in. The generated
+// instants do not change, only their offset.
+func (g *DeterministicGenerator) SetLocation(loc *time.Location) {
+	g.loc = loc
+}
+
+// SetReferenceDate sets the day generated dates count back from. It
+// defaults to the UTC day the generator was created; regenerating a dataset
+// on another day needs the day it was generated.
+func (g *DeterministicGenerator) SetReferenceDate(day time.Time) {
+	g.today = day.UTC().Truncate(24 * time.Hour)
+}
+
+// ReferenceDate returns the day generated dates count back from
+func (g *DeterministicGenerator) ReferenceDate() time.Time {
+	return g.today
+}
+
+// SetNullProbability sets the chance that fields whose type array includes
+// "null" are generated as null
+func (g *DeterministicGenerator) SetNullProbability(prob float64) {
+	g.nullProb = prob
+}
+
+// GenerateValue generates a deterministic value for a schema node. The
+// record index seeds the whole value, array items included, so each item is
+// fixed by the record, the field and its position in the array.
 func (g *DeterministicGenerator) GenerateValue(node *schema.SchemaNode, recordIndex int) (interface{}, error) {
+	return g.generateAttempt(node, recordIndex, 0, 0)
+}
+
+// generateAttempt generates the value for a record from a seed perturbed by
+// attempt, and separately by retry, so retrying an invalid record never
+// repeats a unique_by attempt. Attempt and retry 0 give the same value as
+// GenerateValue.
+func (g *DeterministicGenerator) generateAttempt(node *schema.SchemaNode, recordIndex, attempt, retry int) (interface{}, error) {
 	// Create seed for this specific field and record
-	seed := g.deriveSeed(node.Path, recordIndex)
+	path := node.Path
+	if attempt > 0 {
+		path = fmt.Sprintf("%s#%d", path, attempt)
+	}
+	if retry > 0 {
+		path = fmt.Sprintf("%s!%d", path, retry)
+	}
+	seed := g.deriveSeed(path, recordIndex)
 	rng := mathrand.New(mathrand.NewSource(seed))
 
-	return g.generateValue(node, rng)
+	return g.generateValue(node, rng, seed)
 }
 
 // deriveSeed creates a deterministic seed based on path and record index
@@ -42,7 +101,14 @@ func (g *DeterministicGenerator) deriveSeed(path string, recordIndex int) int64
 	if err != nil {
 		return 0
 	}
-	_, err = h.Write([]byte{byte(recordIndex), byte(recordIndex >> 8), byte(recordIndex >> 16), byte(recordIndex >> 24)})
+	// The high half of the index is only hashed when set, so indices below
+	// 2^32 keep the seeds they always had
+	index := uint64(recordIndex)
+	indexBytes := []byte{byte(index), byte(index >> 8), byte(index >> 16), byte(index >> 24)}
+	if high := index >> 32; high != 0 {
+		indexBytes = append(indexBytes, byte(high), byte(high>>8), byte(high>>16), byte(high>>24))
+	}
+	_, err = h.Write(indexBytes)
 	if err != nil {
 		return 0
 	}
@@ -51,14 +117,76 @@ func (g *DeterministicGenerator) deriveSeed(path string, recordIndex int) int64
 	return g.baseSeed ^ pathHash
 }
 
-// generateValue generates a value based on the schema node type and constraints
-func (g *DeterministicGenerator) generateValue(node *schema.SchemaNode, rng *mathrand.Rand) (interface{}, error) {
+// streamSeed derives the seed of a nested stream, such as an array item or
+// a field with x-seed-salt, from the seed of the stream it sits in. Nested
+// streams thus differ from record to record like the record's own.
+func streamSeed(seed int64, key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return seed ^ int64(h.Sum64()&0x7FFFFFFFFFFFFFFF)
+}
+
+// SetReferences sets the key values that x-ref fields sample from, keyed by
+// the x-ref target such as products.sku
+func (g *DeterministicGenerator) SetReferences(refs map[string][]interface{}) {
+	g.refs = refs
+}
+
+// generateValue generates a value for a schema node from rng, which was
+// created from seed. A node with x-seed-salt is generated from a stream of
+// its own instead.
+func (g *DeterministicGenerator) generateValue(node *schema.SchemaNode, rng *mathrand.Rand, seed int64) (interface{}, error) {
+	if node.SeedSalt == "" {
+		return g.generateNode(node, rng, seed)
+	}
+
+	// Draw the unsalted value anyway, so the fields generated after this one
+	// see the same stream whatever the salt
+	if _, err := g.generateNode(node, rng, seed); err != nil {
+		return nil, err
+	}
+	salted := streamSeed(seed, node.Path+"\x00"+node.SeedSalt)
+	return g.generateNode(node, mathrand.New(mathrand.NewSource(salted)), salted)
+}
+
+// generateNode generates a value based on the schema node type and constraints
+func (g *DeterministicGenerator) generateNode(node *schema.SchemaNode, rng *mathrand.Rand, seed int64) (interface{}, error) {
+	// x-nullable injects nulls whatever the field would otherwise hold
+	if node.Nullable && rng.Float64() < g.nullRate(node) {
+		return nil, nil
+	}
+
+	// A const pins the value, whatever the type. Copy it so records never
+	// share a mutable object.
+	if node.HasConst {
+		return copyValue(node.Const), nil
+	}
+
+	// Foreign keys sample uniformly from the referenced keys, so each parent
+	// gets count/len(keys) children on average, matching the population ratio
+	if keys := g.refs[node.Reference]; node.Reference != "" && len(keys) > 0 {
+		return keys[rng.Intn(len(keys))], nil
+	}
+
+	// Pick one anyOf/oneOf branch
+	if len(node.Variants) > 0 {
+		return g.generateValue(node.Variants[rng.Intn(len(node.Variants))], rng, seed)
+	}
+
 	// Handle enum values first
 	if len(node.Enum) > 0 {
+		if len(node.EnumWeights) == len(node.Enum) {
+			return node.Enum[pickWeighted(node.EnumWeights, rng)], nil
+		}
 		idx := rng.Intn(len(node.Enum))
 		return node.Enum[idx], nil
 	}
 
+	// Emit the schema default as often as x-default-prob asks
+	if node.HasDefault && node.DefaultProb > 0 && rng.Float64() < node.DefaultProb {
+		return copyValue(node.Default), nil
+	}
+
 	// Handle examples if available
 	if len(node.Examples) > 0 && rng.Float64() < 0.7 { // 70% chance to use examples
 		idx := rng.Intn(len(node.Examples))
@@ -66,7 +194,7 @@ func (g *DeterministicGenerator) generateValue(node *schema.SchemaNode, rng *mat
 	}
 
 	// Generate based on type
-	switch node.Type {
+	switch g.pickType(node, rng) {
 	case "string":
 		return g.generateString(node, rng)
 	case "integer":
@@ -76,9 +204,9 @@ func (g *DeterministicGenerator) generateValue(node *schema.SchemaNode, rng *mat
 	case "boolean":
 		return rng.Float64() < 0.5, nil
 	case "array":
-		return g.generateArray(node, rng)
+		return g.generateArray(node, rng, seed)
 	case "object":
-		return g.generateObject(node, rng)
+		return g.generateObject(node, rng, seed)
 	case "null":
 		return nil, nil
 	default:
@@ -86,22 +214,85 @@ func (g *DeterministicGenerator) generateValue(node *schema.SchemaNode, rng *mat
 	}
 }
 
+// copyValue deep-copies a decoded JSON value
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	}
+	return value
+}
+
+// pickWeighted returns an index chosen with the given normalized weights
+func pickWeighted(weights []float64, rng *mathrand.Rand) int {
+	r := rng.Float64()
+	last := 0
+	for i, weight := range weights {
+		if weight == 0 {
+			continue
+		}
+		if r < weight {
+			return i
+		}
+		r -= weight
+		last = i
+	}
+	// Rounding can leave r just above the final weight
+	return last
+}
+
+// nullRate returns the chance of generating null for a node that allows it:
+// its x-null-rate, or the configured null probability
+func (g *DeterministicGenerator) nullRate(node *schema.SchemaNode) float64 {
+	if node.NullRate != nil {
+		return *node.NullRate
+	}
+	return g.nullProb
+}
+
+// pickType chooses the type to generate. For type arrays "null" is picked
+// with the node's null rate, otherwise one of the other types. Nodes with
+// x-nullable have had their chance of null already.
+func (g *DeterministicGenerator) pickType(node *schema.SchemaNode, rng *mathrand.Rand) string {
+	if len(node.Types) == 0 {
+		return node.Type
+	}
+
+	nonNull := make([]string, 0, len(node.Types))
+	for _, t := range node.Types {
+		if t != "null" {
+			nonNull = append(nonNull, t)
+		}
+	}
+	if len(nonNull) == 0 || (len(nonNull) < len(node.Types) && !node.Nullable && rng.Float64() < g.nullRate(node)) {
+		return "null"
+	}
+
+	return nonNull[rng.Intn(len(nonNull))]
+}
+
 // generateString generates string values with format and pattern constraints
 func (g *DeterministicGenerator) generateString(node *schema.SchemaNode, rng *mathrand.Rand) (string, error) {
+	if node.Generator != "" {
+		return g.generateNamed(node, rng)
+	}
+
 	// Handle specific formats
-	switch node.Format {
-	case "email":
-		return g.generateEmail(rng), nil
-	case "uuid":
-		return g.generateUUID(rng), nil
-	case "date":
-		return g.generateDate(rng), nil
-	case "date-time":
-		return g.generateDateTime(rng), nil
-	case "uri":
-		return g.generateURI(rng), nil
-	case "phone":
-		return g.generatePhone(rng), nil
+	if value, ok, err := g.generateFormat(node, rng); ok || err != nil {
+		if err != nil {
+			return "", err
+		}
+		return g.fitFormatLength(node, value, rng)
 	}
 
 	// Handle pattern constraint
@@ -118,8 +309,16 @@ func (g *DeterministicGenerator) generateString(node *schema.SchemaNode, rng *ma
 	}
 	if node.MaxLength != nil {
 		maxLen = *node.MaxLength
-		if maxLen < minLen {
-			maxLen = minLen
+	}
+	if maxLen < minLen {
+		switch {
+		case node.MinLength != nil && node.MaxLength != nil:
+			return "", fmt.Errorf("no string satisfies the length bounds of %s (minLength %d, maxLength %d)", node.Path, minLen, maxLen)
+		case node.MinLength != nil:
+			// Only one bound is set, so move the default one out of the way
+			maxLen = minLen + 15
+		default:
+			minLen = min(1, maxLen)
 		}
 	}
 
@@ -127,64 +326,259 @@ func (g *DeterministicGenerator) generateString(node *schema.SchemaNode, rng *ma
 	return g.generateRandomString(length, rng), nil
 }
 
+// generateFormat generates a string in the node's format. It reports false
+// when the format has no generator.
+func (g *DeterministicGenerator) generateFormat(node *schema.SchemaNode, rng *mathrand.Rand) (string, bool, error) {
+	switch node.Format {
+	case "email":
+		return g.generateEmail(rng), true, nil
+	case "uuid":
+		return g.generateUUID(rng), true, nil
+	case "date":
+		return g.generateDate(g.location(node), rng), true, nil
+	case "date-time":
+		return g.generateDateTime(g.location(node), rng), true, nil
+	case "time":
+		return g.generateTime(node, rng), true, nil
+	case "duration":
+		return g.generateDuration(node, rng), true, nil
+	case "uri":
+		return g.generateURI(rng), true, nil
+	case "phone":
+		return g.generatePhone(node, rng), true, nil
+	case "phone-e164":
+		return g.generatePhoneE164(rng), true, nil
+	case "ipv4":
+		return g.generateIPv4(rng), true, nil
+	case "ipv6":
+		return g.generateIPv6(rng), true, nil
+	case "hostname":
+		return g.generateHostname(rng), true, nil
+	case "vin":
+		value, err := g.generateVIN(node.WMI, rng)
+		return value, true, err
+	case "credit-card":
+		value, err := g.generateCreditCard(rng)
+		return value, true, err
+	case "iban":
+		value, err := g.generateIBAN(rng)
+		return value, true, err
+	case "bic":
+		return g.generateBIC(rng), true, nil
+	case "loinc":
+		value, err := g.generateLOINC(rng)
+		return value, true, err
+	}
+	return "", false, nil
+}
+
+// fitFormatLength checks a formatted string against minLength and
+// maxLength. Emails and URIs are shortened or lengthened where the format
+// allows it and phone numbers lose their separators; a format that cannot
+// fit the bounds is an error.
+func (g *DeterministicGenerator) fitFormatLength(node *schema.SchemaNode, value string, rng *mathrand.Rand) (string, error) {
+	minLen, maxLen := 0, math.MaxInt
+	if node.MinLength != nil {
+		minLen = *node.MinLength
+	}
+	if node.MaxLength != nil {
+		maxLen = *node.MaxLength
+	}
+	if len(value) >= minLen && len(value) <= maxLen {
+		return value, nil
+	}
+
+	fitted, ok := "", false
+	switch node.Format {
+	case "email":
+		fitted, ok = fitEmail(value, minLen, maxLen, rng)
+	case "uri":
+		fitted, ok = fitURI(value, minLen, maxLen, rng)
+	case "phone":
+		fitted, ok = compactPhone(value, minLen, maxLen)
+	}
+	if ok {
+		return fitted, nil
+	}
+
+	if len(value) > maxLen {
+		return "", fmt.Errorf("format %s of %s cannot be generated within maxLength %d, as in %q", node.Format, node.Path, maxLen, value)
+	}
+	return "", fmt.Errorf("format %s of %s cannot be generated with minLength %d, as in %q", node.Format, node.Path, minLen, value)
+}
+
 // generateInteger generates integer values with min/max constraints
 func (g *DeterministicGenerator) generateInteger(node *schema.SchemaNode, rng *mathrand.Rand) (int64, error) {
 	min := int64(0)
 	max := int64(1000)
+	hasMin, hasMax := false, false
 
 	if node.Minimum != nil {
-		min = int64(*node.Minimum)
+		min = int64(math.Ceil(*node.Minimum))
+		hasMin = true
+	}
+	if node.ExclusiveMinimum != nil {
+		if exMin := int64(math.Floor(*node.ExclusiveMinimum)) + 1; !hasMin || exMin > min {
+			min = exMin
+		}
+		hasMin = true
 	}
 	if node.Maximum != nil {
-		max = int64(*node.Maximum)
+		max = int64(math.Floor(*node.Maximum))
+		hasMax = true
+	}
+	if node.ExclusiveMaximum != nil {
+		if exMax := int64(math.Ceil(*node.ExclusiveMaximum)) - 1; !hasMax || exMax < max {
+			max = exMax
+		}
+		hasMax = true
 	}
 
 	if max < min {
-		max = min
+		if hasMin && hasMax {
+			return 0, fmt.Errorf("no integer satisfies the bounds of %s (lowest allowed %d, highest allowed %d)", node.Path, min, max)
+		}
+		// Only one bound is set, so move the default one out of the way
+		if hasMin {
+			max = min + 1000
+		} else {
+			min = max - 1000
+		}
 	}
 
-	value := min + rng.Int63n(max-min+1)
+	var value int64
+	if node.Distribution == nil || node.Distribution.Type == "uniform" {
+		value = min + rng.Int63n(max-min+1)
+	} else {
+		// Sample the continuous range covering [min, max] and round
+		sample, err := sampleInRange(node.Distribution, float64(min)-0.5, float64(max)+0.5, rng)
+		if err != nil {
+			return 0, fmt.Errorf("invalid distribution for %s: %w", node.Path, err)
+		}
+		value = int64(math.Round(sample))
+		if value < min {
+			value = min
+		}
+		if value > max {
+			value = max
+		}
+	}
 
-	// Apply multipleOf constraint
+	// Apply multipleOf constraint, stepping from the lowest multiple in
+	// range so negative bounds round the same way as positive ones
 	if node.MultipleOf != nil {
 		multiple := int64(*node.MultipleOf)
 		if multiple > 0 {
-			value = (value / multiple) * multiple
+			first, last := ceilDiv(min, multiple)*multiple, floorDiv(max, multiple)*multiple
+			if first > last {
+				return 0, fmt.Errorf("no multiple of %d satisfies the bounds of %s", multiple, node.Path)
+			}
+			step := floorDiv(value-first, multiple)
+			if step < 0 {
+				step = 0
+			}
+			value = first + step*multiple
+			if value > last {
+				value = last
+			}
 		}
 	}
 
 	return value, nil
 }
 
+// floorDiv divides a by a positive b, rounding toward negative infinity
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// ceilDiv divides a by a positive b, rounding toward positive infinity
+func ceilDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a > 0 {
+		q++
+	}
+	return q
+}
+
 // generateNumber generates float values with min/max constraints
 func (g *DeterministicGenerator) generateNumber(node *schema.SchemaNode, rng *mathrand.Rand) (float64, error) {
 	min := 0.0
 	max := 1000.0
+	hasMin, hasMax := false, false
+	exclusiveMin, exclusiveMax := false, false
 
 	if node.Minimum != nil {
 		min = *node.Minimum
+		hasMin = true
+	}
+	if node.ExclusiveMinimum != nil && (!hasMin || *node.ExclusiveMinimum >= min) {
+		min = *node.ExclusiveMinimum
+		hasMin, exclusiveMin = true, true
 	}
 	if node.Maximum != nil {
 		max = *node.Maximum
+		hasMax = true
+	}
+	if node.ExclusiveMaximum != nil && (!hasMax || *node.ExclusiveMaximum <= max) {
+		max = *node.ExclusiveMaximum
+		hasMax, exclusiveMax = true, true
 	}
 
-	if max < min {
-		max = min
+	if max < min || (max == min && (exclusiveMin || exclusiveMax)) {
+		if hasMin && hasMax {
+			return 0, fmt.Errorf("no number satisfies the bounds of %s (%v to %v)", node.Path, min, max)
+		}
+		// Only one bound is set, so move the default one out of the way
+		if hasMin {
+			max = min + 1000
+		} else {
+			min = max - 1000
+		}
 	}
 
-	value := min + rng.Float64()*(max-min)
+	value, err := sampleInRange(node.Distribution, min, max, rng)
+	if err != nil {
+		return 0, fmt.Errorf("invalid distribution for %s: %w", node.Path, err)
+	}
 
 	// Apply multipleOf constraint
 	if node.MultipleOf != nil && *node.MultipleOf > 0 {
-		value = math.Round(value/(*node.MultipleOf)) * (*node.MultipleOf)
+		multiple := *node.MultipleOf
+		value = math.Round(value/multiple) * multiple
+		if value < min || (exclusiveMin && value <= min) {
+			value += multiple
+		}
+		if value > max || (exclusiveMax && value >= max) {
+			value -= multiple
+		}
+		if value < min || value > max || (exclusiveMin && value <= min) || (exclusiveMax && value >= max) {
+			return 0, fmt.Errorf("no multiple of %v satisfies the bounds of %s", multiple, node.Path)
+		}
+		return value, nil
+	}
+
+	// Keep strict bounds strict
+	if exclusiveMin && value <= min {
+		value = math.Nextafter(min, math.Inf(1))
+	}
+	if exclusiveMax && value >= max {
+		value = math.Nextafter(max, math.Inf(-1))
 	}
 
 	return value, nil
 }
 
 // generateArray generates array values with item constraints
-func (g *DeterministicGenerator) generateArray(node *schema.SchemaNode, rng *mathrand.Rand) ([]interface{}, error) {
-	if node.Items == nil {
+func (g *DeterministicGenerator) generateArray(node *schema.SchemaNode, rng *mathrand.Rand, seed int64) ([]interface{}, error) {
+	if len(node.TupleItems) > 0 {
+		return g.generateTuple(node, rng, seed)
+	}
+	if node.Items == nil && node.Contains == nil {
 		return []interface{}{}, nil
 	}
 
@@ -201,36 +595,184 @@ func (g *DeterministicGenerator) generateArray(node *schema.SchemaNode, rng *mat
 		}
 	}
 
-	length := minItems + rng.Intn(maxItems-minItems+1)
-	result := make([]interface{}, length)
+	if node.Contains != nil {
+		return g.generateContains(node, minItems, maxItems, rng, seed)
+	}
+	return g.generateItems(node, minItems, maxItems, rng, seed)
+}
+
+// generateContains generates an array holding minContains items from the
+// contains schema, placed at random positions among items from the items
+// schema. The matching items count towards the array length. Other items
+// may match contains by chance, so maxContains is only checked by
+// validation.
+func (g *DeterministicGenerator) generateContains(node *schema.SchemaNode, minItems, maxItems int, rng *mathrand.Rand, seed int64) ([]interface{}, error) {
+	// The parser rejects a maxItems below minContains, so this only lifts
+	// the default upper bound
+	if maxItems < node.MinContains {
+		maxItems = node.MinContains
+	}
+	length := sampleLength(node.LengthDist, minItems, maxItems, rng)
+	if length < node.MinContains {
+		length = node.MinContains
+	}
+
+	// Matching items come first so uniqueItems never crowds them out
+	matches := make([]interface{}, 0, node.MinContains)
+	for i := 0; i < node.MinContains; i++ {
+		value, ok, err := g.generateDistinctItem(node, node.Contains, fmt.Sprintf("%s{contains}[%d]", node.Path, i), matches, seed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate contains item %d: %w", i, err)
+		}
+		if !ok {
+			log.Warn().
+				Str("path", node.Path).
+				Int("min_contains", node.MinContains).
+				Int("generated", len(matches)).
+				Msg("Not enough distinct values for uniqueItems, array has fewer than minContains matching items")
+			break
+		}
+		matches = append(matches, value)
+	}
+
+	others := length - len(matches)
+	result := make([]interface{}, 0, others+len(matches))
+	for i := 0; i < others; i++ {
+		value, ok, err := g.generateArrayItem(node, i, append(matches[:len(matches):len(matches)], result...), seed)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		result = append(result, value)
+	}
+
+	for _, match := range matches {
+		pos := rng.Intn(len(result) + 1)
+		result = append(result, nil)
+		copy(result[pos+1:], result[pos:])
+		result[pos] = match
+	}
+	return result, nil
+}
+
+// generateTuple generates a tuple array. By default the full tuple is
+// emitted; minItems may allow a shorter prefix and maxItems longer arrays
+// when items past the tuple are allowed.
+func (g *DeterministicGenerator) generateTuple(node *schema.SchemaNode, rng *mathrand.Rand, seed int64) ([]interface{}, error) {
+	tupleLen := len(node.TupleItems)
+	minItems, maxItems := tupleLen, tupleLen
+
+	if node.MinItems != nil {
+		minItems = *node.MinItems
+	}
+	if node.MaxItems != nil && !node.ClosedTuple {
+		maxItems = *node.MaxItems
+	}
+	if node.ClosedTuple && minItems > tupleLen {
+		return nil, fmt.Errorf("minItems %d of %s exceeds its closed tuple of %d items", minItems, node.Path, tupleLen)
+	}
+	if maxItems < minItems {
+		maxItems = minItems
+	}
+
+	return g.generateItems(node, minItems, maxItems, rng, seed)
+}
+
+// generateItems generates between minItems and maxItems array elements
+func (g *DeterministicGenerator) generateItems(node *schema.SchemaNode, minItems, maxItems int, rng *mathrand.Rand, seed int64) ([]interface{}, error) {
+	length := sampleLength(node.LengthDist, minItems, maxItems, rng)
+	result := make([]interface{}, 0, length)
 
 	for i := 0; i < length; i++ {
+		value, ok, err := g.generateArrayItem(node, i, result, seed)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			// The item value space is exhausted, so the array stays shorter
+			break
+		}
+		result = append(result, value)
+	}
+
+	if len(result) < minItems {
+		log.Warn().
+			Str("path", node.Path).
+			Int("min_items", minItems).
+			Int("generated", len(result)).
+			Msg("Not enough distinct values for uniqueItems, array is shorter than minItems")
+	}
+
+	return result, nil
+}
+
+// generateArrayItem generates the item at index i of an array drawn from
+// the stream created from seed. With uniqueItems set it retries with derived
+// seeds until the value differs from every earlier item, reporting false if
+// no distinct value was found.
+func (g *DeterministicGenerator) generateArrayItem(node *schema.SchemaNode, i int, existing []interface{}, seed int64) (interface{}, bool, error) {
+	value, ok, err := g.generateDistinctItem(node, node.ItemAt(i), fmt.Sprintf("%s[%d]", node.Path, i), existing, seed)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to generate array item %d: %w", i, err)
+	}
+	return value, ok, nil
+}
+
+// generateDistinctItem generates an item of array node from itemNode, seeded
+// by itemPath and the seed of the array's stream. A nil itemNode allows any
+// value. With uniqueItems set it retries until the value differs from every
+// existing item.
+func (g *DeterministicGenerator) generateDistinctItem(node, itemNode *schema.SchemaNode, itemPath string, existing []interface{}, seed int64) (interface{}, bool, error) {
+	for attempt := 0; attempt < maxUniqueAttempts; attempt++ {
 		// Create unique seed for each array item
-		itemSeed := g.deriveSeed(fmt.Sprintf("%s[%d]", node.Path, i), 0)
+		attemptPath := itemPath
+		if attempt > 0 {
+			attemptPath = fmt.Sprintf("%s#%d", itemPath, attempt)
+		}
+		itemSeed := streamSeed(seed, attemptPath)
 		itemRng := mathrand.New(mathrand.NewSource(itemSeed))
 
-		value, err := g.generateValue(node.Items, itemRng)
+		valueNode := itemNode
+		if valueNode == nil {
+			// Past the tuple with no schema for further items
+			valueNode = &schema.SchemaNode{Path: attemptPath}
+		}
+		value, err := g.generateValue(valueNode, itemRng, itemSeed)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate array item %d: %w", i, err)
+			return nil, false, err
+		}
+		if !node.UniqueItems || !containsValue(existing, value) {
+			return value, true, nil
 		}
-		result[i] = value
 	}
 
-	return result, nil
+	return nil, false, nil
+}
+
+// containsValue reports whether values holds an element deeply equal to value
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, existing := range values {
+		if reflect.DeepEqual(existing, value) {
+			return true
+		}
+	}
+	return false
 }
 
 // generateObject generates object values with property constraints
-func (g *DeterministicGenerator) generateObject(node *schema.SchemaNode, rng *mathrand.Rand) (map[string]interface{}, error) {
+func (g *DeterministicGenerator) generateObject(node *schema.SchemaNode, rng *mathrand.Rand, seed int64) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
 
-	if node.Properties == nil {
+	if node.Properties == nil && node.PatternProperties == nil && node.AdditionalProperties == nil && node.Conditionals == nil && node.MinProperties == nil {
 		return result, nil
 	}
 
 	// Generate required fields first
 	for _, propName := range node.Required {
 		if prop, exists := node.Properties[propName]; exists {
-			value, err := g.generateValue(prop, rng)
+			value, err := g.generateValue(prop, rng, seed)
 			if err != nil {
 				return nil, fmt.Errorf("failed to generate required property %s: %w", propName, err)
 			}
@@ -244,11 +786,29 @@ func (g *DeterministicGenerator) generateObject(node *schema.SchemaNode, rng *ma
 		requiredMap[req] = true
 	}
 
-	for propName, prop := range node.Properties {
+	// Iterate in sorted order so the RNG is consumed reproducibly
+	propNames := make([]string, 0, len(node.Properties))
+	for propName := range node.Properties {
+		propNames = append(propNames, propName)
+	}
+	sort.Strings(propNames)
+
+	// Properties with x-optional-when come last, so their conditions can
+	// read every other property
+	var conditional []string
+	for _, propName := range propNames {
+		prop := node.Properties[propName]
 		if !requiredMap[propName] {
+			if len(prop.OptionalWhen) > 0 {
+				conditional = append(conditional, propName)
+				continue
+			}
+			if propertiesFull(node, result) {
+				continue
+			}
 			// Use field-specific probability
 			if rng.Float64() < prop.OptionalProb {
-				value, err := g.generateValue(prop, rng)
+				value, err := g.generateValue(prop, rng, seed)
 				if err != nil {
 					return nil, fmt.Errorf("failed to generate optional property %s: %w", propName, err)
 				}
@@ -256,23 +816,371 @@ func (g *DeterministicGenerator) generateObject(node *schema.SchemaNode, rng *ma
 			}
 		}
 	}
+	for _, propName := range conditional {
+		prop := node.Properties[propName]
+		if propertiesFull(node, result) {
+			continue
+		}
+		if rng.Float64() < optionalProbability(prop, result) {
+			value, err := g.generateValue(prop, rng, seed)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate optional property %s: %w", propName, err)
+			}
+			result[propName] = value
+		}
+	}
+
+	for _, pp := range node.PatternProperties {
+		if err := g.generatePatternProperties(node, pp, result, rng, seed); err != nil {
+			return nil, err
+		}
+	}
+
+	if node.AdditionalProperties != nil {
+		if err := g.generateAdditionalProperties(node, result, rng, seed); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := g.applyDependencies(node, result, rng, seed); err != nil {
+		return nil, err
+	}
+	for _, conditional := range node.Conditionals {
+		branch, keys := conditional.Branch(result)
+		if err := g.applyBranch(branch, keys, result, rng, seed); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := g.fillProperties(node, result, rng, seed); err != nil {
+		return nil, err
+	}
+
+	// Regenerate fields related by ordering rules so the rules hold
+	g.applyOrdering(node, result, rng)
+
+	// Overwrite geo-IP fields with a coherent tuple
+	if node.GeoIP != nil {
+		if err := g.applyGeoIP(node.GeoIP, result, rng); err != nil {
+			return nil, fmt.Errorf("failed to generate geo-IP fields: %w", err)
+		}
+	}
 
 	return result, nil
 }
 
+// optionalProbability returns the chance of generating an optional property:
+// that of its first x-optional-when condition the generated siblings meet,
+// or its own
+func optionalProbability(prop *schema.SchemaNode, siblings map[string]interface{}) float64 {
+	for _, condition := range prop.OptionalWhen {
+		if condition.Matches(siblings) {
+			return condition.Probability
+		}
+	}
+	return prop.OptionalProb
+}
+
+// propertiesFull reports whether an object already has maxProperties keys,
+// so no optional or undeclared key may be added
+func propertiesFull(node *schema.SchemaNode, result map[string]interface{}) bool {
+	return node.MaxProperties != nil && len(result) >= *node.MaxProperties
+}
+
+// fillProperties adds keys until an object has minProperties of them:
+// declared optional properties first, in sorted order, then undeclared keys
+// from additionalProperties or the first patternProperties entry
+func (g *DeterministicGenerator) fillProperties(node *schema.SchemaNode, result map[string]interface{}, rng *mathrand.Rand, seed int64) error {
+	if node.MinProperties == nil || len(result) >= *node.MinProperties {
+		return nil
+	}
+
+	propNames := make([]string, 0, len(node.Properties))
+	for propName := range node.Properties {
+		propNames = append(propNames, propName)
+	}
+	sort.Strings(propNames)
+	for _, propName := range propNames {
+		if len(result) >= *node.MinProperties {
+			return nil
+		}
+		if _, present := result[propName]; present {
+			continue
+		}
+		value, err := g.generateValue(node.Properties[propName], rng, seed)
+		if err != nil {
+			return fmt.Errorf("failed to generate optional property %s: %w", propName, err)
+		}
+		result[propName] = value
+	}
+
+	var owner *schema.PatternProperty
+	extra := node.AdditionalProperties
+	if extra == nil && len(node.PatternProperties) > 0 {
+		owner = node.PatternProperties[0]
+		extra = owner.Node
+	}
+	for extra != nil && len(result) < *node.MinProperties {
+		key, ok, err := g.generatePropertyKey(node, owner, result, rng)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		value, err := g.generateValue(extra, rng, seed)
+		if err != nil {
+			return fmt.Errorf("failed to generate additional property %s: %w", key, err)
+		}
+		result[key] = value
+	}
+
+	if len(result) < *node.MinProperties {
+		log.Warn().Str("path", node.Path).Int("min_properties", *node.MinProperties).Int("properties", len(result)).
+			Msg("Could not generate enough properties to meet minProperties")
+	}
+	return nil
+}
+
+// applyDependencies generates the properties that present properties
+// depend on, whatever their optional probability. A dependent property can
+// be a trigger itself, so triggers are checked until nothing changes; each
+// dependent schema is applied once.
+func (g *DeterministicGenerator) applyDependencies(node *schema.SchemaNode, result map[string]interface{}, rng *mathrand.Rand, seed int64) error {
+	if node.DependentRequired == nil && node.DependentSchemas == nil {
+		return nil
+	}
+
+	triggers := make([]string, 0, len(node.DependentRequired)+len(node.DependentSchemas))
+	for trigger := range node.DependentRequired {
+		triggers = append(triggers, trigger)
+	}
+	for trigger := range node.DependentSchemas {
+		if _, ok := node.DependentRequired[trigger]; !ok {
+			triggers = append(triggers, trigger)
+		}
+	}
+	sort.Strings(triggers)
+
+	applied := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, trigger := range triggers {
+			if _, present := result[trigger]; !present || applied[trigger] {
+				continue
+			}
+			applied[trigger] = true
+			changed = true
+
+			for _, name := range node.DependentRequired[trigger] {
+				if _, present := result[name]; present {
+					continue
+				}
+				prop := node.PropertyNode(name)
+				if prop == nil {
+					prop = &schema.SchemaNode{Path: propertyPath(node, name)}
+				}
+				value, err := g.generateValue(prop, rng, seed)
+				if err != nil {
+					return fmt.Errorf("failed to generate dependent property %s: %w", name, err)
+				}
+				result[name] = value
+			}
+			if dependency := node.DependentSchemas[trigger]; dependency != nil {
+				if err := g.applyBranch(dependency.Schema, dependency.Keys, result, rng, seed); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// propertyPath returns the schema path of a property of node
+func propertyPath(node *schema.SchemaNode, name string) string {
+	if node.Path == "" {
+		return name
+	}
+	return node.Path + "." + name
+}
+
+// applyBranch applies a schema that holds on top of an object's own, such
+// as the then or else of a conditional that applies, by regenerating the
+// properties it constrains: those it requires and those already present.
+// Properties an if schema looks at are not in keys, so the same branch
+// keeps applying.
+func (g *DeterministicGenerator) applyBranch(branch *schema.SchemaNode, keys []string, result map[string]interface{}, rng *mathrand.Rand, seed int64) error {
+	if branch == nil {
+		return nil
+	}
+
+	required := make(map[string]bool, len(branch.Required))
+	for _, name := range branch.Required {
+		required[name] = true
+	}
+	for _, name := range keys {
+		prop := branch.PropertyNode(name)
+		if _, present := result[name]; !present && !required[name] {
+			continue
+		}
+		if prop == nil {
+			// Required with no schema for its value
+			prop = &schema.SchemaNode{Path: propertyPath(branch, name)}
+		}
+		value, err := g.generateValue(prop, rng, seed)
+		if err != nil {
+			return fmt.Errorf("failed to generate property %s: %w", name, err)
+		}
+		result[name] = value
+	}
+	return nil
+}
+
+// maxAdditionalProperties caps how many undeclared keys are added to an
+// object whose additionalProperties is a schema
+const maxAdditionalProperties = 3
+
+// maxPatternPropertyKeys caps how many keys are generated per
+// patternProperties entry
+const maxPatternPropertyKeys = 3
+
+// generatePatternProperties adds between one and maxPatternPropertyKeys keys
+// generated from the pattern, with values from its schema
+func (g *DeterministicGenerator) generatePatternProperties(node *schema.SchemaNode, pp *schema.PatternProperty, result map[string]interface{}, rng *mathrand.Rand, seed int64) error {
+	count := 1 + rng.Intn(maxPatternPropertyKeys)
+	for i := 0; i < count && !propertiesFull(node, result); i++ {
+		key, ok, err := g.generatePropertyKey(node, pp, result, rng)
+		if err != nil {
+			return fmt.Errorf("failed to generate key for patternProperties %q: %w", pp.Pattern, err)
+		}
+		if !ok {
+			continue
+		}
+
+		value, err := g.generateValue(pp.Node, rng, seed)
+		if err != nil {
+			return fmt.Errorf("failed to generate pattern property %s: %w", key, err)
+		}
+		result[key] = value
+	}
+	return nil
+}
+
+// generateAdditionalProperties adds a seeded number of extra keys with
+// values from the additionalProperties schema. Keys never reuse a declared
+// property name, even one that was left out of this record.
+func (g *DeterministicGenerator) generateAdditionalProperties(node *schema.SchemaNode, result map[string]interface{}, rng *mathrand.Rand, seed int64) error {
+	count := rng.Intn(maxAdditionalProperties + 1)
+	for i := 0; i < count && !propertiesFull(node, result); i++ {
+		key, ok, err := g.generatePropertyKey(node, nil, result, rng)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		value, err := g.generateValue(node.AdditionalProperties, rng, seed)
+		if err != nil {
+			return fmt.Errorf("failed to generate additional property %s: %w", key, err)
+		}
+		result[key] = value
+	}
+	return nil
+}
+
+// generatePropertyKey returns a new key for an undeclared property. Keys for
+// a pattern property are generated from its regex; other keys look like
+// "attr_kqzv". The key must be neither declared nor already present, and may
+// only match the owning pattern so its value is checked against one schema.
+func (g *DeterministicGenerator) generatePropertyKey(node *schema.SchemaNode, owner *schema.PatternProperty, result map[string]interface{}, rng *mathrand.Rand) (string, bool, error) {
+	for attempt := 0; attempt < maxUniqueAttempts; attempt++ {
+		var key string
+		if owner != nil {
+			var err error
+			if key, err = g.generateFromPattern(owner.Pattern, rng); err != nil {
+				return "", false, err
+			}
+		} else {
+			key = "attr_" + g.generateLowercase(4+rng.Intn(5), rng)
+		}
+
+		if keyAvailable(node, owner, result, key) {
+			return key, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// keyAvailable reports whether key is free in result and matches exactly the
+// owning pattern property, or none when owner is nil
+func keyAvailable(node *schema.SchemaNode, owner *schema.PatternProperty, result map[string]interface{}, key string) bool {
+	if _, declared := node.Properties[key]; declared {
+		return false
+	}
+	if _, taken := result[key]; taken {
+		return false
+	}
+	for _, pp := range node.PatternProperties {
+		if pp.Matches(key) != (pp == owner) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *DeterministicGenerator) generateLowercase(length int, rng *mathrand.Rand) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	result := make([]byte, length)
+	for i := range result {
+		result[i] = letters[rng.Intn(len(letters))]
+	}
+	return string(result)
+}
+
 // Format-specific generators
 
+// emailDomains are the domains of generated email addresses
+var emailDomains = []string{"example.com", "test.org", "sample.net", "demo.co"}
+
 func (g *DeterministicGenerator) generateEmail(rng *mathrand.Rand) string {
-	domains := []string{"example.com", "test.org", "sample.net", "demo.co"}
 	names := []string{"user", "test", "demo", "sample", "john", "jane", "admin"}
 
 	name := names[rng.Intn(len(names))]
-	domain := domains[rng.Intn(len(domains))]
+	domain := emailDomains[rng.Intn(len(emailDomains))]
 	suffix := rng.Intn(1000)
 
 	return fmt.Sprintf("%s%d@%s", name, suffix, domain)
 }
 
+// fitEmail truncates or pads the local part of an email address with digits
+// to fit [minLen, maxLen], moving to the shortest domain when the address
+// is too long for its own. Local parts are at most 64 characters.
+func fitEmail(email string, minLen, maxLen int, rng *mathrand.Rand) (string, bool) {
+	local, domain, _ := strings.Cut(email, "@")
+	if len(domain)+2 > maxLen {
+		for _, shorter := range emailDomains {
+			if len(shorter) < len(domain) {
+				domain = shorter
+			}
+		}
+	}
+
+	if room := maxLen - len(domain) - 1; len(local) > room {
+		if room < 1 {
+			return "", false
+		}
+		local = local[:room]
+	}
+	for len(local)+1+len(domain) < minLen {
+		local += strconv.Itoa(rng.Intn(10))
+	}
+	if len(local) > 64 {
+		return "", false
+	}
+	return local + "@" + domain, true
+}
+
 func (g *DeterministicGenerator) generateUUID(rng *mathrand.Rand) string {
 	b := make([]byte, 16)
 	_, err := rng.Read(b)
@@ -287,315 +1195,411 @@ func (g *DeterministicGenerator) generateUUID(rng *mathrand.Rand) string {
 	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
 }
 
-func (g *DeterministicGenerator) generateDate(rng *mathrand.Rand) string {
+func (g *DeterministicGenerator) generateDate(loc *time.Location, rng *mathrand.Rand) string {
 	// Generate date within last 5 years
-	now := time.Now()
+	now := g.today
 	start := now.AddDate(-5, 0, 0)
 	days := int(now.Sub(start).Hours() / 24)
 
 	randomDays := rng.Intn(days)
 	date := start.AddDate(0, 0, randomDays)
 
-	return date.Format("2006-01-02")
+	return date.In(loc).Format("2006-01-02")
 }
 
-func (g *DeterministicGenerator) generateDateTime(rng *mathrand.Rand) string {
+func (g *DeterministicGenerator) generateDateTime(loc *time.Location, rng *mathrand.Rand) string {
 	// Generate datetime within last year
-	now := time.Now()
+	now := g.today
 	start := now.AddDate(-1, 0, 0)
 	duration := now.Sub(start)
 
 	randomDuration := time.Duration(rng.Int63n(int64(duration)))
 	dateTime := start.Add(randomDuration)
 
-	return dateTime.Format(time.RFC3339)
+	return dateTime.In(loc).Format(time.RFC3339)
 }
 
+// generateTime generates an RFC 3339 full-time such as 14:30:00Z, within
+// the node's x-time-range or anywhere in the day. Times carry the current
+// UTC offset of the node's timezone.
+func (g *DeterministicGenerator) generateTime(node *schema.SchemaNode, rng *mathrand.Rand) string {
+	start, end := 0, 24*60*60-1
+	if node.TimeRange != nil {
+		start, end = node.TimeRange.Start, node.TimeRange.End
+	}
+	seconds := start + rng.Intn(end-start+1)
+
+	_, offset := g.today.In(g.location(node)).Zone()
+	wallClock := time.Date(2000, time.January, 1, 0, 0, seconds, 0, time.FixedZone("", offset))
+	return wallClock.Format("15:04:05Z07:00")
+}
+
+// durationUnits are the steps generated durations are rounded to, so they
+// read like P3DT4H as well as PT2H17M45S
+var durationUnits = []int64{1, 60, 3600, 24 * 3600}
+
+// generateDuration generates an ISO 8601 duration within the node's
+// x-duration-range, or of up to schema.DefaultDurationMax
+func (g *DeterministicGenerator) generateDuration(node *schema.SchemaNode, rng *mathrand.Rand) string {
+	bounds := schema.DurationRange{Max: schema.DefaultDurationMax}
+	if node.DurationRange != nil {
+		bounds = *node.DurationRange
+	}
+	min, max := int64(bounds.Min/time.Second), int64(bounds.Max/time.Second)
+	seconds := min + rng.Int63n(max-min+1)
+
+	// Round down to a whole unit when that stays in range
+	unit := durationUnits[rng.Intn(len(durationUnits))]
+	if rounded := seconds - seconds%unit; rounded >= min {
+		seconds = rounded
+	}
+	return formatISODuration(seconds)
+}
+
+// formatISODuration writes a number of seconds as an ISO 8601 duration in
+// days, hours, minutes and seconds, leaving out zero components. Zero is
+// PT0S.
+func formatISODuration(seconds int64) string {
+	if seconds == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	b.WriteString("P")
+	if days := seconds / (24 * 3600); days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	hours, minutes, secs := seconds/3600%24, seconds/60%60, seconds%60
+	if hours > 0 || minutes > 0 || secs > 0 {
+		b.WriteString("T")
+	}
+	for _, part := range []struct {
+		value int64
+		unit  string
+	}{{hours, "H"}, {minutes, "M"}, {secs, "S"}} {
+		if part.value > 0 {
+			fmt.Fprintf(&b, "%d%s", part.value, part.unit)
+		}
+	}
+	return b.String()
+}
+
+// location returns the timezone for rendering a node's dates: its
+// x-timezone if set, otherwise the configured one
+func (g *DeterministicGenerator) location(node *schema.SchemaNode) *time.Location {
+	if node.Location != nil {
+		return node.Location
+	}
+	return g.loc
+}
+
+// uriHosts are the hosts of generated URIs
+var uriHosts = []string{"example.com", "test.org", "api.sample.net"}
+
 func (g *DeterministicGenerator) generateURI(rng *mathrand.Rand) string {
 	schemes := []string{"http", "https"}
-	hosts := []string{"example.com", "test.org", "api.sample.net"}
 	paths := []string{"/api/v1", "/data", "/users", "/items"}
 
 	scheme := schemes[rng.Intn(len(schemes))]
-	host := hosts[rng.Intn(len(hosts))]
+	host := uriHosts[rng.Intn(len(uriHosts))]
 	path := paths[rng.Intn(len(paths))]
 	id := rng.Intn(10000)
 
 	return fmt.Sprintf("%s://%s%s/%d", scheme, host, path, id)
 }
 
-func (g *DeterministicGenerator) generatePhone(rng *mathrand.Rand) string {
-	// Generate US phone number format
-	area := 200 + rng.Intn(800)
-	exchange := 200 + rng.Intn(800)
-	number := rng.Intn(10000)
-
-	return fmt.Sprintf("(%03d) %03d-%04d", area, exchange, number)
-}
-
-func (g *DeterministicGenerator) generateFromPattern(pattern string, rng *mathrand.Rand) (string, error) {
-	// Enhanced pattern generation with specific pattern recognition
-
-	// Handle common e-commerce patterns
-	switch pattern {
-	case "^[A-Z]{2}[0-9]{6}$":
-		// SKU format: 2 uppercase letters + 6 digits
-		letters := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
-		result := make([]rune, 8)
-		result[0] = letters[rng.Intn(len(letters))]
-		result[1] = letters[rng.Intn(len(letters))]
-		for i := 2; i < 8; i++ {
-			result[i] = rune('0' + rng.Intn(10))
-		}
-		return string(result), nil
-
-	case "^PRD[0-9]{8}$":
-		// Product ID format: PRD + 8 digits
-		return fmt.Sprintf("PRD%08d", rng.Intn(100000000)), nil
-
-	case "^PRD-[0-9]{6}$":
-		// Product ID format: PRD- + 6 digits
-		return fmt.Sprintf("PRD-%06d", rng.Intn(1000000)), nil
-
-	case "^WH[0-9]{3}$":
-		// Warehouse format: WH + 3 digits
-		return fmt.Sprintf("WH%03d", rng.Intn(1000)), nil
-
-	case "^SUP[0-9]{5}$":
-		// Supplier format: SUP + 5 digits
-		return fmt.Sprintf("SUP%05d", rng.Intn(100000)), nil
-
-	case "^TXN-[0-9]{10}$":
-		// Transaction ID format: TXN- + 10 digits
-		return fmt.Sprintf("TXN-%010d", rng.Intn(1000000000)), nil
-
-	case "^[0-9]{10}$":
-		// 10 digit number (account numbers, NPI)
-		return fmt.Sprintf("%010d", rng.Intn(1000000000)), nil
-
-	case "^[0-9]{9}$":
-		// 9 digit number (routing numbers)
-		return fmt.Sprintf("%09d", rng.Intn(1000000000)), nil
-
-	case "^[0-9]{4}$":
-		// 4 digit number (MCC codes)
-		return fmt.Sprintf("%04d", rng.Intn(10000)), nil
-
-	case "^[0-9]{5}$":
-		// 5 digit number (procedure codes)
-		return fmt.Sprintf("%05d", rng.Intn(100000)), nil
-
-	case "^[A-Z][0-9]{2}\\.[0-9]{1,2}$":
-		// ICD-10 format: Letter + 2 digits + dot + 1-2 digits
-		letters := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
-		letter := letters[rng.Intn(len(letters))]
-		first := rng.Intn(100)
-		second := rng.Intn(100)
-		return fmt.Sprintf("%c%02d.%02d", letter, first, second), nil
-
-	case "^[A-Z]{2}-[A-Z]{3}-[0-9]{3}$":
-		// Warehouse location format: XX-XXX-000
-		letters := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
-		result := make([]rune, 9)
-		result[0] = letters[rng.Intn(len(letters))]
-		result[1] = letters[rng.Intn(len(letters))]
-		result[2] = '-'
-		result[3] = letters[rng.Intn(len(letters))]
-		result[4] = letters[rng.Intn(len(letters))]
-		result[5] = letters[rng.Intn(len(letters))]
-		result[6] = '-'
-		result[7] = rune('0' + rng.Intn(10))
-		result[8] = rune('0' + rng.Intn(10))
-		result = append(result, rune('0'+rng.Intn(10)))
-		return string(result), nil
-
-	// X12 EDI specific patterns
-	case "^PO[0-9]{8}$":
-		// Purchase Order format: PO + 8 digits
-		return fmt.Sprintf("PO%08d", rng.Intn(100000000)), nil
-
-	case "^[A-Z0-9]{2,15}$":
-		// Party ID format: 2-15 alphanumeric characters
-		length := 2 + rng.Intn(14) // 2-15 characters
-		charset := "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		result := make([]rune, length)
-		for i := range result {
-			result[i] = rune(charset[rng.Intn(len(charset))])
-		}
-		return string(result), nil
-
-	case "^[A-Z0-9]{6,20}$":
-		// Product ID format: 6-20 alphanumeric characters
-		length := 6 + rng.Intn(15) // 6-20 characters
-		charset := "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		result := make([]rune, length)
-		for i := range result {
-			result[i] = rune(charset[rng.Intn(len(charset))])
-		}
-		return string(result), nil
-
-	case "^MPN[A-Z0-9]{8,15}$":
-		// Manufacturer Part Number format: MPN + 8-15 alphanumeric
-		length := 8 + rng.Intn(8) // 8-15 characters after MPN
-		charset := "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		result := "MPN"
-		for i := 0; i < length; i++ {
-			result += string(charset[rng.Intn(len(charset))])
+// fitURI shortens a URI to fit maxLen by dropping its path and then moving
+// to http and the shortest host, or lengthens it to minLen with a longer
+// last path segment
+func fitURI(uri string, minLen, maxLen int, rng *mathrand.Rand) (string, bool) {
+	if len(uri) < minLen {
+		for len(uri) < minLen {
+			uri += strconv.Itoa(rng.Intn(10))
 		}
-		return result, nil
+		return uri, len(uri) <= maxLen
+	}
 
-	case "^[A-Z]{2}$":
-		// 2-letter state/country code
-		letters := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
-		return fmt.Sprintf("%c%c",
-			letters[rng.Intn(len(letters))],
-			letters[rng.Intn(len(letters))]), nil
-
-	case "^[0-9]{5}(-[0-9]{4})?$":
-		// ZIP code format: 5 digits or ZIP+4
-		zip5 := fmt.Sprintf("%05d", rng.Intn(100000))
-		if rng.Float32() < 0.3 { // 30% chance of ZIP+4
-			zip4 := fmt.Sprintf("%04d", rng.Intn(10000))
-			return fmt.Sprintf("%s-%s", zip5, zip4), nil
-		}
-		return zip5, nil
-
-	// Medical/Pharmacy specific patterns
-	case "^RX[0-9]{8}$":
-		return fmt.Sprintf("RX%08d", rng.Intn(100000000)), nil
-	case "^[0-9]{5}-[0-9]{4}-[0-9]{2}$":
-		// NDC code format
-		return fmt.Sprintf("%05d-%04d-%02d",
-			rng.Intn(100000), rng.Intn(10000), rng.Intn(100)), nil
-	case "^[A-Z]{2}[0-9]{7}$":
-		// DEA number format
-		letters := "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-		return fmt.Sprintf("%c%c%07d",
-			letters[rng.Intn(26)], letters[rng.Intn(26)], rng.Intn(10000000)), nil
-	case "^PA[0-9]{8}$":
-		// Prior authorization number
-		return fmt.Sprintf("PA%08d", rng.Intn(100000000)), nil
-	case "^INS[0-9]{6}$":
-		// Insurance ID format
-		return fmt.Sprintf("INS%06d", rng.Intn(1000000)), nil
-
-	// Healthcare Claims 837 patterns
-	case "^CLM[0-9]{10}$":
-		// Claim control number
-		return fmt.Sprintf("CLM%010d", rng.Intn(10000000000)), nil
-	case "^[A-Z0-9]{8,15}$":
-		// Insurance member ID
-		chars := "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		length := 8 + rng.Intn(8) // 8-15 characters
-		result := make([]byte, length)
-		for i := range result {
-			result[i] = chars[rng.Intn(len(chars))]
-		}
-		return string(result), nil
-	case "^[0-9]{2}-[0-9]{7}$":
-		// Federal Tax ID format
-		return fmt.Sprintf("%02d-%07d", rng.Intn(100), rng.Intn(10000000)), nil
-	case "^[A-Z0-9]{5,10}$":
-		// Payer ID
-		chars := "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		length := 5 + rng.Intn(6) // 5-10 characters
-		result := make([]byte, length)
-		for i := range result {
-			result[i] = chars[rng.Intn(len(chars))]
-		}
-		return string(result), nil
-	case "^[A-Z][0-9]{2}\\.[0-9A-Z]{1,4}$":
-		// ICD-10 diagnosis code format
-		letters := "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-		digits := "0123456789"
-		alphanumeric := "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-		suffixLength := 1 + rng.Intn(4) // 1-4 characters
-		suffix := make([]byte, suffixLength)
-		for i := range suffix {
-			suffix[i] = alphanumeric[rng.Intn(len(alphanumeric))]
-		}
-		return fmt.Sprintf("%c%c%c.%s",
-			letters[rng.Intn(26)],
-			digits[rng.Intn(10)],
-			digits[rng.Intn(10)],
-			string(suffix)), nil
-
-	case "^[A-Z0-9]{6,12}$":
-		// Insurance group number format: 6-12 alphanumeric
-		length := 6 + rng.Intn(7) // 6-12 characters
-		charset := "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		result := make([]rune, length)
-		for i := range result {
-			result[i] = rune(charset[rng.Intn(len(charset))])
-		}
-		return string(result), nil
-
-	case "^[0-9]{6}$":
-		// BIN (Bank Identification Number) format: 6 digits
-		return fmt.Sprintf("%06d", rng.Intn(1000000)), nil
-
-	case "^[A-Z0-9]{3,10}$":
-		// PCN (Processor Control Number) format: 3-10 alphanumeric
-		length := 3 + rng.Intn(8) // 3-10 characters
-		charset := "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		result := make([]rune, length)
-		for i := range result {
-			result[i] = rune(charset[rng.Intn(len(charset))])
-		}
-		return string(result), nil
-	}
-
-	// Fallback: analyze pattern structure
-	if strings.Contains(pattern, "[0-9]") && strings.Contains(pattern, "[A-Z]") {
-		// Mixed alphanumeric pattern
-		return g.generateMixedPattern(pattern, rng)
-	}
-
-	if strings.Contains(pattern, "[0-9]") {
-		// Numeric pattern - extract length from pattern
-		length := g.extractNumericLength(pattern)
-		return fmt.Sprintf("%0*d", length, rng.Intn(int(math.Pow(10, float64(length))))), nil
-	}
-
-	if strings.Contains(pattern, "[a-zA-Z]") || strings.Contains(pattern, "[A-Z]") {
-		// Alphabetic pattern
-		return g.generateRandomString(8, rng), nil
-	}
-
-	// Default to random string
-	return g.generateRandomString(10, rng), nil
+	scheme, rest, _ := strings.Cut(uri, "://")
+	host, _, _ := strings.Cut(rest, "/")
+	shortest := uriHosts[0]
+	for _, candidate := range uriHosts {
+		if len(candidate) < len(shortest) {
+			shortest = candidate
+		}
+	}
+	for _, candidate := range []string{scheme + "://" + host, "http://" + host, "http://" + shortest} {
+		if len(candidate) >= minLen && len(candidate) <= maxLen {
+			return candidate, true
+		}
+	}
+	return "", false
 }
 
-func (g *DeterministicGenerator) generateRandomString(length int, rng *mathrand.Rand) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	result := make([]byte, length)
+// compactPhone drops the spaces, brackets and dashes of a phone number when
+// that fits it within [minLen, maxLen]
+func compactPhone(phone string, minLen, maxLen int) (string, bool) {
+	compact := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' || r == '+' {
+			return r
+		}
+		return -1
+	}, phone)
+	return compact, len(compact) >= minLen && len(compact) <= maxLen
+}
 
-	for i := range result {
-		result[i] = charset[rng.Intn(len(charset))]
+// generatePhone generates a phone number in the node's locale
+func (g *DeterministicGenerator) generatePhone(node *schema.SchemaNode, rng *mathrand.Rand) string {
+	return g.fakerLocale(node).Phone(rng)
+}
+
+// e164Countries describes the mobile numbers generated in E.164 form, as a
+// country calling code and a national number layout of digits: X for any
+// digit, N for 2-9 and other digits as they are
+var e164Countries = []struct {
+	code   string
+	layout string
+}{
+	{code: "1", layout: "NXXNXXXXXX"},
+	{code: "44", layout: "7XXXXXXXXX"},
+	{code: "49", layout: "15XXXXXXXXX"},
+	{code: "33", layout: "6XXXXXXXX"},
+	{code: "34", layout: "6XXXXXXXX"},
+	{code: "39", layout: "3XXXXXXXXX"},
+	{code: "61", layout: "4XXXXXXXX"},
+	{code: "81", layout: "90XXXXXXXX"},
+	{code: "91", layout: "9XXXXXXXXX"},
+	{code: "55", layout: "NX9XXXXXXXX"},
+	{code: "52", layout: "NXXXXXXXXX"},
+	{code: "86", layout: "13XXXXXXXXX"},
+}
+
+// generatePhoneE164 generates a mobile number in E.164 form, such as
+// +447911123456
+func (g *DeterministicGenerator) generatePhoneE164(rng *mathrand.Rand) string {
+	country := e164Countries[rng.Intn(len(e164Countries))]
+
+	var phone strings.Builder
+	phone.WriteString("+" + country.code)
+	for _, char := range country.layout {
+		switch char {
+		case 'X':
+			phone.WriteByte(byte('0' + rng.Intn(10)))
+		case 'N':
+			phone.WriteByte(byte('2' + rng.Intn(8)))
+		default:
+			phone.WriteRune(char)
+		}
 	}
+	return phone.String()
+}
 
-	return string(result)
+func (g *DeterministicGenerator) generateIPv4(rng *mathrand.Rand) string {
+	// Keep the first octet away from 0 (this network) and 255 (broadcast)
+	return fmt.Sprintf("%d.%d.%d.%d", 1+rng.Intn(254), rng.Intn(256), rng.Intn(256), rng.Intn(256))
 }
 
-// generateMixedPattern generates strings for mixed alphanumeric patterns
-func (g *DeterministicGenerator) generateMixedPattern(pattern string, rng *mathrand.Rand) (string, error) {
-	// Simple implementation for mixed patterns
-	// This could be enhanced with proper regex parsing
-	return g.generateRandomString(8, rng), nil
+// generateIPv6 generates eight random hex groups. A quarter of the time a
+// run of two or more groups is zero instead, written in its compressed ::
+// form.
+func (g *DeterministicGenerator) generateIPv6(rng *mathrand.Rand) string {
+	groups := make([]string, 8)
+	for i := range groups {
+		groups[i] = strconv.FormatInt(int64(rng.Intn(0x10000)), 16)
+	}
+
+	if rng.Intn(4) != 0 {
+		return strings.Join(groups, ":")
+	}
+
+	// The zero run replaces the random groups it covers, so only the groups
+	// around it are written
+	start := rng.Intn(7)
+	end := start + 2 + rng.Intn(7-start) // at least two groups, at most up to the end
+	return strings.Join(groups[:start], ":") + "::" + strings.Join(groups[end:], ":")
 }
 
-// extractNumericLength extracts the expected length from numeric patterns
-func (g *DeterministicGenerator) extractNumericLength(pattern string) int {
-	// Extract length from patterns like [0-9]{6} or {10}
-	if strings.Contains(pattern, "{") && strings.Contains(pattern, "}") {
-		start := strings.Index(pattern, "{") + 1
-		end := strings.Index(pattern, "}")
-		if end > start {
-			if length, err := strconv.Atoi(pattern[start:end]); err == nil {
-				return length
+// hostnameTLDs are the top-level domains used for generated hostnames
+var hostnameTLDs = []string{"com", "net", "org", "io", "dev", "internal"}
+
+// generateHostname generates an RFC 1123 hostname of two to four labels.
+// Labels are at most 16 characters, so names stay far below 253.
+func (g *DeterministicGenerator) generateHostname(rng *mathrand.Rand) string {
+	const alphanumeric = "abcdefghijklmnopqrstuvwxyz0123456789"
+	const inner = alphanumeric + "-"
+
+	labels := make([]string, 1+rng.Intn(3), 4)
+	for i := range labels {
+		length := 1 + rng.Intn(16)
+		label := make([]byte, length)
+		for j := range label {
+			// Hyphens are only allowed inside a label
+			if j == 0 || j == length-1 {
+				label[j] = alphanumeric[rng.Intn(len(alphanumeric))]
+			} else {
+				label[j] = inner[rng.Intn(len(inner))]
 			}
 		}
+		labels[i] = string(label)
 	}
-	// Default length for numeric patterns
-	return 6
+	labels = append(labels, hostnameTLDs[rng.Intn(len(hostnameTLDs))])
+
+	return strings.Join(labels, ".")
+}
+
+// vinAlphabet excludes I, O and Q, which are not valid VIN characters
+const vinAlphabet = "ABCDEFGHJKLMNPRSTUVWXYZ0123456789"
+
+// vinModelYears lists the model year codes used in VIN position 10
+const vinModelYears = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+func (g *DeterministicGenerator) generateVIN(wmi string, rng *mathrand.Rand) (string, error) {
+	if len(wmi) > 3 {
+		return "", fmt.Errorf("WMI prefix must be at most 3 characters, got %q", wmi)
+	}
+
+	vin := make([]byte, 17)
+	copy(vin, wmi)
+	for i := len(wmi); i < 17; i++ {
+		vin[i] = vinAlphabet[rng.Intn(len(vinAlphabet))]
+	}
+	vin[9] = vinModelYears[rng.Intn(len(vinModelYears))]
+	vin[8] = '0'
+
+	checkDigit, err := validator.VINCheckDigit(string(vin))
+	if err != nil {
+		return "", fmt.Errorf("invalid WMI prefix %q: %w", wmi, err)
+	}
+	vin[8] = checkDigit
+
+	return string(vin), nil
+}
+
+// cardNetworks lists the issuer prefixes and number lengths used for the
+// credit-card format
+var cardNetworks = []struct {
+	prefixes []string
+	length   int
+}{
+	{prefixes: []string{"4"}, length: 16},                                          // Visa
+	{prefixes: []string{"51", "52", "53", "54", "55", "2221", "2720"}, length: 16}, // Mastercard
+	{prefixes: []string{"34", "37"}, length: 15},                                   // American Express
+}
+
+// generateCreditCard generates a card number with a real network prefix and
+// a valid Luhn check digit
+func (g *DeterministicGenerator) generateCreditCard(rng *mathrand.Rand) (string, error) {
+	network := cardNetworks[rng.Intn(len(cardNetworks))]
+	prefix := network.prefixes[rng.Intn(len(network.prefixes))]
+
+	number := make([]byte, network.length)
+	copy(number, prefix)
+	for i := len(prefix); i < network.length-1; i++ {
+		number[i] = byte('0' + rng.Intn(10))
+	}
+
+	checkDigit, err := validator.LuhnCheckDigit(string(number[:network.length-1]))
+	if err != nil {
+		return "", err
+	}
+	number[network.length-1] = checkDigit
+
+	return string(number), nil
+}
+
+// ibanCountries describes the BBAN layout of the countries IBANs are
+// generated for, as runs of digits (n), uppercase letters (a) or either (c)
+var ibanCountries = []struct {
+	code   string
+	layout string
+}{
+	{code: "DE", layout: "18n"},
+	{code: "GB", layout: "4a14n"},
+	{code: "FR", layout: "10n11c2n"},
+	{code: "ES", layout: "20n"},
+	{code: "IT", layout: "1a10n12c"},
+	{code: "NL", layout: "4a10n"},
+	{code: "BE", layout: "12n"},
+	{code: "CH", layout: "5n12c"},
+	{code: "AT", layout: "16n"},
+	{code: "SE", layout: "20n"},
+}
+
+// generateIBAN generates an IBAN with a country-specific BBAN and correct
+// mod-97 check digits
+func (g *DeterministicGenerator) generateIBAN(rng *mathrand.Rand) (string, error) {
+	country := ibanCountries[rng.Intn(len(ibanCountries))]
+
+	var bban strings.Builder
+	count := 0
+	for _, char := range country.layout {
+		if char >= '0' && char <= '9' {
+			count = count*10 + int(char-'0')
+			continue
+		}
+		charset := "0123456789"
+		switch char {
+		case 'a':
+			charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+		case 'c':
+			charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+		}
+		for i := 0; i < count; i++ {
+			bban.WriteByte(charset[rng.Intn(len(charset))])
+		}
+		count = 0
+	}
+
+	checkDigits, err := validator.IBANCheckDigits(country.code, bban.String())
+	if err != nil {
+		return "", err
+	}
+
+	return country.code + checkDigits + bban.String(), nil
+}
+
+// generateBIC generates an 8 or 11 character SWIFT/BIC code
+func (g *DeterministicGenerator) generateBIC(rng *mathrand.Rand) string {
+	const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	const alphanumeric = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	var bic strings.Builder
+	for i := 0; i < 4; i++ {
+		bic.WriteByte(letters[rng.Intn(len(letters))])
+	}
+	bic.WriteString(ibanCountries[rng.Intn(len(ibanCountries))].code)
+	for i := 0; i < 2; i++ {
+		bic.WriteByte(alphanumeric[rng.Intn(len(alphanumeric))])
+	}
+	if rng.Intn(2) == 0 {
+		// Branch code
+		for i := 0; i < 3; i++ {
+			bic.WriteByte(alphanumeric[rng.Intn(len(alphanumeric))])
+		}
+	}
+
+	return bic.String()
+}
+
+// generateLOINC generates a LOINC code with a main part of up to five digits
+// and a valid mod-10 check digit
+func (g *DeterministicGenerator) generateLOINC(rng *mathrand.Rand) (string, error) {
+	main := strconv.Itoa(1 + rng.Intn(99999))
+
+	checkDigit, err := validator.LuhnCheckDigit(main)
+	if err != nil {
+		return "", err
+	}
+
+	return main + "-" + string(checkDigit), nil
+}
+
+func (g *DeterministicGenerator) generateRandomString(length int, rng *mathrand.Rand) string {
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	result := make([]byte, length)
+
+	for i := range result {
+		result[i] = charset[rng.Intn(len(charset))]
+	}
+
+	return string(result)
 }
@@ -0,0 +1,1180 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+func float64Ptr(v float64) *float64 { return &v }
+
+func intPtr(v int) *int { return &v }
+
+// TestExclusiveBounds_Integer verifies that exclusive bounds are strict
+func TestExclusiveBounds_Integer(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	node := &schema.SchemaNode{
+		Type:             "integer",
+		ExclusiveMinimum: float64Ptr(0),
+		ExclusiveMaximum: float64Ptr(2),
+	}
+
+	for seed := int64(1); seed <= 100; seed++ {
+		value, err := generator.generateInteger(node, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("Failed to generate integer: %v", err)
+		}
+		if value != 1 {
+			t.Fatalf("Expected only 1 between exclusive bounds 0 and 2, got %d (seed: %d)", value, seed)
+		}
+	}
+}
+
+// TestExclusiveBounds_Number verifies that numbers never equal an exclusive bound
+func TestExclusiveBounds_Number(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	node := &schema.SchemaNode{
+		Type:             "number",
+		ExclusiveMinimum: float64Ptr(0),
+		Maximum:          float64Ptr(1),
+		ExclusiveMaximum: float64Ptr(0.5),
+	}
+
+	for seed := int64(1); seed <= 1000; seed++ {
+		value, err := generator.generateNumber(node, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("Failed to generate number: %v", err)
+		}
+		if value <= 0 || value >= 0.5 {
+			t.Fatalf("Generated %v outside (0, 0.5) (seed: %d)", value, seed)
+		}
+	}
+}
+
+// TestExclusiveBounds_Impossible verifies that empty ranges are reported
+func TestExclusiveBounds_Impossible(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	rng := rand.New(rand.NewSource(1))
+
+	integer := &schema.SchemaNode{Type: "integer", Path: "count", ExclusiveMinimum: float64Ptr(1), ExclusiveMaximum: float64Ptr(2)}
+	if value, err := generator.generateInteger(integer, rng); err == nil {
+		t.Errorf("Expected an error for an empty integer range, got %d", value)
+	}
+
+	number := &schema.SchemaNode{Type: "number", Path: "ratio", Minimum: float64Ptr(1), ExclusiveMaximum: float64Ptr(1)}
+	if value, err := generator.generateNumber(number, rng); err == nil {
+		t.Errorf("Expected an error for an empty number range, got %v", value)
+	}
+}
+
+// TestMultipleOf_Integer verifies integer multiples stay within the bounds
+// when the range is negative or crosses zero, and reach both ends of it
+func TestMultipleOf_Integer(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	testCases := []struct {
+		min, max, multiple float64
+	}{
+		{min: -10, max: -6, multiple: 5},
+		{min: -23, max: -3, multiple: 4},
+		{min: -7, max: 7, multiple: 3},
+		{min: 3, max: 21, multiple: 6},
+	}
+
+	for _, tc := range testCases {
+		node := &schema.SchemaNode{Type: "integer", Path: "n", Minimum: float64Ptr(tc.min), Maximum: float64Ptr(tc.max), MultipleOf: float64Ptr(tc.multiple)}
+		seen := make(map[int64]bool)
+		for seed := int64(1); seed <= 200; seed++ {
+			value, err := generator.generateInteger(node, rand.New(rand.NewSource(seed)))
+			if err != nil {
+				t.Fatalf("Failed to generate a multiple of %v in [%v, %v]: %v", tc.multiple, tc.min, tc.max, err)
+			}
+			if value < int64(tc.min) || value > int64(tc.max) || value%int64(tc.multiple) != 0 {
+				t.Fatalf("Generated %d, not a multiple of %v in [%v, %v] (seed: %d)", value, tc.multiple, tc.min, tc.max, seed)
+			}
+			seen[value] = true
+		}
+		first := int64(math.Ceil(tc.min/tc.multiple) * tc.multiple)
+		last := int64(math.Floor(tc.max/tc.multiple) * tc.multiple)
+		if !seen[first] || !seen[last] {
+			t.Errorf("Expected both %d and %d among the multiples of %v in [%v, %v], got %v", first, last, tc.multiple, tc.min, tc.max, seen)
+		}
+	}
+
+	node := &schema.SchemaNode{Type: "integer", Path: "n", Minimum: float64Ptr(-9), Maximum: float64Ptr(-6), MultipleOf: float64Ptr(5)}
+	if value, err := generator.generateInteger(node, rand.New(rand.NewSource(1))); err == nil {
+		t.Errorf("Expected an error when no multiple of 5 is in [-9, -6], got %d", value)
+	}
+}
+
+// TestStringLength_OneBound verifies a lone minLength or maxLength outside
+// the default range moves the other bound instead of being exceeded
+func TestStringLength_OneBound(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	testCases := []struct {
+		node     *schema.SchemaNode
+		min, max int
+	}{
+		{&schema.SchemaNode{Type: "string", MaxLength: intPtr(3)}, 1, 3},
+		{&schema.SchemaNode{Type: "string", MaxLength: intPtr(0)}, 0, 0},
+		{&schema.SchemaNode{Type: "string", MinLength: intPtr(30)}, 30, 45},
+		{&schema.SchemaNode{Type: "string", MinLength: intPtr(8), MaxLength: intPtr(8)}, 8, 8},
+	}
+
+	for _, tc := range testCases {
+		lengths := make(map[int]bool)
+		for seed := int64(1); seed <= 200; seed++ {
+			value, err := generator.generateString(tc.node, rand.New(rand.NewSource(seed)))
+			if err != nil {
+				t.Fatalf("Failed to generate string: %v", err)
+			}
+			if len(value) < tc.min || len(value) > tc.max {
+				t.Fatalf("Generated %q outside lengths [%d, %d]", value, tc.min, tc.max)
+			}
+			lengths[len(value)] = true
+		}
+		if len(lengths) != tc.max-tc.min+1 {
+			t.Errorf("Expected every length in [%d, %d], got %v", tc.min, tc.max, lengths)
+		}
+	}
+
+	impossible := &schema.SchemaNode{Type: "string", Path: "code", MinLength: intPtr(5), MaxLength: intPtr(3)}
+	if value, err := generator.generateString(impossible, rand.New(rand.NewSource(1))); err == nil || !strings.Contains(err.Error(), "code") {
+		t.Errorf("Expected an error naming code, got %q and %v", value, err)
+	}
+}
+
+// TestUniqueItems verifies that uniqueItems arrays hold distinct values and
+// stay reproducible for a seed
+func TestUniqueItems(t *testing.T) {
+	node := &schema.SchemaNode{
+		Type:        "array",
+		Path:        "codes",
+		MinItems:    intPtr(4),
+		MaxItems:    intPtr(4),
+		UniqueItems: true,
+		Items:       &schema.SchemaNode{Type: "integer", Path: "codes[]", Minimum: float64Ptr(1), Maximum: float64Ptr(6)},
+	}
+
+	first, err := NewDeterministicGenerator(12345).generateArray(node, rand.New(rand.NewSource(1)), 1)
+	if err != nil {
+		t.Fatalf("Failed to generate array: %v", err)
+	}
+	if len(first) != 4 {
+		t.Fatalf("Expected 4 items, got %d", len(first))
+	}
+	seen := make(map[interface{}]bool)
+	for _, item := range first {
+		if seen[item] {
+			t.Fatalf("Duplicate item %v in %v", item, first)
+		}
+		seen[item] = true
+	}
+
+	second, err := NewDeterministicGenerator(12345).generateArray(node, rand.New(rand.NewSource(1)), 1)
+	if err != nil {
+		t.Fatalf("Failed to generate array: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected identical arrays for the same seed, got %v and %v", first, second)
+	}
+}
+
+// TestUniqueItems_SmallValueSpace verifies that arrays shrink instead of
+// looping when there are fewer distinct values than minItems
+func TestUniqueItems_SmallValueSpace(t *testing.T) {
+	node := &schema.SchemaNode{
+		Type:        "array",
+		Path:        "flags",
+		MinItems:    intPtr(5),
+		UniqueItems: true,
+		Items:       &schema.SchemaNode{Type: "boolean", Path: "flags[]"},
+	}
+
+	result, err := NewDeterministicGenerator(12345).generateArray(node, rand.New(rand.NewSource(1)), 1)
+	if err != nil {
+		t.Fatalf("Failed to generate array: %v", err)
+	}
+	if len(result) != 2 {
+		t.Errorf("Expected the array to shrink to the 2 distinct booleans, got %v", result)
+	}
+}
+
+// TestNullRate verifies x-nullable and x-null-rate inject nulls at the
+// requested rate, that fields which do not allow null never get one, and
+// that the injected nulls pass validation
+func TestNullRate(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["id", "name", "code", "status", "score"],
+		"properties": {
+			"id": {"type": "integer", "x-null-rate": 1},
+			"name": {"type": "string", "minLength": 3, "x-nullable": true, "x-null-rate": 0.5},
+			"code": {"type": ["string", "null"], "x-null-rate": 1},
+			"status": {"enum": ["open", "closed"], "x-nullable": true},
+			"score": {"type": "number", "x-nullable": true, "x-null-rate": 0}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	if warnings := parser.Warnings(); len(warnings) != 1 || warnings[0].Path != "id" || warnings[0].Keyword != "x-null-rate" {
+		t.Errorf("Expected x-null-rate on id to be reported, got %+v", warnings)
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	generator.SetNullProbability(0)
+	names, statuses := 0, 0
+	for i := 0; i < 200; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		record := value.(map[string]interface{})
+		if record["id"] == nil || record["score"] == nil {
+			t.Errorf("Record %d: expected id and score never to be null, got %v", i, record)
+		}
+		if record["code"] != nil {
+			t.Errorf("Record %d: expected code always to be null, got %v", i, record["code"])
+		}
+		if record["name"] == nil {
+			names++
+		}
+		if record["status"] == nil {
+			statuses++
+		}
+		if err := parser.Validate(record); err != nil {
+			t.Errorf("Record %d does not validate: %v", i, err)
+		}
+
+		again, _ := generator.GenerateValue(root, i)
+		if !reflect.DeepEqual(value, again) {
+			t.Fatalf("Record %d: expected %v on regeneration, got %v", i, value, again)
+		}
+	}
+	if names < 60 || names > 140 {
+		t.Errorf("Expected about half the names to be null, got %d of 200", names)
+	}
+	if statuses != 0 {
+		t.Errorf("Expected no null statuses with null probability 0, got %d", statuses)
+	}
+
+	for _, invalid := range []string{
+		`{"type": "string", "x-nullable": "yes"}`,
+		`{"type": ["string", "null"], "x-null-rate": 1.5}`,
+	} {
+		parser := schema.NewParser()
+		if err := parser.ParseBytes([]byte(invalid)); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		if _, err := parser.GetRootNode(); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+// TestTypeArray_Nullable verifies that nullable fields emit both nulls and
+// values, reproducibly for each record index
+func TestTypeArray_Nullable(t *testing.T) {
+	node := &schema.SchemaNode{
+		Type:    "number",
+		Types:   []string{"number", "null"},
+		Path:    "amount",
+		Minimum: float64Ptr(0),
+		Maximum: float64Ptr(10),
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	generator.SetNullProbability(0.5)
+
+	nulls := 0
+	for i := 0; i < 200; i++ {
+		value, err := generator.GenerateValue(node, i)
+		if err != nil {
+			t.Fatalf("Failed to generate value: %v", err)
+		}
+		again, _ := generator.GenerateValue(node, i)
+		if !reflect.DeepEqual(value, again) {
+			t.Fatalf("Record %d: expected %v on regeneration, got %v", i, value, again)
+		}
+
+		switch v := value.(type) {
+		case nil:
+			nulls++
+		case float64:
+			if v < 0 || v > 10 {
+				t.Errorf("Record %d: %v outside [0, 10]", i, v)
+			}
+		default:
+			t.Errorf("Record %d: unexpected value %v (%T)", i, value, value)
+		}
+	}
+
+	if nulls == 0 || nulls == 200 {
+		t.Errorf("Expected a mix of nulls and numbers, got %d nulls out of 200", nulls)
+	}
+
+	generator.SetNullProbability(0)
+	for i := 0; i < 50; i++ {
+		if value, _ := generator.GenerateValue(node, i); value == nil {
+			t.Fatalf("Record %d: expected no nulls with null probability 0", i)
+		}
+	}
+}
+
+// TestWeightedEnum verifies that enum values follow x-enum-weights
+func TestWeightedEnum(t *testing.T) {
+	node := &schema.SchemaNode{
+		Type:        "string",
+		Path:        "status",
+		Enum:        []interface{}{"completed", "pending", "cancelled"},
+		EnumWeights: []float64{0.8, 0.15, 0.05},
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	counts := make(map[interface{}]int)
+	for i := 0; i < 10000; i++ {
+		value, err := generator.GenerateValue(node, i)
+		if err != nil {
+			t.Fatalf("Failed to generate value: %v", err)
+		}
+		counts[value]++
+	}
+
+	for i, value := range node.Enum {
+		share := float64(counts[value]) / 10000
+		if diff := share - node.EnumWeights[i]; diff > 0.02 || diff < -0.02 {
+			t.Errorf("Expected %v about %.0f%% of the time, got %.1f%%", value, node.EnumWeights[i]*100, share*100)
+		}
+	}
+}
+
+// TestConst verifies that const values are emitted unchanged for any type
+func TestConst(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+
+	for _, constVal := range []interface{}{
+		"Patient",
+		42.5,
+		false,
+		nil,
+		map[string]interface{}{"system": "http://loinc.org", "codes": []interface{}{"1234-5"}},
+	} {
+		node := &schema.SchemaNode{Type: "string", Path: "value", Const: constVal, HasConst: true}
+		value, err := generator.GenerateValue(node, 7)
+		if err != nil {
+			t.Fatalf("Failed to generate value: %v", err)
+		}
+		if !reflect.DeepEqual(value, constVal) {
+			t.Errorf("Expected const %v, got %v", constVal, value)
+		}
+	}
+
+	// Records must not share the constant object
+	node := &schema.SchemaNode{Type: "object", Path: "meta", Const: map[string]interface{}{"v": 1.0}, HasConst: true}
+	first, _ := generator.GenerateValue(node, 0)
+	first.(map[string]interface{})["v"] = 2.0
+	if second, _ := generator.GenerateValue(node, 1); second.(map[string]interface{})["v"] != 1.0 {
+		t.Errorf("Expected each record to get its own copy of the const object")
+	}
+}
+
+// TestDateTime_TimezoneIndependent verifies that the machine timezone does
+// not affect output and that a configured timezone only changes the offset
+func TestDateTime_TimezoneIndependent(t *testing.T) {
+	node := &schema.SchemaNode{Type: "string", Format: "date-time", Path: "created_at"}
+
+	original := time.Local
+	defer func() { time.Local = original }()
+
+	var outputs []interface{}
+	for _, zone := range []string{"America/New_York", "Asia/Tokyo"} {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			t.Skipf("Timezone data unavailable: %v", err)
+		}
+		time.Local = loc
+
+		value, err := NewDeterministicGenerator(12345).GenerateValue(node, 3)
+		if err != nil {
+			t.Fatalf("Failed to generate value: %v", err)
+		}
+		outputs = append(outputs, value)
+	}
+	if outputs[0] != outputs[1] {
+		t.Errorf("Expected identical output across machine timezones, got %v and %v", outputs[0], outputs[1])
+	}
+	if !strings.HasSuffix(outputs[0].(string), "Z") {
+		t.Errorf("Expected UTC by default, got %v", outputs[0])
+	}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Timezone data unavailable: %v", err)
+	}
+	generator := NewDeterministicGenerator(12345)
+	generator.SetLocation(berlin)
+	value, _ := generator.GenerateValue(node, 3)
+
+	utcTime, _ := time.Parse(time.RFC3339, outputs[0].(string))
+	berlinTime, _ := time.Parse(time.RFC3339, value.(string))
+	if !utcTime.Equal(berlinTime) || strings.HasSuffix(value.(string), "Z") {
+		t.Errorf("Expected the same instant with a Berlin offset, got %v and %v", outputs[0], value)
+	}
+
+	// x-timezone on the node wins over the configured timezone
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	node.Location = tokyo
+	if value, _ := generator.GenerateValue(node, 3); !strings.HasSuffix(value.(string), "+09:00") {
+		t.Errorf("Expected a Tokyo offset from x-timezone, got %v", value)
+	}
+}
+
+// TestTupleItems verifies positional generation and tuple length handling
+func TestTupleItems(t *testing.T) {
+	tuple := []*schema.SchemaNode{
+		{Type: "string", Path: "point[0]", Enum: []interface{}{"x"}},
+		{Type: "integer", Path: "point[1]"},
+	}
+	generator := NewDeterministicGenerator(12345)
+
+	closed := &schema.SchemaNode{Type: "array", Path: "point", TupleItems: tuple, ClosedTuple: true, MaxItems: intPtr(5)}
+	for i := 0; i < 20; i++ {
+		value, err := generator.GenerateValue(closed, i)
+		if err != nil {
+			t.Fatalf("Failed to generate tuple: %v", err)
+		}
+		items := value.([]interface{})
+		if len(items) != 2 || items[0] != "x" {
+			t.Fatalf("Expected a full 2-item tuple, got %v", items)
+		}
+		if _, ok := items[1].(int64); !ok {
+			t.Fatalf("Expected an integer in position 1, got %T", items[1])
+		}
+	}
+
+	open := &schema.SchemaNode{
+		Type: "array", Path: "row", TupleItems: tuple,
+		Items:    &schema.SchemaNode{Type: "boolean", Path: "row[]"},
+		MinItems: intPtr(4), MaxItems: intPtr(4),
+	}
+	value, err := generator.GenerateValue(open, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate tuple: %v", err)
+	}
+	items := value.([]interface{})
+	if len(items) != 4 {
+		t.Fatalf("Expected 4 items, got %v", items)
+	}
+	for _, item := range items[2:] {
+		if _, ok := item.(bool); !ok {
+			t.Errorf("Expected trailing booleans, got %v", items)
+		}
+	}
+
+	closed.MinItems = intPtr(3)
+	if _, err := generator.GenerateValue(closed, 0); err == nil {
+		t.Errorf("Expected an error when minItems exceeds a closed tuple")
+	}
+}
+
+// TestAdditionalProperties verifies extra keys are generated from the
+// additionalProperties schema without colliding with declared properties
+func TestAdditionalProperties(t *testing.T) {
+	node := &schema.SchemaNode{
+		Type: "object",
+		Path: "labels",
+		Properties: map[string]*schema.SchemaNode{
+			"team": {Type: "string", Path: "labels.team", IsRequired: true},
+		},
+		Required:             []string{"team"},
+		AdditionalProperties: &schema.SchemaNode{Type: "string", Path: "labels.*"},
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	sawExtra := false
+	for i := 0; i < 50; i++ {
+		value, err := generator.GenerateValue(node, i)
+		if err != nil {
+			t.Fatalf("Failed to generate object: %v", err)
+		}
+		obj := value.(map[string]interface{})
+		if _, ok := obj["team"].(string); !ok {
+			t.Fatalf("Expected declared property team, got %v", obj)
+		}
+		if len(obj) > 1+maxAdditionalProperties {
+			t.Errorf("Expected at most %d extra keys, got %v", maxAdditionalProperties, obj)
+		}
+		for key, extra := range obj {
+			if key == "team" {
+				continue
+			}
+			sawExtra = true
+			if _, ok := extra.(string); !ok {
+				t.Errorf("Expected string value for %s, got %T", key, extra)
+			}
+		}
+	}
+	if !sawExtra {
+		t.Errorf("Expected some records to have additional properties")
+	}
+
+	first, _ := generator.GenerateValue(node, 7)
+	second, _ := generator.GenerateValue(node, 7)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected additional properties to be deterministic")
+	}
+}
+
+// TestPatternProperties verifies generated keys match their pattern and the
+// records pass schema validation alongside declared properties
+func TestPatternProperties(t *testing.T) {
+	parser := schema.NewParser()
+	err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {"name": {"type": "string"}},
+		"patternProperties": {
+			"^x-[a-z]{3,6}$": {"type": "string", "maxLength": 8},
+			"^n[0-9]{2}$": {"type": "integer", "minimum": 1, "maximum": 9}
+		},
+		"additionalProperties": false
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	for i := 0; i < 50; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate object: %v", err)
+		}
+		record := value.(map[string]interface{})
+		if _, ok := record["name"]; !ok {
+			t.Fatalf("Expected declared property name, got %v", record)
+		}
+
+		var xKeys, nKeys int
+		for key := range record {
+			switch {
+			case strings.HasPrefix(key, "x-"):
+				xKeys++
+			case strings.HasPrefix(key, "n"):
+				nKeys++
+			}
+		}
+		if xKeys == 0 || nKeys == 0 {
+			t.Errorf("Expected keys for both patterns, got %v", record)
+		}
+
+		if err := parser.Validate(record); err != nil {
+			t.Errorf("Generated record %v failed validation: %v", record, err)
+		}
+	}
+}
+
+// TestDefault verifies defaults are emitted at their probability, preserved
+// exactly, and never take precedence over const or enum
+func TestDefault(t *testing.T) {
+	settings := map[string]interface{}{"theme": "dark", "tabs": []interface{}{1.0, 2.0}}
+	node := &schema.SchemaNode{
+		Type:        "object",
+		Path:        "settings",
+		Default:     settings,
+		HasDefault:  true,
+		DefaultProb: 0.3,
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	defaults := 0
+	const samples = 2000
+	for i := 0; i < samples; i++ {
+		value, err := generator.GenerateValue(node, i)
+		if err != nil {
+			t.Fatalf("Failed to generate value: %v", err)
+		}
+		if reflect.DeepEqual(value, settings) {
+			defaults++
+			// Records must not share the default object
+			value.(map[string]interface{})["theme"] = "light"
+		}
+	}
+	if ratio := float64(defaults) / samples; ratio < 0.25 || ratio > 0.35 {
+		t.Errorf("Expected about 30%% defaults, got %.3f", ratio)
+	}
+	if settings["theme"] != "dark" {
+		t.Errorf("Expected the schema default to be left untouched")
+	}
+
+	never := &schema.SchemaNode{Type: "string", Path: "never", Default: "x", HasDefault: true}
+	enum := &schema.SchemaNode{Type: "string", Path: "enum", Enum: []interface{}{"a", "b"}, Default: "x", HasDefault: true, DefaultProb: 1}
+	constant := &schema.SchemaNode{Type: "string", Path: "const", Const: "c", HasConst: true, Default: "x", HasDefault: true, DefaultProb: 1}
+	for i := 0; i < 100; i++ {
+		if value, _ := generator.GenerateValue(never, i); value == "x" {
+			t.Fatalf("Expected no defaults without x-default-prob")
+		}
+		if value, _ := generator.GenerateValue(enum, i); value == "x" {
+			t.Fatalf("Expected enum to take precedence over default")
+		}
+		if value, _ := generator.GenerateValue(constant, i); value != "c" {
+			t.Fatalf("Expected const to take precedence over default, got %v", value)
+		}
+	}
+}
+
+// TestContains verifies arrays hold at least minContains items matching
+// contains, pass validation and are reproducible
+func TestContains(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["diagnoses"],
+		"properties": {
+			"diagnoses": {
+				"type": "array",
+				"maxItems": 4,
+				"uniqueItems": true,
+				"items": {
+					"type": "object",
+					"required": ["code", "primary"],
+					"properties": {
+						"code": {"type": "string", "pattern": "^[A-Z][0-9]{2}$"},
+						"primary": {"type": "boolean"}
+					}
+				},
+				"contains": {
+					"properties": {"primary": {"const": true}}
+				},
+				"minContains": 2
+			}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	for i := 0; i < 50; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if err := parser.Validate(value); err != nil {
+			t.Fatalf("Record %d failed validation: %v", i, err)
+		}
+
+		primary := 0
+		for _, item := range value.(map[string]interface{})["diagnoses"].([]interface{}) {
+			if item.(map[string]interface{})["primary"] == true {
+				primary++
+			}
+		}
+		if primary < 2 {
+			t.Errorf("Expected at least 2 primary diagnoses in record %d, got %d", i, primary)
+		}
+
+		again, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if !reflect.DeepEqual(value, again) {
+			t.Fatalf("Expected record %d to be reproducible", i)
+		}
+	}
+}
+
+// TestConditional verifies then and else constraints are applied according
+// to the generated discriminator, including conditionals combined in allOf
+func TestConditional(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["type", "country"],
+		"properties": {
+			"type": {"enum": ["personal", "business"]},
+			"country": {"enum": ["US", "NO"]},
+			"tax_id": {"type": "string", "pattern": "^[0-9]{9}$"},
+			"ssn": {"type": "string"},
+			"postal_code": {"type": "string"}
+		},
+		"if": {"properties": {"type": {"const": "business"}}},
+		"then": {"required": ["tax_id"], "properties": {"tax_id": {"pattern": "^9[0-9]{8}$"}}},
+		"else": {"required": ["ssn"]},
+		"allOf": [
+			{
+				"if": {"properties": {"country": {"const": "US"}}},
+				"then": {"required": ["postal_code"], "properties": {"postal_code": {"pattern": "^[0-9]{5}$"}}}
+			}
+		]
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	if len(root.Conditionals) != 2 {
+		t.Fatalf("Expected 2 conditionals, got %d", len(root.Conditionals))
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if err := parser.Validate(value); err != nil {
+			t.Fatalf("Record %d failed validation: %v", i, err)
+		}
+		record := value.(map[string]interface{})
+		seen[record["type"].(string)] = true
+
+		again, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if !reflect.DeepEqual(value, again) {
+			t.Fatalf("Expected record %d to be reproducible", i)
+		}
+	}
+	if !seen["personal"] || !seen["business"] {
+		t.Errorf("Expected both branches to be exercised, saw %v", seen)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"credit_card": {"type": "string", "pattern": "^[0-9]{16}$"},
+			"billing_address": {"type": "string"},
+			"country": {"enum": ["US", "NO"]},
+			"postal_code": {"type": "string"}
+		},
+		"dependentRequired": {"credit_card": ["billing_address"]},
+		"dependentSchemas": {
+			"country": {"required": ["postal_code"], "properties": {"postal_code": {"pattern": "^[0-9]{4,5}$"}}}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	// Left to chance, billing_address would never be generated
+	root.PropertyNode("billing_address").OptionalProb = 0
+	root.PropertyNode("postal_code").OptionalProb = 0
+
+	generator := NewDeterministicGenerator(12345)
+	cards := 0
+	for i := 0; i < 100; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if err := parser.Validate(value); err != nil {
+			t.Fatalf("Record %d failed validation: %v", i, err)
+		}
+		record := value.(map[string]interface{})
+		if _, ok := record["credit_card"]; ok {
+			cards++
+			if _, ok := record["billing_address"]; !ok {
+				t.Errorf("Record %d has credit_card without billing_address", i)
+			}
+		} else if _, ok := record["billing_address"]; ok {
+			t.Errorf("Record %d has billing_address without credit_card", i)
+		}
+		if _, ok := record["country"]; ok {
+			if _, ok := record["postal_code"]; !ok {
+				t.Errorf("Record %d has country without postal_code", i)
+			}
+		}
+
+		again, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if !reflect.DeepEqual(value, again) {
+			t.Fatalf("Expected record %d to be reproducible", i)
+		}
+	}
+	if cards == 0 {
+		t.Error("Expected some records with a credit_card")
+	}
+}
+
+// TestSeedSalt verifies x-seed-salt changes only the salted fields, at the
+// top level, in nested objects and in array items, and does so reproducibly
+func TestSeedSalt(t *testing.T) {
+	generate := func(salt string) []map[string]interface{} {
+		t.Helper()
+		parser := schema.NewParser()
+		if err := parser.ParseBytes([]byte(strings.ReplaceAll(`{
+			"type": "object",
+			"required": ["ssn", "name", "address", "visits"],
+			"properties": {
+				"ssn": {"type": "string", "pattern": "^[0-9]{3}-[0-9]{2}-[0-9]{4}$"SALT},
+				"name": {"type": "string"},
+				"address": {
+					"type": "object",
+					"required": ["zip", "city"],
+					"properties": {"zip": {"type": "string", "pattern": "^[0-9]{5}$"SALT}, "city": {"type": "string"}}
+				},
+				"visits": {
+					"type": "array", "minItems": 2, "maxItems": 4,
+					"items": {
+						"type": "object",
+						"required": ["code", "note"],
+						"properties": {"code": {"type": "string", "pattern": "^[A-Z]{6}$"SALT}, "note": {"type": "string"}}
+					}
+				}
+			}
+		}`, "SALT", salt))); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		root, err := parser.GetRootNode()
+		if err != nil {
+			t.Fatalf("Failed to build root node: %v", err)
+		}
+
+		generator := NewDeterministicGenerator(12345)
+		var records []map[string]interface{}
+		for i := 0; i < 20; i++ {
+			value, err := generator.GenerateValue(root, i)
+			if err != nil {
+				t.Fatalf("Failed to generate record: %v", err)
+			}
+			records = append(records, value.(map[string]interface{}))
+		}
+		return records
+	}
+	// salted returns the salted fields of a record and blanks them
+	salted := func(record map[string]interface{}) []interface{} {
+		fields := []interface{}{record["ssn"]}
+		record["ssn"] = nil
+		address := record["address"].(map[string]interface{})
+		fields = append(fields, address["zip"])
+		address["zip"] = nil
+		for _, visit := range record["visits"].([]interface{}) {
+			fields = append(fields, visit.(map[string]interface{})["code"])
+			visit.(map[string]interface{})["code"] = nil
+		}
+		return fields
+	}
+
+	plain := generate("")
+	first := generate(`, "x-seed-salt": "rotation-1"`)
+	again := generate(`, "x-seed-salt": "rotation-1"`)
+	second := generate(`, "x-seed-salt": 2`)
+	if !reflect.DeepEqual(first, again) {
+		t.Fatal("Expected salted records to be reproducible")
+	}
+
+	changed, total := 0, 0
+	for i := range plain {
+		plainFields, firstFields, secondFields := salted(plain[i]), salted(first[i]), salted(second[i])
+		if !reflect.DeepEqual(plain[i], first[i]) || !reflect.DeepEqual(first[i], second[i]) {
+			t.Fatalf("Expected record %d to differ only in salted fields:\n%v\n%v\n%v", i, plain[i], first[i], second[i])
+		}
+		for j := range firstFields {
+			total++
+			if firstFields[j] != plainFields[j] && firstFields[j] != secondFields[j] {
+				changed++
+			}
+		}
+	}
+	if changed < total*9/10 {
+		t.Errorf("Expected the salt to change the salted fields, %d of %d changed", changed, total)
+	}
+}
+
+// TestArrayItems_VaryByRecord verifies array items are seeded per record,
+// so records do not share array contents, and that the whole record index
+// is hashed
+func TestArrayItems_VaryByRecord(t *testing.T) {
+	node := &schema.SchemaNode{
+		Path:     "tags",
+		Type:     "array",
+		MinItems: intPtr(3),
+		MaxItems: intPtr(3),
+		Items:    &schema.SchemaNode{Path: "tags[]", Type: "string", MinLength: intPtr(10), MaxLength: intPtr(10)},
+	}
+	generator := NewDeterministicGenerator(12345)
+
+	seen := make(map[string]int)
+	for i := 0; i < 50; i++ {
+		value, err := generator.GenerateValue(node, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		key := fmt.Sprint(value)
+		if previous, ok := seen[key]; ok {
+			t.Fatalf("Records %d and %d have the same items %s", previous, i, key)
+		}
+		seen[key] = i
+
+		again, err := generator.GenerateValue(node, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if !reflect.DeepEqual(value, again) {
+			t.Fatalf("Expected record %d to be reproducible", i)
+		}
+	}
+
+	if high := uint64(1) << 32; math.MaxInt > math.MaxUint32 && generator.deriveSeed("tags", 7) == generator.deriveSeed("tags", int(high|7)) {
+		t.Error("Expected indices that differ above 32 bits to get different seeds")
+	}
+}
+
+// TestArrayItems_Nested verifies arrays nested in objects and in other
+// arrays vary by record, and that an item depends only on the record, the
+// field and its position, not on how long the array is
+func TestArrayItems_Nested(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["id", "orders"],
+		"properties": {
+			"id": {"type": "integer"},
+			"orders": {
+				"type": "array", "minItems": 1, "maxItems": 4,
+				"items": {
+					"type": "object",
+					"required": ["sku", "lines"],
+					"properties": {
+						"sku": {"type": "string", "pattern": "^[A-Z]{8}$"},
+						"lines": {"type": "array", "minItems": 2, "maxItems": 2, "items": {"type": "integer", "minimum": 0, "maximum": 1000000}}
+					}
+				}
+			}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	firstSKUs := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		orders := value.(map[string]interface{})["orders"].([]interface{})
+		first := orders[0].(map[string]interface{})
+		if previous, ok := firstSKUs[first["sku"].(string)]; ok {
+			t.Fatalf("Records %d and %d share their first order %v", previous, i, first)
+		}
+		firstSKUs[first["sku"].(string)] = i
+		if lines := first["lines"].([]interface{}); reflect.DeepEqual(lines[0], lines[1]) {
+			t.Errorf("Expected the lines of record %d to differ, got %v", i, lines)
+		}
+
+		again, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if !reflect.DeepEqual(value, again) {
+			t.Fatalf("Expected record %d to be reproducible", i)
+		}
+	}
+
+	// Pinning the length keeps the items that were generated before
+	orders := root.PropertyNode("orders")
+	orders.MinItems, orders.MaxItems = intPtr(4), intPtr(4)
+	long, err := generator.GenerateValue(root, 3)
+	if err != nil {
+		t.Fatalf("Failed to generate record: %v", err)
+	}
+	orders.MinItems, orders.MaxItems = intPtr(1), intPtr(1)
+	short, err := generator.GenerateValue(root, 3)
+	if err != nil {
+		t.Fatalf("Failed to generate record: %v", err)
+	}
+	longOrders := long.(map[string]interface{})["orders"].([]interface{})
+	shortOrders := short.(map[string]interface{})["orders"].([]interface{})
+	if !reflect.DeepEqual(longOrders[0], shortOrders[0]) {
+		t.Errorf("Expected the first order not to depend on the array length, got %v and %v", longOrders[0], shortOrders[0])
+	}
+}
+
+// TestOptionalWhen verifies x-optional-when overrides the chance of an
+// optional property from the values of required and optional siblings,
+// matching numbers whatever their Go type, reproducibly
+func TestOptionalWhen(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["status", "priority"],
+		"properties": {
+			"status": {"enum": ["admitted", "discharged"]},
+			"priority": {"type": "integer", "minimum": 1, "maximum": 2},
+			"ward": {"enum": ["icu", "general"]},
+			"discharge_date": {"type": "string", "format": "date", "x-optional-when": [
+				{"field": "status", "equals": "discharged", "probability": 1},
+				{"field": "status", "equals": "admitted", "probability": 0}
+			]},
+			"escalation": {"type": "boolean", "x-optional-when": [{"field": "priority", "equals": 1, "probability": 1}]},
+			"bed": {"type": "integer", "x-optional-when": [{"field": "ward", "equals": "icu", "probability": 1}]}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	root.PropertyNode("escalation").OptionalProb = 0
+	root.PropertyNode("bed").OptionalProb = 0
+	root.PropertyNode("ward").OptionalProb = 0.5
+
+	generator := NewDeterministicGenerator(12345)
+	discharged, escalated, beds := 0, 0, 0
+	for i := 0; i < 200; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		record := value.(map[string]interface{})
+
+		_, hasDate := record["discharge_date"]
+		if discharge := record["status"] == "discharged"; hasDate != discharge {
+			t.Errorf("Record %d has status %v but discharge_date present %v", i, record["status"], hasDate)
+		} else if discharge {
+			discharged++
+		}
+		_, hasEscalation := record["escalation"]
+		if urgent := record["priority"] == int64(1); hasEscalation != urgent {
+			t.Errorf("Record %d has priority %v but escalation present %v", i, record["priority"], hasEscalation)
+		} else if urgent {
+			escalated++
+		}
+		_, hasBed := record["bed"]
+		if icu := record["ward"] == "icu"; hasBed != icu {
+			t.Errorf("Record %d has ward %v but bed present %v", i, record["ward"], hasBed)
+		} else if icu {
+			beds++
+		}
+
+		again, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if !reflect.DeepEqual(value, again) {
+			t.Fatalf("Expected record %d to be reproducible", i)
+		}
+	}
+	if discharged == 0 || discharged == 200 || escalated == 0 || escalated == 200 || beds == 0 || beds == 200 {
+		t.Errorf("Expected both outcomes, got %d discharged, %d escalated and %d beds", discharged, escalated, beds)
+	}
+
+	for _, invalid := range []string{
+		`{"type": "object", "properties": {"a": {"type": "string", "x-optional-when": [{"field": "b", "equals": 1, "probability": 1}]}}}`,
+		`{"type": "object", "properties": {"a": {"type": "string", "x-optional-when": [{"field": "a", "equals": 1, "probability": 1}]}}}`,
+		`{"type": "object", "properties": {"a": {"type": "string"}, "b": {"type": "string", "x-optional-when": [{"field": "a", "equals": "x", "probability": 1.5}]}}}`,
+		`{"type": "object", "properties": {"a": {"type": "string"}, "b": {"type": "string", "x-optional-when": [{"field": "a", "probability": 1}]}}}`,
+	} {
+		parser := schema.NewParser()
+		if err := parser.ParseBytes([]byte(invalid)); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		if _, err := parser.GetRootNode(); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+// TestMinMaxProperties verifies objects stay within minProperties and
+// maxProperties counting declared and undeclared keys, and that impossible
+// bounds are rejected
+func TestMinMaxProperties(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["id", "labels", "tags"],
+		"properties": {
+			"id": {"type": "integer"},
+			"labels": {"type": "object", "minProperties": 4, "maxProperties": 5, "properties": {"name": {"type": "string"}}, "additionalProperties": {"type": "string"}},
+			"tags": {"type": "object", "minProperties": 2, "maxProperties": 2, "patternProperties": {"^t_[a-z]{3}$": {"type": "boolean"}}, "additionalProperties": false},
+			"note": {"type": "string"},
+			"extra": {"type": "object", "maxProperties": 1, "additionalProperties": {"type": "integer"}}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	for _, warning := range parser.Warnings() {
+		t.Errorf("Unexpected warning for a supported keyword: %+v", warning)
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	for i := 0; i < 100; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		record := value.(map[string]interface{})
+		if labels := len(record["labels"].(map[string]interface{})); labels < 4 || labels > 5 {
+			t.Errorf("Record %d has %d labels, expected 4 to 5", i, labels)
+		}
+		if tags := len(record["tags"].(map[string]interface{})); tags != 2 {
+			t.Errorf("Record %d has %d tags, expected 2", i, tags)
+		}
+		if extra, ok := record["extra"].(map[string]interface{}); ok && len(extra) > 1 {
+			t.Errorf("Record %d has %d extra keys, expected at most 1", i, len(extra))
+		}
+		if err := parser.Validate(record); err != nil {
+			t.Errorf("Record %d does not validate: %v", i, err)
+		}
+
+		again, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if !reflect.DeepEqual(value, again) {
+			t.Fatalf("Expected record %d to be reproducible", i)
+		}
+	}
+
+	for _, invalid := range []string{
+		`{"type": "object", "minProperties": 3, "maxProperties": 2}`,
+		`{"type": "object", "required": ["a", "b"], "maxProperties": 1, "properties": {"a": {"type": "string"}, "b": {"type": "string"}}}`,
+		`{"type": "object", "minProperties": 2, "properties": {"a": {"type": "string"}}, "additionalProperties": false}`,
+	} {
+		parser := schema.NewParser()
+		if err := parser.ParseBytes([]byte(invalid)); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		if _, err := parser.GetRootNode(); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	mathrand "math/rand"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// maxDistributionAttempts bounds how often a draw outside the range is
+// resampled before falling back to a uniform value
+const maxDistributionAttempts = 100
+
+// sampleInRange draws a value in [min, max] from the node's distribution.
+// Draws outside the range are rejected and redrawn rather than clamped, so
+// no probability mass piles up at the bounds.
+func sampleInRange(dist *schema.Distribution, min, max float64, rng *mathrand.Rand) (float64, error) {
+	if dist == nil || dist.Type == "uniform" || max <= min {
+		return min + rng.Float64()*(max-min), nil
+	}
+
+	draw, err := distributionSampler(dist, min, max)
+	if err != nil {
+		return 0, err
+	}
+
+	for attempt := 0; attempt < maxDistributionAttempts; attempt++ {
+		if value := draw(rng); value >= min && value <= max {
+			return value, nil
+		}
+	}
+
+	// The distribution barely overlaps the range
+	return min + rng.Float64()*(max-min), nil
+}
+
+// distributionSampler returns a function drawing from dist. Parameters that
+// are not set default to values that fit the distribution within [min, max].
+func distributionSampler(dist *schema.Distribution, min, max float64) (func(*mathrand.Rand) float64, error) {
+	switch dist.Type {
+	case "normal":
+		mean := (min + max) / 2
+		stddev := (max - min) / 6
+		if dist.Mean != nil {
+			mean = *dist.Mean
+		}
+		if dist.StdDev != nil {
+			stddev = *dist.StdDev
+		}
+		return func(rng *mathrand.Rand) float64 {
+			return mean + rng.NormFloat64()*stddev
+		}, nil
+
+	case "lognormal":
+		if max <= 0 {
+			return nil, fmt.Errorf("lognormal distribution needs a positive maximum")
+		}
+		// Default to spreading the logarithm across the positive part of the range
+		lower := math.Max(min, max/1000)
+		mu := (math.Log(lower) + math.Log(max)) / 2
+		sigma := (math.Log(max) - math.Log(lower)) / 6
+		if dist.Mean != nil {
+			mu = *dist.Mean
+		}
+		if dist.StdDev != nil {
+			sigma = *dist.StdDev
+		}
+		return func(rng *mathrand.Rand) float64 {
+			return math.Exp(mu + rng.NormFloat64()*sigma)
+		}, nil
+
+	case "exponential":
+		mean := min + (max-min)/5
+		if dist.Mean != nil {
+			mean = *dist.Mean
+		}
+		if mean <= min {
+			return nil, fmt.Errorf("exponential distribution needs a mean above the minimum %v", min)
+		}
+		return func(rng *mathrand.Rand) float64 {
+			return min + rng.ExpFloat64()*(mean-min)
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown distribution %q", dist.Type)
+}
+
+// sampleLength draws an array length in [min, max] from dist. Without a
+// distribution the length is uniform, as it always was. Unlike
+// sampleInRange, draws outside the bounds are clamped, so a long tail ends
+// at maxItems.
+func sampleLength(dist *schema.LengthDistribution, min, max int, rng *mathrand.Rand) int {
+	if dist == nil || dist.Type == "uniform" || max <= min {
+		return min + rng.Intn(max-min+1)
+	}
+
+	// Parameters that are not set center the distribution in the range
+	mid := float64(min+max) / 2
+	var draw float64
+	switch dist.Type {
+	case "poisson":
+		lambda := mid
+		if dist.Lambda != nil {
+			lambda = *dist.Lambda
+		}
+		draw = samplePoisson(lambda, rng)
+	case "geometric":
+		p := 1 / math.Max(mid, 1)
+		if dist.P != nil {
+			p = *dist.P
+		}
+		draw = sampleGeometric(p, rng)
+	}
+
+	return int(math.Max(float64(min), math.Min(float64(max), draw)))
+}
+
+// samplePoisson draws a count with mean lambda, by Knuth's method for
+// small means and a normal approximation for large ones
+func samplePoisson(lambda float64, rng *mathrand.Rand) float64 {
+	if lambda > 30 {
+		return math.Round(lambda + math.Sqrt(lambda)*rng.NormFloat64())
+	}
+
+	limit := math.Exp(-lambda)
+	count, product := 0.0, rng.Float64()
+	for product > limit {
+		count++
+		product *= rng.Float64()
+	}
+	return count
+}
+
+// sampleGeometric draws the number of trials up to and including the first
+// success, each succeeding with probability p
+func sampleGeometric(p float64, rng *mathrand.Rand) float64 {
+	if p >= 1 {
+		return 1
+	}
+	return math.Max(1, math.Ceil(math.Log(1-rng.Float64())/math.Log(1-p)))
+}
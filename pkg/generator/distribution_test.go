@@ -0,0 +1,167 @@
+package generator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// TestDistribution_Normal verifies the shape and bounds of normal values
+func TestDistribution_Normal(t *testing.T) {
+	mean, stddev := 40.0, 10.0
+	node := &schema.SchemaNode{
+		Type:         "number",
+		Path:         "age",
+		Minimum:      float64Ptr(18),
+		Maximum:      float64Ptr(90),
+		Distribution: &schema.Distribution{Type: "normal", Mean: &mean, StdDev: &stddev},
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	sum, sumSq := 0.0, 0.0
+	const n = 10000
+	for i := 0; i < n; i++ {
+		value, err := generator.GenerateValue(node, i)
+		if err != nil {
+			t.Fatalf("Failed to generate value: %v", err)
+		}
+		v := value.(float64)
+		if v < 18 || v > 90 {
+			t.Fatalf("Generated %v outside [18, 90]", v)
+		}
+		if again, _ := generator.GenerateValue(node, i); again != value {
+			t.Fatalf("Record %d: expected %v on regeneration, got %v", i, value, again)
+		}
+		sum += v
+		sumSq += v * v
+	}
+
+	gotMean := sum / n
+	gotStdDev := math.Sqrt(sumSq/n - gotMean*gotMean)
+	// Truncation at 18 shifts the mean up slightly
+	if math.Abs(gotMean-40.5) > 1 || math.Abs(gotStdDev-9.5) > 1 {
+		t.Errorf("Expected mean ~40.5 and stddev ~9.5, got %.2f and %.2f", gotMean, gotStdDev)
+	}
+}
+
+// TestDistribution_NoBoundaryPileUp verifies that out-of-range draws are
+// resampled instead of clamped onto the bounds
+func TestDistribution_NoBoundaryPileUp(t *testing.T) {
+	for _, dist := range []string{"normal", "lognormal", "exponential"} {
+		mean := 10.0
+		node := &schema.SchemaNode{
+			Type:         "integer",
+			Path:         "items",
+			Minimum:      float64Ptr(1),
+			Maximum:      float64Ptr(20),
+			Distribution: &schema.Distribution{Type: dist},
+		}
+		if dist == "normal" {
+			// Centered on the range with most mass beyond the bounds
+			stddev := 50.0
+			node.Distribution.Mean, node.Distribution.StdDev = &mean, &stddev
+		}
+
+		generator := NewDeterministicGenerator(12345)
+		counts := make(map[int64]int)
+		const n = 5000
+		for i := 0; i < n; i++ {
+			value, err := generator.GenerateValue(node, i)
+			if err != nil {
+				t.Fatalf("%s: failed to generate value: %v", dist, err)
+			}
+			v := value.(int64)
+			if v < 1 || v > 20 {
+				t.Fatalf("%s: generated %d outside [1, 20]", dist, v)
+			}
+			counts[v]++
+		}
+
+		// Clamping would make each bound far more common than its neighbor
+		for _, pair := range [][2]int64{{1, 2}, {20, 19}} {
+			bound, neighbor := counts[pair[0]], counts[pair[1]]
+			if float64(bound) > 1.5*float64(neighbor)+10 {
+				t.Errorf("%s: %d values on bound %d but only %d on %d", dist, bound, pair[0], neighbor, pair[1])
+			}
+		}
+	}
+}
+
+// TestDistribution_Invalid verifies that impossible parameters are reported
+func TestDistribution_Invalid(t *testing.T) {
+	mean := 5.0
+	node := &schema.SchemaNode{
+		Type:         "number",
+		Path:         "wait",
+		Minimum:      float64Ptr(10),
+		Maximum:      float64Ptr(20),
+		Distribution: &schema.Distribution{Type: "exponential", Mean: &mean},
+	}
+
+	if _, err := NewDeterministicGenerator(1).GenerateValue(node, 0); err == nil {
+		t.Errorf("Expected an error for an exponential mean below the minimum")
+	}
+}
+
+// TestDistribution_ArrayLength verifies array lengths follow
+// x-length-distribution within minItems and maxItems, and stay uniform
+// without it
+func TestDistribution_ArrayLength(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["cart", "visits", "plain"],
+		"properties": {
+			"cart": {"type": "array", "minItems": 1, "maxItems": 10, "items": {"type": "integer"}, "x-length-distribution": {"type": "poisson", "lambda": 2}},
+			"visits": {"type": "array", "maxItems": 8, "items": {"type": "integer"}, "x-length-distribution": {"type": "geometric", "p": 0.5}},
+			"plain": {"type": "array", "minItems": 1, "maxItems": 4, "items": {"type": "integer"}}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	counts := map[string]map[int]int{"cart": {}, "visits": {}, "plain": {}}
+	sums := make(map[string]float64)
+	const n = 5000
+	for i := 0; i < n; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		again, _ := generator.GenerateValue(root, i)
+		for field := range counts {
+			length := len(value.(map[string]interface{})[field].([]interface{}))
+			if length != len(again.(map[string]interface{})[field].([]interface{})) {
+				t.Fatalf("Record %d: %s length differs on regeneration", i, field)
+			}
+			counts[field][length]++
+			sums[field] += float64(length)
+		}
+	}
+
+	// Poisson(2) clamped to [1, 10] has a mean of about 2.14, and 1 to 3
+	// items are most common
+	if mean := sums["cart"] / n; math.Abs(mean-2.14) > 0.1 {
+		t.Errorf("Expected a mean cart length of ~2.14, got %.2f", mean)
+	}
+	if counts["cart"][2] < counts["cart"][5]*5 || counts["cart"][0] != 0 || counts["cart"][11] != 0 {
+		t.Errorf("Unexpected cart lengths %v", counts["cart"])
+	}
+	// Geometric(0.5) halves with each extra item
+	if half := float64(counts["visits"][2]) / float64(counts["visits"][1]); math.Abs(half-0.5) > 0.05 {
+		t.Errorf("Expected half as many visits of length 2 as of 1, got %v", counts["visits"])
+	}
+	// Without the extension each length is about equally likely
+	for length := 1; length <= 4; length++ {
+		if math.Abs(float64(counts["plain"][length])-n/4) > n/20 {
+			t.Errorf("Expected uniform plain lengths, got %v", counts["plain"])
+			break
+		}
+	}
+}
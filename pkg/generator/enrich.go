@@ -0,0 +1,405 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// parseLLMRecord extracts the JSON object from a record-mode LLM response.
+// Models often wrap JSON in prose or code fences, so only the outermost
+// braces are decoded.
+func parseLLMRecord(response string) (map[string]interface{}, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object in LLM response")
+	}
+
+	var candidate map[string]interface{}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &candidate); err != nil {
+		return nil, fmt.Errorf("invalid JSON in LLM response: %w", err)
+	}
+
+	return candidate, nil
+}
+
+// mergeLLMObject copies values from candidate into data for fields that
+// already exist in data and whose new value still matches the schema type
+func mergeLLMObject(node *schema.SchemaNode, data, candidate map[string]interface{}) {
+	for name, value := range candidate {
+		prop, ok := node.Properties[name]
+		if !ok || prop.Reference != "" {
+			// Foreign keys must keep pointing at generated records
+			continue
+		}
+		current, exists := data[name]
+		if !exists {
+			continue
+		}
+
+		currentMap, isMap := current.(map[string]interface{})
+		candidateMap, candidateIsMap := value.(map[string]interface{})
+		if isMap && candidateIsMap && prop.Properties != nil {
+			mergeLLMObject(prop, currentMap, candidateMap)
+			continue
+		}
+
+		converted, ok := conformValue(prop, value)
+		if !ok {
+			if candidateIsMap {
+				log.Warn().Str("field", prop.Path).Msg("LLM returned an object that does not match the schema, keeping the deterministic value")
+			}
+			continue
+		}
+		data[name] = converted
+	}
+}
+
+// conformValue checks that an LLM-provided value matches the node's type and
+// enum, converting whole JSON numbers to integers where the schema expects them
+func conformValue(node *schema.SchemaNode, value interface{}) (interface{}, bool) {
+	if node.HasConst {
+		if !inEnum([]interface{}{node.Const}, value) {
+			return nil, false
+		}
+		return copyValue(node.Const), true
+	}
+
+	if len(node.Variants) > 0 {
+		for _, variant := range node.Variants {
+			if converted, ok := conformValue(variant, value); ok {
+				return converted, true
+			}
+		}
+		return nil, false
+	}
+
+	if len(node.Types) > 1 {
+		for _, t := range node.Types {
+			typed := *node
+			typed.Type, typed.Types = t, nil
+			if converted, ok := conformValue(&typed, value); ok {
+				return converted, true
+			}
+		}
+		return nil, false
+	}
+
+	var converted interface{}
+	switch node.Type {
+	case "string", "":
+		str, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		converted = str
+	case "integer":
+		num, ok := value.(float64)
+		if !ok || num != math.Trunc(num) {
+			return nil, false
+		}
+		converted = int64(num)
+	case "number":
+		num, ok := value.(float64)
+		if !ok {
+			return nil, false
+		}
+		converted = num
+	case "boolean":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, false
+		}
+		converted = b
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		if node.Items != nil || len(node.TupleItems) > 0 {
+			result := make([]interface{}, len(items))
+			for i, item := range items {
+				itemNode := node.ItemAt(i)
+				if itemNode == nil {
+					if node.ClosedTuple {
+						return nil, false
+					}
+					result[i] = item
+					continue
+				}
+				if result[i], ok = conformValue(itemNode, item); !ok {
+					return nil, false
+				}
+			}
+			items = result
+		}
+		converted = items
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if converted, ok = conformObject(node, obj); !ok {
+			return nil, false
+		}
+	case "null":
+		if value != nil {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	if len(node.Enum) > 0 && !inEnum(node.Enum, value) {
+		return nil, false
+	}
+
+	return converted, true
+}
+
+// conformObject checks an LLM-provided object against the node: required
+// properties must be present, the object must be within minProperties and
+// maxProperties, and every value must conform to the schema of its key
+func conformObject(node *schema.SchemaNode, obj map[string]interface{}) (map[string]interface{}, bool) {
+	for _, name := range node.Required {
+		if _, ok := obj[name]; !ok {
+			return nil, false
+		}
+	}
+	if (node.MinProperties != nil && len(obj) < *node.MinProperties) || (node.MaxProperties != nil && len(obj) > *node.MaxProperties) {
+		return nil, false
+	}
+
+	result := make(map[string]interface{}, len(obj))
+	for key, value := range obj {
+		var keyNodes []*schema.SchemaNode
+		if prop, ok := node.Properties[key]; ok {
+			keyNodes = append(keyNodes, prop)
+		}
+		for _, pp := range node.PatternProperties {
+			if pp.Matches(key) {
+				keyNodes = append(keyNodes, pp.Node)
+			}
+		}
+		if len(keyNodes) == 0 && node.AdditionalProperties != nil {
+			keyNodes = append(keyNodes, node.AdditionalProperties)
+		}
+
+		result[key] = value
+		for _, keyNode := range keyNodes {
+			converted, ok := conformValue(keyNode, value)
+			if !ok {
+				return nil, false
+			}
+			result[key] = converted
+		}
+	}
+	return result, true
+}
+
+// inEnum reports whether value is one of the enum entries. JSON numbers are
+// compared numerically so 1 and 1.0 match.
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if reflect.DeepEqual(allowed, value) {
+			return true
+		}
+		if a, ok := toFloat(allowed); ok {
+			if b, ok := toFloat(value); ok && a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// describeSchema builds a compact outline of the node's structure for LLM
+// prompts, e.g. {"name": "string", "tags": ["string"]}
+func describeSchema(node *schema.SchemaNode) interface{} {
+	if len(node.Variants) > 0 {
+		return describeSchema(node.Variants[0])
+	}
+
+	switch node.Type {
+	case "object":
+		outline := make(map[string]interface{}, len(node.Properties))
+		for name, prop := range node.Properties {
+			outline[name] = describeSchema(prop)
+		}
+		return outline
+	case "array":
+		if len(node.TupleItems) > 0 {
+			outline := make([]interface{}, len(node.TupleItems))
+			for i, item := range node.TupleItems {
+				outline[i] = describeSchema(item)
+			}
+			return outline
+		}
+		if node.Items == nil {
+			return []interface{}{}
+		}
+		return []interface{}{describeSchema(node.Items)}
+	}
+
+	description := node.Type
+	if len(node.Types) > 1 {
+		description = strings.Join(node.Types, " or ")
+	}
+	if description == "" {
+		description = "string"
+	}
+	if node.Format != "" {
+		description += " (" + node.Format + ")"
+	}
+	if len(node.Enum) > 0 {
+		values := make([]string, len(node.Enum))
+		for i, value := range node.Enum {
+			values[i] = fmt.Sprint(value)
+		}
+		description += ", one of: " + strings.Join(values, ", ")
+	}
+	return description
+}
+
+// fieldSegment is one step of a dotted field path. index is -1 for plain
+// keys and -2 for the "every element" form key[].
+type fieldSegment struct {
+	key   string
+	index int
+}
+
+const allElements = -2
+
+// parseFieldPath splits paths like billing.lines[0].code or items[].name
+func parseFieldPath(fieldPath string) ([]fieldSegment, error) {
+	if fieldPath == "" {
+		return nil, fmt.Errorf("empty field path")
+	}
+
+	parts := strings.Split(fieldPath, ".")
+	segments := make([]fieldSegment, 0, len(parts))
+	for _, part := range parts {
+		segment := fieldSegment{key: part, index: -1}
+
+		if open := strings.Index(part, "["); open >= 0 {
+			if !strings.HasSuffix(part, "]") {
+				return nil, fmt.Errorf("invalid field path %s", fieldPath)
+			}
+			segment.key = part[:open]
+			indexStr := part[open+1 : len(part)-1]
+			if indexStr == "" {
+				segment.index = allElements
+			} else {
+				index, err := strconv.Atoi(indexStr)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid array index in field path %s", fieldPath)
+				}
+				segment.index = index
+			}
+		}
+
+		if segment.key == "" {
+			return nil, fmt.Errorf("invalid field path %s", fieldPath)
+		}
+		segments = append(segments, segment)
+	}
+
+	return segments, nil
+}
+
+// expandFieldPath replaces each key[] in a path with concrete indices for
+// the elements present in data. Paths that cannot be resolved are dropped.
+func expandFieldPath(data map[string]interface{}, fieldPath string) []string {
+	segments, err := parseFieldPath(fieldPath)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	var walk func(current interface{}, i int, prefix string)
+	walk = func(current interface{}, i int, prefix string) {
+		if i == len(segments) {
+			paths = append(paths, prefix)
+			return
+		}
+
+		segment := segments[i]
+		path := segment.key
+		if prefix != "" {
+			path = prefix + "." + segment.key
+		}
+
+		obj, _ := current.(map[string]interface{})
+		if segment.index != allElements {
+			if segment.index >= 0 {
+				path += fmt.Sprintf("[%d]", segment.index)
+			}
+			var next interface{}
+			if obj != nil {
+				next = obj[segment.key]
+				if items, ok := next.([]interface{}); ok && segment.index >= 0 && segment.index < len(items) {
+					next = items[segment.index]
+				}
+			}
+			walk(next, i+1, path)
+			return
+		}
+
+		if obj == nil {
+			return
+		}
+		items, _ := obj[segment.key].([]interface{})
+		for index, item := range items {
+			walk(item, i+1, fmt.Sprintf("%s[%d]", path, index))
+		}
+	}
+	walk(data, 0, "")
+
+	return paths
+}
+
+// fieldNode returns the schema node a dotted field path such as
+// items[0].name refers to, or nil when the schema does not declare it
+func fieldNode(root *schema.SchemaNode, fieldPath string) *schema.SchemaNode {
+	segments, err := parseFieldPath(fieldPath)
+	if err != nil {
+		return nil
+	}
+
+	node := root
+	for _, segment := range segments {
+		if node == nil {
+			return nil
+		}
+		node = node.Properties[segment.key]
+		if node == nil || segment.index == -1 {
+			continue
+		}
+		if segment.index == allElements {
+			node = node.Items
+		} else {
+			node = node.ItemAt(segment.index)
+		}
+	}
+	return node
+}
@@ -0,0 +1,318 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/specmint/specmint/pkg/schema"
+	"github.com/specmint/specmint/pkg/writer"
+)
+
+// TestMergeLLMRecord verifies that record-mode responses only overwrite
+// existing fields with values of the right type
+func TestMergeLLMRecord(t *testing.T) {
+	root := &schema.SchemaNode{
+		Type: "object",
+		Properties: map[string]*schema.SchemaNode{
+			"name":   {Type: "string"},
+			"age":    {Type: "integer"},
+			"status": {Type: "string", Enum: []interface{}{"active", "inactive"}},
+			"address": {Type: "object", Properties: map[string]*schema.SchemaNode{
+				"city": {Type: "string"},
+				"zip":  {Type: "string"},
+			}},
+		},
+	}
+
+	data := map[string]interface{}{
+		"name":    "x7Kq",
+		"age":     int64(40),
+		"status":  "active",
+		"address": map[string]interface{}{"city": "abc", "zip": "12345"},
+	}
+
+	response := "Here you go:\n```json\n" + `{
+		"name": "Maria Lopez",
+		"age": "forty-two",
+		"status": "retired",
+		"address": {"city": "Denver", "zip": 80202},
+		"nickname": "Mo"
+	}` + "\n```"
+
+	candidate, err := parseLLMRecord(response)
+	if err != nil {
+		t.Fatalf("Failed to parse LLM response: %v", err)
+	}
+	mergeLLMObject(root, data, candidate)
+
+	if data["name"] != "Maria Lopez" {
+		t.Errorf("Expected name to be replaced, got %v", data["name"])
+	}
+	if data["age"] != int64(40) {
+		t.Errorf("Expected age with the wrong type to be ignored, got %v", data["age"])
+	}
+	if data["status"] != "active" {
+		t.Errorf("Expected status outside the enum to be ignored, got %v", data["status"])
+	}
+	address := data["address"].(map[string]interface{})
+	if address["city"] != "Denver" || address["zip"] != "12345" {
+		t.Errorf("Expected nested merge to keep type checks, got %v", address)
+	}
+	if _, ok := data["nickname"]; ok {
+		t.Errorf("Expected fields outside the record to be ignored")
+	}
+}
+
+// TestMergeLLMRecord_Objects verifies objects the model returns whole are
+// checked against the schema before they replace the deterministic value
+func TestMergeLLMRecord_Objects(t *testing.T) {
+	lineNode := &schema.SchemaNode{Type: "object", Required: []string{"sku"}, Properties: map[string]*schema.SchemaNode{
+		"sku": {Type: "string"},
+		"qty": {Type: "integer"},
+	}}
+	root := &schema.SchemaNode{
+		Type: "object",
+		Properties: map[string]*schema.SchemaNode{
+			"labels": {Type: "object", AdditionalProperties: &schema.SchemaNode{Type: "string"}},
+			"contact": {Types: []string{"object", "null"}, Required: []string{"email"}, Properties: map[string]*schema.SchemaNode{
+				"email": {Type: "string"},
+			}},
+			"lines": {Type: "array", Items: lineNode},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		field     string
+		candidate string
+		merged    bool
+	}{
+		{"labels", "labels", `{"labels": {"team": "billing"}}`, true},
+		{"labels with a wrong value type", "labels", `{"labels": {"team": 5}}`, false},
+		{"contact", "contact", `{"contact": {"email": "ada@example.com"}}`, true},
+		{"contact without a required property", "contact", `{"contact": {"phone": "555-0100"}}`, false},
+		{"lines", "lines", `{"lines": [{"sku": "SKU-2", "qty": 3}]}`, true},
+		{"lines with a wrong property type", "lines", `{"lines": [{"sku": "SKU-2", "qty": "three"}]}`, false},
+		{"lines without a required property", "lines", `{"lines": [{"qty": 3}]}`, false},
+	}
+
+	for _, tc := range testCases {
+		data := map[string]interface{}{
+			"labels":  map[string]interface{}{"team": "core"},
+			"contact": nil,
+			"lines":   []interface{}{map[string]interface{}{"sku": "SKU-1", "qty": int64(1)}},
+		}
+		original := copyValue(data)
+
+		candidate, err := parseLLMRecord(tc.candidate)
+		if err != nil {
+			t.Fatalf("Failed to parse LLM response: %v", err)
+		}
+		mergeLLMObject(root, data, candidate)
+
+		changed := fmt.Sprint(data[tc.field]) != fmt.Sprint(original.(map[string]interface{})[tc.field])
+		if changed != tc.merged {
+			t.Errorf("%s: expected merged %v, got %v", tc.name, tc.merged, data[tc.field])
+		}
+	}
+}
+
+// TestParseLLMRecord_Malformed verifies that unusable responses are rejected
+func TestParseLLMRecord_Malformed(t *testing.T) {
+	for _, response := range []string{"", "no json here", `{"name": "Maria"`, `{"name": }`} {
+		if _, err := parseLLMRecord(response); err == nil {
+			t.Errorf("Expected an error for response %q", response)
+		}
+	}
+}
+
+// TestSetFieldValue_NestedPaths verifies dotted and indexed path handling
+func TestSetFieldValue_NestedPaths(t *testing.T) {
+	data := map[string]interface{}{
+		"patient": map[string]interface{}{
+			"demographics": map[string]interface{}{"first_name": "abc"},
+		},
+		"items": []interface{}{
+			map[string]interface{}{"name": "a"},
+			map[string]interface{}{"name": "b"},
+		},
+		"code": "X1",
+	}
+
+	if err := setFieldValue(data, "patient.demographics.first_name", "Ana"); err != nil {
+		t.Fatalf("Failed to set nested field: %v", err)
+	}
+	if err := setFieldValue(data, "items[1].name", "Widget"); err != nil {
+		t.Fatalf("Failed to set indexed field: %v", err)
+	}
+	if err := setFieldValue(data, "billing.payer.name", "Acme"); err != nil {
+		t.Fatalf("Failed to create intermediate objects: %v", err)
+	}
+
+	demographics := data["patient"].(map[string]interface{})["demographics"].(map[string]interface{})
+	if demographics["first_name"] != "Ana" {
+		t.Errorf("Expected nested first_name to be set, got %v", demographics["first_name"])
+	}
+	if name := data["items"].([]interface{})[1].(map[string]interface{})["name"]; name != "Widget" {
+		t.Errorf("Expected items[1].name to be set, got %v", name)
+	}
+	payer := data["billing"].(map[string]interface{})["payer"].(map[string]interface{})
+	if payer["name"] != "Acme" {
+		t.Errorf("Expected billing.payer.name to be created, got %v", payer["name"])
+	}
+	if _, ok := data["patient.demographics.first_name"]; ok {
+		t.Errorf("Dotted path must not be stored as a literal key")
+	}
+
+	for _, path := range []string{"code.value", "items[5].name", "patient[0]", "items[x].name", ""} {
+		if err := setFieldValue(data, path, "v"); err == nil {
+			t.Errorf("Expected an error for path %q", path)
+		}
+	}
+}
+
+// TestExpandFieldPath verifies that array paths expand to existing elements
+func TestExpandFieldPath(t *testing.T) {
+	data := map[string]interface{}{
+		"orders": []interface{}{
+			map[string]interface{}{"lines": []interface{}{map[string]interface{}{}, map[string]interface{}{}}},
+			map[string]interface{}{"lines": []interface{}{map[string]interface{}{}}},
+		},
+	}
+
+	got := expandFieldPath(data, "orders[].lines[].sku")
+	want := []string{"orders[0].lines[0].sku", "orders[0].lines[1].sku", "orders[1].lines[0].sku"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %s, got %s", want[i], got[i])
+		}
+	}
+
+	if got := expandFieldPath(data, "missing[].name"); len(got) != 0 {
+		t.Errorf("Expected no paths for a missing array, got %v", got)
+	}
+}
+
+// TestCreateFieldPrompt verifies x-llm-prompt templates render with the
+// record's fields, reach fields inside arrays, and fall back to the default
+// prompt when the record lacks a field the template uses
+func TestCreateFieldPrompt(t *testing.T) {
+	gen, root := newTestGenerator(t, `{
+		"type": "object",
+		"properties": {
+			"category": {"type": "string"},
+			"name": {"type": "string", "x-llm-prompt": "Name a {{.category}} product{{with .brand}} from {{.}}{{end}}"},
+			"lines": {"type": "array", "items": {"type": "object", "properties": {
+				"note": {"type": "string", "x-llm-prompt": "Write a note about {{(index .lines 0).sku}}"}
+			}}},
+			"summary": {"type": "string"}
+		}
+	}`)
+
+	data := map[string]interface{}{
+		"category": "garden",
+		"brand":    "Acme",
+		"lines":    []interface{}{map[string]interface{}{"sku": "SKU-1"}},
+	}
+	if got := gen.createFieldPrompt(root, "name", data); got != "Name a garden product from Acme" {
+		t.Errorf("Unexpected rendered prompt %q", got)
+	}
+	if got := gen.createFieldPrompt(root, "lines[0].note", data); got != "Write a note about SKU-1" {
+		t.Errorf("Unexpected rendered prompt for an array field %q", got)
+	}
+
+	delete(data, "brand")
+	if got := gen.createFieldPrompt(root, "name", data); !strings.Contains(got, "realistic product name for the garden category") {
+		t.Errorf("Expected the default prompt when brand is missing, got %q", got)
+	}
+	if got := gen.createFieldPrompt(root, "summary", data); !strings.Contains(got, "field 'summary'") {
+		t.Errorf("Expected the default prompt for a field without a template, got %q", got)
+	}
+}
+
+// concurrentLLMClient answers with the seed after a delay and records the
+// most calls it saw in flight at once
+type concurrentLLMClient struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (c *concurrentLLMClient) Generate(ctx context.Context, prompt string, seed int64) (string, error) {
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.peak {
+		c.peak = c.inFlight
+	}
+	c.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return fmt.Sprintf("note-%d", seed), nil
+}
+
+func (c *concurrentLLMClient) HealthCheck(ctx context.Context) error { return nil }
+
+func (c *concurrentLLMClient) Close() error { return nil }
+
+// TestLLMWorkers verifies the LLM stage runs up to llm.workers calls at once
+// and produces the same records as enriching them one at a time
+func TestLLMWorkers(t *testing.T) {
+	gen, root := newTestGenerator(t, `{
+		"type": "object",
+		"required": ["id", "note"],
+		"properties": {
+			"id": {"type": "integer"},
+			"note": {"type": "string", "x-llm": true}
+		}
+	}`)
+	client := &concurrentLLMClient{}
+	gen.llmClient = client
+	gen.config.LLM.Mode = "fields"
+	gen.config.LLM.Workers = 3
+	gen.config.Generation.Workers = 2
+	gen.config.Generation.Count = 30
+	gen.config.Generation.CheckpointEvery = 0
+	gen.config.Output.Directory = t.TempDir()
+
+	w, err := writer.New(gen.config.Output)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	gen.writer = w
+
+	result, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	if result.RecordCount != 30 || result.LLMCallCount != 30 {
+		t.Fatalf("Expected 30 enriched records, got %d records and %d LLM calls", result.RecordCount, result.LLMCallCount)
+	}
+	if client.peak < 2 || client.peak > 3 {
+		t.Errorf("Expected up to 3 LLM calls in flight at once, got %d", client.peak)
+	}
+
+	notes, err := loadReferenceValues(result.DatasetFile, "note")
+	if err != nil || len(notes) != 30 {
+		t.Fatalf("Expected 30 distinct notes in the dataset, got %d: %v", len(notes), err)
+	}
+	for i, note := range notes {
+		want, err := gen.generateRecord(context.Background(), root, i, 0)
+		if err != nil {
+			t.Fatalf("Failed to generate record %d: %v", i, err)
+		}
+		if wantNote := want.Data.(map[string]interface{})["note"]; note != wantNote {
+			t.Errorf("Expected record %d to match sequential enrichment, got %v and %v", i, note, wantNote)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/specmint/specmint/pkg/schema"
+	mathrand "math/rand"
+)
+
+// fakerLocale holds the word lists and formats of one locale. The lists are
+// small on purpose: values only need to look plausible, and every pick comes
+// from the field's seeded rng, so a given seed and locale always yield the
+// same text.
+type fakerLocale struct {
+	FirstNames      []string
+	LastNames       []string
+	Cities          []string
+	CompanySuffixes []string
+	Address         func(rng *mathrand.Rand) string
+	PostalCode      func(rng *mathrand.Rand) string
+	Phone           func(rng *mathrand.Rand) string
+}
+
+var fakerLocales = map[string]*fakerLocale{
+	"en-US": {
+		FirstNames: []string{
+			"James", "Mary", "Robert", "Patricia", "John", "Jennifer", "Michael", "Linda",
+			"David", "Elizabeth", "William", "Barbara", "Richard", "Susan", "Joseph", "Jessica",
+			"Thomas", "Sarah", "Charles", "Karen", "Daniel", "Nancy", "Matthew", "Lisa",
+			"Anthony", "Betty", "Mark", "Margaret", "Steven", "Sandra", "Paul", "Ashley",
+		},
+		LastNames: []string{
+			"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis",
+			"Rodriguez", "Martinez", "Hernandez", "Lopez", "Wilson", "Anderson", "Thomas", "Taylor",
+			"Moore", "Jackson", "Martin", "Lee", "Thompson", "White", "Harris", "Clark",
+			"Lewis", "Robinson", "Walker", "Young", "Allen", "King", "Wright", "Scott",
+		},
+		Cities: []string{
+			"Springfield", "Riverside", "Franklin", "Greenville", "Bristol", "Clinton", "Fairview", "Salem",
+			"Madison", "Georgetown", "Arlington", "Ashland", "Burlington", "Manchester", "Milton", "Oxford",
+		},
+		CompanySuffixes: []string{"Inc", "LLC", "Ltd", "Group", "Co"},
+		Address: func(rng *mathrand.Rand) string {
+			return fmt.Sprintf("%d %s %s", 1+rng.Intn(9999),
+				pick([]string{
+					"Main", "Oak", "Pine", "Maple", "Cedar", "Elm", "Washington", "Lake",
+					"Hill", "Park", "Sunset", "Lincoln", "Jackson", "Church", "River", "Highland",
+				}, rng),
+				pick([]string{"St", "Ave", "Rd", "Blvd", "Ln", "Dr", "Way", "Ct"}, rng))
+		},
+		PostalCode: func(rng *mathrand.Rand) string {
+			return fmt.Sprintf("%05d", 1001+rng.Intn(98999))
+		},
+		Phone: func(rng *mathrand.Rand) string {
+			// Area codes and exchanges never start with 0 or 1
+			return fmt.Sprintf("(%03d) %03d-%04d", 200+rng.Intn(800), 200+rng.Intn(800), rng.Intn(10000))
+		},
+	},
+	"en-GB": {
+		FirstNames: []string{
+			"Oliver", "Olivia", "George", "Amelia", "Harry", "Isla", "Jack", "Ava",
+			"Charlie", "Emily", "Thomas", "Sophie", "Oscar", "Grace", "William", "Lily",
+			"James", "Freya", "Alfie", "Ella", "Henry", "Poppy", "Arthur", "Evie",
+		},
+		LastNames: []string{
+			"Smith", "Jones", "Taylor", "Brown", "Williams", "Wilson", "Johnson", "Davies",
+			"Robinson", "Wright", "Thompson", "Evans", "Walker", "White", "Roberts", "Green",
+			"Hall", "Wood", "Jackson", "Clarke", "Hughes", "Edwards", "Turner", "Hill",
+		},
+		Cities: []string{
+			"London", "Manchester", "Birmingham", "Leeds", "Glasgow", "Liverpool", "Bristol", "Sheffield",
+			"Edinburgh", "Cardiff", "Leicester", "Nottingham", "Newcastle", "Brighton", "Oxford", "York",
+		},
+		CompanySuffixes: []string{"Ltd", "PLC", "LLP", "Group"},
+		Address: func(rng *mathrand.Rand) string {
+			return fmt.Sprintf("%d %s %s", 1+rng.Intn(250),
+				pick([]string{
+					"High", "Station", "Church", "Victoria", "Park", "Mill", "Queen's", "King's",
+					"Manor", "London", "Green", "School", "Kingsway", "Albert", "Chapel", "Windsor",
+				}, rng),
+				pick([]string{"Street", "Road", "Lane", "Avenue", "Close", "Way", "Drive", "Gardens"}, rng))
+		},
+		PostalCode: func(rng *mathrand.Rand) string {
+			// Outward code, then a sector digit and a unit of two letters
+			// that are never C, I, K, M, O or V
+			const unit = "ABDEFGHJLNPQRSTUWXYZ"
+			outward := pick([]string{"SW1A", "EC1A", "W1D", "N1", "M1", "B33", "LS1", "G2", "EH1", "CF10", "BS1", "L1"}, rng)
+			return fmt.Sprintf("%s %d%c%c", outward, rng.Intn(10), unit[rng.Intn(len(unit))], unit[rng.Intn(len(unit))])
+		},
+		Phone: func(rng *mathrand.Rand) string {
+			// Mobile numbers
+			return fmt.Sprintf("07%03d %06d", rng.Intn(1000), rng.Intn(1000000))
+		},
+	},
+	"de-DE": {
+		FirstNames: []string{
+			"Lukas", "Anna", "Maximilian", "Sophie", "Paul", "Marie", "Jonas", "Emma",
+			"Leon", "Mia", "Felix", "Hannah", "Elias", "Lena", "Noah", "Lea",
+			"Finn", "Clara", "Ben", "Laura", "Luis", "Johanna", "Julian", "Katharina",
+		},
+		LastNames: []string{
+			"Müller", "Schmidt", "Schneider", "Fischer", "Weber", "Meyer", "Wagner", "Becker",
+			"Schulz", "Hoffmann", "Schäfer", "Koch", "Bauer", "Richter", "Klein", "Wolf",
+			"Schröder", "Neumann", "Schwarz", "Zimmermann", "Braun", "Krüger", "Hofmann", "Hartmann",
+		},
+		Cities: []string{
+			"Berlin", "Hamburg", "München", "Köln", "Frankfurt am Main", "Stuttgart", "Düsseldorf", "Leipzig",
+			"Dortmund", "Essen", "Bremen", "Dresden", "Hannover", "Nürnberg", "Bonn", "Freiburg",
+		},
+		CompanySuffixes: []string{"GmbH", "AG", "KG", "GmbH & Co. KG"},
+		Address: func(rng *mathrand.Rand) string {
+			return fmt.Sprintf("%s %d",
+				pick([]string{
+					"Hauptstraße", "Schulstraße", "Gartenstraße", "Bahnhofstraße", "Dorfstraße", "Bergstraße",
+					"Lindenstraße", "Kirchstraße", "Waldstraße", "Ringstraße", "Birkenweg", "Am Markt",
+				}, rng),
+				1+rng.Intn(199))
+		},
+		PostalCode: func(rng *mathrand.Rand) string {
+			return fmt.Sprintf("%05d", 1067+rng.Intn(98932))
+		},
+		Phone: func(rng *mathrand.Rand) string {
+			// Area code of a large city, then the subscriber number
+			return fmt.Sprintf("0%s %07d", pick([]string{"30", "40", "69", "89", "221", "211", "711", "341"}, rng), rng.Intn(10000000))
+		},
+	},
+	"fr-FR": {
+		FirstNames: []string{
+			"Gabriel", "Louise", "Léo", "Emma", "Raphaël", "Jade", "Louis", "Alice",
+			"Arthur", "Chloé", "Jules", "Lina", "Adam", "Rose", "Hugo", "Léa",
+			"Lucas", "Manon", "Nathan", "Camille", "Paul", "Inès", "Tom", "Juliette",
+		},
+		LastNames: []string{
+			"Martin", "Bernard", "Dubois", "Thomas", "Robert", "Richard", "Petit", "Durand",
+			"Leroy", "Moreau", "Simon", "Laurent", "Lefèvre", "Michel", "Garcia", "David",
+			"Bertrand", "Roux", "Vincent", "Fournier", "Morel", "Girard", "André", "Mercier",
+		},
+		Cities: []string{
+			"Paris", "Marseille", "Lyon", "Toulouse", "Nice", "Nantes", "Strasbourg", "Montpellier",
+			"Bordeaux", "Lille", "Rennes", "Reims", "Toulon", "Grenoble", "Dijon", "Angers",
+		},
+		CompanySuffixes: []string{"SA", "SARL", "SAS"},
+		Address: func(rng *mathrand.Rand) string {
+			return fmt.Sprintf("%d %s", 1+rng.Intn(150),
+				pick([]string{
+					"rue de la Paix", "avenue Victor Hugo", "rue de la République", "boulevard Voltaire",
+					"place de la Mairie", "rue Pasteur", "rue Jean Jaurès", "allée des Tilleuls",
+					"rue du Moulin", "chemin des Vignes", "avenue de la Gare", "rue Nationale",
+				}, rng))
+		},
+		PostalCode: func(rng *mathrand.Rand) string {
+			// Département, then the office
+			return fmt.Sprintf("%02d%03d", 1+rng.Intn(95), rng.Intn(1000))
+		},
+		Phone: func(rng *mathrand.Rand) string {
+			return fmt.Sprintf("0%d %02d %02d %02d %02d", 1+rng.Intn(7), rng.Intn(100), rng.Intn(100), rng.Intn(100), rng.Intn(100))
+		},
+	},
+}
+
+var (
+	fakerCompanyWords = []string{
+		"Apex", "Summit", "Blue Harbor", "Ironwood", "Northstar", "Silverline", "Brightpath", "Evergreen",
+		"Keystone", "Pinnacle", "Redwood", "Clearwater", "Granite", "Horizon", "Lakeside", "Meridian",
+	}
+	fakerCompanyKinds = []string{"Systems", "Logistics", "Health", "Analytics", "Foods", "Energy", "Labs", "Partners"}
+	fakerLoremWords   = []string{
+		"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit",
+		"sed", "do", "eiusmod", "tempor", "incididunt", "ut", "labore", "et",
+		"dolore", "magna", "aliqua", "enim", "ad", "minim", "veniam", "quis",
+		"nostrud", "exercitation", "ullamco", "laboris", "nisi", "aliquip", "ex", "ea",
+	}
+)
+
+// fakers maps each x-generator name to its implementation
+var fakers = map[string]func(node *schema.SchemaNode, locale *fakerLocale, rng *mathrand.Rand) string{
+	"first_name": func(_ *schema.SchemaNode, locale *fakerLocale, rng *mathrand.Rand) string {
+		return pick(locale.FirstNames, rng)
+	},
+	"last_name": func(_ *schema.SchemaNode, locale *fakerLocale, rng *mathrand.Rand) string {
+		return pick(locale.LastNames, rng)
+	},
+	"full_name": func(_ *schema.SchemaNode, locale *fakerLocale, rng *mathrand.Rand) string {
+		return pick(locale.FirstNames, rng) + " " + pick(locale.LastNames, rng)
+	},
+	"street_address": func(_ *schema.SchemaNode, locale *fakerLocale, rng *mathrand.Rand) string {
+		return locale.Address(rng)
+	},
+	"city": func(_ *schema.SchemaNode, locale *fakerLocale, rng *mathrand.Rand) string {
+		return pick(locale.Cities, rng)
+	},
+	"postal_code": func(_ *schema.SchemaNode, locale *fakerLocale, rng *mathrand.Rand) string {
+		return locale.PostalCode(rng)
+	},
+	"phone": func(_ *schema.SchemaNode, locale *fakerLocale, rng *mathrand.Rand) string {
+		return locale.Phone(rng)
+	},
+	"company": func(_ *schema.SchemaNode, locale *fakerLocale, rng *mathrand.Rand) string {
+		return pick(fakerCompanyWords, rng) + " " + pick(fakerCompanyKinds, rng) + " " + pick(locale.CompanySuffixes, rng)
+	},
+	"lorem": func(node *schema.SchemaNode, _ *fakerLocale, rng *mathrand.Rand) string {
+		return generateLorem(node, rng)
+	},
+}
+
+// SetLocale sets the locale of synthetic names, addresses and phone
+// numbers. It reports false, leaving the locale unchanged, if the locale
+// is not supported.
+func (g *DeterministicGenerator) SetLocale(locale string) bool {
+	if fakerLocales[locale] == nil {
+		return false
+	}
+	g.locale = locale
+	return true
+}
+
+// fakerLocale returns the tables for a node: its x-locale if set, otherwise
+// the configured locale
+func (g *DeterministicGenerator) fakerLocale(node *schema.SchemaNode) *fakerLocale {
+	if locale := fakerLocales[node.Locale]; locale != nil {
+		return locale
+	}
+	if locale := fakerLocales[g.locale]; locale != nil {
+		return locale
+	}
+	return fakerLocales[schema.DefaultLocale]
+}
+
+// generateNamed produces a value with the x-generator a node names
+func (g *DeterministicGenerator) generateNamed(node *schema.SchemaNode, rng *mathrand.Rand) (string, error) {
+	faker, ok := fakers[node.Generator]
+	if !ok {
+		return "", fmt.Errorf("unknown x-generator %q at %s", node.Generator, node.Path)
+	}
+	return faker(node, g.fakerLocale(node), rng), nil
+}
+
+// generateLorem produces a sentence of placeholder words that fits the
+// node's length bounds, trimming the last word if need be
+func generateLorem(node *schema.SchemaNode, rng *mathrand.Rand) string {
+	minLen, maxLen := 20, 80
+	if node.MinLength != nil {
+		minLen = *node.MinLength
+		if maxLen < minLen {
+			maxLen = minLen
+		}
+	}
+	if node.MaxLength != nil {
+		maxLen = *node.MaxLength
+		if minLen > maxLen {
+			minLen = maxLen
+		}
+	}
+	target := minLen + rng.Intn(maxLen-minLen+1)
+
+	var text strings.Builder
+	for text.Len() < target {
+		if text.Len() > 0 {
+			text.WriteByte(' ')
+		}
+		text.WriteString(pick(fakerLoremWords, rng))
+	}
+	sentence := strings.TrimRight(text.String()[:target], " ")
+	for len(sentence) < minLen {
+		// Trimming a trailing space can leave the sentence one short
+		sentence += "s"
+	}
+	if sentence == "" {
+		return sentence
+	}
+	return strings.ToUpper(sentence[:1]) + sentence[1:]
+}
+
+// pick returns a random element of words
+func pick(words []string, rng *mathrand.Rand) string {
+	return words[rng.Intn(len(words))]
+}
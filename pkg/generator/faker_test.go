@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// TestNamedGenerators verifies every x-generator the parser accepts has an
+// implementation and produces deterministic, plausible values
+func TestNamedGenerators(t *testing.T) {
+	shapes := map[string]*regexp.Regexp{
+		"full_name":      regexp.MustCompile(`^[A-Z][a-z]+ [A-Z][a-z]+$`),
+		"first_name":     regexp.MustCompile(`^[A-Z][a-z]+$`),
+		"last_name":      regexp.MustCompile(`^[A-Z][a-z]+$`),
+		"street_address": regexp.MustCompile(`^[0-9]{1,4} [A-Z][a-z]+ [A-Z][a-z]+$`),
+		"city":           regexp.MustCompile(`^[A-Z][a-z]+$`),
+		"postal_code":    regexp.MustCompile(`^[0-9]{5}$`),
+		"phone":          regexp.MustCompile(`^\([2-9][0-9]{2}\) [2-9][0-9]{2}-[0-9]{4}$`),
+		"company":        regexp.MustCompile(`^[A-Z][A-Za-z ]+ (Inc|LLC|Ltd|Group|Co)$`),
+		"lorem":          regexp.MustCompile(`^[A-Z][a-z ]+$`),
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	for _, name := range schema.SyntheticGenerators {
+		t.Run(name, func(t *testing.T) {
+			shape, ok := shapes[name]
+			if !ok {
+				t.Fatalf("No expected shape for generator %s", name)
+			}
+			node := &schema.SchemaNode{Type: "string", Generator: name, Format: "email"}
+
+			for seed := int64(1); seed <= 50; seed++ {
+				value, err := generator.generateString(node, rand.New(rand.NewSource(seed)))
+				if err != nil {
+					t.Fatalf("Failed to generate %s: %v", name, err)
+				}
+				if !shape.MatchString(value) {
+					t.Errorf("Generated %s %q has the wrong shape (seed: %d)", name, value, seed)
+				}
+
+				again, _ := generator.generateString(node, rand.New(rand.NewSource(seed)))
+				if value != again {
+					t.Errorf("Expected %s to be deterministic, got %q and %q", name, value, again)
+				}
+			}
+		})
+	}
+}
+
+// TestLoremLength verifies lorem text honors minLength and maxLength
+func TestLoremLength(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	for _, bounds := range [][2]int{{1, 5}, {10, 12}, {100, 200}, {30, 30}} {
+		minLen, maxLen := bounds[0], bounds[1]
+		node := &schema.SchemaNode{Type: "string", Generator: "lorem", MinLength: &minLen, MaxLength: &maxLen}
+		for seed := int64(1); seed <= 50; seed++ {
+			value, err := generator.generateString(node, rand.New(rand.NewSource(seed)))
+			if err != nil {
+				t.Fatalf("Failed to generate lorem: %v", err)
+			}
+			if len(value) < minLen || len(value) > maxLen {
+				t.Errorf("Expected lorem of %d-%d characters, got %d: %q", minLen, maxLen, len(value), value)
+			}
+		}
+	}
+}
+
+// TestLocales verifies each supported locale has data tables and formats
+// postal codes and phone numbers its own way, deterministically
+func TestLocales(t *testing.T) {
+	shapes := map[string]struct{ postal, phone *regexp.Regexp }{
+		"en-US": {regexp.MustCompile(`^[0-9]{5}$`), regexp.MustCompile(`^\([2-9][0-9]{2}\) [2-9][0-9]{2}-[0-9]{4}$`)},
+		"en-GB": {regexp.MustCompile(`^[A-Z]{1,2}[0-9][0-9A-Z]? [0-9][A-Z]{2}$`), regexp.MustCompile(`^07[0-9]{3} [0-9]{6}$`)},
+		"de-DE": {regexp.MustCompile(`^[0-9]{5}$`), regexp.MustCompile(`^0[1-9][0-9]{1,2} [0-9]{7}$`)},
+		"fr-FR": {regexp.MustCompile(`^[0-9]{5}$`), regexp.MustCompile(`^0[1-7]( [0-9]{2}){4}$`)},
+	}
+
+	for _, locale := range schema.Locales {
+		t.Run(locale, func(t *testing.T) {
+			shape, ok := shapes[locale]
+			if !ok {
+				t.Fatalf("No expected shapes for locale %s", locale)
+			}
+			generator := NewDeterministicGenerator(12345)
+			if !generator.SetLocale(locale) {
+				t.Fatalf("Expected locale %s to be supported", locale)
+			}
+
+			for seed := int64(1); seed <= 50; seed++ {
+				for _, name := range schema.SyntheticGenerators {
+					node := &schema.SchemaNode{Type: "string", Generator: name}
+					value, err := generator.generateString(node, rand.New(rand.NewSource(seed)))
+					if err != nil {
+						t.Fatalf("Failed to generate %s: %v", name, err)
+					}
+					again, _ := generator.generateString(node, rand.New(rand.NewSource(seed)))
+					if value != again {
+						t.Errorf("Expected %s to be deterministic, got %q and %q", name, value, again)
+					}
+					if name == "postal_code" && !shape.postal.MatchString(value) {
+						t.Errorf("Generated postal code %q has the wrong shape", value)
+					}
+				}
+
+				phone, err := generator.generateString(&schema.SchemaNode{Type: "string", Format: "phone"}, rand.New(rand.NewSource(seed)))
+				if err != nil {
+					t.Fatalf("Failed to generate phone: %v", err)
+				}
+				if !shape.phone.MatchString(phone) {
+					t.Errorf("Generated phone %q has the wrong shape", phone)
+				}
+			}
+		})
+	}
+}
+
+// TestLocaleOverride verifies x-locale wins over the configured locale and
+// that an unsupported locale leaves the default in place
+func TestLocaleOverride(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	if generator.SetLocale("xx-XX") {
+		t.Error("Expected an unsupported locale to be refused")
+	}
+
+	node := &schema.SchemaNode{Type: "string", Generator: "last_name"}
+	german := &schema.SchemaNode{Type: "string", Generator: "last_name", Locale: "de-DE"}
+	for seed := int64(1); seed <= 20; seed++ {
+		value, _ := generator.generateString(node, rand.New(rand.NewSource(seed)))
+		if !contains(fakerLocales["en-US"].LastNames, value) {
+			t.Errorf("Expected an en-US last name, got %q", value)
+		}
+		value, _ = generator.generateString(german, rand.New(rand.NewSource(seed)))
+		if !contains(fakerLocales["de-DE"].LastNames, value) {
+			t.Errorf("Expected a de-DE last name, got %q", value)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,486 @@
+package generator
+
+import (
+	"math/rand"
+	"net"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/specmint/specmint/pkg/schema"
+	"github.com/specmint/specmint/pkg/validator"
+)
+
+// TestVINGeneration_PropertyBased verifies that generated VINs always carry
+// a valid check digit and honor the WMI prefix
+func TestVINGeneration_PropertyBased(t *testing.T) {
+	testCases := []struct {
+		name string
+		wmi  string
+	}{
+		{name: "No_Prefix", wmi: ""},
+		{name: "Honda_WMI", wmi: "1HG"},
+		{name: "Partial_WMI", wmi: "W"},
+	}
+
+	generator := NewDeterministicGenerator(12345)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := &schema.SchemaNode{Type: "string", Format: "vin", WMI: tc.wmi}
+
+			for seed := int64(1); seed <= 100; seed++ {
+				vin, err := generator.generateString(node, rand.New(rand.NewSource(seed)))
+				if err != nil {
+					t.Fatalf("Failed to generate VIN: %v", err)
+				}
+
+				if err := validator.ValidateVIN(vin); err != nil {
+					t.Errorf("Generated VIN '%s' failed validation: %v (seed: %d)", vin, err, seed)
+				}
+				if !strings.HasPrefix(vin, tc.wmi) {
+					t.Errorf("Generated VIN '%s' does not start with WMI '%s'", vin, tc.wmi)
+				}
+				if strings.ContainsAny(vin, "IOQ") {
+					t.Errorf("Generated VIN '%s' contains forbidden characters", vin)
+				}
+
+				vin2, _ := generator.generateString(node, rand.New(rand.NewSource(seed)))
+				if vin != vin2 {
+					t.Errorf("Non-deterministic VIN generation: '%s' != '%s' (seed: %d)", vin, vin2, seed)
+				}
+			}
+		})
+	}
+}
+
+// TestGeoIPGeneration_Consistency verifies that generated IPs always fall in
+// a block assigned to the generated country
+func TestGeoIPGeneration_Consistency(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	node := &schema.SchemaNode{
+		Type: "object",
+		Properties: map[string]*schema.SchemaNode{
+			"ip_address": {Type: "string"},
+			"country":    {Type: "string"},
+			"city":       {Type: "string"},
+		},
+		Required: []string{"ip_address", "country", "city"},
+		GeoIP:    &schema.GeoIPSpec{IPField: "ip_address", CountryField: "country", CityField: "city", Weighted: true},
+	}
+
+	for seed := int64(1); seed <= 100; seed++ {
+		record, err := generator.generateObject(node, rand.New(rand.NewSource(seed)), seed)
+		if err != nil {
+			t.Fatalf("Failed to generate geo-IP record: %v", err)
+		}
+
+		ip := net.ParseIP(record["ip_address"].(string))
+		if ip == nil {
+			t.Fatalf("Generated invalid IP '%v'", record["ip_address"])
+		}
+
+		matched := false
+		for _, country := range geoCountries {
+			if country.Code != record["country"] {
+				continue
+			}
+			for _, cidr := range country.Blocks {
+				_, block, _ := net.ParseCIDR(cidr)
+				if block.Contains(ip) {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			t.Errorf("IP %s is not in a block assigned to %v (seed: %d)", ip, record["country"], seed)
+		}
+	}
+}
+
+// TestCreditCardGeneration_PropertyBased verifies that generated card
+// numbers pass the Luhn check and match their network's length
+func TestCreditCardGeneration_PropertyBased(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	node := &schema.SchemaNode{Type: "string", Format: "credit-card"}
+	networks := make(map[byte]bool)
+
+	for seed := int64(1); seed <= 200; seed++ {
+		number, err := generator.generateString(node, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("Failed to generate card number: %v", err)
+		}
+
+		if err := validator.ValidateCreditCard(number); err != nil {
+			t.Errorf("Generated card number '%s' failed validation: %v (seed: %d)", number, err, seed)
+		}
+		amex := strings.HasPrefix(number, "34") || strings.HasPrefix(number, "37")
+		if (amex && len(number) != 15) || (!amex && len(number) != 16) {
+			t.Errorf("Generated card number '%s' has the wrong length for its network", number)
+		}
+		networks[number[0]] = true
+
+		number2, _ := generator.generateString(node, rand.New(rand.NewSource(seed)))
+		if number != number2 {
+			t.Errorf("Non-deterministic card generation: '%s' != '%s' (seed: %d)", number, number2, seed)
+		}
+	}
+
+	if !networks['4'] || !networks['3'] || !(networks['5'] || networks['2']) {
+		t.Errorf("Expected Visa, Mastercard and Amex numbers, got prefixes %v", networks)
+	}
+}
+
+// TestIBANGeneration_PropertyBased verifies that generated IBANs carry
+// correct mod-97 check digits and the right length for their country
+func TestIBANGeneration_PropertyBased(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	node := &schema.SchemaNode{Type: "string", Format: "iban"}
+
+	for seed := int64(1); seed <= 200; seed++ {
+		iban, err := generator.generateString(node, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("Failed to generate IBAN: %v", err)
+		}
+
+		if err := validator.ValidateIBAN(iban); err != nil {
+			t.Errorf("Generated IBAN '%s' failed validation: %v (seed: %d)", iban, err, seed)
+		}
+
+		iban2, _ := generator.generateString(node, rand.New(rand.NewSource(seed)))
+		if iban != iban2 {
+			t.Errorf("Non-deterministic IBAN generation: '%s' != '%s' (seed: %d)", iban, iban2, seed)
+		}
+	}
+}
+
+// TestPhoneE164Generation_PropertyBased verifies that phone-e164 numbers
+// pass E.164 validation, are deterministic and cover several countries
+func TestPhoneE164Generation_PropertyBased(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	node := &schema.SchemaNode{Type: "string", Format: "phone-e164"}
+
+	countries := make(map[string]bool)
+	for seed := int64(1); seed <= 200; seed++ {
+		phone, err := generator.generateString(node, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("Failed to generate phone number: %v", err)
+		}
+		if err := validator.ValidatePhoneE164(phone); err != nil {
+			t.Errorf("Generated phone number '%s' failed validation: %v (seed: %d)", phone, err, seed)
+		}
+		countries[phone[:3]] = true
+
+		phone2, _ := generator.generateString(node, rand.New(rand.NewSource(seed)))
+		if phone != phone2 {
+			t.Errorf("Non-deterministic phone generation: '%s' != '%s' (seed: %d)", phone, phone2, seed)
+		}
+	}
+	if len(countries) < 5 {
+		t.Errorf("Expected numbers from several countries, got prefixes %v", countries)
+	}
+}
+
+// TestBICGeneration verifies that generated BICs match the SWIFT shape
+func TestBICGeneration(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	node := &schema.SchemaNode{Type: "string", Format: "bic"}
+
+	for seed := int64(1); seed <= 100; seed++ {
+		bic, err := generator.generateString(node, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("Failed to generate BIC: %v", err)
+		}
+		if err := validator.ValidateBIC(bic); err != nil {
+			t.Errorf("Generated BIC '%s' failed validation: %v (seed: %d)", bic, err, seed)
+		}
+
+		bic2, _ := generator.generateString(node, rand.New(rand.NewSource(seed)))
+		if bic != bic2 {
+			t.Errorf("Non-deterministic BIC generation: '%s' != '%s' (seed: %d)", bic, bic2, seed)
+		}
+	}
+}
+
+// TestLOINCGeneration_PropertyBased verifies that generated LOINC codes
+// carry a valid check digit
+func TestLOINCGeneration_PropertyBased(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	node := &schema.SchemaNode{Type: "string", Format: "loinc"}
+
+	for seed := int64(1); seed <= 200; seed++ {
+		code, err := generator.generateString(node, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatalf("Failed to generate LOINC code: %v", err)
+		}
+		if err := validator.ValidateLOINC(code); err != nil {
+			t.Errorf("Generated LOINC code '%s' failed validation: %v (seed: %d)", code, err, seed)
+		}
+
+		code2, _ := generator.generateString(node, rand.New(rand.NewSource(seed)))
+		if code != code2 {
+			t.Errorf("Non-deterministic LOINC generation: '%s' != '%s' (seed: %d)", code, code2, seed)
+		}
+	}
+}
+
+// TestNetworkFormats_PropertyBased verifies that ipv4, ipv6 and hostname
+// values are well formed and deterministic
+func TestNetworkFormats_PropertyBased(t *testing.T) {
+	generator := NewDeterministicGenerator(12345)
+	hostnameLabel := regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+	compressed := false
+
+	for _, format := range []string{"ipv4", "ipv6", "hostname"} {
+		node := &schema.SchemaNode{Type: "string", Format: format}
+
+		for seed := int64(1); seed <= 200; seed++ {
+			value, err := generator.generateString(node, rand.New(rand.NewSource(seed)))
+			if err != nil {
+				t.Fatalf("Failed to generate %s: %v", format, err)
+			}
+
+			switch format {
+			case "ipv4":
+				if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+					t.Errorf("Generated ipv4 '%s' is not parseable", value)
+				}
+			case "ipv6":
+				if ip := net.ParseIP(value); ip == nil || ip.To4() != nil {
+					t.Errorf("Generated ipv6 '%s' is not parseable", value)
+				}
+				if strings.Contains(value, "::") {
+					compressed = true
+					written := 0
+					for _, group := range strings.Split(value, ":") {
+						if group != "" {
+							written++
+						}
+					}
+					if written > 6 {
+						t.Errorf("Generated ipv6 '%s' compresses fewer than two groups", value)
+					}
+				}
+			case "hostname":
+				if len(value) > 253 {
+					t.Errorf("Generated hostname '%s' exceeds 253 characters", value)
+				}
+				for _, label := range strings.Split(value, ".") {
+					if len(label) > 63 || !hostnameLabel.MatchString(label) {
+						t.Errorf("Generated hostname '%s' has invalid label '%s'", value, label)
+					}
+				}
+			}
+
+			value2, _ := generator.generateString(node, rand.New(rand.NewSource(seed)))
+			if value != value2 {
+				t.Errorf("Non-deterministic %s generation: '%s' != '%s' (seed: %d)", format, value, value2, seed)
+			}
+		}
+	}
+
+	if !compressed {
+		t.Errorf("Expected some ipv6 addresses to use :: compression")
+	}
+}
+
+// TestTimeFormat_PropertyBased verifies generated times round-trip through
+// time parsing, pass schema validation, stay within x-time-range and carry
+// the offset of the field's timezone
+func TestTimeFormat_PropertyBased(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["slot", "any"],
+		"properties": {
+			"slot": {"type": "string", "format": "time", "x-time-range": {"start": "09:00", "end": "17:30:00"}, "x-timezone": "Asia/Kolkata"},
+			"any": {"type": "string", "format": "time"}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	afternoon := false
+	for i := 0; i < 200; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if err := parser.Validate(value); err != nil {
+			t.Fatalf("Record %d failed validation: %v", i, err)
+		}
+		record := value.(map[string]interface{})
+
+		for _, field := range []string{"slot", "any"} {
+			text := record[field].(string)
+			parsed, err := time.Parse("15:04:05Z07:00", text)
+			if err != nil || parsed.Format("15:04:05Z07:00") != text {
+				t.Fatalf("Expected %s %q to round-trip, got %v", field, text, err)
+			}
+		}
+
+		slot := record["slot"].(string)
+		if !strings.HasSuffix(slot, "+05:30") || slot < "09:00:00" || slot > "17:30:00" {
+			t.Errorf("Expected slot %q within 09:00 to 17:30 at +05:30", slot)
+		}
+		afternoon = afternoon || slot >= "13:00:00"
+		if err := validator.ValidateTime(slot, root.PropertyNode("slot").TimeRange); err != nil {
+			t.Errorf("Expected slot %q to validate: %v", slot, err)
+		}
+		if anyTime := record["any"].(string); !strings.HasSuffix(anyTime, "Z") {
+			t.Errorf("Expected a UTC time, got %q", anyTime)
+		}
+	}
+	if !afternoon {
+		t.Error("Expected slots to span the range")
+	}
+
+	for _, invalid := range []string{
+		`{"type": "string", "x-time-range": {"start": "09:00", "end": "17:00"}}`,
+		`{"type": "string", "format": "time", "x-time-range": {"start": "17:00", "end": "09:00"}}`,
+		`{"type": "string", "format": "time", "x-time-range": {"start": "9am", "end": "17:00"}}`,
+	} {
+		parser := schema.NewParser()
+		if err := parser.ParseBytes([]byte(invalid)); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		if _, err := parser.GetRootNode(); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+// TestDurationFormat_PropertyBased verifies generated durations parse, pass
+// schema validation, stay within x-duration-range and mix date and time
+// components
+func TestDurationFormat_PropertyBased(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["slot", "any"],
+		"properties": {
+			"slot": {"type": "string", "format": "duration", "x-duration-range": {"min": "PT15M", "max": "P2DT12H"}},
+			"any": {"type": "string", "format": "duration"}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	generator := NewDeterministicGenerator(12345)
+	mixed, whole := false, false
+	for i := 0; i < 300; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if err := parser.Validate(value); err != nil {
+			t.Fatalf("Record %d failed validation: %v", i, err)
+		}
+		record := value.(map[string]interface{})
+
+		slot := record["slot"].(string)
+		duration, err := schema.ParseISODuration(slot)
+		if err != nil {
+			t.Fatalf("Failed to parse slot %q: %v", slot, err)
+		}
+		length, _ := duration.Fixed()
+		if length < 15*time.Minute || length > 60*time.Hour {
+			t.Errorf("Expected slot %q within PT15M to P2DT12H, lasts %s", slot, length)
+		}
+		if err := validator.ValidateDuration(slot, root.PropertyNode("slot").DurationRange); err != nil {
+			t.Errorf("Expected slot %q to validate: %v", slot, err)
+		}
+		mixed = mixed || (duration.Days > 0 && strings.Contains(slot, "T"))
+		whole = whole || !strings.Contains(slot, "T")
+
+		anyDuration, err := schema.ParseISODuration(record["any"].(string))
+		if length, _ := anyDuration.Fixed(); err != nil || length > schema.DefaultDurationMax {
+			t.Errorf("Expected a duration of up to 30 days, got %q", record["any"])
+		}
+	}
+	if !mixed || !whole {
+		t.Errorf("Expected both mixed and whole-day durations, got mixed %v and whole %v", mixed, whole)
+	}
+
+	for seconds, expected := range map[int64]string{0: "PT0S", 59: "PT59S", 3600: "PT1H", 86400: "P1D", 90061: "P1DT1H1M1S"} {
+		if formatted := formatISODuration(seconds); formatted != expected {
+			t.Errorf("Expected %d seconds to format as %s, got %s", seconds, expected, formatted)
+		}
+	}
+
+	for _, invalid := range []string{
+		`{"type": "string", "x-duration-range": {"max": "P1D"}}`,
+		`{"type": "string", "format": "duration", "x-duration-range": {"min": "P2D", "max": "P1D"}}`,
+		`{"type": "string", "format": "duration", "x-duration-range": {"max": "P1M"}}`,
+		`{"type": "string", "format": "duration", "x-duration-range": {"max": "1 day"}}`,
+	} {
+		parser := schema.NewParser()
+		if err := parser.ParseBytes([]byte(invalid)); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		if _, err := parser.GetRootNode(); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+// TestFormatLength_PropertyBased verifies email, uri and phone values are
+// fitted to minLength and maxLength and still pass schema validation, and
+// that a format that cannot fit is an error
+func TestFormatLength_PropertyBased(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["short_email", "long_email", "tiny_email", "short_uri", "long_uri", "phone"],
+		"properties": {
+			"short_email": {"type": "string", "format": "email", "maxLength": 12},
+			"long_email": {"type": "string", "format": "email", "minLength": 40},
+			"tiny_email": {"type": "string", "format": "email", "maxLength": 9},
+			"short_uri": {"type": "string", "format": "uri", "maxLength": 16},
+			"long_uri": {"type": "string", "format": "uri", "minLength": 50, "maxLength": 60},
+			"phone": {"type": "string", "format": "phone", "maxLength": 10}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	v := validator.New(parser)
+
+	generator := NewDeterministicGenerator(12345)
+	for i := 0; i < 200; i++ {
+		value, err := generator.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if errors := v.ValidateRecord(value); len(errors) > 0 {
+			t.Fatalf("Record %d %v failed validation: %+v", i, value, errors)
+		}
+		if again, _ := generator.GenerateValue(root, i); !reflect.DeepEqual(value, again) {
+			t.Fatalf("Record %d differs on regeneration", i)
+		}
+	}
+
+	for _, node := range []*schema.SchemaNode{
+		{Type: "string", Format: "uuid", MaxLength: intPtr(10), Path: "id"},
+		{Type: "string", Format: "email", MaxLength: intPtr(8), Path: "email"},
+		{Type: "string", Format: "date", MinLength: intPtr(12), Path: "day"},
+	} {
+		if _, err := generator.generateString(node, rand.New(rand.NewSource(1))); err == nil || !strings.Contains(err.Error(), node.Path) {
+			t.Errorf("Expected an error naming %s, got %v", node.Path, err)
+		}
+	}
+}
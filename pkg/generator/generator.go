@@ -2,7 +2,11 @@ package generator
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -21,10 +25,21 @@ type Generator struct {
 	parser    *schema.Parser
 	detGen    *DeterministicGenerator
 	llmClient LLMClient
+	budget    *budgetedClient
+	cache     *cachedClient
+	quiet     bool
+	build     BuildInfo
+	resumed   *Checkpoint
 	validator *validator.Validator
 	writer    *writer.Writer
 }
 
+// BuildInfo identifies the specmint build that generated a dataset
+type BuildInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
+
 // LLMClient interface for LLM providers
 type LLMClient interface {
 	Generate(ctx context.Context, prompt string, seed int64) (string, error)
@@ -37,13 +52,110 @@ type GenerationResult struct {
 	RecordCount      int           `json:"record_count"`
 	Duration         time.Duration `json:"duration"`
 	OutputPath       string        `json:"output_path"`
+	DatasetFile      string        `json:"dataset_file"`
 	LLMCallCount     int           `json:"llm_call_count"`
 	ValidationErrors int           `json:"validation_errors"`
 	PatchedRecords   int           `json:"patched_records"`
+	Retries          int           `json:"retries"`        // times a record was regenerated because it was still invalid after patching
+	FailedRecords    int           `json:"failed_records"` // records still invalid after patching and retries, written to rejects.jsonl in strict mode
+	Regenerations    int           `json:"regenerations"`  // times a record was regenerated to satisfy unique_by
+	TotalCostUSD     float64       `json:"total_cost_usd"`
+	LLMCacheHits     int           `json:"llm_cache_hits"`
+	Interrupted      bool          `json:"interrupted,omitempty"` // the run was cancelled and wrote only its first records
 }
 
 // New creates a new generator instance
 func New(cfg *config.Config) (*Generator, error) {
+	g, err := newGenerator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.setupLLM(); err != nil {
+		return nil, err
+	}
+
+	// Initialize writer
+	w, err := writer.New(cfg.Output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create writer: %w", err)
+	}
+	g.writer = w
+
+	return g, nil
+}
+
+// setupLLM creates the LLM client when enrichment is on, wrapped in the
+// response cache and budget tracking as configured
+func (g *Generator) setupLLM() error {
+	cfg := g.config
+	if cfg.LLM.Mode == "off" {
+		return nil
+	}
+
+	client, err := createLLMClient(cfg)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to create LLM client, falling back to deterministic mode")
+		cfg.LLM.Mode = "off"
+		return nil
+	}
+	return g.wrapLLMClient(client)
+}
+
+// wrapLLMClient sets client as the generator's LLM client, inside budget
+// tracking and then the response cache as configured. The cache is
+// outermost, so hits never reach the budget: they cost nothing and are
+// still served once the budget is exhausted.
+func (g *Generator) wrapLLMClient(client LLMClient) error {
+	cfg := g.config
+	g.llmClient = client
+
+	if cfg.LLM.Budget.TrackingEnabled {
+		g.budget = newBudgetedClient(g.llmClient, llmModel(cfg), cfg.LLM.Budget.MaxCostUSD, cfg.LLM.Budget.WarnThreshold)
+		g.llmClient = g.budget
+	}
+
+	if cfg.LLM.CacheEnabled {
+		cache, err := newCachedClient(g.llmClient, llmModel(cfg), cfg.LLM.CacheFile)
+		if err != nil {
+			return err
+		}
+		g.cache = cache
+		g.llmClient = cache
+	}
+	return nil
+}
+
+// checkLLM falls back to deterministic generation when the LLM provider is
+// unreachable
+func (g *Generator) checkLLM(ctx context.Context) {
+	if g.llmClient == nil {
+		return
+	}
+	if err := g.llmClient.HealthCheck(ctx); err != nil {
+		log.Warn().Err(err).Msg("LLM health check failed, falling back to deterministic mode")
+		g.llmClient = nil
+		g.config.LLM.Mode = "off"
+	}
+}
+
+// SetBuildInfo records the specmint build in the manifest
+func (g *Generator) SetBuildInfo(build BuildInfo) {
+	g.build = build
+}
+
+// SetQuiet turns off progress reporting during Generate
+func (g *Generator) SetQuiet(quiet bool) {
+	g.quiet = quiet
+}
+
+// NewPreview creates a generator for dry runs. It has no LLM client and no
+// writer, so it never calls a provider or touches the output directory.
+func NewPreview(cfg *config.Config) (*Generator, error) {
+	return newGenerator(cfg)
+}
+
+// newGenerator sets up the parts shared by real and dry runs
+func newGenerator(cfg *config.Config) (*Generator, error) {
 	// Initialize schema parser
 	parser := schema.NewParser()
 	if err := parser.ParseFile(cfg.Schema); err != nil {
@@ -52,42 +164,46 @@ func New(cfg *config.Config) (*Generator, error) {
 
 	// Initialize deterministic generator
 	detGen := NewDeterministicGenerator(cfg.Generation.Seed)
-
-	// Initialize LLM client if needed
-	var llmClient LLMClient
-	if cfg.LLM.Mode != "off" {
-		client, err := createLLMClient(cfg)
+	detGen.SetNullProbability(cfg.Generation.NullProbability)
+	loc, err := time.LoadLocation(cfg.Generation.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	detGen.SetLocation(loc)
+	if cfg.Generation.ReferenceDate != "" {
+		day, err := time.Parse("2006-01-02", cfg.Generation.ReferenceDate)
 		if err != nil {
-			log.Warn().Err(err).Msg("Failed to create LLM client, falling back to deterministic mode")
-			cfg.LLM.Mode = "off"
-		} else {
-			llmClient = client
+			return nil, fmt.Errorf("invalid reference date: %w", err)
 		}
+		detGen.SetReferenceDate(day)
 	}
-
-	// Initialize validator
-	val := validator.New(parser)
-
-	// Initialize writer
-	w, err := writer.New(cfg.Output)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create writer: %w", err)
+	if cfg.Generation.Locale != "" && !detGen.SetLocale(cfg.Generation.Locale) {
+		log.Warn().
+			Str("locale", cfg.Generation.Locale).
+			Str("fallback", schema.DefaultLocale).
+			Msg("Unsupported locale, using the default")
 	}
 
 	return &Generator{
 		config:    cfg,
 		parser:    parser,
 		detGen:    detGen,
-		llmClient: llmClient,
-		validator: val,
-		writer:    w,
+		validator: validator.New(parser),
 	}, nil
 }
 
-// Generate generates synthetic data according to the configuration
+// Generate generates synthetic data according to the configuration. When
+// ctx is cancelled the records done so far are kept, and Generate returns the
+// partial result along with the context's error.
 func (g *Generator) Generate(ctx context.Context) (*GenerationResult, error) {
 	startTime := time.Now()
 
+	if expr := g.config.Generation.CountExpr; expr != "" {
+		log.Info().
+			Str("count_expr", expr).
+			Int("count", g.config.Generation.Count).
+			Msg("Resolved count expression")
+	}
 	log.Info().
 		Int("count", g.config.Generation.Count).
 		Int64("seed", g.config.Generation.Seed).
@@ -95,13 +211,7 @@ func (g *Generator) Generate(ctx context.Context) (*GenerationResult, error) {
 		Msg("Starting generation")
 
 	// Health check LLM if enabled
-	if g.llmClient != nil {
-		if err := g.llmClient.HealthCheck(ctx); err != nil {
-			log.Warn().Err(err).Msg("LLM health check failed, falling back to deterministic mode")
-			g.llmClient = nil
-			g.config.LLM.Mode = "off"
-		}
-	}
+	g.checkLLM(ctx)
 
 	// Get root schema node
 	rootNode, err := g.parser.GetRootNode()
@@ -109,33 +219,79 @@ func (g *Generator) Generate(ctx context.Context) (*GenerationResult, error) {
 		return nil, fmt.Errorf("failed to get root schema node: %w", err)
 	}
 
+	// Foreign keys need the referenced datasets loaded up front
+	if err := g.loadReferences(rootNode); err != nil {
+		return nil, err
+	}
+
+	// Tabular output takes its columns from the schema, not the records,
+	// and Avro output its schema
+	g.writer.SetColumns(recordColumns(rootNode.RecordNode()))
+	g.writer.SetSchema(rootNode.RecordNode())
+
+	// Surface schema constructs that generation will ignore
+	for _, warning := range g.parser.Warnings() {
+		log.Warn().
+			Str("path", warning.Path).
+			Str("keyword", warning.Keyword).
+			Msg(warning.Message)
+	}
+
 	// Initialize result tracking
 	result := &GenerationResult{
-		OutputPath: g.config.Output.Directory,
+		OutputPath:  g.config.Output.Directory,
+		DatasetFile: g.writer.GetOutputPath(),
 	}
 
-	// Create worker pools
+	// Deterministic generation feeds an optional LLM enrichment stage with
+	// its own worker pool, so LLM concurrency is set by llm.workers alone
 	recordChan := make(chan int, g.config.Generation.Workers)
 	resultChan := make(chan generatedRecord, g.config.Generation.Workers)
-
-	var wg sync.WaitGroup
+	pending := g.config.Generation.Count - g.config.Generation.StartIndex
+
+	var wg, llmWg sync.WaitGroup
+	generatedChan := resultChan
+	if g.llmEnabled() {
+		// Buffer every record of the run, which is held in memory anyway, so
+		// a slow LLM never stalls deterministic generation
+		generatedChan = make(chan generatedRecord, pending)
+		for i := 0; i < g.config.LLM.Workers; i++ {
+			llmWg.Add(1)
+			go g.llmWorker(ctx, &llmWg, rootNode.RecordNode(), generatedChan, resultChan)
+		}
+	}
 
 	// Start generation workers
 	for i := 0; i < g.config.Generation.Workers; i++ {
 		wg.Add(1)
-		go g.generationWorker(ctx, &wg, rootNode, recordChan, resultChan)
+		go g.generationWorker(ctx, &wg, rootNode, recordChan, generatedChan)
 	}
 
 	// Start result collector
 	var collectorWg sync.WaitGroup
 	collectorWg.Add(1)
-	records := make([]map[string]interface{}, 0, g.config.Generation.Count)
-	go g.resultCollector(&collectorWg, resultChan, &records, result)
+	var collected []generatedRecord
+	var batch *batchWriter
+	if g.checkpointing() {
+		// Records are written in batches as they complete
+		if batch, err = newBatchWriter(g); err != nil {
+			return nil, err
+		}
+	} else {
+		collected = make([]generatedRecord, 0, pending)
+	}
+	var prog *progress
+	if !g.quiet {
+		prog = startProgress(pending)
+	}
+	metrics := startMetrics(g.config.Metrics)
+	defer metrics.stop()
+	go g.resultCollector(&collectorWg, resultChan, &collected, batch, result, prog, metrics)
 
 	// Send work to workers
 	go func() {
 		defer close(recordChan)
-		for i := 0; i < g.config.Generation.Count; i++ {
+		for i := g.config.Generation.StartIndex; i < g.config.Generation.Count; i++ {
 			select {
 			case recordChan <- i:
 			case <-ctx.Done():
@@ -144,15 +300,114 @@ func (g *Generator) Generate(ctx context.Context) (*GenerationResult, error) {
 		}
 	}()
 
-	// Wait for generation to complete
+	// Wait for each stage to drain before closing the next one's input
 	wg.Wait()
+	if generatedChan != resultChan {
+		close(generatedChan)
+		llmWg.Wait()
+	}
 	close(resultChan)
 	collectorWg.Wait()
+	if prog != nil {
+		prog.finish()
+	}
 
-	// Write results
-	if err := g.writer.WriteRecords(records); err != nil {
+	written := 0
+	interrupted := ctx.Err()
+	if batch != nil {
+		if batch.err != nil {
+			return nil, batch.err
+		}
+		if interrupted != nil {
+			// Keep what is contiguous and the checkpoint so --resume can
+			// pick up from there
+			if err := batch.flush(); err != nil {
+				return nil, err
+			}
+			partial := batch.totals
+			partial.OutputPath = result.OutputPath
+			partial.DatasetFile = result.DatasetFile
+			partial.RecordCount = batch.written
+			partial.Duration = time.Since(startTime)
+			partial.Interrupted = true
+			if err := g.writer.WriteManifest(g.createManifest(&partial, startTime)); err != nil {
+				return nil, fmt.Errorf("failed to write manifest: %w", err)
+			}
+			return &partial, fmt.Errorf("generation interrupted after record %d, rerun with --resume to continue: %w", batch.next-1, interrupted)
+		}
+		written = batch.written
+		collected = batch.remaining()
+	}
+
+	// Restore record index order so output is reproducible across runs
+	sort.Slice(collected, func(i, j int) bool {
+		return collected[i].Index < collected[j].Index
+	})
+
+	if interrupted != nil {
+		// Records that finished after a missing one are dropped, so the
+		// dataset is the start of the complete one. Deduplication below
+		// still runs to completion, so the context is no longer cancelled.
+		start, kept := g.config.Generation.StartIndex, 0
+		for kept < len(collected) && collected[kept].Index == start+kept {
+			kept++
+		}
+		for _, record := range collected[kept:] {
+			result.tally(record, -1)
+		}
+		collected = collected[:kept]
+		result.Interrupted = true
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	// Deduplicate in index order so the same records are regenerated every run
+	if g.config.Generation.UniqueBy != "" {
+		if err := g.deduplicate(ctx, rootNode, collected, result); err != nil {
+			return nil, err
+		}
+	}
+	strict := g.config.Generation.Validation == "strict"
+	records := make([]interface{}, 0, len(collected))
+	var rejects []interface{}
+	for _, record := range collected {
+		if strict && record.Remaining != nil {
+			rejects = append(rejects, map[string]interface{}{
+				"index":  record.Index,
+				"errors": record.Remaining,
+				"record": record.Data,
+			})
+			continue
+		}
+		records = append(records, record.Data)
+	}
+
+	// Write results, after any batches already written
+	if written > 0 {
+		err = g.writer.AppendRecords(records)
+	} else {
+		err = g.writer.WriteRecords(records)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to write records: %w", err)
 	}
+	if strict {
+		if err := g.writer.WriteRejects(rejects); err != nil {
+			return nil, fmt.Errorf("failed to write rejects: %w", err)
+		}
+	}
+
+	result.RecordCount = written + len(records)
+	result.Duration = time.Since(startTime)
+	if g.budget != nil {
+		result.TotalCostUSD = g.budget.TotalCost()
+	}
+	if g.cache != nil {
+		stats := g.cache.GetStats()
+		result.LLMCacheHits = stats["cache_hits"].(int)
+		if err := g.cache.Save(); err != nil {
+			log.Warn().Err(err).Msg("Failed to save LLM cache")
+		}
+	}
 
 	// Write manifest
 	manifest := g.createManifest(result, startTime)
@@ -160,14 +415,26 @@ func (g *Generator) Generate(ctx context.Context) (*GenerationResult, error) {
 		return nil, fmt.Errorf("failed to write manifest: %w", err)
 	}
 
-	result.RecordCount = len(records)
-	result.Duration = time.Since(startTime)
+	if interrupted != nil {
+		return result, fmt.Errorf("generation interrupted, wrote the first %d records and a partial manifest: %w", result.RecordCount, interrupted)
+	}
+
+	// The run is complete, so there is nothing left to resume
+	if batch != nil {
+		if err := os.Remove(CheckpointPath(g.config.Output.Directory)); err != nil && !os.IsNotExist(err) {
+			log.Warn().Err(err).Msg("Failed to remove checkpoint")
+		}
+	}
 
 	log.Info().
 		Int("records", result.RecordCount).
 		Dur("duration", result.Duration).
 		Int("llm_calls", result.LLMCallCount).
+		Int("llm_cache_hits", result.LLMCacheHits).
+		Float64("llm_cost_usd", result.TotalCostUSD).
 		Int("validation_errors", result.ValidationErrors).
+		Int("retries", result.Retries).
+		Int("failed_records", result.FailedRecords).
 		Msg("Generation completed")
 
 	return result, nil
@@ -175,13 +442,18 @@ func (g *Generator) Generate(ctx context.Context) (*GenerationResult, error) {
 
 // generatedRecord represents a generated record with metadata
 type generatedRecord struct {
-	Data             map[string]interface{}
+	Index            int
+	Data             interface{}
 	LLMEnhanced      bool
-	ValidationErrors []string
+	ValidationErrors []validator.ValidationError
 	Patched          bool
+	Remaining        []validator.ValidationError // errors left after patching, checked only in strict mode or with retries
+	Retries          int                         // regenerations after patching left the record invalid
 }
 
-// generationWorker generates individual records
+// generationWorker generates individual records. When the LLM stage is
+// enabled records are sent on unenriched and unvalidated, and llmWorker
+// finishes them.
 func (g *Generator) generationWorker(ctx context.Context, wg *sync.WaitGroup, rootNode *schema.SchemaNode, recordChan <-chan int, resultChan chan<- generatedRecord) {
 	defer wg.Done()
 
@@ -192,7 +464,13 @@ func (g *Generator) generationWorker(ctx context.Context, wg *sync.WaitGroup, ro
 		default:
 		}
 
-		record, err := g.generateRecord(ctx, rootNode, recordIndex)
+		var record generatedRecord
+		var err error
+		if g.llmEnabled() {
+			record, err = g.baseRecord(rootNode.RecordNode(), recordIndex, 0, 0)
+		} else {
+			record, err = g.generateRecord(ctx, rootNode, recordIndex, 0)
+		}
 		if err != nil {
 			log.Error().Err(err).Int("record_index", recordIndex).Msg("Failed to generate record")
 			continue
@@ -202,76 +480,165 @@ func (g *Generator) generationWorker(ctx context.Context, wg *sync.WaitGroup, ro
 	}
 }
 
-// generateRecord generates a single record
-func (g *Generator) generateRecord(ctx context.Context, rootNode *schema.SchemaNode, recordIndex int) (generatedRecord, error) {
-	// Generate base record deterministically
-	value, err := g.detGen.GenerateValue(rootNode, recordIndex)
+// llmWorker enriches and validates records from the generation stage. LLM
+// seeds derive from the record index, so the order records arrive in does
+// not change the output.
+func (g *Generator) llmWorker(ctx context.Context, wg *sync.WaitGroup, recordNode *schema.SchemaNode, recordChan <-chan generatedRecord, resultChan chan<- generatedRecord) {
+	defer wg.Done()
+
+	for record := range recordChan {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		finished, err := g.finishRecord(ctx, recordNode, record, 0)
+		if err != nil {
+			log.Error().Err(err).Int("record_index", record.Index).Msg("Failed to generate record")
+			continue
+		}
+		resultChan <- finished
+	}
+}
+
+// generateRecord generates, enriches and validates a single record. For an
+// array-root schema the record is one item of the array; other roots,
+// including primitives, are generated as the record itself. Attempts above 0
+// perturb the seed to get a different record for the same index.
+func (g *Generator) generateRecord(ctx context.Context, rootNode *schema.SchemaNode, recordIndex, attempt int) (generatedRecord, error) {
+	recordNode := rootNode.RecordNode()
+
+	record, err := g.baseRecord(recordNode, recordIndex, attempt, 0)
 	if err != nil {
-		return generatedRecord{}, fmt.Errorf("deterministic generation failed: %w", err)
+		return generatedRecord{}, err
 	}
+	return g.finishRecord(ctx, recordNode, record, attempt)
+}
 
-	record := generatedRecord{
-		Data: value.(map[string]interface{}),
+// finishRecord enriches and validates a deterministic record. A record
+// patching cannot fix is regenerated from perturbed seeds, and enriched
+// again, up to validation_retries times; the last attempt is kept even if
+// it is still invalid. Every retry is seeded by the record index, attempt
+// and retry number, so retries are reproducible.
+func (g *Generator) finishRecord(ctx context.Context, recordNode *schema.SchemaNode, record generatedRecord, attempt int) (generatedRecord, error) {
+	if g.llmEnabled() {
+		g.enrich(ctx, &record, recordNode)
 	}
+	g.validateRecord(&record)
 
-	log.Debug().Interface("base_record", record.Data).Msg("Generated base deterministic record")
+	for retry := 1; record.Remaining != nil && retry <= g.config.Generation.ValidationRetries; retry++ {
+		retried, err := g.baseRecord(recordNode, record.Index, attempt, retry)
+		if err != nil {
+			return record, err
+		}
+		if g.llmEnabled() {
+			g.enrich(ctx, &retried, recordNode)
+		}
+		g.validateRecord(&retried)
+		retried.Retries = retry
+		record = retried
+	}
 
-	// Apply LLM enrichment if enabled
-	if g.llmClient != nil && g.config.LLM.Mode != "off" {
-		log.Debug().Str("llm_mode", g.config.LLM.Mode).Msg("Starting LLM enrichment")
+	return record, nil
+}
 
-		// Direct LLM enhancement for specific fields
-		if g.config.LLM.Mode == "field" {
-			// Enhance name field if it exists and has x-llm marker
-			if _, hasName := record.Data["name"]; hasName {
-				prompt := g.createFieldPrompt("name", record.Data)
-				enhanced, err := g.llmClient.Generate(ctx, prompt, int64(recordIndex))
-				if err == nil {
-					cleanValue := strings.TrimSpace(enhanced)
-					if len(cleanValue) > 0 && cleanValue != "null" {
-						record.Data["name"] = cleanValue
-						record.LLMEnhanced = true
-					}
+// baseRecord generates the deterministic part of a record
+func (g *Generator) baseRecord(recordNode *schema.SchemaNode, recordIndex, attempt, retry int) (generatedRecord, error) {
+	value, err := g.detGen.generateAttempt(recordNode, recordIndex, attempt, retry)
+	if err != nil {
+		return generatedRecord{}, fmt.Errorf("deterministic generation failed: %w", err)
+	}
+
+	log.Debug().Interface("base_record", value).Msg("Generated base deterministic record")
+
+	return generatedRecord{
+		Index: recordIndex,
+		Data:  value,
+	}, nil
+}
+
+// llmEnabled reports whether records go through LLM enrichment
+func (g *Generator) llmEnabled() bool {
+	return g.llmClient != nil && g.config.LLM.Mode != "off"
+}
+
+// enrich applies LLM enrichment to a record; it works on object records only
+func (g *Generator) enrich(ctx context.Context, record *generatedRecord, recordNode *schema.SchemaNode) {
+	data, ok := record.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+	recordIndex := record.Index
+
+	log.Debug().Str("llm_mode", g.config.LLM.Mode).Msg("Starting LLM enrichment")
+
+	// Direct LLM enhancement for specific fields
+	if g.config.LLM.Mode == "field" {
+		// Enhance name field if it exists and has x-llm marker
+		if _, hasName := data["name"]; hasName {
+			prompt := g.createFieldPrompt(recordNode, "name", data)
+			enhanced, err := g.llmClient.Generate(ctx, prompt, int64(recordIndex))
+			if err == nil {
+				cleanValue := strings.TrimSpace(enhanced)
+				if len(cleanValue) > 0 && cleanValue != "null" {
+					data["name"] = cleanValue
+					record.LLMEnhanced = true
 				}
 			}
+		}
 
-			// Enhance description field if it exists and has x-llm marker
-			if _, hasDesc := record.Data["description"]; hasDesc {
-				prompt := g.createFieldPrompt("description", record.Data)
-				enhanced, err := g.llmClient.Generate(ctx, prompt, int64(recordIndex+1000))
-				if err == nil {
-					cleanValue := strings.TrimSpace(enhanced)
-					if len(cleanValue) > 0 && cleanValue != "null" {
-						record.Data["description"] = cleanValue
-						record.LLMEnhanced = true
-					}
+		// Enhance description field if it exists and has x-llm marker
+		if _, hasDesc := data["description"]; hasDesc {
+			prompt := g.createFieldPrompt(recordNode, "description", data)
+			enhanced, err := g.llmClient.Generate(ctx, prompt, int64(recordIndex+1000))
+			if err == nil {
+				cleanValue := strings.TrimSpace(enhanced)
+				if len(cleanValue) > 0 && cleanValue != "null" {
+					data["description"] = cleanValue
+					record.LLMEnhanced = true
 				}
 			}
-		} else {
-			enhanced, err := g.enrichWithLLM(ctx, record.Data, rootNode, recordIndex)
-			if err != nil {
-				log.Warn().Err(err).Int("record_index", recordIndex).Msg("LLM enrichment failed, using deterministic data")
-			} else {
-				log.Debug().Interface("enhanced_record", enhanced).Msg("LLM enrichment completed")
-				record.Data = enhanced
-				record.LLMEnhanced = true
-			}
 		}
+		return
 	}
 
-	// Validate record
-	if errors := g.validator.ValidateRecord(record.Data); len(errors) > 0 {
-		record.ValidationErrors = errors
+	enhanced, err := g.enrichWithLLM(ctx, data, recordNode, recordIndex)
+	if err != nil {
+		log.Warn().Err(err).Int("record_index", recordIndex).Msg("LLM enrichment failed, using deterministic data")
+		return
+	}
+	log.Debug().Interface("enhanced_record", enhanced).Msg("LLM enrichment completed")
+	record.Data = enhanced
+	record.LLMEnhanced = true
+}
 
-		// Try to patch validation errors
-		patched, err := g.validator.PatchRecord(record.Data, errors)
-		if err == nil {
-			record.Data = patched
-			record.Patched = true
-		}
+// validateRecord validates a record and patches what it can
+func (g *Generator) validateRecord(record *generatedRecord) {
+	errors := g.validator.ValidateRecord(record.Data)
+	if len(errors) == 0 {
+		return
 	}
+	record.ValidationErrors = errors
 
-	return record, nil
+	// Try to patch validation errors
+	patched, err := g.validator.PatchRecord(record.Data, errors)
+	if err == nil {
+		record.Data = patched
+		record.Patched = true
+	}
+
+	// Strict mode sets aside records that patching did not fix, and
+	// retries regenerate them
+	if g.config.Generation.Validation != "strict" && g.config.Generation.ValidationRetries == 0 {
+		return
+	}
+	if record.Patched {
+		errors = g.validator.ValidateRecord(record.Data)
+	}
+	if len(errors) > 0 {
+		record.Remaining = errors
+	}
 }
 
 // enrichWithLLM applies LLM enrichment to a record
@@ -300,9 +667,15 @@ func (g *Generator) enrichFields(ctx context.Context, data map[string]interface{
 
 	log.Debug().Int("llm_fields_count", len(llmFields)).Strs("llm_fields", llmFields).Msg("Found LLM fields for enhancement")
 
+	// Expand array paths such as items[].name to one path per element
+	var fieldPaths []string
 	for _, fieldPath := range llmFields {
+		fieldPaths = append(fieldPaths, expandFieldPath(data, fieldPath)...)
+	}
+
+	for _, fieldPath := range fieldPaths {
 		log.Debug().Str("field", fieldPath).Msg("Processing LLM field")
-		prompt := g.createFieldPrompt(fieldPath, data)
+		prompt := g.createFieldPrompt(rootNode, fieldPath, data)
 		seed := g.detGen.deriveSeed(fieldPath, recordIndex)
 
 		log.Debug().Str("field", fieldPath).Str("prompt", prompt).Msg("Calling LLM")
@@ -323,11 +696,12 @@ func (g *Generator) enrichFields(ctx context.Context, data map[string]interface{
 		log.Debug().Str("field", fieldPath).Str("raw_response", enhanced).Str("clean_value", cleanValue).Msg("LLM response received")
 
 		if cleanValue != "" && cleanValue != "null" && len(cleanValue) > 0 {
-			// Set the enhanced value in the data - FORCE replacement
-			originalValue := data[fieldPath]
-			data[fieldPath] = cleanValue // Direct assignment to ensure replacement
+			if err := setFieldValue(data, fieldPath, cleanValue); err != nil {
+				log.Warn().Err(err).Str("field", fieldPath).Msg("Failed to apply LLM value")
+				continue
+			}
 
-			log.Debug().Str("field", fieldPath).Interface("original", originalValue).Str("enhanced", cleanValue).Interface("final", data[fieldPath]).Msg("LLM enhancement applied")
+			log.Debug().Str("field", fieldPath).Str("enhanced", cleanValue).Msg("LLM enhancement applied")
 		}
 	}
 
@@ -339,39 +713,79 @@ func (g *Generator) enrichRecord(ctx context.Context, data map[string]interface{
 	prompt := g.createRecordPrompt(data, rootNode)
 	seed := g.detGen.deriveSeed("record", recordIndex)
 
-	_, err := g.llmClient.Generate(ctx, prompt, seed)
+	response, err := g.llmClient.Generate(ctx, prompt, seed)
 	if err != nil {
 		return data, err
 	}
 
-	// Parse LLM response and merge with original data
-	// This is a simplified implementation
+	// Merge the model's values over the deterministic record, keeping the
+	// deterministic data when the response cannot be used
+	candidate, err := parseLLMRecord(response)
+	if err != nil {
+		return data, err
+	}
+	mergeLLMObject(rootNode, data, candidate)
+
 	return data, nil
 }
 
-// resultCollector collects generated records and updates statistics
-func (g *Generator) resultCollector(wg *sync.WaitGroup, resultChan <-chan generatedRecord, records *[]map[string]interface{}, result *GenerationResult) {
+// resultCollector collects generated records, or hands them to batch when
+// the run writes in batches, and updates statistics and, when they are not
+// nil, progress and metrics
+func (g *Generator) resultCollector(wg *sync.WaitGroup, resultChan <-chan generatedRecord, records *[]generatedRecord, batch *batchWriter, result *GenerationResult, prog *progress, metrics *runMetrics) {
 	defer wg.Done()
 
 	for record := range resultChan {
-		*records = append(*records, record.Data)
-
-		if record.LLMEnhanced {
-			result.LLMCallCount++
-		}
-		if len(record.ValidationErrors) > 0 {
-			result.ValidationErrors++
+		if batch != nil {
+			batch.add(record)
+		} else {
+			*records = append(*records, record)
 		}
-		if record.Patched {
-			result.PatchedRecords++
+		result.tally(record, 1)
+		metrics.observe(record)
+		if prog != nil {
+			prog.add(1)
 		}
 	}
 }
 
+// tally adds (delta 1) or removes (delta -1) a record's statistics
+func (r *GenerationResult) tally(record generatedRecord, delta int) {
+	if record.LLMEnhanced {
+		r.LLMCallCount += delta
+	}
+	if len(record.ValidationErrors) > 0 {
+		r.ValidationErrors += delta
+	}
+	if record.Patched {
+		r.PatchedRecords += delta
+	}
+	r.Retries += delta * record.Retries
+	if record.Remaining != nil {
+		r.FailedRecords += delta
+	}
+}
+
 // Helper functions
 
 func createLLMClient(cfg *config.Config) (LLMClient, error) {
-	// For now, only support Ollama
+	if cfg.LLM.Provider == "openai" {
+		openAIConfig := llm.OpenAIConfig{
+			APIKey:      cfg.LLM.OpenAI.APIKey,
+			Model:       cfg.LLM.OpenAI.Model,
+			MaxTokens:   cfg.LLM.OpenAI.MaxTokens,
+			Temperature: cfg.LLM.OpenAI.Temperature,
+			MaxRPS:      cfg.LLM.MaxRPS,
+			Timeout:     cfg.LLM.Timeout,
+		}
+
+		client, err := llm.NewOpenAIClient(openAIConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OpenAI client: %w", err)
+		}
+		return client, nil
+	}
+
 	ollamaConfig := llm.OllamaConfig{
 		Host:        cfg.LLM.Ollama.Host,
 		Model:       cfg.LLM.Ollama.Model,
@@ -381,12 +795,47 @@ func createLLMClient(cfg *config.Config) (LLMClient, error) {
 		Temperature: cfg.LLM.Ollama.Temperature,
 		MaxRPS:      cfg.LLM.MaxRPS,
 		Timeout:     cfg.LLM.Timeout,
+		Stream:      cfg.LLM.Ollama.Stream,
 	}
 
 	return llm.NewOllamaClient(ollamaConfig)
 }
 
-func (g *Generator) createFieldPrompt(fieldPath string, data map[string]interface{}) string {
+// llmModel returns the model name used by the configured provider
+func llmModel(cfg *config.Config) string {
+	if cfg.LLM.Provider == "openai" {
+		return cfg.LLM.OpenAI.Model
+	}
+	return cfg.LLM.Ollama.Model
+}
+
+// recordColumns returns the declared top-level fields of a record, none
+// when records are not objects
+func recordColumns(recordNode *schema.SchemaNode) []string {
+	columns := make([]string, 0, len(recordNode.Properties))
+	for name := range recordNode.Properties {
+		columns = append(columns, name)
+	}
+	return columns
+}
+
+// createFieldPrompt returns the prompt that enriches fieldPath. A field with
+// x-llm-prompt renders its template with the record as data, so
+// {{.category}} is the record's category; when rendering fails the default
+// prompt for the field is used instead.
+func (g *Generator) createFieldPrompt(rootNode *schema.SchemaNode, fieldPath string, data map[string]interface{}) string {
+	if node := fieldNode(rootNode, fieldPath); node != nil && node.LLMPrompt != nil {
+		var prompt strings.Builder
+		err := node.LLMPrompt.Execute(&prompt, data)
+		if err == nil && strings.TrimSpace(prompt.String()) != "" {
+			return prompt.String()
+		}
+		if err == nil {
+			err = fmt.Errorf("template rendered an empty prompt")
+		}
+		log.Warn().Err(err).Str("field", fieldPath).Msg("Failed to render x-llm-prompt, using the default prompt")
+	}
+
 	// Create more specific prompts based on field name
 	switch fieldPath {
 	case "name":
@@ -407,34 +856,148 @@ func (g *Generator) createFieldPrompt(fieldPath string, data map[string]interfac
 }
 
 func (g *Generator) createRecordPrompt(data map[string]interface{}, rootNode *schema.SchemaNode) string {
-	return "Enhance this record with realistic data while maintaining the existing structure."
+	structure, _ := json.Marshal(describeSchema(rootNode))
+	record, _ := json.Marshal(data)
+
+	return fmt.Sprintf("Enhance this record with realistic data while maintaining the existing structure. "+
+		"Keep every field and its type as described by the structure below.\n\n"+
+		"Structure:\n%s\n\nRecord:\n%s\n\n"+
+		"Respond with only the JSON object, no explanation.", structure, record)
 }
 
-func setFieldValue(data map[string]interface{}, fieldPath, value string) error {
-	// For simple field paths (no dots), set directly
-	if !strings.Contains(fieldPath, ".") {
-		data[fieldPath] = value
-		return nil
+// setFieldValue sets a value at a dotted field path such as
+// patient.demographics.first_name or items[0].name. Missing intermediate
+// objects are created; array indices must refer to existing elements.
+func setFieldValue(data map[string]interface{}, fieldPath string, value interface{}) error {
+	segments, err := parseFieldPath(fieldPath)
+	if err != nil {
+		return err
+	}
+
+	var current interface{} = data
+	for i, segment := range segments {
+		last := i == len(segments)-1
+
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot set %s: %s is not an object", fieldPath, segment.key)
+		}
+		if segment.index == allElements {
+			return fmt.Errorf("cannot set %s: array paths need a concrete index", fieldPath)
+		}
+
+		if segment.index < 0 {
+			if last {
+				obj[segment.key] = value
+				return nil
+			}
+			next, exists := obj[segment.key]
+			if !exists || next == nil {
+				next = make(map[string]interface{})
+				obj[segment.key] = next
+			}
+			current = next
+			continue
+		}
+
+		items, ok := obj[segment.key].([]interface{})
+		if !ok {
+			return fmt.Errorf("cannot set %s: %s is not an array", fieldPath, segment.key)
+		}
+		if segment.index >= len(items) {
+			return fmt.Errorf("cannot set %s: index %d out of range for %s", fieldPath, segment.index, segment.key)
+		}
+		if last {
+			items[segment.index] = value
+			return nil
+		}
+		if items[segment.index] == nil {
+			items[segment.index] = make(map[string]interface{})
+		}
+		current = items[segment.index]
 	}
 
-	// For nested paths, would need proper path parsing
-	// For now, just handle simple cases
-	data[fieldPath] = value
 	return nil
 }
 
 func (g *Generator) createManifest(result *GenerationResult, startTime time.Time) map[string]interface{} {
-	return map[string]interface{}{
+	manifest := map[string]interface{}{
 		"version":           "1.0",
 		"generated_at":      startTime.Format(time.RFC3339),
 		"generation_time":   result.Duration.String(),
-		"record_count":      result.RecordCount,
+		"record_count":      g.config.Generation.StartIndex + result.RecordCount,
 		"seed":              g.config.Generation.Seed,
+		"reference_date":    g.detGen.ReferenceDate().Format("2006-01-02"),
 		"llm_mode":          g.config.LLM.Mode,
 		"llm_calls":         result.LLMCallCount,
+		"total_cost_usd":    result.TotalCostUSD,
 		"validation_errors": result.ValidationErrors,
 		"patched_records":   result.PatchedRecords,
 		"schema_file":       g.config.Schema,
+		"dataset_file":      filepath.Base(result.DatasetFile),
+		"compressed":        g.config.Output.Compress,
+		"schema_warnings":   g.parser.Warnings(),
 		"config":            g.config,
+		"specmint":          g.build,
+	}
+
+	// Hashes let two datasets be checked for the same schema and settings
+	if hash, err := g.parser.CanonicalHash(); err == nil {
+		manifest["schema_sha256"] = hash
+	} else {
+		log.Warn().Err(err).Msg("Failed to hash schema for the manifest")
 	}
+	if hash, err := configHash(g.config); err == nil {
+		manifest["config_sha256"] = hash
+	} else {
+		log.Warn().Err(err).Msg("Failed to hash config for the manifest")
+	}
+
+	if shards := g.writer.Shards(); shards != nil {
+		manifest["shards"] = shards
+	}
+	if prior := g.resumed; prior != nil {
+		// Totals cover the interrupted run as well as this one
+		manifest["llm_calls"] = prior.LLMCallCount + result.LLMCallCount
+		manifest["total_cost_usd"] = prior.TotalCostUSD + result.TotalCostUSD
+		manifest["validation_errors"] = prior.ValidationErrors + result.ValidationErrors
+		manifest["patched_records"] = prior.PatchedRecords + result.PatchedRecords
+		manifest["resumed_from"] = g.config.Generation.StartIndex
+	} else if g.config.Generation.StartIndex > 0 {
+		manifest["extended_from"] = g.config.Generation.StartIndex
+	}
+	if g.config.Generation.Validation == "strict" {
+		// A resumed run kept every record before StartIndex: checkpointed
+		// runs never reject, so the interrupted run adds no rejects
+		manifest["accepted_records"] = result.RecordCount
+		if g.resumed != nil {
+			manifest["accepted_records"] = g.config.Generation.StartIndex + result.RecordCount
+		}
+		manifest["rejected_records"] = result.FailedRecords
+		manifest["rejects_file"] = "rejects.jsonl"
+	}
+	if g.config.Generation.CountExpr != "" {
+		manifest["count_expr"] = g.config.Generation.CountExpr
+	}
+	if g.config.Generation.ValidationRetries > 0 {
+		manifest["retries"] = result.Retries
+		manifest["failed_records"] = result.FailedRecords
+		if prior := g.resumed; prior != nil {
+			manifest["retries"] = prior.Retries + result.Retries
+			manifest["failed_records"] = prior.FailedRecords + result.FailedRecords
+		}
+	}
+	if g.cache != nil {
+		manifest["llm_cache_hits"] = result.LLMCacheHits
+	}
+	if result.Interrupted {
+		manifest["interrupted"] = true
+		manifest["requested_count"] = g.config.Generation.Count
+	}
+	if g.config.Generation.UniqueBy != "" {
+		manifest["unique_by"] = g.config.Generation.UniqueBy
+		manifest["regenerations"] = result.Regenerations
+	}
+
+	return manifest
 }
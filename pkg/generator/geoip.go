@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/specmint/specmint/pkg/schema"
+	mathrand "math/rand"
+)
+
+// geoCountry is a small IP-to-country reference entry. Blocks are
+// representative IPv4 allocations registered to the country.
+type geoCountry struct {
+	Code       string
+	Name       string
+	Population float64 // millions, used for weighted selection
+	Blocks     []string
+	Cities     []string
+}
+
+var geoCountries = []geoCountry{
+	{Code: "US", Name: "United States", Population: 335, Blocks: []string{"73.0.0.0/8", "99.0.0.0/10", "174.0.0.0/13"}, Cities: []string{"New York", "Los Angeles", "Chicago", "Houston", "Phoenix", "Seattle"}},
+	{Code: "CN", Name: "China", Population: 1410, Blocks: []string{"36.96.0.0/11", "113.64.0.0/11"}, Cities: []string{"Beijing", "Shanghai", "Guangzhou", "Shenzhen", "Chengdu"}},
+	{Code: "IN", Name: "India", Population: 1430, Blocks: []string{"117.192.0.0/10", "59.88.0.0/13"}, Cities: []string{"Mumbai", "Delhi", "Bengaluru", "Chennai", "Hyderabad"}},
+	{Code: "BR", Name: "Brazil", Population: 216, Blocks: []string{"177.0.0.0/10", "189.0.0.0/11"}, Cities: []string{"São Paulo", "Rio de Janeiro", "Brasília", "Salvador"}},
+	{Code: "JP", Name: "Japan", Population: 124, Blocks: []string{"126.0.0.0/8", "153.128.0.0/10"}, Cities: []string{"Tokyo", "Osaka", "Yokohama", "Nagoya", "Sapporo"}},
+	{Code: "DE", Name: "Germany", Population: 84, Blocks: []string{"79.192.0.0/10", "217.224.0.0/11"}, Cities: []string{"Berlin", "Hamburg", "Munich", "Cologne", "Frankfurt"}},
+	{Code: "GB", Name: "United Kingdom", Population: 68, Blocks: []string{"86.128.0.0/10", "81.128.0.0/12"}, Cities: []string{"London", "Manchester", "Birmingham", "Glasgow", "Leeds"}},
+	{Code: "FR", Name: "France", Population: 68, Blocks: []string{"90.0.0.0/9", "86.192.0.0/11"}, Cities: []string{"Paris", "Marseille", "Lyon", "Toulouse", "Nice"}},
+	{Code: "CA", Name: "Canada", Population: 40, Blocks: []string{"99.224.0.0/11", "142.112.0.0/12"}, Cities: []string{"Toronto", "Montreal", "Vancouver", "Calgary", "Ottawa"}},
+	{Code: "AU", Name: "Australia", Population: 27, Blocks: []string{"1.120.0.0/13", "101.160.0.0/11"}, Cities: []string{"Sydney", "Melbourne", "Brisbane", "Perth", "Adelaide"}},
+}
+
+// applyGeoIP fills the fields named by spec with a coherent IP/country/city tuple
+func (g *DeterministicGenerator) applyGeoIP(spec *schema.GeoIPSpec, result map[string]interface{}, rng *mathrand.Rand) error {
+	candidates, err := geoCandidates(spec.Countries)
+	if err != nil {
+		return err
+	}
+
+	country := pickGeoCountry(candidates, spec.Weighted, rng)
+
+	ip, err := randomIPInBlock(country.Blocks[rng.Intn(len(country.Blocks))], rng)
+	if err != nil {
+		return err
+	}
+
+	result[spec.IPField] = ip
+	if spec.CountryField != "" {
+		result[spec.CountryField] = country.Code
+	}
+	if spec.CountryNameField != "" {
+		result[spec.CountryNameField] = country.Name
+	}
+	if spec.CityField != "" {
+		result[spec.CityField] = country.Cities[rng.Intn(len(country.Cities))]
+	}
+
+	return nil
+}
+
+// geoCandidates returns the reference entries allowed by the country filter
+func geoCandidates(codes []string) ([]geoCountry, error) {
+	if len(codes) == 0 {
+		return geoCountries, nil
+	}
+
+	candidates := make([]geoCountry, 0, len(codes))
+	for _, code := range codes {
+		found := false
+		for _, country := range geoCountries {
+			if country.Code == code {
+				candidates = append(candidates, country)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no geo-IP reference data for country %s", code)
+		}
+	}
+
+	return candidates, nil
+}
+
+// pickGeoCountry selects a country uniformly or weighted by population
+func pickGeoCountry(candidates []geoCountry, weighted bool, rng *mathrand.Rand) geoCountry {
+	if !weighted {
+		return candidates[rng.Intn(len(candidates))]
+	}
+
+	total := 0.0
+	for _, country := range candidates {
+		total += country.Population
+	}
+
+	target := rng.Float64() * total
+	for _, country := range candidates {
+		target -= country.Population
+		if target < 0 {
+			return country
+		}
+	}
+
+	return candidates[len(candidates)-1]
+}
+
+// randomIPInBlock returns a host address inside an IPv4 CIDR block
+func randomIPInBlock(cidr string, rng *mathrand.Rand) (string, error) {
+	_, block, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", fmt.Errorf("invalid geo-IP block %s: %w", cidr, err)
+	}
+
+	ones, bits := block.Mask.Size()
+	hostBits := uint(bits - ones)
+	base := uint32(block.IP[0])<<24 | uint32(block.IP[1])<<16 | uint32(block.IP[2])<<8 | uint32(block.IP[3])
+
+	// Avoid the network and broadcast addresses
+	offset := uint32(1)
+	if hostBits > 1 {
+		offset += uint32(rng.Int63n(int64(1)<<hostBits - 2))
+	}
+	addr := base + offset
+
+	return net.IPv4(byte(addr>>24), byte(addr>>16), byte(addr>>8), byte(addr)).String(), nil
+}
@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/specmint/specmint/internal/config"
+	"github.com/specmint/specmint/pkg/writer"
+)
+
+// Manifest is the subset of a generation manifest needed to continue or
+// reproduce an existing dataset
+type Manifest struct {
+	Version       string         `json:"version"`
+	RecordCount   int            `json:"record_count"`
+	Rejected      int            `json:"rejected_records"` // records written to rejects.jsonl instead of the dataset
+	Seed          int64          `json:"seed"`
+	ReferenceDate string         `json:"reference_date"` // day generated dates count back from, empty in manifests that predate it
+	LLMMode       string         `json:"llm_mode"`
+	SchemaFile    string         `json:"schema_file"`
+	SchemaHash    string         `json:"schema_sha256"` // of the canonical schema, see schema.Parser.CanonicalHash
+	ConfigHash    string         `json:"config_sha256"`
+	Specmint      BuildInfo      `json:"specmint"`
+	DatasetFile   string         `json:"dataset_file"`
+	Shards        []writer.Shard `json:"shards,omitempty"`
+	Config        struct {
+		Output struct {
+			Format          string `json:"format"`
+			Compress        bool   `json:"compress"`
+			ShardMaxRecords int    `json:"shard_max_records"`
+			ShardMaxBytes   int64  `json:"shard_max_bytes"`
+		} `json:"output"`
+		Generation struct {
+			Validation string `json:"validation"`
+		} `json:"generation"`
+	} `json:"config"`
+}
+
+// configHash returns the SHA-256 of the effective configuration as JSON.
+// API keys are left out, so the same settings hash the same whoever runs
+// them.
+func configHash(cfg *config.Config) (string, error) {
+	redacted := *cfg
+	redacted.LLM.OpenAI.APIKey = ""
+	redacted.LLM.Anthropic.APIKey = ""
+
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ReadManifest loads a manifest written by a previous generation run
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+
+	return &manifest, nil
+}
+
+// ReadManifestConfig loads the full configuration a manifest was generated
+// with, on top of the defaults for settings it predates
+func ReadManifestConfig(path string) (*config.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest struct {
+		Config json.RawMessage `json:"config"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	if len(manifest.Config) == 0 {
+		return nil, fmt.Errorf("manifest %s has no config", path)
+	}
+
+	cfg := config.Default()
+	if err := json.Unmarshal(manifest.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse the config in manifest %s: %w", path, err)
+	}
+	return cfg, nil
+}
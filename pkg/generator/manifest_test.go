@@ -0,0 +1,33 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/specmint/specmint/internal/config"
+)
+
+// TestConfigHash verifies the config hash follows settings but not API keys
+func TestConfigHash(t *testing.T) {
+	cfg := config.Default()
+	cfg.Generation.Seed = 42
+	base, err := configHash(cfg)
+	if err != nil {
+		t.Fatalf("Failed to hash config: %v", err)
+	}
+
+	keyed := *cfg
+	keyed.LLM.OpenAI.APIKey = "sk-test"
+	keyed.LLM.Anthropic.APIKey = "sk-ant-test"
+	if sum, _ := configHash(&keyed); sum != base {
+		t.Error("Expected API keys to be left out of the config hash")
+	}
+	if cfg.LLM.OpenAI.APIKey != "" || keyed.LLM.OpenAI.APIKey != "sk-test" {
+		t.Error("Expected hashing to leave the configs unchanged")
+	}
+
+	changed := *cfg
+	changed.Generation.Seed = 43
+	if sum, _ := configHash(&changed); sum == base {
+		t.Error("Expected a different seed to change the config hash")
+	}
+}
@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/specmint/specmint/internal/config"
+)
+
+const metricsShutdownTimeout = 5 * time.Second
+
+// runMetrics serves the counters of a run in the Prometheus text format
+// while it generates. Like progress, the result collector updates it
+// through atomic counters only.
+type runMetrics struct {
+	records          atomic.Int64
+	validationErrors atomic.Int64
+	llmEnhanced      atomic.Int64
+	patched          atomic.Int64
+	start            time.Time
+
+	server *http.Server
+	served chan struct{}
+}
+
+// startMetrics starts the metrics endpoint when it is enabled. A port that
+// cannot be listened on is logged and the run goes on without metrics, so
+// it returns nil then, as it does when metrics are disabled.
+func startMetrics(cfg config.Metrics) *runMetrics {
+	if !cfg.Enabled {
+		return nil
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+	if err != nil {
+		log.Warn().Err(err).Int("port", cfg.Port).Msg("Failed to start metrics endpoint, continuing without it")
+		return nil
+	}
+
+	path := cfg.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	m := &runMetrics{start: time.Now(), served: make(chan struct{})}
+	mux := http.NewServeMux()
+	mux.Handle(path, m)
+	m.server = &http.Server{Handler: mux, ReadHeaderTimeout: metricsShutdownTimeout}
+
+	go func() {
+		defer close(m.served)
+		if err := m.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn().Err(err).Msg("Metrics endpoint failed")
+		}
+	}()
+	log.Info().Str("address", listener.Addr().String()).Str("path", path).Msg("Serving metrics")
+	return m
+}
+
+// observe counts a finished record
+func (m *runMetrics) observe(record generatedRecord) {
+	if m == nil {
+		return
+	}
+	m.records.Add(1)
+	if len(record.ValidationErrors) > 0 {
+		m.validationErrors.Add(1)
+	}
+	if record.LLMEnhanced {
+		m.llmEnhanced.Add(1)
+	}
+	if record.Patched {
+		m.patched.Add(1)
+	}
+}
+
+// stop shuts the endpoint down, waiting for in-flight scrapes
+func (m *runMetrics) stop() {
+	if m == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+	defer cancel()
+	if err := m.server.Shutdown(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to shut down metrics endpoint")
+	}
+	<-m.served
+}
+
+// ServeHTTP writes the counters and the generation rate since the run
+// started
+func (m *runMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	records := m.records.Load()
+	rate := 0.0
+	if seconds := time.Since(m.start).Seconds(); seconds > 0 {
+		rate = float64(records) / seconds
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, metric := range []struct {
+		name, kind, help string
+		value            float64
+	}{
+		{"specmint_records_generated_total", "counter", "Records generated", float64(records)},
+		{"specmint_validation_failures_total", "counter", "Records that failed schema validation", float64(m.validationErrors.Load())},
+		{"specmint_llm_enhanced_records_total", "counter", "Records enriched by the LLM", float64(m.llmEnhanced.Load())},
+		{"specmint_patched_records_total", "counter", "Records patched to fix validation errors", float64(m.patched.Load())},
+		{"specmint_generation_rate", "gauge", "Records generated per second since the run started", rate},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", metric.name, metric.help, metric.name, metric.kind, metric.name, metric.value)
+	}
+}
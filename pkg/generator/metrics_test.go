@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/specmint/specmint/internal/config"
+	"github.com/specmint/specmint/pkg/validator"
+)
+
+// TestRunMetrics verifies the endpoint serves live counters on the
+// configured path, stops serving once the run ends, and that a port already
+// in use leaves the run without metrics instead of failing
+func TestRunMetrics(t *testing.T) {
+	held, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer held.Close()
+	if m := startMetrics(config.Metrics{Enabled: true, Port: held.Addr().(*net.TCPAddr).Port}); m != nil {
+		m.stop()
+		t.Fatalf("Expected a port in use to disable metrics")
+	}
+
+	free, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	port := free.Addr().(*net.TCPAddr).Port
+	free.Close()
+
+	m := startMetrics(config.Metrics{Enabled: true, Port: port, Path: "/stats"})
+	if m == nil {
+		t.Fatalf("Expected metrics to start on port %d", port)
+	}
+	m.observe(generatedRecord{LLMEnhanced: true})
+	m.observe(generatedRecord{ValidationErrors: []validator.ValidationError{{}}, Patched: true})
+	m.observe(generatedRecord{})
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/stats", port)
+	resp, err := http.Get(url)
+	if err != nil {
+		m.stop()
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, line := range []string{
+		"specmint_records_generated_total 3\n",
+		"specmint_validation_failures_total 1\n",
+		"specmint_llm_enhanced_records_total 1\n",
+		"specmint_patched_records_total 1\n",
+		"# TYPE specmint_generation_rate gauge\n",
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("Expected %q in metrics:\n%s", line, body)
+		}
+	}
+
+	m.stop()
+	if _, err := http.Get(url); err == nil {
+		t.Errorf("Expected the endpoint to stop serving after the run")
+	}
+
+	if startMetrics(config.Metrics{Enabled: false, Port: port}) != nil {
+		t.Errorf("Expected disabled metrics not to start")
+	}
+}
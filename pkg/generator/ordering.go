@@ -0,0 +1,280 @@
+package generator
+
+import (
+	mathrand "math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// orderConstraint requires field hi to be at least field lo, or greater
+// than it when strict
+type orderConstraint struct {
+	lo, hi string
+	strict bool
+}
+
+// orderingKind returns how values of a property are ordered: "number" for
+// plain integers and numbers, "date" or "date-time" for plain date strings.
+// Properties whose value is pinned or drawn from a fixed set return "", as
+// they cannot be generated against a bound.
+func orderingKind(node *schema.SchemaNode) string {
+	if node == nil || node.HasConst || node.HasDefault || node.Reference != "" ||
+		len(node.Enum) > 0 || len(node.Examples) > 0 || len(node.Variants) > 0 || len(node.Types) > 0 {
+		return ""
+	}
+
+	switch node.Type {
+	case "integer", "number":
+		return "number"
+	case "string":
+		if node.Format == "date" || node.Format == "date-time" {
+			return node.Format
+		}
+	}
+	return ""
+}
+
+// orderConstraints returns the date_ordering and simple comparison rules of
+// an object node as constraints between its properties. Rules that relate
+// expressions, unordered properties or properties of different kinds are
+// left out and only checked by validation.
+func orderConstraints(node *schema.SchemaNode) []orderConstraint {
+	var constraints []orderConstraint
+	for _, rule := range node.CrossFieldRules {
+		var candidates []orderConstraint
+		switch rule.Rule {
+		case "date_ordering":
+			for i := 1; i < len(rule.Fields); i++ {
+				candidates = append(candidates, orderConstraint{lo: rule.Fields[i-1], hi: rule.Fields[i]})
+			}
+		case "comparison":
+			if c, ok := parseComparison(rule.Constraint); ok {
+				candidates = append(candidates, c)
+			}
+		}
+
+		for _, c := range candidates {
+			lo, hi := node.Properties[c.lo], node.Properties[c.hi]
+			kind := orderingKind(lo)
+			if kind == "" || kind != orderingKind(hi) || lo.Location != hi.Location {
+				candidates = nil
+				break
+			}
+		}
+		constraints = append(constraints, candidates...)
+	}
+	return constraints
+}
+
+// parseComparison reads a constraint of the form "a <= b" between two field
+// names
+func parseComparison(constraint string) (orderConstraint, bool) {
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		left, right, found := strings.Cut(constraint, op)
+		if !found {
+			continue
+		}
+		left, right = strings.TrimSpace(left), strings.TrimSpace(right)
+		if !isFieldName(left) || !isFieldName(right) {
+			return orderConstraint{}, false
+		}
+
+		strict := len(op) == 1
+		if op[0] == '>' {
+			return orderConstraint{lo: right, hi: left, strict: strict}, true
+		}
+		return orderConstraint{lo: left, hi: right, strict: strict}, true
+	}
+	return orderConstraint{}, false
+}
+
+// isFieldName reports whether a comparison operand is a bare field name
+// rather than an expression
+func isFieldName(operand string) bool {
+	return operand != "" && !strings.ContainsAny(operand, "+-*/<>= ")
+}
+
+// orderFields sorts the fields of constraints so each comes after every
+// field it is bounded by, breaking ties by name. It reports false when the
+// constraints form a cycle.
+func orderFields(constraints []orderConstraint) ([]string, bool) {
+	pending := make(map[string]int)
+	next := make(map[string][]string)
+	for _, c := range constraints {
+		if _, ok := pending[c.lo]; !ok {
+			pending[c.lo] = 0
+		}
+		pending[c.hi]++
+		next[c.lo] = append(next[c.lo], c.hi)
+	}
+
+	var ready, order []string
+	for field, count := range pending {
+		if count == 0 {
+			ready = append(ready, field)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		field := ready[0]
+		ready = ready[1:]
+		order = append(order, field)
+		for _, hi := range next[field] {
+			if pending[hi]--; pending[hi] == 0 {
+				ready = append(ready, hi)
+			}
+		}
+	}
+
+	return order, len(order) == len(pending)
+}
+
+// applyOrdering regenerates the fields of node's ordering rules in
+// dependency order, each bounded below by the fields it must not precede,
+// so the rules hold by construction. A field whose bound leaves no room in
+// its range keeps its original value, and the rule falls back to validation
+// and patching; so do all rules of a node whose rules form a cycle.
+func (g *DeterministicGenerator) applyOrdering(node *schema.SchemaNode, result map[string]interface{}, rng *mathrand.Rand) {
+	constraints := orderConstraints(node)
+	if len(constraints) == 0 {
+		return
+	}
+	order, ok := orderFields(constraints)
+	if !ok {
+		return
+	}
+
+	for _, field := range order {
+		if _, present := result[field]; !present {
+			continue
+		}
+
+		// The field must clear the highest of its lower fields
+		var bound interface{}
+		strict, bounded := false, false
+		for _, c := range constraints {
+			lower, ok := result[c.lo]
+			if c.hi != field || !ok {
+				continue
+			}
+			if cmp := compareOrdered(lower, bound); !bounded || cmp > 0 {
+				bound, strict, bounded = lower, c.strict, true
+			} else if cmp == 0 && c.strict {
+				strict = true
+			}
+		}
+		if !bounded {
+			continue
+		}
+
+		if value, ok := g.generateAbove(node.Properties[field], bound, strict, rng); ok {
+			result[field] = value
+		}
+	}
+}
+
+// compareOrdered compares two values of the same ordering kind. Dates
+// compare as strings, as ISO 8601 sorts chronologically.
+func compareOrdered(a, b interface{}) int {
+	if x, ok := toFloat(a); ok {
+		y, _ := toFloat(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	x, _ := a.(string)
+	y, _ := b.(string)
+	return strings.Compare(x, y)
+}
+
+// generateAbove generates a value for node that is at least bound, or
+// greater when strict. It reports false when no such value fits the node.
+func (g *DeterministicGenerator) generateAbove(node *schema.SchemaNode, bound interface{}, strict bool, rng *mathrand.Rand) (interface{}, bool) {
+	switch orderingKind(node) {
+	case "number":
+		limit, ok := toFloat(bound)
+		if !ok {
+			return nil, false
+		}
+		boundedNode := *node
+		if strict {
+			if node.ExclusiveMinimum == nil || *node.ExclusiveMinimum < limit {
+				boundedNode.ExclusiveMinimum = &limit
+			}
+		} else if node.Minimum == nil || *node.Minimum < limit {
+			boundedNode.Minimum = &limit
+		}
+
+		var value interface{}
+		var err error
+		if node.Type == "integer" {
+			value, err = g.generateInteger(&boundedNode, rng)
+		} else {
+			value, err = g.generateNumber(&boundedNode, rng)
+		}
+		return value, err == nil
+
+	case "date":
+		return g.generateDateAbove(bound, strict, rng)
+	case "date-time":
+		return g.generateDateTimeAbove(g.location(node), bound, strict, rng)
+	}
+	return nil, false
+}
+
+// generateDateAbove is generateDate restricted to days on or after bound
+func (g *DeterministicGenerator) generateDateAbove(bound interface{}, strict bool, rng *mathrand.Rand) (interface{}, bool) {
+	s, _ := bound.(string)
+	lower, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, false
+	}
+	if strict {
+		lower = lower.AddDate(0, 0, 1)
+	}
+
+	now := g.today
+	if start := now.AddDate(-5, 0, 0); lower.Before(start) {
+		lower = start
+	}
+	last := now.AddDate(0, 0, -1)
+	if lower.After(last) {
+		return nil, false
+	}
+
+	days := int(last.Sub(lower).Hours()/24) + 1
+	return lower.AddDate(0, 0, rng.Intn(days)).Format("2006-01-02"), true
+}
+
+// generateDateTimeAbove is generateDateTime restricted to times on or after
+// bound
+func (g *DeterministicGenerator) generateDateTimeAbove(loc *time.Location, bound interface{}, strict bool, rng *mathrand.Rand) (interface{}, bool) {
+	s, _ := bound.(string)
+	lower, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, false
+	}
+	// Generated times have whole seconds
+	lower = lower.Truncate(time.Second)
+	if strict {
+		lower = lower.Add(time.Second)
+	}
+
+	now := g.today
+	if start := now.AddDate(-1, 0, 0); lower.Before(start) {
+		lower = start
+	}
+	if !lower.Before(now) {
+		return nil, false
+	}
+
+	offset := time.Duration(rng.Int63n(int64(now.Sub(lower))))
+	return lower.Add(offset).In(loc).Format(time.RFC3339), true
+}
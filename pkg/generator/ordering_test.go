@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestOrderingRules verifies fields related by date_ordering and comparison
+// rules are generated in order, reproducibly
+func TestOrderingRules(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"required": ["admitted", "discharged", "follow_up", "service_at", "submitted_at", "min_qty", "max_qty"],
+		"properties": {
+			"admitted": {"type": "string", "format": "date"},
+			"discharged": {"type": "string", "format": "date"},
+			"follow_up": {"type": "string", "format": "date"},
+			"service_at": {"type": "string", "format": "date-time"},
+			"submitted_at": {"type": "string", "format": "date-time"},
+			"min_qty": {"type": "integer", "minimum": 0, "maximum": 99},
+			"max_qty": {"type": "integer", "minimum": 0, "maximum": 100}
+		},
+		"x-cross-field-rules": [
+			{"name": "stay", "rule": "date_ordering", "fields": ["admitted", "discharged", "follow_up"]},
+			{"name": "submission", "rule": "comparison", "fields": ["submitted_at", "service_at"], "constraint": "submitted_at >= service_at"},
+			{"name": "quantity", "rule": "comparison", "fields": ["min_qty", "max_qty"], "constraint": "min_qty < max_qty"}
+		]
+	}`
+	gen, root := newTestGenerator(t, schemaJSON)
+	again, _ := newTestGenerator(t, schemaJSON)
+
+	for i := 0; i < 200; i++ {
+		value, err := gen.detGen.GenerateValue(root, i)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if errors := gen.validator.ValidateRecord(value); len(errors) > 0 {
+			t.Fatalf("Expected record %d to satisfy its rules, got %v", i, errors)
+		}
+
+		same, _ := again.detGen.GenerateValue(root, i)
+		if !reflect.DeepEqual(value, same) {
+			t.Fatalf("Expected record %d to be reproducible, got %v and %v", i, value, same)
+		}
+	}
+}
+
+// TestOrderingRules_Fallback verifies rules that cannot be satisfied by
+// construction leave generation untouched
+func TestOrderingRules_Fallback(t *testing.T) {
+	gen, root := newTestGenerator(t, `{
+		"type": "object",
+		"required": ["a", "b", "low", "high", "total", "part"],
+		"properties": {
+			"a": {"type": "integer"},
+			"b": {"type": "integer"},
+			"low": {"type": "integer", "minimum": 50, "maximum": 60},
+			"high": {"type": "integer", "minimum": 0, "maximum": 10},
+			"total": {"type": "number"},
+			"part": {"type": "number"}
+		},
+		"x-cross-field-rules": [
+			{"name": "forward", "rule": "comparison", "fields": ["a", "b"], "constraint": "a < b"},
+			{"name": "backward", "rule": "comparison", "fields": ["a", "b"], "constraint": "b < a"},
+			{"name": "impossible", "rule": "comparison", "fields": ["low", "high"], "constraint": "low <= high"},
+			{"name": "expression", "rule": "comparison", "fields": ["total", "part"], "constraint": "total >= part * 2"}
+		]
+	}`)
+
+	if _, ok := orderFields(orderConstraints(root)); ok {
+		t.Errorf("Expected the forward and backward rules to form a cycle")
+	}
+
+	value, err := gen.detGen.GenerateValue(root, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate record: %v", err)
+	}
+	record := value.(map[string]interface{})
+	if high := record["high"].(int64); high < 0 || high > 10 {
+		t.Errorf("Expected high to stay within its own bounds, got %d", high)
+	}
+
+	if constraints := orderConstraints(root); len(constraints) != 3 {
+		t.Errorf("Expected the expression rule to be left to validation, got %v", constraints)
+	}
+}
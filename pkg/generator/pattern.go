@@ -0,0 +1,13 @@
+package generator
+
+import (
+	mathrand "math/rand"
+
+	"github.com/specmint/specmint/pkg/regexgen"
+)
+
+// generateFromPattern generates a string matching a regular expression, so
+// any pattern the schema declares is honored
+func (g *DeterministicGenerator) generateFromPattern(pattern string, rng *mathrand.Rand) (string, error) {
+	return regexgen.Generate(pattern, rng)
+}
@@ -163,6 +163,27 @@ func TestPatternGeneration_PropertyBased(t *testing.T) {
 			pattern: "^[A-Z0-9]{3,10}$",
 			regex:   regexp.MustCompile("^[A-Z0-9]{3,10}$"),
 		},
+		// Patterns with no special handling
+		{
+			name:    "Novel_Class_And_Shorthand",
+			pattern: "^[AB]{3}-\\d{2,4}$",
+			regex:   regexp.MustCompile(`^[AB]{3}-\d{2,4}$`),
+		},
+		{
+			name:    "Novel_Alternation",
+			pattern: "^(ORD|INV)_[a-f0-9]{4}\\w?$",
+			regex:   regexp.MustCompile(`^(ORD|INV)_[a-f0-9]{4}\w?$`),
+		},
+		{
+			name:    "Novel_Negated_Class",
+			pattern: "^[^0-9]{3}\\.x+$",
+			regex:   regexp.MustCompile(`^[^0-9]{3}\.x+$`),
+		},
+		{
+			name:    "Novel_Unanchored",
+			pattern: "[a-z]+@example\\.(com|org)",
+			regex:   regexp.MustCompile(`[a-z]+@example\.(com|org)`),
+		},
 	}
 
 	generator := NewDeterministicGenerator(12345)
@@ -220,7 +241,7 @@ func TestPatternGeneration_EdgeCases(t *testing.T) {
 		{
 			name:    "Unknown_Pattern",
 			pattern: "^[X-Y]{5}[#]{2}$",
-			regex:   regexp.MustCompile(".+"), // should generate something
+			regex:   regexp.MustCompile("^[X-Y]{5}[#]{2}$"),
 		},
 	}
 
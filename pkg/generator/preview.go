@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/specmint/specmint/internal/config"
+	"github.com/specmint/specmint/pkg/validator"
+)
+
+// PreviewResult describes a dry run: one sample record and the dataset size
+// it suggests
+type PreviewResult struct {
+	Record           interface{}                 `json:"record"`
+	RecordIndex      int                         `json:"record_index"`
+	ValidationErrors []validator.ValidationError `json:"validation_errors,omitempty"`
+	RecordBytes      int                         `json:"record_bytes"`    // size of the record as a JSONL line
+	RecordCount      int                         `json:"record_count"`    // records the real run would generate
+	EstimatedBytes   int64                       `json:"estimated_bytes"` // uncompressed JSONL size of the real run
+}
+
+// Preview generates the first record of the run through the same path as
+// Generate, without LLM enrichment, and estimates the dataset size from it
+func (g *Generator) Preview(ctx context.Context) (*PreviewResult, error) {
+	rootNode, err := g.parser.GetRootNode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get root schema node: %w", err)
+	}
+
+	if err := g.loadReferences(rootNode); err != nil {
+		return nil, err
+	}
+
+	for _, warning := range g.parser.Warnings() {
+		log.Warn().
+			Str("path", warning.Path).
+			Str("keyword", warning.Keyword).
+			Msg(warning.Message)
+	}
+
+	recordIndex := g.config.Generation.StartIndex
+	record, err := g.generateRecord(ctx, rootNode, recordIndex, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate sample record: %w", err)
+	}
+
+	line, err := json.Marshal(record.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sample record: %w", err)
+	}
+	recordBytes := len(line) + 1 // trailing newline
+
+	count := g.config.Generation.Count - recordIndex
+	return &PreviewResult{
+		Record:           record.Data,
+		RecordIndex:      recordIndex,
+		ValidationErrors: record.ValidationErrors,
+		RecordBytes:      recordBytes,
+		RecordCount:      count,
+		EstimatedBytes:   int64(recordBytes) * int64(count),
+	}, nil
+}
+
+// NewSampler creates a generator for samples. Unlike a preview it has the
+// configured LLM client, but it has no writer either.
+func NewSampler(cfg *config.Config) (*Generator, error) {
+	g, err := newGenerator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.setupLLM(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Sample generates the first n records of the run through the same path as
+// Generate, LLM enrichment included, and returns them without writing
+// anything. The run's count is ignored.
+func (g *Generator) Sample(ctx context.Context, n int) ([]interface{}, error) {
+	g.checkLLM(ctx)
+
+	rootNode, err := g.parser.GetRootNode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get root schema node: %w", err)
+	}
+
+	if err := g.loadReferences(rootNode); err != nil {
+		return nil, err
+	}
+
+	for _, warning := range g.parser.Warnings() {
+		log.Warn().
+			Str("path", warning.Path).
+			Str("keyword", warning.Keyword).
+			Msg(warning.Message)
+	}
+
+	records := make([]interface{}, 0, n)
+	start := g.config.Generation.StartIndex
+	for recordIndex := start; recordIndex < start+n; recordIndex++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		record, err := g.generateRecord(ctx, rootNode, recordIndex, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate sample record %d: %w", recordIndex, err)
+		}
+		for _, validationErr := range record.ValidationErrors {
+			log.Warn().
+				Int("record_index", recordIndex).
+				Str("error", validationErr.String()).
+				Msg("Sample record failed validation")
+		}
+		records = append(records, record.Data)
+	}
+
+	if g.cache != nil {
+		if err := g.cache.Save(); err != nil {
+			log.Warn().Err(err).Msg("Failed to save LLM cache")
+		}
+	}
+	return records, nil
+}
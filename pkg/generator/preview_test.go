@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestPreview verifies the sample is the first record of the real run and
+// the size estimate scales with the remaining count
+func TestPreview(t *testing.T) {
+	gen, root := newTestGenerator(t, `{
+		"type": "object",
+		"required": ["id", "name"],
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"name": {"type": "string", "minLength": 3, "maxLength": 12}
+		}
+	}`)
+	gen.config.Generation.Count = 1000
+	gen.config.Generation.StartIndex = 10
+
+	preview, err := gen.Preview(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+
+	expected, err := gen.generateRecord(context.Background(), root, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate record: %v", err)
+	}
+	if preview.RecordIndex != 10 || !reflect.DeepEqual(preview.Record, expected.Data) {
+		t.Errorf("Expected the preview to match record 10, got %v", preview.Record)
+	}
+
+	line, _ := json.Marshal(expected.Data)
+	if preview.RecordBytes != len(line)+1 {
+		t.Errorf("Expected %d bytes per record, got %d", len(line)+1, preview.RecordBytes)
+	}
+	if preview.RecordCount != 990 || preview.EstimatedBytes != int64(preview.RecordBytes)*990 {
+		t.Errorf("Expected an estimate for 990 records, got %d bytes for %d", preview.EstimatedBytes, preview.RecordCount)
+	}
+}
+
+// TestSample verifies samples are the first records of the real run, LLM
+// enrichment included, whatever the configured count
+func TestSample(t *testing.T) {
+	gen, root := newTestGenerator(t, `{
+		"type": "object",
+		"required": ["id", "note"],
+		"properties": {
+			"id": {"type": "integer"},
+			"note": {"type": "string", "x-llm": true}
+		}
+	}`)
+	gen.llmClient = &concurrentLLMClient{}
+	gen.config.LLM.Mode = "fields"
+	gen.config.Generation.Count = 3
+
+	records, err := gen.Sample(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("Failed to sample: %v", err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("Expected 5 records, got %d", len(records))
+	}
+
+	for i, record := range records {
+		expected, err := gen.generateRecord(context.Background(), root, i, 0)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		if !reflect.DeepEqual(record, expected.Data) {
+			t.Errorf("Expected sample %d to match the run's record, got %v", i, record)
+		}
+		if note, _ := record.(map[string]interface{})["note"].(string); !strings.HasPrefix(note, "note-") {
+			t.Errorf("Expected sample %d to be enriched, got note %v", i, note)
+		}
+	}
+}
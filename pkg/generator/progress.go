@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	progressBarInterval = 200 * time.Millisecond
+	progressLogInterval = 10 * time.Second
+	progressBarWidth    = 30
+)
+
+// progress reports how many records of a run are done. The result
+// collector counts records with add, which only touches an atomic counter;
+// a separate goroutine renders a bar when stderr is a terminal and logs
+// periodically otherwise.
+type progress struct {
+	total int
+	done  atomic.Int64
+	start time.Time
+
+	out      io.Writer // terminal to draw the bar on, nil to log instead
+	stop     chan struct{}
+	finished sync.WaitGroup
+}
+
+// startProgress begins reporting on a run of total records
+func startProgress(total int) *progress {
+	p := &progress{
+		total: total,
+		start: time.Now(),
+		stop:  make(chan struct{}),
+	}
+	if isTerminal(os.Stderr) {
+		p.out = os.Stderr
+	}
+
+	interval := progressLogInterval
+	if p.out != nil {
+		interval = progressBarInterval
+	}
+	p.finished.Add(1)
+	go p.run(interval)
+	return p
+}
+
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// add counts n more records as done
+func (p *progress) add(n int) {
+	p.done.Add(int64(n))
+}
+
+func (p *progress) run(interval time.Duration) {
+	defer p.finished.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.report()
+		case <-p.stop:
+			if p.out != nil {
+				p.report()
+				fmt.Fprintln(p.out)
+			}
+			return
+		}
+	}
+}
+
+// finish stops reporting, leaving a completed bar on the terminal
+func (p *progress) finish() {
+	close(p.stop)
+	p.finished.Wait()
+}
+
+func (p *progress) report() {
+	done := int(p.done.Load())
+	elapsed := time.Since(p.start)
+	rate := 0.0
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		rate = float64(done) / seconds
+	}
+	eta := progressETA(done, p.total, rate)
+
+	if p.out != nil {
+		fmt.Fprintf(p.out, "\r%s", progressLine(done, p.total, rate, eta))
+		return
+	}
+	log.Info().
+		Int("records", done).
+		Int("total", p.total).
+		Float64("records_per_sec", rate).
+		Dur("eta", eta).
+		Msg("Generation progress")
+}
+
+// progressETA estimates the time left at the current rate
+func progressETA(done, total int, rate float64) time.Duration {
+	if rate <= 0 || done >= total {
+		return 0
+	}
+	return time.Duration(float64(total-done) / rate * float64(time.Second)).Round(time.Second)
+}
+
+// progressLine renders the terminal progress bar
+func progressLine(done, total int, rate float64, eta time.Duration) string {
+	fraction := 1.0
+	if total > 0 {
+		fraction = float64(done) / float64(total)
+	}
+	filled := int(fraction * progressBarWidth)
+	if filled > progressBarWidth {
+		filled = progressBarWidth
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+	return fmt.Sprintf("%s %3.0f%% %d/%d %.0f rec/s ETA %s ", bar, fraction*100, done, total, rate, eta)
+}
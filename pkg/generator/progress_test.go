@@ -0,0 +1,30 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestProgressLine verifies the bar, percentage and ETA of the terminal
+// progress display
+func TestProgressLine(t *testing.T) {
+	eta := progressETA(250, 1000, 50)
+	if eta != 15*time.Second {
+		t.Errorf("Expected 750 records at 50/s to take 15s, got %v", eta)
+	}
+	if progressETA(1000, 1000, 50) != 0 || progressETA(0, 1000, 0) != 0 {
+		t.Errorf("Expected no ETA when done or before any records")
+	}
+
+	line := progressLine(250, 1000, 50, eta)
+	if !strings.Contains(line, " 25% 250/1000 50 rec/s ETA 15s") {
+		t.Errorf("Unexpected progress line %q", line)
+	}
+	if filled := strings.Count(line, "█"); filled != progressBarWidth/4 {
+		t.Errorf("Expected a quarter of the bar filled, got %d of %d", filled, progressBarWidth)
+	}
+	if strings.Count(progressLine(5, 0, 0, 0), "█") != progressBarWidth {
+		t.Errorf("Expected an empty run to show a full bar")
+	}
+}
@@ -0,0 +1,143 @@
+package generator
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/specmint/specmint/pkg/schema"
+	"github.com/specmint/specmint/pkg/writer"
+)
+
+// collectReferences returns the x-ref targets used anywhere under node
+func collectReferences(node *schema.SchemaNode, refs map[string]bool) {
+	if node == nil {
+		return
+	}
+	if node.Reference != "" {
+		refs[node.Reference] = true
+	}
+	for _, prop := range node.Properties {
+		collectReferences(prop, refs)
+	}
+	for _, variant := range node.Variants {
+		collectReferences(variant, refs)
+	}
+	for _, item := range node.TupleItems {
+		collectReferences(item, refs)
+	}
+	collectReferences(node.Items, refs)
+	for _, pp := range node.PatternProperties {
+		collectReferences(pp.Node, refs)
+	}
+	collectReferences(node.AdditionalProperties, refs)
+}
+
+// loadReferences reads the key values for every x-ref in the schema from
+// the datasets of the referenced record types, so those types must be
+// generated first
+func (g *Generator) loadReferences(rootNode *schema.SchemaNode) error {
+	refs := make(map[string]bool)
+	collectReferences(rootNode, refs)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	targets := make([]string, 0, len(refs))
+	for ref := range refs {
+		targets = append(targets, ref)
+	}
+	sort.Strings(targets)
+
+	pools := make(map[string][]interface{}, len(targets))
+	for _, ref := range targets {
+		recordType, field, _ := strings.Cut(ref, ".")
+		datasetFile := g.referenceDataset(recordType)
+
+		values, err := loadReferenceValues(datasetFile, field)
+		if err != nil {
+			return fmt.Errorf("failed to load x-ref %s from %s: %w", ref, datasetFile, err)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("x-ref %s: no values for %s in %s", ref, field, datasetFile)
+		}
+
+		log.Info().
+			Str("ref", ref).
+			Str("dataset", datasetFile).
+			Int("keys", len(values)).
+			Msg("Loaded reference keys")
+		pools[ref] = values
+	}
+
+	g.detGen.SetReferences(pools)
+	return nil
+}
+
+// referenceDataset returns the dataset file holding records of recordType.
+// Without an explicit entry in generation.references it is expected next to
+// this run's output, e.g. output/products/dataset.jsonl for output/orders.
+func (g *Generator) referenceDataset(recordType string) string {
+	if path, ok := g.config.Generation.References[recordType]; ok {
+		return path
+	}
+	return filepath.Join(filepath.Dir(filepath.Clean(g.config.Output.Directory)), recordType, "dataset.jsonl")
+}
+
+// loadReferenceValues reads a JSONL dataset (optionally gzipped) and returns
+// the distinct scalar values of a dotted field path in file order
+func loadReferenceValues(datasetFile, field string) ([]interface{}, error) {
+	file, err := os.Open(datasetFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(datasetFile, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	var values []interface{}
+	seen := make(map[interface{}]bool)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid record: %w", err)
+		}
+
+		writer.Flatten(record, func(path string, value interface{}) {
+			if path != field {
+				return
+			}
+			switch value.(type) {
+			case string, float64, bool:
+				// Only scalar values can serve as keys
+				if !seen[value] {
+					seen[value] = true
+					values = append(values, value)
+				}
+			}
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// TestLoadReferenceValues verifies that distinct keys are read in file order
+func TestLoadReferenceValues(t *testing.T) {
+	datasetFile := filepath.Join(t.TempDir(), "dataset.jsonl")
+	records := `{"sku": "A", "meta": {"id": 1}}
+{"sku": "B", "meta": {"id": 2}}
+{"sku": "A", "meta": {"id": 3}}
+`
+	if err := os.WriteFile(datasetFile, []byte(records), 0600); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+
+	skus, err := loadReferenceValues(datasetFile, "sku")
+	if err != nil {
+		t.Fatalf("Failed to load references: %v", err)
+	}
+	if !reflect.DeepEqual(skus, []interface{}{"A", "B"}) {
+		t.Errorf("Expected distinct skus [A B], got %v", skus)
+	}
+
+	ids, err := loadReferenceValues(datasetFile, "meta.id")
+	if err != nil {
+		t.Fatalf("Failed to load references: %v", err)
+	}
+	if len(ids) != 3 {
+		t.Errorf("Expected 3 nested ids, got %v", ids)
+	}
+}
+
+// TestReferenceSampling verifies that x-ref fields only produce referenced
+// keys, reproducibly, and spread over all of them
+func TestReferenceSampling(t *testing.T) {
+	keys := []interface{}{"SKU-1", "SKU-2", "SKU-3", "SKU-4"}
+	node := &schema.SchemaNode{Type: "string", Path: "sku", Reference: "products.sku"}
+
+	generator := NewDeterministicGenerator(12345)
+	generator.SetReferences(map[string][]interface{}{"products.sku": keys})
+
+	counts := make(map[interface{}]int)
+	for i := 0; i < 400; i++ {
+		value, err := generator.GenerateValue(node, i)
+		if err != nil {
+			t.Fatalf("Failed to generate value: %v", err)
+		}
+		if again, _ := generator.GenerateValue(node, i); again != value {
+			t.Fatalf("Record %d: expected %v on regeneration, got %v", i, value, again)
+		}
+		counts[value]++
+	}
+
+	for _, key := range keys {
+		// 100 children per parent on average
+		if counts[key] < 60 || counts[key] > 140 {
+			t.Errorf("Expected roughly 100 references to %v, got %d", key, counts[key])
+		}
+	}
+	if len(counts) != len(keys) {
+		t.Errorf("Expected only referenced keys, got %v", counts)
+	}
+}
@@ -0,0 +1,168 @@
+package generator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// uniqueByRecord is the unique_by value that makes whole records unique
+const uniqueByRecord = "record"
+
+// maxRegenerateAttempts caps how often a single record is regenerated while
+// looking for an unused key
+const maxRegenerateAttempts = 100
+
+// recordKey is a 128-bit hash of a record's unique_by value. Hashes keep the
+// seen set small for large datasets.
+type recordKey [16]byte
+
+// deduplicate regenerates records whose unique_by value was already seen,
+// perturbing the seed until the value is new. Records must be in index order.
+// When appending, keys already in the dataset count as seen.
+func (g *Generator) deduplicate(ctx context.Context, rootNode *schema.SchemaNode, records []generatedRecord, result *GenerationResult) error {
+	segments, err := uniqueBySegments(g.config.Generation.UniqueBy)
+	if err != nil {
+		return err
+	}
+
+	seen, err := g.existingKeys(segments)
+	if err != nil {
+		return fmt.Errorf("failed to read existing keys: %w", err)
+	}
+
+	for i := range records {
+		key, hasKey, err := uniqueKey(records[i].Data, segments)
+		if err != nil {
+			return err
+		}
+
+		for attempt := 1; hasKey && seen[key]; attempt++ {
+			if attempt > maxRegenerateAttempts {
+				return fmt.Errorf("no unique %s found for record %d after %d attempts", g.config.Generation.UniqueBy, records[i].Index, maxRegenerateAttempts)
+			}
+
+			regenerated, err := g.generateRecord(ctx, rootNode, records[i].Index, attempt)
+			if err != nil {
+				return fmt.Errorf("failed to regenerate record %d: %w", records[i].Index, err)
+			}
+			result.tally(records[i], -1)
+			result.tally(regenerated, 1)
+			result.Regenerations++
+			records[i] = regenerated
+
+			if key, hasKey, err = uniqueKey(regenerated.Data, segments); err != nil {
+				return err
+			}
+		}
+
+		if hasKey {
+			seen[key] = true
+		}
+	}
+
+	if result.Regenerations > 0 {
+		log.Info().
+			Str("unique_by", g.config.Generation.UniqueBy).
+			Int("regenerations", result.Regenerations).
+			Msg("Regenerated duplicate records")
+	}
+	return nil
+}
+
+// uniqueBySegments parses a unique_by setting. The whole record is the key
+// for "record", signalled by nil segments.
+func uniqueBySegments(uniqueBy string) ([]fieldSegment, error) {
+	if uniqueBy == uniqueByRecord {
+		return nil, nil
+	}
+
+	segments, err := parseFieldPath(uniqueBy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid unique_by: %w", err)
+	}
+	for _, segment := range segments {
+		if segment.index == allElements {
+			return nil, fmt.Errorf("invalid unique_by %s: must name a single field", uniqueBy)
+		}
+	}
+	return segments, nil
+}
+
+// uniqueKey hashes the value at segments in data. It reports false when the
+// record has no such field, since a missing key cannot collide.
+func uniqueKey(data interface{}, segments []fieldSegment) (recordKey, bool, error) {
+	value := data
+	for _, segment := range segments {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return recordKey{}, false, nil
+		}
+		if value, ok = obj[segment.key]; !ok {
+			return recordKey{}, false, nil
+		}
+		if segment.index >= 0 {
+			items, ok := value.([]interface{})
+			if !ok || segment.index >= len(items) {
+				return recordKey{}, false, nil
+			}
+			value = items[segment.index]
+		}
+	}
+
+	// JSON encoding sorts object keys, so equal values encode identically
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return recordKey{}, false, fmt.Errorf("failed to encode unique_by value: %w", err)
+	}
+
+	var key recordKey
+	h := fnv.New128a()
+	h.Write(encoded)
+	copy(key[:], h.Sum(nil))
+	return key, true, nil
+}
+
+// existingKeys returns the keys of records already in the dataset when this
+// run appends to it
+func (g *Generator) existingKeys(segments []fieldSegment) (map[recordKey]bool, error) {
+	seen := make(map[recordKey]bool)
+	if !g.config.Output.Append {
+		return seen, nil
+	}
+
+	file, err := os.Open(g.writer.GetOutputPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return seen, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var record interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid record: %w", err)
+		}
+
+		key, hasKey, err := uniqueKey(record, segments)
+		if err != nil {
+			return nil, err
+		}
+		if hasKey {
+			seen[key] = true
+		}
+	}
+	return seen, scanner.Err()
+}
@@ -0,0 +1,113 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/specmint/specmint/internal/config"
+	"github.com/specmint/specmint/pkg/schema"
+	"github.com/specmint/specmint/pkg/validator"
+)
+
+// newTestGenerator builds a generator for schemaJSON without touching the
+// filesystem
+func newTestGenerator(t *testing.T, schemaJSON string) (*Generator, *schema.SchemaNode) {
+	t.Helper()
+
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	return &Generator{
+		config:    config.Default(),
+		parser:    parser,
+		detGen:    NewDeterministicGenerator(42),
+		validator: validator.New(parser),
+	}, root
+}
+
+// TestDeduplicate verifies duplicate keys are regenerated until unique
+func TestDeduplicate(t *testing.T) {
+	gen, root := newTestGenerator(t, `{
+		"type": "object",
+		"required": ["code"],
+		"properties": {"code": {"type": "string", "enum": ["a", "b", "c", "d", "e"]}}
+	}`)
+	gen.config.Generation.UniqueBy = "code"
+
+	var records []generatedRecord
+	for i := 0; i < 5; i++ {
+		record, err := gen.generateRecord(context.Background(), root, i, 0)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	result := &GenerationResult{}
+	if err := gen.deduplicate(context.Background(), root, records, result); err != nil {
+		t.Fatalf("Failed to deduplicate: %v", err)
+	}
+
+	seen := make(map[interface{}]bool)
+	for i, record := range records {
+		code := record.Data.(map[string]interface{})["code"]
+		if seen[code] {
+			t.Errorf("Duplicate code %v after deduplication", code)
+		}
+		seen[code] = true
+		if record.Index != i {
+			t.Errorf("Expected record %d to keep its index, got %d", i, record.Index)
+		}
+	}
+	if result.Regenerations == 0 {
+		t.Errorf("Expected some of 5 draws from 5 codes to be regenerated")
+	}
+
+	// A sixth record cannot get a free code
+	extra, _ := gen.generateRecord(context.Background(), root, 5, 0)
+	if err := gen.deduplicate(context.Background(), root, append(records, extra), &GenerationResult{}); err == nil {
+		t.Errorf("Expected an error once every key is taken")
+	}
+}
+
+// TestUniqueKey verifies field lookup and that a whole-record key ignores
+// object key order
+func TestUniqueKey(t *testing.T) {
+	segments, err := uniqueBySegments("patient.ids[0]")
+	if err != nil {
+		t.Fatalf("Failed to parse unique_by: %v", err)
+	}
+
+	record := map[string]interface{}{
+		"patient": map[string]interface{}{"ids": []interface{}{"MRN-1", "MRN-2"}},
+	}
+	key, ok, err := uniqueKey(record, segments)
+	if err != nil || !ok {
+		t.Fatalf("Expected a key for patient.ids[0], got ok=%v err=%v", ok, err)
+	}
+	same, _, _ := uniqueKey(map[string]interface{}{
+		"patient": map[string]interface{}{"ids": []interface{}{"MRN-1"}, "name": "x"},
+	}, segments)
+	if key != same {
+		t.Errorf("Expected records sharing patient.ids[0] to share a key")
+	}
+	if _, ok, _ := uniqueKey(map[string]interface{}{"patient": nil}, segments); ok {
+		t.Errorf("Expected no key when the field is missing")
+	}
+
+	a, _, _ := uniqueKey(map[string]interface{}{"x": 1.0, "y": "z"}, nil)
+	b, _, _ := uniqueKey(map[string]interface{}{"y": "z", "x": 1.0}, nil)
+	if a != b {
+		t.Errorf("Expected whole-record keys to ignore key order")
+	}
+
+	if _, err := uniqueBySegments("items[].id"); err == nil {
+		t.Errorf("Expected unique_by over every element to be rejected")
+	}
+}
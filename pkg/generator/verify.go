@@ -0,0 +1,338 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/specmint/specmint/internal/config"
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// VerifyResult reports how a dataset compares with the records its
+// manifest regenerates
+type VerifyResult struct {
+	Records       int       `json:"records"`                  // records that matched
+	SkippedFields []string  `json:"skipped_fields,omitempty"` // LLM-enriched fields left out of the comparison
+	Mismatch      *Mismatch `json:"mismatch,omitempty"`       // first divergence, nil when the dataset matches
+}
+
+// Mismatch describes the first place a dataset diverges from its
+// regeneration
+type Mismatch struct {
+	Index    int         `json:"index"`           // record index, counting from 0
+	Field    string      `json:"field,omitempty"` // first differing field, empty when the record differs as a whole
+	Reason   string      `json:"reason"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+}
+
+// NewVerifier creates a generator that regenerates a dataset to check it
+// against its manifest. It has no LLM client and no writer.
+func NewVerifier(cfg *config.Config) (*Generator, error) {
+	return newGenerator(cfg)
+}
+
+// Verify regenerates the records of the run described by manifest and
+// compares them, byte for byte, with the JSONL records in datasetFiles,
+// read in order. Dates are generated from the manifest's reference day.
+// Fields the LLM enriched cannot be regenerated, so they are left out of
+// the comparison. A schema that does not match the manifest's hash is
+// rejected before anything is generated.
+func (g *Generator) Verify(ctx context.Context, manifest *Manifest, datasetFiles []string) (*VerifyResult, error) {
+	if manifest.LLMMode == "record" {
+		return nil, fmt.Errorf("the dataset was enriched in llm record mode, which may rewrite any field, so it cannot be regenerated")
+	}
+	if manifest.SchemaHash != "" {
+		hash, err := g.parser.CanonicalHash()
+		if err != nil {
+			return nil, err
+		}
+		if hash != manifest.SchemaHash {
+			return nil, fmt.Errorf("schema %s does not match the manifest: its sha256 is %s, the manifest records %s", g.config.Schema, hash, manifest.SchemaHash)
+		}
+	}
+
+	if manifest.ReferenceDate != "" {
+		day, err := time.Parse("2006-01-02", manifest.ReferenceDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference date in the manifest: %w", err)
+		}
+		g.detGen.SetReferenceDate(day)
+	}
+
+	rootNode, err := g.parser.GetRootNode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get root schema node: %w", err)
+	}
+	if err := g.loadReferences(rootNode); err != nil {
+		return nil, err
+	}
+
+	result := &VerifyResult{SkippedFields: g.llmFieldPaths(manifest.LLMMode, rootNode.RecordNode())}
+	var skipped [][]fieldSegment
+	for _, field := range result.SkippedFields {
+		segments, err := parseFieldPath(field)
+		if err != nil {
+			return nil, err
+		}
+		skipped = append(skipped, segments)
+	}
+
+	// unique_by regenerates duplicates in index order across the whole
+	// dataset, so those runs are regenerated up front. Records strict
+	// validation rejected were generated but are not in the dataset.
+	count := manifest.RecordCount
+	total := count + manifest.Rejected
+	var regenerated []generatedRecord
+	if g.config.Generation.UniqueBy != "" {
+		regenerated = make([]generatedRecord, total)
+		for i := range regenerated {
+			if regenerated[i], err = g.generateRecord(ctx, rootNode, i, 0); err != nil {
+				return nil, fmt.Errorf("failed to regenerate record %d: %w", i, err)
+			}
+		}
+		if err := g.deduplicate(ctx, rootNode, regenerated, &GenerationResult{}); err != nil {
+			return nil, err
+		}
+	}
+
+	index, next := 0, 0
+	compare := func(line []byte) (bool, error) {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		if index >= count {
+			result.Mismatch = &Mismatch{Index: index, Reason: fmt.Sprintf("the dataset has more than the manifest's %d records", count)}
+			return false, nil
+		}
+
+		var expected generatedRecord
+		for {
+			if next >= total {
+				result.Mismatch = &Mismatch{Index: index, Reason: fmt.Sprintf("the manifest's %d rejected records leave fewer than %d to compare", manifest.Rejected, count)}
+				return false, nil
+			}
+			if regenerated != nil {
+				expected = regenerated[next]
+			} else if expected, err = g.generateRecord(ctx, rootNode, next, 0); err != nil {
+				return false, fmt.Errorf("failed to regenerate record %d: %w", next, err)
+			}
+			next++
+			if g.config.Generation.Validation != "strict" || expected.Remaining == nil {
+				break
+			}
+		}
+		if result.Mismatch = compareRecord(index, expected.Data, line, skipped); result.Mismatch != nil {
+			return false, nil
+		}
+		index++
+		return true, nil
+	}
+
+	for _, path := range datasetFiles {
+		more, err := eachLine(path, compare)
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			result.Records = index
+			return result, nil
+		}
+	}
+	if index < count {
+		result.Mismatch = &Mismatch{Index: index, Reason: fmt.Sprintf("the dataset ends after %d of the manifest's %d records", index, count)}
+	}
+	result.Records = index
+	return result, nil
+}
+
+// llmFieldPaths returns the fields enrichment in mode may have changed,
+// sorted, using the same selection as enrich and enrichFields
+func (g *Generator) llmFieldPaths(mode string, recordNode *schema.SchemaNode) []string {
+	var fields []string
+	switch mode {
+	case "field":
+		fields = []string{"name", "description"}
+	case "fields":
+		fields = append(g.parser.GetLLMFields(recordNode), "name", "description")
+	default:
+		return nil
+	}
+
+	seen := make(map[string]bool, len(fields))
+	paths := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if !seen[field] {
+			seen[field] = true
+			paths = append(paths, field)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// eachLine calls fn with each line of a JSONL file, gzipped if its name ends
+// in .gz, until fn returns false. It reports whether every line was read.
+func eachLine(path string, fn func(line []byte) (bool, error)) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		more, err := fn(scanner.Bytes())
+		if err != nil || !more {
+			return false, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// compareRecord checks a dataset line against the regenerated record. With
+// no fields skipped the line must be exactly what the writer would write;
+// otherwise the decoded values are compared with skipped fields blanked.
+func compareRecord(index int, expected interface{}, line []byte, skipped [][]fieldSegment) *Mismatch {
+	// Encode the way the jsonl writer does
+	var buffer bytes.Buffer
+	if err := json.NewEncoder(&buffer).Encode(expected); err != nil {
+		return &Mismatch{Index: index, Reason: fmt.Sprintf("failed to encode the regenerated record: %v", err)}
+	}
+	want := bytes.TrimSuffix(buffer.Bytes(), []byte("\n"))
+	if len(skipped) == 0 && bytes.Equal(want, line) {
+		return nil
+	}
+
+	var wantValue, gotValue interface{}
+	if err := json.Unmarshal(line, &gotValue); err != nil {
+		return &Mismatch{Index: index, Reason: fmt.Sprintf("the record is not valid JSON: %v", err)}
+	}
+	if err := json.Unmarshal(want, &wantValue); err != nil {
+		return &Mismatch{Index: index, Reason: fmt.Sprintf("failed to decode the regenerated record: %v", err)}
+	}
+	for _, segments := range skipped {
+		blankField(wantValue, segments)
+		blankField(gotValue, segments)
+	}
+
+	if field, reason, wantField, gotField := firstDifference("", wantValue, gotValue); reason != "" {
+		return &Mismatch{Index: index, Field: field, Reason: reason, Expected: wantField, Actual: gotField}
+	}
+	if len(skipped) == 0 {
+		return &Mismatch{Index: index, Reason: "the record is encoded differently", Expected: string(want), Actual: string(line)}
+	}
+	return nil
+}
+
+// blankField sets the value at a field path to null wherever it is present
+func blankField(value interface{}, segments []fieldSegment) {
+	obj, ok := value.(map[string]interface{})
+	if !ok || len(segments) == 0 {
+		return
+	}
+	segment := segments[0]
+	child, present := obj[segment.key]
+	if !present {
+		return
+	}
+
+	if segment.index == -1 {
+		if len(segments) == 1 {
+			obj[segment.key] = nil
+		} else {
+			blankField(child, segments[1:])
+		}
+		return
+	}
+	items, _ := child.([]interface{})
+	for i := range items {
+		if segment.index != allElements && segment.index != i {
+			continue
+		}
+		if len(segments) == 1 {
+			items[i] = nil
+		} else {
+			blankField(items[i], segments[1:])
+		}
+	}
+}
+
+// firstDifference walks two decoded JSON values in key order and returns
+// the path of the first place they differ, why, and the values there. The
+// reason is empty when the values are equal.
+func firstDifference(path string, want, got interface{}) (string, string, interface{}, interface{}) {
+	wantObj, wantIsObj := want.(map[string]interface{})
+	gotObj, gotIsObj := got.(map[string]interface{})
+	if wantIsObj && gotIsObj {
+		keys := make([]string, 0, len(wantObj)+len(gotObj))
+		for key := range wantObj {
+			keys = append(keys, key)
+		}
+		for key := range gotObj {
+			if _, ok := wantObj[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			wantValue, inWant := wantObj[key]
+			gotValue, inGot := gotObj[key]
+			if !inGot {
+				return keyPath, "the field is missing", wantValue, nil
+			}
+			if !inWant {
+				return keyPath, "the field is not generated by the schema", nil, gotValue
+			}
+			if field, reason, w, g := firstDifference(keyPath, wantValue, gotValue); reason != "" {
+				return field, reason, w, g
+			}
+		}
+		return "", "", nil, nil
+	}
+
+	wantItems, wantIsArray := want.([]interface{})
+	gotItems, gotIsArray := got.([]interface{})
+	if wantIsArray && gotIsArray && len(wantItems) == len(gotItems) {
+		for i := range wantItems {
+			if field, reason, w, g := firstDifference(fmt.Sprintf("%s[%d]", path, i), wantItems[i], gotItems[i]); reason != "" {
+				return field, reason, w, g
+			}
+		}
+		return "", "", nil, nil
+	}
+
+	if reflect.DeepEqual(want, got) {
+		return "", "", nil, nil
+	}
+	return path, "the value differs", want, got
+}
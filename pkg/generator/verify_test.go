@@ -0,0 +1,306 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/specmint/specmint/pkg/writer"
+)
+
+// writeVerifyDataset writes the first n records of the run as JSONL, passing
+// each line through edit
+func writeVerifyDataset(t *testing.T, gen *Generator, n int, edit func(index int, line []byte) []byte) string {
+	t.Helper()
+
+	root, err := gen.parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	var dataset bytes.Buffer
+	for i := 0; i < n; i++ {
+		record, err := gen.generateRecord(context.Background(), root, i, 0)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		line, _ := json.Marshal(record.Data)
+		dataset.Write(edit(i, line))
+		dataset.WriteByte('\n')
+	}
+
+	path := filepath.Join(t.TempDir(), "dataset.jsonl")
+	if err := os.WriteFile(path, dataset.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	return path
+}
+
+// TestVerify verifies a regenerated dataset matches, and that a changed
+// record, a short dataset and a different schema are reported
+func TestVerify(t *testing.T) {
+	gen, _ := newTestGenerator(t, `{
+		"type": "object",
+		"required": ["id", "score"],
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"score": {"type": "integer", "minimum": 0, "maximum": 100}
+		}
+	}`)
+	hash, err := gen.parser.CanonicalHash()
+	if err != nil {
+		t.Fatalf("Failed to hash schema: %v", err)
+	}
+	manifest := &Manifest{RecordCount: 5, SchemaHash: hash}
+	unchanged := func(index int, line []byte) []byte { return line }
+
+	result, err := gen.Verify(context.Background(), manifest, []string{writeVerifyDataset(t, gen, 5, unchanged)})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Mismatch != nil || result.Records != 5 {
+		t.Errorf("Expected all 5 records to match, got %d and %+v", result.Records, result.Mismatch)
+	}
+
+	tampered := writeVerifyDataset(t, gen, 5, func(index int, line []byte) []byte {
+		if index != 3 {
+			return line
+		}
+		var record map[string]interface{}
+		json.Unmarshal(line, &record)
+		record["score"] = 101
+		line, _ = json.Marshal(record)
+		return line
+	})
+	result, err = gen.Verify(context.Background(), manifest, []string{tampered})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Mismatch == nil || result.Mismatch.Index != 3 || result.Mismatch.Field != "score" || result.Mismatch.Actual != float64(101) {
+		t.Errorf("Expected score of record 3 to differ, got %+v", result.Mismatch)
+	}
+	if result.Records != 3 {
+		t.Errorf("Expected 3 matching records, got %d", result.Records)
+	}
+
+	result, err = gen.Verify(context.Background(), manifest, []string{writeVerifyDataset(t, gen, 4, unchanged)})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Mismatch == nil || result.Mismatch.Index != 4 || !strings.Contains(result.Mismatch.Reason, "ends after 4") {
+		t.Errorf("Expected the short dataset to be reported, got %+v", result.Mismatch)
+	}
+
+	manifest.SchemaHash = strings.Repeat("0", 64)
+	if _, err := gen.Verify(context.Background(), manifest, []string{tampered}); err == nil || !strings.Contains(err.Error(), "does not match the manifest") {
+		t.Errorf("Expected a schema hash mismatch, got %v", err)
+	}
+}
+
+// TestVerifySkipsLLMFields verifies LLM-enriched fields are left out of the
+// comparison in fields mode and record mode is refused
+func TestVerifySkipsLLMFields(t *testing.T) {
+	gen, _ := newTestGenerator(t, `{
+		"type": "object",
+		"required": ["id", "notes"],
+		"properties": {
+			"id": {"type": "integer"},
+			"notes": {"type": "array", "items": {"type": "object", "required": ["text"], "properties": {"text": {"type": "string", "x-llm": true}}}}
+		}
+	}`)
+	dataset := writeVerifyDataset(t, gen, 3, func(index int, line []byte) []byte {
+		var record map[string]interface{}
+		json.Unmarshal(line, &record)
+		for _, note := range record["notes"].([]interface{}) {
+			note.(map[string]interface{})["text"] = "written by the LLM"
+		}
+		line, _ = json.Marshal(record)
+		return line
+	})
+
+	result, err := gen.Verify(context.Background(), &Manifest{RecordCount: 3, LLMMode: "fields"}, []string{dataset})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Mismatch != nil {
+		t.Errorf("Expected enriched fields to be skipped, got %+v", result.Mismatch)
+	}
+	if strings.Join(result.SkippedFields, ",") != "description,name,notes[].text" {
+		t.Errorf("Unexpected skipped fields %v", result.SkippedFields)
+	}
+
+	if result, _ := gen.Verify(context.Background(), &Manifest{RecordCount: 3}, []string{dataset}); result == nil || result.Mismatch == nil {
+		t.Error("Expected enriched fields to differ when the LLM was off")
+	}
+	if _, err := gen.Verify(context.Background(), &Manifest{RecordCount: 3, LLMMode: "record"}, []string{dataset}); err == nil {
+		t.Error("Expected record mode to be refused")
+	}
+}
+
+// TestVerifyReferenceDate verifies a dataset generated on an earlier day
+// still matches when regenerated from the manifest's reference date, and
+// differs when regenerated from today
+func TestVerifyReferenceDate(t *testing.T) {
+	const schemaJSON = `{
+		"type": "object",
+		"required": ["admitted", "updated"],
+		"properties": {
+			"admitted": {"type": "string", "format": "date"},
+			"updated": {"type": "string", "format": "date-time"}
+		}
+	}`
+	generated, _ := newTestGenerator(t, schemaJSON)
+	generated.detGen.SetReferenceDate(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC))
+	dataset := writeVerifyDataset(t, generated, 5, func(index int, line []byte) []byte { return line })
+
+	// A later run's clock reads today, not the day the dataset was generated
+	gen, _ := newTestGenerator(t, schemaJSON)
+	result, err := gen.Verify(context.Background(), &Manifest{RecordCount: 5, ReferenceDate: "2024-03-01"}, []string{dataset})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Mismatch != nil || result.Records != 5 {
+		t.Errorf("Expected all 5 records to match, got %d and %+v", result.Records, result.Mismatch)
+	}
+
+	gen, _ = newTestGenerator(t, schemaJSON)
+	result, err = gen.Verify(context.Background(), &Manifest{RecordCount: 5}, []string{dataset})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if result.Mismatch == nil || result.Mismatch.Index != 0 || result.Mismatch.Field != "admitted" {
+		t.Errorf("Expected dates regenerated from today to differ at record 0, got %+v", result.Mismatch)
+	}
+
+	if _, err := gen.Verify(context.Background(), &Manifest{RecordCount: 5, ReferenceDate: "03/01/2024"}, []string{dataset}); err == nil {
+		t.Error("Expected an invalid reference date to be rejected")
+	}
+}
+
+// TestStrictValidation verifies records still invalid after patching go to
+// rejects.jsonl with their errors, the manifest counts both, and the dataset
+// still verifies against its manifest
+func TestStrictValidation(t *testing.T) {
+	// not is ignored by generation but enforced by validation, so about a
+	// quarter of the records are rejected
+	schemaJSON := `{
+		"type": "object",
+		"required": ["n"],
+		"properties": {"n": {"type": "integer", "minimum": 0, "maximum": 3}},
+		"not": {"properties": {"n": {"const": 0}}}
+	}`
+	gen, _ := newTestGenerator(t, schemaJSON)
+	dir := t.TempDir()
+	gen.quiet = true
+	gen.config.Generation.Count = 40
+	gen.config.Generation.Validation = "strict"
+	gen.config.Output.Directory = dir
+	w, err := writer.New(gen.config.Output)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	gen.writer = w
+
+	result, err := gen.Generate(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	if result.FailedRecords == 0 || result.RecordCount+result.FailedRecords != 40 {
+		t.Fatalf("Expected 40 records split between accepted and rejected, got %d and %d", result.RecordCount, result.FailedRecords)
+	}
+
+	var accepted int
+	eachLine(filepath.Join(dir, "dataset.jsonl"), func(line []byte) (bool, error) {
+		var record map[string]interface{}
+		json.Unmarshal(line, &record)
+		if record["n"] == float64(0) {
+			t.Errorf("Invalid record in the dataset: %s", line)
+		}
+		accepted++
+		return true, nil
+	})
+	var rejected int
+	eachLine(filepath.Join(dir, "rejects.jsonl"), func(line []byte) (bool, error) {
+		var reject struct {
+			Index  int                      `json:"index"`
+			Errors []map[string]interface{} `json:"errors"`
+			Record map[string]interface{}   `json:"record"`
+		}
+		json.Unmarshal(line, &reject)
+		if reject.Record["n"] != float64(0) || len(reject.Errors) == 0 {
+			t.Errorf("Unexpected reject: %s", line)
+		}
+		rejected++
+		return true, nil
+	})
+	if accepted != result.RecordCount || rejected != result.FailedRecords {
+		t.Errorf("Expected %d accepted and %d rejected lines, got %d and %d", result.RecordCount, result.FailedRecords, accepted, rejected)
+	}
+
+	manifest, err := ReadManifest(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	if manifest.RecordCount != accepted || manifest.Rejected != rejected || manifest.Config.Generation.Validation != "strict" {
+		t.Errorf("Unexpected manifest counts %d and %d", manifest.RecordCount, manifest.Rejected)
+	}
+
+	verifier, _ := newTestGenerator(t, schemaJSON)
+	verifier.config.Generation.Validation = "strict"
+	verified, err := verifier.Verify(context.Background(), manifest, []string{filepath.Join(dir, "dataset.jsonl")})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if verified.Mismatch != nil || verified.Records != accepted {
+		t.Errorf("Expected the strict dataset to verify, got %d records and %+v", verified.Records, verified.Mismatch)
+	}
+}
+
+// TestValidationRetries verifies a record patching cannot fix is
+// regenerated until it is valid, and that retries are reproducible
+func TestValidationRetries(t *testing.T) {
+	gen, root := newTestGenerator(t, `{
+		"type": "object",
+		"required": ["n"],
+		"properties": {"n": {"type": "integer", "minimum": 0, "maximum": 3}},
+		"not": {"properties": {"n": {"const": 0}}}
+	}`)
+	gen.config.Generation.ValidationRetries = 10
+
+	result := &GenerationResult{}
+	for i := 0; i < 40; i++ {
+		record, err := gen.generateRecord(context.Background(), root, i, 0)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+		result.tally(record, 1)
+		if errors := gen.validator.ValidateRecord(record.Data); len(errors) > 0 {
+			t.Errorf("Record %d is still invalid after %d retries: %v", i, record.Retries, record.Data)
+		}
+
+		again, _ := gen.generateRecord(context.Background(), root, i, 0)
+		if !reflect.DeepEqual(record, again) {
+			t.Errorf("Record %d differs between runs: %v and %v", i, record.Data, again.Data)
+		}
+	}
+	if result.Retries == 0 || result.FailedRecords != 0 {
+		t.Errorf("Expected retries and no failures, got %d and %d", result.Retries, result.FailedRecords)
+	}
+
+	// Without retries the same records are kept invalid and counted
+	gen.config.Generation.ValidationRetries = 0
+	gen.config.Generation.Validation = "strict"
+	failed := &GenerationResult{}
+	for i := 0; i < 40; i++ {
+		record, _ := gen.generateRecord(context.Background(), root, i, 0)
+		failed.tally(record, 1)
+	}
+	if failed.FailedRecords == 0 || failed.Retries != 0 {
+		t.Errorf("Expected failures without retries, got %d and %d retries", failed.FailedRecords, failed.Retries)
+	}
+}
@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	anthropicBaseURL = "https://api.anthropic.com/v1"
+	anthropicVersion = "2023-06-01"
+)
+
+// PingAnthropic verifies an Anthropic API key by listing a single model.
+// The call is free and bounded by ctx and the client's timeout.
+func PingAnthropic(ctx context.Context, client *http.Client, apiKey string) error {
+	if apiKey == "" {
+		return fmt.Errorf("anthropic API key is required (set llm.anthropic.api_key or ANTHROPIC_API_KEY)")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", anthropicBaseURL+"/models?limit=1", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("anthropic ping failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		var errResp struct {
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+			return fmt.Errorf("anthropic error (HTTP %d): %s", resp.StatusCode, errResp.Error.Message)
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
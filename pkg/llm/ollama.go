@@ -38,6 +38,7 @@ type OllamaConfig struct {
 	MaxRPS      int
 	Timeout     time.Duration
 	MaxConns    int
+	Stream      bool // read the response as it is generated instead of in one piece
 }
 
 // OllamaRequest represents a request to Ollama API
@@ -242,7 +243,7 @@ func (c *OllamaClient) doGenerate(ctx context.Context, prompt string, seed int64
 	req := OllamaRequest{
 		Model:   c.model,
 		Prompt:  prompt,
-		Stream:  false,
+		Stream:  c.config.Stream,
 		Options: options,
 	}
 
@@ -271,6 +272,10 @@ func (c *OllamaClient) doGenerate(ctx context.Context, prompt string, seed int64
 		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
 	}
 
+	if c.config.Stream {
+		return readStream(ctx, resp.Body)
+	}
+
 	// Parse response
 	var ollamaResp OllamaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
@@ -284,6 +289,34 @@ func (c *OllamaClient) doGenerate(ctx context.Context, prompt string, seed int64
 	return strings.TrimSpace(ollamaResp.Response), nil
 }
 
+// readStream concatenates the response tokens of a streamed generation,
+// which arrives as one JSON object per line until one has done set. A stream
+// that errors or ends early is an error, never a truncated response.
+func readStream(ctx context.Context, body io.Reader) (string, error) {
+	var response strings.Builder
+	decoder := json.NewDecoder(body)
+	for {
+		var chunk OllamaResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			if err == io.EOF {
+				return "", fmt.Errorf("stream ended before the response was done")
+			}
+			return "", fmt.Errorf("failed to decode stream: %w", err)
+		}
+
+		if chunk.Error != "" {
+			return "", fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		response.WriteString(chunk.Response)
+		if chunk.Done {
+			return strings.TrimSpace(response.String()), nil
+		}
+	}
+}
+
 // ping checks if Ollama is responding
 func (c *OllamaClient) ping(ctx context.Context) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
@@ -346,7 +379,10 @@ func (c *OllamaClient) pullModel(ctx context.Context) error {
 
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	// A pull can take far longer than a generation, so it is bounded by ctx
+	// rather than the client timeout
+	pullClient := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := pullClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -357,10 +393,58 @@ func (c *OllamaClient) pullModel(ctx context.Context) error {
 		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
 	}
 
-	// Wait for pull to complete (simplified - in production would stream progress)
-	time.Sleep(10 * time.Second)
+	return c.readPullProgress(ctx, resp.Body)
+}
 
-	return nil
+// pullProgress is one line of the streamed /api/pull response
+type pullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// readPullProgress logs pull progress until Ollama reports success. An
+// error line or a stream that ends without success fails the pull.
+func (c *OllamaClient) readPullProgress(ctx context.Context, body io.Reader) error {
+	decoder := json.NewDecoder(body)
+	lastPercent := make(map[string]int64)
+	for {
+		var progress pullProgress
+		if err := decoder.Decode(&progress); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == io.EOF {
+				return fmt.Errorf("pull ended before it succeeded")
+			}
+			return fmt.Errorf("failed to decode pull progress: %w", err)
+		}
+
+		if progress.Error != "" {
+			return fmt.Errorf("pull failed: %s", progress.Error)
+		}
+		if progress.Status == "success" {
+			log.Info().Str("model", c.model).Msg("Model pulled")
+			return nil
+		}
+
+		// Log each layer every 10 percent
+		if progress.Total > 0 {
+			percent := progress.Completed * 100 / progress.Total
+			if last, seen := lastPercent[progress.Digest]; !seen || percent >= last+10 || (percent == 100 && last != 100) {
+				lastPercent[progress.Digest] = percent
+				log.Info().
+					Str("model", c.model).
+					Str("digest", progress.Digest).
+					Int64("percent", percent).
+					Msg("Pulling model")
+			}
+		} else if progress.Status != "" {
+			log.Debug().Str("model", c.model).Str("status", progress.Status).Msg("Pulling model")
+		}
+	}
 }
 
 // Close closes the client and releases resources
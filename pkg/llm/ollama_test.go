@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestOllamaClient returns a client for an Ollama server served by handler
+func newTestOllamaClient(t *testing.T, config OllamaConfig, handler http.HandlerFunc) *OllamaClient {
+	t.Helper()
+	t.Setenv("SKIP_OLLAMA_TESTS", "")
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config.Host = server.URL
+	config.MaxRetries = 1
+	config.MaxRPS = 100
+	client, err := NewOllamaClient(config)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+// TestOllamaGenerate_Stream verifies streamed tokens are joined once the
+// stream is done, and that a stream cut off before done or carrying an
+// error line fails instead of returning a truncated response
+func TestOllamaGenerate_Stream(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		response string
+		err      string
+	}{
+		{
+			name:     "complete",
+			body:     `{"response": "Acme ", "done": false}` + "\n" + `{"response": "Garden Hose", "done": false}` + "\n" + `{"response": "", "done": true}` + "\n",
+			response: "Acme Garden Hose",
+		},
+		{
+			name: "truncated",
+			body: `{"response": "Acme ", "done": false}` + "\n" + `{"response": "Gar", "done": false}` + "\n",
+			err:  "stream ended before the response was done",
+		},
+		{
+			name: "cut mid-line",
+			body: `{"response": "Acme ", "done": false}` + "\n" + `{"response": "Gar`,
+			err:  "failed to decode stream",
+		},
+		{
+			name: "error line",
+			body: `{"response": "Acme ", "done": false}` + "\n" + `{"error": "model runner has unexpectedly stopped"}` + "\n",
+			err:  "ollama error: model runner has unexpectedly stopped",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestOllamaClient(t, OllamaConfig{Stream: true}, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.body))
+			})
+
+			response, err := client.Generate(context.Background(), "Name a product", 1)
+			if tc.err == "" {
+				if err != nil || response != tc.response {
+					t.Errorf("Expected %q, got %q, %v", tc.response, response, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Expected an error containing %q, got %q, %v", tc.err, response, err)
+			}
+		})
+	}
+}
+
+// TestOllamaPullModel verifies auto-pull waits for the pull to succeed and
+// fails on an error line or a stream that ends without success
+func TestOllamaPullModel(t *testing.T) {
+	progress := `{"status": "pulling manifest"}` + "\n" +
+		`{"status": "pulling abc", "digest": "sha256:abc", "total": 100, "completed": 40}` + "\n"
+
+	testCases := []struct {
+		name string
+		body string
+		err  string
+	}{
+		{name: "success", body: progress + `{"status": "verifying sha256 digest"}` + "\n" + `{"status": "success"}` + "\n"},
+		{name: "error line", body: progress + `{"error": "pull model manifest: file does not exist"}` + "\n", err: "pull failed: pull model manifest: file does not exist"},
+		{name: "ended early", body: progress, err: "pull ended before it succeeded"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pulled := false
+			client := newTestOllamaClient(t, OllamaConfig{Model: "qwen2.5:latest", AutoPull: true}, func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/tags":
+					w.Write([]byte(`{"models": [{"name": "llama3:latest"}]}`))
+				case "/api/pull":
+					pulled = true
+					w.Write([]byte(tc.body))
+				default:
+					http.NotFound(w, r)
+				}
+			})
+
+			err := client.HealthCheck(context.Background())
+			if !pulled {
+				t.Fatalf("Expected a missing model to be pulled")
+			}
+			if tc.err == "" {
+				if err != nil {
+					t.Errorf("Expected the pull to succeed, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
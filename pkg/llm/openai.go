@@ -0,0 +1,291 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"golang.org/x/time/rate"
+)
+
+// OpenAIClient handles communication with the OpenAI chat completions API
+type OpenAIClient struct {
+	baseURL     string
+	model       string
+	httpClient  *http.Client
+	rateLimiter *rate.Limiter
+	breaker     *gobreaker.CircuitBreaker
+	config      OpenAIConfig
+}
+
+// OpenAIConfig holds OpenAI-specific configuration
+type OpenAIConfig struct {
+	APIKey      string
+	Model       string
+	BaseURL     string
+	MaxTokens   int
+	Temperature float32
+	MaxRetries  int
+	MaxRPS      int
+	Timeout     time.Duration
+}
+
+// openAIMessage is a single chat message
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// openAIRequest represents a chat completions request
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature float32         `json:"temperature"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Seed        int64           `json:"seed"`
+}
+
+// openAIResponse represents a chat completions response
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Error *openAIError `json:"error,omitempty"`
+}
+
+// openAIError is the error object returned by the API
+type openAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+}
+
+// NewOpenAIClient creates a new OpenAI client with rate limiting and a circuit breaker
+func NewOpenAIClient(config OpenAIConfig) (*OpenAIClient, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("openai API key is required (set llm.openai.api_key or OPENAI_API_KEY)")
+	}
+	if config.Model == "" {
+		config.Model = "gpt-4o-mini"
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.openai.com/v1"
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = 3
+	}
+	if config.MaxRPS <= 0 {
+		config.MaxRPS = 3
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	httpClient := &http.Client{
+		Timeout: config.Timeout,
+	}
+
+	// Create rate limiter
+	rateLimiter := rate.NewLimiter(rate.Limit(config.MaxRPS), config.MaxRPS)
+
+	// Create circuit breaker
+	breaker := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        "openai",
+		MaxRequests: 3,
+		Interval:    10 * time.Second,
+		Timeout:     30 * time.Second,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures > 2
+		},
+	})
+
+	return &OpenAIClient{
+		baseURL:     strings.TrimSuffix(config.BaseURL, "/"),
+		model:       config.Model,
+		httpClient:  httpClient,
+		rateLimiter: rateLimiter,
+		breaker:     breaker,
+		config:      config,
+	}, nil
+}
+
+// HealthCheck verifies the API key and that the configured model is available
+func (c *OpenAIClient) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models/"+c.model, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("openai ping failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("model %s not available: %w", c.model, c.apiError(resp))
+	}
+
+	return nil
+}
+
+// Generate generates text using OpenAI with the given prompt and seed
+func (c *OpenAIClient) Generate(ctx context.Context, prompt string, seed int64) (string, error) {
+	// Wait for rate limit
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return "", fmt.Errorf("rate limit wait failed: %w", err)
+	}
+
+	// Use circuit breaker
+	result, err := c.breaker.Execute(func() (interface{}, error) {
+		return c.generateWithRetry(ctx, prompt, seed)
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("generation failed: %w", err)
+	}
+
+	return result.(string), nil
+}
+
+// generateWithRetry performs the actual generation with retry logic
+func (c *OpenAIClient) generateWithRetry(ctx context.Context, prompt string, seed int64) (string, error) {
+	var lastErr error
+
+	for attempt := 0; attempt < c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			// Exponential backoff
+			backoff := time.Duration(attempt*attempt) * time.Second
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+
+		response, err := c.doGenerate(ctx, prompt, seed)
+		if err == nil {
+			return response, nil
+		}
+
+		lastErr = err
+
+		// Don't retry on context cancellation
+		if ctx.Err() != nil {
+			break
+		}
+		if !retryable(err) {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("generation failed after %d attempts: %w", c.config.MaxRetries, lastErr)
+}
+
+// retryable reports whether a failed request may succeed if sent again.
+// Rate limiting and server errors are retried, as are failures to reach the
+// API; other 4xx responses such as a bad key or request never succeed.
+func retryable(err error) bool {
+	var statusErr *apiStatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+	return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+}
+
+// doGenerate performs a single chat completions request
+func (c *OpenAIClient) doGenerate(ctx context.Context, prompt string, seed int64) (string, error) {
+	req := openAIRequest{
+		Model:       c.model,
+		Messages:    []openAIMessage{{Role: "user", Content: prompt}},
+		Temperature: c.config.Temperature,
+		MaxTokens:   c.config.MaxTokens,
+		Seed:        seed,
+	}
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Create HTTP request
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(reqBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+
+	// Execute request
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", c.apiError(resp)
+	}
+
+	// Parse response
+	var openAIResp openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&openAIResp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if openAIResp.Error != nil {
+		return "", fmt.Errorf("openai error: %s", openAIResp.Error.Message)
+	}
+	if len(openAIResp.Choices) == 0 {
+		return "", fmt.Errorf("openai returned no choices")
+	}
+
+	return strings.TrimSpace(openAIResp.Choices[0].Message.Content), nil
+}
+
+// apiStatusError is a non-200 API response
+type apiStatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiStatusError) Error() string {
+	return e.Message
+}
+
+// apiError builds an error from a non-200 API response
+func (c *OpenAIClient) apiError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var errResp openAIResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+		return &apiStatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("openai error (HTTP %d): %s", resp.StatusCode, errResp.Error.Message)}
+	}
+
+	return &apiStatusError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body))}
+}
+
+// Close closes the client and releases resources
+func (c *OpenAIClient) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// GetStats returns client statistics
+func (c *OpenAIClient) GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"model":         c.model,
+		"base_url":      c.baseURL,
+		"max_rps":       c.config.MaxRPS,
+		"breaker_state": c.breaker.State().String(),
+	}
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestOpenAIClient returns a client for an API served by handler
+func newTestOpenAIClient(t *testing.T, handler http.HandlerFunc) *OpenAIClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewOpenAIClient(OpenAIConfig{APIKey: "sk-test", BaseURL: server.URL, MaxRetries: 3, MaxRPS: 100})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+// writeCompletion answers a chat completions request with content
+func writeCompletion(w http.ResponseWriter, content string) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"choices": []interface{}{map[string]interface{}{"message": map[string]string{"role": "assistant", "content": content}}},
+	})
+}
+
+// TestOpenAIGenerate verifies the prompt, seed and key are sent and the
+// first choice is returned
+func TestOpenAIGenerate(t *testing.T) {
+	var request openAIRequest
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("Unexpected request %s with authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		writeCompletion(w, "  Acme Garden Hose \n")
+	})
+
+	response, err := client.Generate(context.Background(), "Name a product", 42)
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	if response != "Acme Garden Hose" {
+		t.Errorf("Expected the trimmed first choice, got %q", response)
+	}
+	if request.Seed != 42 || len(request.Messages) != 1 || request.Messages[0].Content != "Name a product" {
+		t.Errorf("Unexpected request %+v", request)
+	}
+}
+
+// TestOpenAIGenerate_NoRetryOnClientError verifies a rejected key fails on
+// the first attempt instead of being retried
+func TestOpenAIGenerate_NoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": {"message": "Incorrect API key provided", "type": "invalid_request_error"}}`))
+	})
+
+	_, err := client.Generate(context.Background(), "Name a product", 1)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") || !strings.Contains(err.Error(), "Incorrect API key") {
+		t.Errorf("Expected the 401 error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected one request for a 401, got %d", calls.Load())
+	}
+}
+
+// TestOpenAIGenerate_RetryOnRateLimit verifies a 429 is retried until the
+// API answers
+func TestOpenAIGenerate_RetryOnRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestOpenAIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": {"message": "Rate limit reached", "type": "requests"}}`))
+			return
+		}
+		writeCompletion(w, "Acme Garden Hose")
+	})
+
+	response, err := client.Generate(context.Background(), "Name a product", 1)
+	if err != nil || response != "Acme Garden Hose" {
+		t.Errorf("Expected the retry to succeed, got %q, %v", response, err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected two requests, got %d", calls.Load())
+	}
+}
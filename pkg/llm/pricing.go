@@ -0,0 +1,56 @@
+package llm
+
+import "strings"
+
+// ModelRate is the price of a model in USD per million tokens
+type ModelRate struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// modelRates holds published prices for hosted models. Models that are not
+// listed, such as local Ollama models, are treated as free.
+var modelRates = map[string]ModelRate{
+	// OpenAI
+	"gpt-4o-mini":   {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+	"gpt-4o":        {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+	"gpt-4-turbo":   {InputPerMillion: 10.00, OutputPerMillion: 30.00},
+	"gpt-4":         {InputPerMillion: 30.00, OutputPerMillion: 60.00},
+	"gpt-3.5-turbo": {InputPerMillion: 0.50, OutputPerMillion: 1.50},
+
+	// Anthropic
+	"claude-3-haiku":    {InputPerMillion: 0.25, OutputPerMillion: 1.25},
+	"claude-3-5-haiku":  {InputPerMillion: 0.80, OutputPerMillion: 4.00},
+	"claude-3-sonnet":   {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	"claude-3-5-sonnet": {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	"claude-3-opus":     {InputPerMillion: 15.00, OutputPerMillion: 75.00},
+}
+
+// RateFor returns the rate for a model. Dated model names such as
+// gpt-4o-mini-2024-07-18 match the longest listed prefix.
+func RateFor(model string) ModelRate {
+	if rate, ok := modelRates[model]; ok {
+		return rate
+	}
+
+	var best string
+	for name := range modelRates {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	return modelRates[best]
+}
+
+// EstimateTokens approximates the token count of text at four characters per token
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// EstimateCost estimates the USD cost of a single call from its prompt and response
+func EstimateCost(model, prompt, response string) float64 {
+	rate := RateFor(model)
+	input := float64(EstimateTokens(prompt)) * rate.InputPerMillion
+	output := float64(EstimateTokens(response)) * rate.OutputPerMillion
+	return (input + output) / 1_000_000
+}
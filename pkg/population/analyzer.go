@@ -169,6 +169,7 @@ func (pa *PopulationAnalyzer) parseWithPatterns(description string) *PopulationS
 		"retail":    regexp.MustCompile(`(\d+)\s+stores?`),
 		"ecommerce": regexp.MustCompile(`(\d+[KM]?)\s+.*users?`),
 		"insurance": regexp.MustCompile(`(\d+[KM]?)\s+.*policyholders?`),
+		"telecom":   regexp.MustCompile(`(\d+[KM]?)\s+.*subscribers?`),
 	}
 	
 	for domain, pattern := range patterns {
@@ -222,6 +223,7 @@ func (pa *PopulationAnalyzer) getBaseUnit(domain string) string {
 		"retail":    "stores",
 		"ecommerce": "users",
 		"insurance": "policyholders",
+		"telecom":   "subscribers",
 	}
 	return units[domain]
 }
@@ -360,4 +362,5 @@ func (pa *PopulationAnalyzer) loadBuiltinTemplates() {
 	pa.templates["retail"] = GetRetailTemplate()
 	pa.templates["ecommerce"] = GetEcommerceTemplate()
 	pa.templates["insurance"] = GetInsuranceTemplate()
+	pa.templates["telecom"] = GetTelecomTemplate()
 }
@@ -45,6 +45,12 @@ func TestPopulationAnalyzer_AnalyzePopulation(t *testing.T) {
 			wantDomain:  "insurance",
 			wantError:   false,
 		},
+		{
+			name:        "telecom scenario",
+			description: "mobile carrier with 50K subscribers",
+			wantDomain:  "telecom",
+			wantError:   false,
+		},
 		{
 			name:        "empty description",
 			description: "",
@@ -160,6 +166,15 @@ func TestPopulationAnalyzer_parseScenario(t *testing.T) {
 			wantLocation:  "unknown",
 			wantError:     false,
 		},
+		{
+			name:          "telecom subscribers",
+			description:   "mobile carrier with 2M subscribers",
+			wantDomain:    "telecom",
+			wantBaseUnit:  "subscribers",
+			wantBaseCount: 2000000,
+			wantLocation:  "unknown",
+			wantError:     false,
+		},
 		{
 			name:        "no numbers",
 			description: "hospital without numbers",
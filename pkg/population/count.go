@@ -0,0 +1,105 @@
+package population
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// countExpression matches a count relative to a base unit, such as
+// "500 beds * 7.5" or "2K stores * transactions": a base count, the unit
+// and an optional ratio that is a number or the name of a template metric
+var countExpression = regexp.MustCompile(`^(\d+[KkMm]?)?\s*([A-Za-z_]+)\s*(?:\*\s*([A-Za-z_]+|\d+(?:\.\d+)?))?$`)
+
+// ResolveCount turns a count expression into a number of records. A plain
+// integer is returned as it is. Otherwise the base count of a domain's
+// base unit is multiplied by a numeric ratio, or by the ratio of a metric
+// in the domain's template, which also applies the metric's minimum and
+// maximum, the way AnalyzePopulation counts records.
+func (pa *PopulationAnalyzer) ResolveCount(expr string) (int, error) {
+	expr = strings.TrimSpace(expr)
+	if count, err := strconv.Atoi(expr); err == nil {
+		if count <= 0 {
+			return 0, fmt.Errorf("count must be positive, got %d", count)
+		}
+		return count, nil
+	}
+
+	matches := countExpression.FindStringSubmatch(expr)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid count expression %q: expected a number or a base count, unit and ratio such as \"500 beds * 7.5\"", expr)
+	}
+	if matches[1] == "" {
+		return 0, fmt.Errorf("count expression %q needs a base count before %s, such as \"500 %s\"", expr, matches[2], strings.TrimSpace(expr))
+	}
+	base, err := pa.parseCount(matches[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid base count in %q: %w", expr, err)
+	}
+	domain := pa.domainForUnit(strings.ToLower(matches[2]))
+	if domain == "" {
+		return 0, fmt.Errorf("unknown base unit %q in %q, expected one of %s", matches[2], expr, strings.Join(pa.baseUnits(), ", "))
+	}
+	template, err := pa.findTemplate(domain)
+	if err != nil {
+		return 0, err
+	}
+
+	name, metric := "count", MetricRatio{Ratio: 1}
+	if ratio := matches[3]; ratio != "" {
+		if value, err := strconv.ParseFloat(ratio, 64); err == nil {
+			metric.Ratio = value
+		} else if named, ok := template.BaseMetrics[strings.ToLower(ratio)]; ok {
+			name, metric = strings.ToLower(ratio), named
+		} else {
+			return 0, fmt.Errorf("unknown %s metric %q in %q, expected a number or one of %s", domain, ratio, expr, strings.Join(metricNames(template), ", "))
+		}
+	}
+
+	scenario := &PopulationScenario{
+		Domain:    domain,
+		BaseUnit:  pa.getBaseUnit(domain),
+		BaseCount: base,
+		Template:  &PopulationTemplate{Domain: domain, BaseMetrics: map[string]MetricRatio{name: metric}},
+	}
+	count := pa.calculateRecordCounts(scenario)[name]
+	if count <= 0 {
+		return 0, fmt.Errorf("count expression %q resolves to %d records", expr, count)
+	}
+	return count, nil
+}
+
+// domainForUnit returns the domain whose base unit is unit, singular or
+// plural, or "" when there is none
+func (pa *PopulationAnalyzer) domainForUnit(unit string) string {
+	for domain := range pa.templates {
+		if base := pa.getBaseUnit(domain); base != "" && (unit == base || unit+"s" == base) {
+			return domain
+		}
+	}
+	return ""
+}
+
+// baseUnits returns the base units of the loaded templates, sorted
+func (pa *PopulationAnalyzer) baseUnits() []string {
+	units := make([]string, 0, len(pa.templates))
+	for domain := range pa.templates {
+		if unit := pa.getBaseUnit(domain); unit != "" {
+			units = append(units, unit)
+		}
+	}
+	sort.Strings(units)
+	return units
+}
+
+// metricNames returns the names of a template's metrics, sorted
+func metricNames(template *PopulationTemplate) []string {
+	names := make([]string, 0, len(template.BaseMetrics))
+	for name := range template.BaseMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,54 @@
+package population
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPopulationAnalyzer_ResolveCount verifies plain counts, numeric and
+// metric ratios, and that invalid expressions are explained
+func TestPopulationAnalyzer_ResolveCount(t *testing.T) {
+	analyzer := NewPopulationAnalyzer(nil)
+
+	tests := []struct {
+		expr     string
+		expected int
+	}{
+		{"1000", 1000},
+		{" 42 ", 42},
+		{"500 beds * 7.5", 3750},
+		{"500beds*7.5", 3750},
+		{"1 bed * 7.5", 7},
+		{"2K stores", 2000},
+		{"12 branches * 0.5", 6},
+		// The claims metric is 7.5 per bed, capped at 200
+		{"500 beds * claims", 200},
+		{"10 beds * claims", 75},
+		// and at least 20
+		{"1 bed * claims", 20},
+	}
+	for _, tt := range tests {
+		count, err := analyzer.ResolveCount(tt.expr)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.expr, err)
+			continue
+		}
+		if count != tt.expected {
+			t.Errorf("%q: expected %d, got %d", tt.expr, tt.expected, count)
+		}
+	}
+
+	errors := map[string]string{
+		"0":                "must be positive",
+		"beds * 7.5":       "needs a base count",
+		"500 widgets * 2":  "unknown base unit",
+		"500 beds * wards": "unknown hospital metric",
+		"500 beds + 2":     "invalid count expression",
+		"1 bed * 0.1":      "resolves to 0 records",
+	}
+	for expr, expected := range errors {
+		if _, err := analyzer.ResolveCount(expr); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q: expected an error containing %q, got %v", expr, expected, err)
+		}
+	}
+}
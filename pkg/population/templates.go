@@ -396,3 +396,76 @@ func GetInsuranceTemplate() *PopulationTemplate {
 		},
 	}
 }
+
+// GetTelecomTemplate returns a mobile carrier template keyed on subscribers
+func GetTelecomTemplate() *PopulationTemplate {
+	return &PopulationTemplate{
+		Domain:      "telecom",
+		Description: "Mobile carrier with subscribers, devices, and usage records",
+		BaseMetrics: map[string]MetricRatio{
+			"call_records": {
+				Name:         "call_records",
+				Ratio:        150.0, // 150 voice calls per subscriber monthly
+				Distribution: "poisson",
+				MinValue:     1000,
+				MaxValue:     0,
+				Description:  "Monthly voice call detail records per subscriber",
+			},
+			"sms_records": {
+				Name:         "sms_records",
+				Ratio:        100.0, // 100 text messages per subscriber monthly
+				Distribution: "poisson",
+				MinValue:     1000,
+				MaxValue:     0,
+				Description:  "Monthly SMS records per subscriber",
+			},
+			"data_sessions": {
+				Name:         "data_sessions",
+				Ratio:        300.0, // 300 data sessions per subscriber monthly
+				Distribution: "poisson",
+				MinValue:     1000,
+				MaxValue:     0,
+				Description:  "Monthly data sessions per subscriber",
+			},
+			"devices": {
+				Name:         "devices",
+				Ratio:        1.3, // 1.3 devices per subscriber (phones, tablets, watches)
+				Distribution: "normal",
+				MinValue:     1,
+				MaxValue:     0,
+				Description:  "Registered devices per subscriber",
+			},
+		},
+		Schemas: []SchemaRecommendation{
+			{
+				SchemaPath:   "test/schemas/telecom/call-detail-records.json",
+				RecordType:   "call_records",
+				Priority:     "critical",
+				Dependencies: []string{"subscribers", "devices"},
+			},
+		},
+		Relationships: []RelationshipRule{
+			{
+				ParentType:   "subscribers",
+				ChildType:    "devices",
+				Relationship: "one-to-many",
+				Ratio:        1.3,
+				Description:  "Each subscriber registers one or more devices",
+			},
+			{
+				ParentType:   "subscribers",
+				ChildType:    "call_records",
+				Relationship: "one-to-many",
+				Ratio:        150.0,
+				Description:  "Each subscriber places many calls",
+			},
+			{
+				ParentType:   "subscribers",
+				ChildType:    "data_sessions",
+				Relationship: "one-to-many",
+				Ratio:        300.0,
+				Description:  "Each subscriber opens many data sessions",
+			},
+		},
+	}
+}
@@ -0,0 +1,146 @@
+// Package regexgen generates random strings that match regular expressions
+package regexgen
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"regexp/syntax"
+	"strings"
+)
+
+// maxRepeat caps how many extra repetitions unbounded quantifiers
+// (*, + and {n,}) produce
+const maxRepeat = 5
+
+// anyChars is the alphabet used for "." in patterns
+const anyChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Generate returns a random string matching a regular expression by walking
+// its syntax tree. The same rng state always yields the same string.
+func Generate(pattern string, rng *mathrand.Rand) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", fmt.Errorf("unsupported pattern %q: %w", pattern, err)
+	}
+
+	var sb strings.Builder
+	if err := generateRegexp(re.Simplify(), rng, &sb); err != nil {
+		return "", fmt.Errorf("unsupported pattern %q: %w", pattern, err)
+	}
+
+	return sb.String(), nil
+}
+
+// generateRegexp appends a random match of re to sb
+func generateRegexp(re *syntax.Regexp, rng *mathrand.Rand, sb *strings.Builder) error {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine,
+		syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return nil
+
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			sb.WriteRune(r)
+		}
+		return nil
+
+	case syntax.OpCharClass:
+		r, ok := pickFromClass(re.Rune, rng)
+		if !ok {
+			return fmt.Errorf("empty character class")
+		}
+		sb.WriteRune(r)
+		return nil
+
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		sb.WriteByte(anyChars[rng.Intn(len(anyChars))])
+		return nil
+
+	case syntax.OpCapture:
+		return generateRegexp(re.Sub[0], rng, sb)
+
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := generateRegexp(sub, rng, sb); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case syntax.OpAlternate:
+		return generateRegexp(re.Sub[rng.Intn(len(re.Sub))], rng, sb)
+
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := repeatBounds(re)
+		count := min + rng.Intn(max-min+1)
+		for i := 0; i < count; i++ {
+			if err := generateRegexp(re.Sub[0], rng, sb); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case syntax.OpNoMatch:
+		return fmt.Errorf("pattern can never match")
+	}
+
+	return fmt.Errorf("unsupported regex operator %v", re.Op)
+}
+
+// repeatBounds returns the repetition range for a quantifier node
+func repeatBounds(re *syntax.Regexp) (int, int) {
+	switch re.Op {
+	case syntax.OpStar:
+		return 0, maxRepeat
+	case syntax.OpPlus:
+		return 1, 1 + maxRepeat
+	case syntax.OpQuest:
+		return 0, 1
+	}
+
+	if re.Max < 0 {
+		return re.Min, re.Min + maxRepeat
+	}
+	return re.Min, re.Max
+}
+
+// pickFromClass picks a rune from a character class given as [lo, hi]
+// range pairs. Printable ASCII is preferred so negated classes such as
+// [^0-9] do not produce control or exotic Unicode characters.
+func pickFromClass(ranges []rune, rng *mathrand.Rand) (rune, bool) {
+	printable := make([]rune, 0, len(ranges))
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if lo < ' ' {
+			lo = ' '
+		}
+		if hi > '~' {
+			hi = '~'
+		}
+		if lo <= hi {
+			printable = append(printable, lo, hi)
+		}
+	}
+	if len(printable) > 0 {
+		ranges = printable
+	}
+
+	total := 0
+	for i := 0; i+1 < len(ranges); i += 2 {
+		total += int(ranges[i+1]-ranges[i]) + 1
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	n := rng.Intn(total)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		size := int(ranges[i+1]-ranges[i]) + 1
+		if n < size {
+			return ranges[i] + rune(n), true
+		}
+		n -= size
+	}
+
+	return ranges[0], true
+}
@@ -0,0 +1,45 @@
+package regexgen
+
+import (
+	mathrand "math/rand"
+	"regexp"
+	"testing"
+)
+
+// TestGenerate_PropertyBased verifies generated strings match their pattern
+// and that the same seed gives the same string
+func TestGenerate_PropertyBased(t *testing.T) {
+	patterns := []string{
+		`^MRN-[0-9]{6}$`,
+		`^[A-Z]{2}\d{2}[A-Z0-9]{4,10}$`,
+		`^(ICD|CPT)-[a-f0-9]+(\.[0-9]{1,2})?$`,
+		`^[^0-9\s]{3}x*$`,
+		`^\w+@example\.(com|org)$`,
+		`^.{5}$`,
+	}
+
+	for _, pattern := range patterns {
+		re := regexp.MustCompile(pattern)
+		for seed := int64(1); seed <= 100; seed++ {
+			value, err := Generate(pattern, mathrand.New(mathrand.NewSource(seed)))
+			if err != nil {
+				t.Fatalf("Failed to generate for %s: %v", pattern, err)
+			}
+			if !re.MatchString(value) {
+				t.Errorf("Generated '%s' does not match %s (seed: %d)", value, pattern, seed)
+			}
+			if again, _ := Generate(pattern, mathrand.New(mathrand.NewSource(seed))); again != value {
+				t.Errorf("Non-deterministic generation for %s: '%s' != '%s' (seed: %d)", pattern, value, again, seed)
+			}
+		}
+	}
+}
+
+// TestGenerate_Unsupported verifies patterns that cannot be generated fail
+func TestGenerate_Unsupported(t *testing.T) {
+	for _, pattern := range []string{`[a-`, `[^\x00-\x{10FFFF}]`} {
+		if value, err := Generate(pattern, mathrand.New(mathrand.NewSource(1))); err == nil {
+			t.Errorf("Expected %s to fail, got '%s'", pattern, value)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ISODuration is an ISO 8601 duration such as P3DT4H, split into its
+// components. Weeks appear only on their own, as in P2W.
+type ISODuration struct {
+	Years, Months, Weeks, Days int64
+	Hours, Minutes, Seconds    int64
+}
+
+// ParseISODuration parses a duration in the RFC 3339 grammar that JSON
+// Schema's duration format uses: P followed by date components in Y, M, D
+// order, then T and time components in H, M, S order, or a number of weeks
+// alone. Components are whole numbers and at least one must be present.
+func ParseISODuration(text string) (ISODuration, error) {
+	var d ISODuration
+	rest, ok := strings.CutPrefix(text, "P")
+	if !ok || rest == "" {
+		return d, fmt.Errorf("duration must start with P and have a component, got %q", text)
+	}
+
+	if strings.HasSuffix(rest, "W") {
+		if err := parseDurationComponents(rest, "W", []*int64{&d.Weeks}); err != nil {
+			return d, fmt.Errorf("invalid duration %q: %w", text, err)
+		}
+		return d, nil
+	}
+
+	datePart, timePart, hasTime := strings.Cut(rest, "T")
+	if hasTime && timePart == "" {
+		return d, fmt.Errorf("duration %q has T but no time components", text)
+	}
+	if err := parseDurationComponents(datePart, "YMD", []*int64{&d.Years, &d.Months, &d.Days}); err != nil {
+		return d, fmt.Errorf("invalid duration %q: %w", text, err)
+	}
+	if err := parseDurationComponents(timePart, "HMS", []*int64{&d.Hours, &d.Minutes, &d.Seconds}); err != nil {
+		return d, fmt.Errorf("invalid duration %q: %w", text, err)
+	}
+	return d, nil
+}
+
+// parseDurationComponents reads number-unit pairs whose units appear in
+// units, in order, into the matching fields
+func parseDurationComponents(text, units string, fields []*int64) error {
+	next := 0
+	for text != "" {
+		digits := 0
+		for digits < len(text) && text[digits] >= '0' && text[digits] <= '9' {
+			digits++
+		}
+		if digits == 0 {
+			return fmt.Errorf("missing number before %q", text)
+		}
+		if digits == len(text) {
+			return fmt.Errorf("missing unit after %s", text)
+		}
+
+		unit := strings.IndexByte(units[next:], text[digits])
+		if unit == -1 {
+			return fmt.Errorf("unit %q is unknown or out of order", text[digits])
+		}
+		n, err := strconv.ParseInt(text[:digits], 10, 64)
+		if err != nil {
+			return err
+		}
+		*fields[next+unit] = n
+		next += unit + 1
+		text = text[digits+1:]
+	}
+	return nil
+}
+
+// Fixed returns the length of a duration without years or months, whose
+// length depends on the calendar. It reports false for those.
+func (d ISODuration) Fixed() (time.Duration, bool) {
+	if d.Years != 0 || d.Months != 0 {
+		return 0, false
+	}
+	seconds := ((d.Weeks*7+d.Days)*24+d.Hours)*3600 + d.Minutes*60 + d.Seconds
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,58 @@
+package schema
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseISODuration verifies the RFC 3339 duration grammar, including
+// zero and mixed date and time components
+func TestParseISODuration(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected ISODuration
+		length   time.Duration // zero for calendar durations
+		valid    bool
+	}{
+		{text: "PT0S", length: 0, valid: true},
+		{text: "P3DT4H", expected: ISODuration{Days: 3, Hours: 4}, length: 76 * time.Hour, valid: true},
+		{text: "PT90M", expected: ISODuration{Minutes: 90}, length: 90 * time.Minute, valid: true},
+		{text: "P1DT2H3M4S", expected: ISODuration{Days: 1, Hours: 2, Minutes: 3, Seconds: 4}, length: 26*time.Hour + 3*time.Minute + 4*time.Second, valid: true},
+		{text: "P2W", expected: ISODuration{Weeks: 2}, length: 14 * 24 * time.Hour, valid: true},
+		{text: "P1Y2M", expected: ISODuration{Years: 1, Months: 2}, valid: true},
+		{text: "P", valid: false},
+		{text: "PT", valid: false},
+		{text: "3D", valid: false},
+		{text: "P1H", valid: false},
+		{text: "PT1D", valid: false},
+		{text: "PT4H3H", valid: false},
+		{text: "P1D2W", valid: false},
+		{text: "PT1.5S", valid: false},
+		{text: "P-1D", valid: false},
+	}
+
+	for _, tc := range testCases {
+		duration, err := ParseISODuration(tc.text)
+		if !tc.valid {
+			if err == nil {
+				t.Errorf("Expected %q to be invalid, got %+v", tc.text, duration)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected %q to be valid, got: %v", tc.text, err)
+			continue
+		}
+		if duration != tc.expected {
+			t.Errorf("Expected %q to parse as %+v, got %+v", tc.text, tc.expected, duration)
+		}
+
+		length, fixed := duration.Fixed()
+		if calendar := tc.expected.Years != 0 || tc.expected.Months != 0; fixed == calendar {
+			t.Errorf("Expected %q fixed to be %v", tc.text, !calendar)
+		}
+		if fixed && length != tc.length {
+			t.Errorf("Expected %q to last %s, got %s", tc.text, tc.length, length)
+		}
+	}
+}
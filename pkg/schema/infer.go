@@ -0,0 +1,289 @@
+package schema
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// DefaultMaxEnum is the largest number of distinct values a string field may
+// have to be inferred as an enum
+const DefaultMaxEnum = 10
+
+// inferFormats are the string formats recognized by inference, in the order
+// they are tried
+var inferFormats = []struct {
+	name  string
+	match func(string) bool
+}{
+	{"uuid", regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString},
+	{"date-time", func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	}},
+	{"date", func(s string) bool {
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	}},
+	{"email", regexp.MustCompile(`^[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}$`).MatchString},
+}
+
+// Inferrer builds a JSON Schema from sample values. Each value passed to
+// Add is merged into a running summary, so a sample of any size is read
+// once in memory bounded by the number of distinct fields.
+type Inferrer struct {
+	root    *fieldSummary
+	maxEnum int
+}
+
+// fieldSummary accumulates what has been seen at one schema location
+type fieldSummary struct {
+	types map[string]int
+
+	// Strings
+	values    map[string]bool // distinct values, nil once there are too many
+	strings   int
+	format    string
+	hasFormat bool
+	minLength int
+	maxLength int
+
+	// Numbers
+	minimum float64
+	maximum float64
+	numbers int
+	cents   bool // every number is a multiple of 0.01
+
+	// Objects
+	objects    int
+	properties map[string]*fieldSummary
+	present    map[string]int
+
+	// Arrays
+	items    *fieldSummary
+	arrays   int
+	minItems int
+	maxItems int
+}
+
+// NewInferrer creates an inferrer that turns string fields with at most
+// maxEnum distinct values into enums. A maxEnum of 0 disables enums.
+func NewInferrer(maxEnum int) *Inferrer {
+	return &Inferrer{root: newFieldSummary(), maxEnum: maxEnum}
+}
+
+func newFieldSummary() *fieldSummary {
+	return &fieldSummary{types: make(map[string]int), values: make(map[string]bool), cents: true}
+}
+
+// Add merges a decoded JSON value into the inferred schema
+func (inf *Inferrer) Add(value interface{}) {
+	inf.root.add(value, inf.maxEnum)
+}
+
+func (f *fieldSummary) add(value interface{}, maxEnum int) {
+	switch v := value.(type) {
+	case nil:
+		f.types["null"]++
+
+	case bool:
+		f.types["boolean"]++
+
+	case float64:
+		if v == math.Trunc(v) {
+			f.types["integer"]++
+		} else {
+			f.types["number"]++
+		}
+		if f.numbers == 0 || v < f.minimum {
+			f.minimum = v
+		}
+		if f.numbers == 0 || v > f.maximum {
+			f.maximum = v
+		}
+		f.numbers++
+		if scaled := v * 100; math.Abs(scaled-math.Round(scaled)) > 1e-9 {
+			f.cents = false
+		}
+
+	case string:
+		f.types["string"]++
+		length := len([]rune(v))
+		if f.strings == 0 || length < f.minLength {
+			f.minLength = length
+		}
+		if f.strings == 0 || length > f.maxLength {
+			f.maxLength = length
+		}
+		f.strings++
+
+		format := detectFormat(v)
+		if !f.hasFormat {
+			f.format, f.hasFormat = format, true
+		} else if f.format != format {
+			f.format = ""
+		}
+
+		if f.values != nil {
+			f.values[v] = true
+			if len(f.values) > maxEnum {
+				f.values = nil
+			}
+		}
+
+	case map[string]interface{}:
+		f.types["object"]++
+		f.objects++
+		if f.properties == nil {
+			f.properties = make(map[string]*fieldSummary)
+			f.present = make(map[string]int)
+		}
+		for key, item := range v {
+			prop, ok := f.properties[key]
+			if !ok {
+				prop = newFieldSummary()
+				f.properties[key] = prop
+			}
+			prop.add(item, maxEnum)
+			f.present[key]++
+		}
+
+	case []interface{}:
+		f.types["array"]++
+		if f.arrays == 0 || len(v) < f.minItems {
+			f.minItems = len(v)
+		}
+		if f.arrays == 0 || len(v) > f.maxItems {
+			f.maxItems = len(v)
+		}
+		f.arrays++
+		for _, item := range v {
+			if f.items == nil {
+				f.items = newFieldSummary()
+			}
+			f.items.add(item, maxEnum)
+		}
+	}
+}
+
+// detectFormat returns the first inferFormats entry matching s, or ""
+func detectFormat(s string) string {
+	for _, format := range inferFormats {
+		if format.match(s) {
+			return format.name
+		}
+	}
+	return ""
+}
+
+// Schema returns the inferred JSON Schema for every value added so far
+func (inf *Inferrer) Schema() map[string]interface{} {
+	schema := inf.root.schema()
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	return schema
+}
+
+func (f *fieldSummary) schema() map[string]interface{} {
+	schema := make(map[string]interface{})
+
+	// Integers widen to numbers when both are seen
+	if f.types["integer"] > 0 && f.types["number"] > 0 {
+		f.types["number"] += f.types["integer"]
+		delete(f.types, "integer")
+	}
+	types := make([]string, 0, len(f.types))
+	for t := range f.types {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	switch len(types) {
+	case 0:
+		return schema
+	case 1:
+		schema["type"] = types[0]
+	default:
+		schema["type"] = types
+	}
+
+	if f.numbers > 0 {
+		schema["minimum"] = f.minimum
+		schema["maximum"] = f.maximum
+		if f.types["number"] > 0 && f.cents {
+			schema["multipleOf"] = 0.01
+		}
+	}
+
+	if f.strings > 0 {
+		switch {
+		case f.format != "":
+			schema["format"] = f.format
+		case f.isEnum():
+			values := make([]string, 0, len(f.values))
+			for value := range f.values {
+				values = append(values, value)
+			}
+			sort.Strings(values)
+			enum := make([]interface{}, 0, len(values)+1)
+			for _, value := range values {
+				enum = append(enum, value)
+			}
+			// A nullable enum must list null to accept it
+			if f.types["null"] > 0 {
+				enum = append(enum, nil)
+			}
+			schema["enum"] = enum
+		default:
+			schema["minLength"] = f.minLength
+			schema["maxLength"] = f.maxLength
+		}
+	}
+
+	if f.objects > 0 {
+		properties := make(map[string]interface{}, len(f.properties))
+		var required []string
+		for key, prop := range f.properties {
+			properties[key] = prop.schema()
+			if f.present[key] == f.objects {
+				required = append(required, key)
+			}
+		}
+		schema["properties"] = properties
+		if len(required) > 0 {
+			sort.Strings(required)
+			schema["required"] = required
+		}
+	}
+
+	if f.arrays > 0 {
+		if f.items != nil {
+			schema["items"] = f.items.schema()
+		}
+		schema["minItems"] = f.minItems
+		schema["maxItems"] = f.maxItems
+	}
+
+	return schema
+}
+
+// isEnum reports whether a string field repeats few enough values to be an
+// enum. Each value must be seen twice on average, so a small sample of
+// free-form strings is not mistaken for one.
+func (f *fieldSummary) isEnum() bool {
+	if len(f.values) == 0 {
+		return false
+	}
+	// An enum holds every value of the field, strings or not
+	return f.strings == f.total() && f.strings >= 2*len(f.values)
+}
+
+// total returns the number of non-null values seen
+func (f *fieldSummary) total() int {
+	total := 0
+	for t, count := range f.types {
+		if t != "null" {
+			total += count
+		}
+	}
+	return total
+}
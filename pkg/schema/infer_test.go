@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestInferSchema verifies types, formats, enums and required fields are
+// inferred across records, and that every sample satisfies the result
+func TestInferSchema(t *testing.T) {
+	samples := []string{
+		`{"id": "0b6b2c3e-5f1a-4c7e-9a2d-1e2f3a4b5c6d", "email": "a@example.com", "status": "active", "joined": "2024-01-05", "score": 3, "address": {"city": "Oslo", "zip": "0150"}, "tags": ["a", "b"], "note": "first"}`,
+		`{"id": "7d1e8f9a-0b1c-4d2e-8f3a-4b5c6d7e8f90", "email": "b@example.org", "status": "inactive", "joined": "2023-11-30", "score": 4.25, "address": {"city": "Bergen"}, "tags": [], "note": null}`,
+		`{"id": "c2d3e4f5-a6b7-4c8d-9e0f-a1b2c3d4e5f6", "email": "c@example.net", "status": "active", "joined": "2022-06-15", "score": 10, "address": {"city": "Oslo", "zip": "0151"}, "tags": ["c"]}`,
+		`{"id": "e5f6a7b8-c9d0-4e1f-a2b3-c4d5e6f7a8b9", "email": "d@example.com", "status": "active", "joined": "2021-02-28", "score": 7.5, "address": {"city": "Oslo"}, "tags": ["a"]}`,
+	}
+
+	inferrer := NewInferrer(DefaultMaxEnum)
+	var records []interface{}
+	for _, sample := range samples {
+		var record interface{}
+		if err := json.Unmarshal([]byte(sample), &record); err != nil {
+			t.Fatalf("Failed to decode sample: %v", err)
+		}
+		inferrer.Add(record)
+		records = append(records, record)
+	}
+
+	inferred := inferrer.Schema()
+	properties := inferred["properties"].(map[string]interface{})
+	property := func(name string) map[string]interface{} {
+		return properties[name].(map[string]interface{})
+	}
+
+	if got := inferred["required"]; !reflect.DeepEqual(got, []string{"address", "email", "id", "joined", "score", "status", "tags"}) {
+		t.Errorf("Expected every field but note to be required, got %v", got)
+	}
+	for name, format := range map[string]string{"id": "uuid", "email": "email", "joined": "date"} {
+		if got := property(name)["format"]; got != format {
+			t.Errorf("Expected %s to have format %s, got %v", name, format, got)
+		}
+	}
+	if got := property("status")["enum"]; !reflect.DeepEqual(got, []interface{}{"active", "inactive"}) {
+		t.Errorf("Expected status to be an enum, got %v", got)
+	}
+	if got := property("score")["type"]; got != "number" {
+		t.Errorf("Expected integers and numbers to merge into number, got %v", got)
+	}
+	if got := property("score")["multipleOf"]; got != 0.01 {
+		t.Errorf("Expected score to be a multiple of 0.01, got %v", got)
+	}
+	if got := property("note")["type"]; !reflect.DeepEqual(got, []string{"null", "string"}) {
+		t.Errorf("Expected note to be a nullable string, got %v", got)
+	}
+	if got := property("address")["required"]; !reflect.DeepEqual(got, []string{"city"}) {
+		t.Errorf("Expected only address.city to be required, got %v", got)
+	}
+	tags := property("tags")
+	if tags["minItems"] != 0 || tags["maxItems"] != 2 || tags["items"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("Expected tags to be an array of 0 to 2 strings, got %v", tags)
+	}
+
+	data, err := json.Marshal(inferred)
+	if err != nil {
+		t.Fatalf("Failed to encode schema: %v", err)
+	}
+	parser := NewParser()
+	if err := parser.ParseBytes(data); err != nil {
+		t.Fatalf("Failed to parse inferred schema: %v", err)
+	}
+	if _, err := parser.GetRootNode(); err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	for i, record := range records {
+		if err := parser.Validate(record); err != nil {
+			t.Errorf("Expected sample %d to satisfy the inferred schema: %v", i, err)
+		}
+	}
+}
+
+// TestInferSchema_Enum verifies free-form strings in a small sample are not
+// taken for enums
+func TestInferSchema_Enum(t *testing.T) {
+	inferrer := NewInferrer(DefaultMaxEnum)
+	for _, name := range []string{"Ada", "Grace", "Linus"} {
+		inferrer.Add(map[string]interface{}{"name": name})
+	}
+	name := inferrer.Schema()["properties"].(map[string]interface{})["name"].(map[string]interface{})
+	if _, ok := name["enum"]; ok {
+		t.Errorf("Expected distinct names not to become an enum, got %v", name)
+	}
+	if name["minLength"] != 3 || name["maxLength"] != 5 {
+		t.Errorf("Expected name lengths 3 to 5, got %v", name)
+	}
+}
@@ -1,48 +1,236 @@
 package schema
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
 )
 
 // Parser handles JSON Schema parsing and validation
 type Parser struct {
 	compiler *jsonschema.Compiler
 	schema   *jsonschema.Schema
+	item     *jsonschema.Schema // items schema of an array root, for validating single records
 	raw      map[string]interface{}
+	warnings []SchemaWarning
+	refDepth map[string]int
+}
+
+// SchemaWarning describes a schema construct that SpecMint ignores during generation
+type SchemaWarning struct {
+	Path    string `json:"path"`
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+// unsupportedKeywords lists JSON Schema keywords that are accepted in a schema
+// but not honored during generation
+var unsupportedKeywords = map[string]bool{
+	"$dynamicRef":           true,
+	"$recursiveRef":         true,
+	"$vocabulary":           true,
+	"not":                   true,
+	"unevaluatedItems":      true,
+	"propertyNames":         true,
+	"unevaluatedProperties": true,
+	"contentSchema":         true,
+	"contentMediaType":      true,
+	"contentEncoding":       true,
 }
 
 // SchemaNode represents a parsed schema node with metadata
 type SchemaNode struct {
-	Type        string                 `json:"type"`
-	Properties  map[string]*SchemaNode `json:"properties,omitempty"`
-	Items       *SchemaNode            `json:"items,omitempty"`
-	Required    []string               `json:"required,omitempty"`
-	Enum        []interface{}          `json:"enum,omitempty"`
-	Examples    []interface{}          `json:"examples,omitempty"`
-	Format      string                 `json:"format,omitempty"`
-	Pattern     string                 `json:"pattern,omitempty"`
-	MinLength   *int                   `json:"minLength,omitempty"`
-	MaxLength   *int                   `json:"maxLength,omitempty"`
-	Minimum     *float64               `json:"minimum,omitempty"`
-	Maximum     *float64               `json:"maximum,omitempty"`
-	MinItems    *int                   `json:"minItems,omitempty"`
-	MaxItems    *int                   `json:"maxItems,omitempty"`
-	MultipleOf  *float64               `json:"multipleOf,omitempty"`
-	Description string                 `json:"description,omitempty"`
+	Type          string                 `json:"type"`
+	Properties    map[string]*SchemaNode `json:"properties,omitempty"`
+	Items         *SchemaNode            `json:"items,omitempty"`
+	Required      []string               `json:"required,omitempty"`
+	Enum          []interface{}          `json:"enum,omitempty"`
+	Const         interface{}            `json:"const,omitempty"`
+	HasConst      bool                   `json:"-"` // set when const is present, since the constant may be null
+	Default       interface{}            `json:"default,omitempty"`
+	HasDefault    bool                   `json:"-"` // set when default is present, since the default may be null
+	Examples      []interface{}          `json:"examples,omitempty"`
+	Format        string                 `json:"format,omitempty"`
+	Pattern       string                 `json:"pattern,omitempty"`
+	MinLength     *int                   `json:"minLength,omitempty"`
+	MaxLength     *int                   `json:"maxLength,omitempty"`
+	Minimum       *float64               `json:"minimum,omitempty"`
+	Maximum       *float64               `json:"maximum,omitempty"`
+	MinItems      *int                   `json:"minItems,omitempty"`
+	MaxItems      *int                   `json:"maxItems,omitempty"`
+	MinProperties *int                   `json:"minProperties,omitempty"`
+	MaxProperties *int                   `json:"maxProperties,omitempty"`
+	UniqueItems   bool                   `json:"uniqueItems,omitempty"`
+	Contains      *SchemaNode            `json:"contains,omitempty"`
+	MinContains   int                    `json:"minContains,omitempty"` // items that must match Contains, 1 unless set
+	MaxContains   *int                   `json:"maxContains,omitempty"`
+	MultipleOf    *float64               `json:"multipleOf,omitempty"`
+	Description   string                 `json:"description,omitempty"`
+	Variants      []*SchemaNode          `json:"-"` // anyOf/oneOf branches, one is picked per value
+	Types         []string               `json:"-"` // allowed types when "type" is an array
+	TupleItems    []*SchemaNode          `json:"-"` // positional item schemas from items arrays or prefixItems
+	ClosedTuple   bool                   `json:"-"` // no items allowed after TupleItems
+
+	// Schemas for undeclared keys: PatternProperties for keys matching a
+	// regex, AdditionalProperties when additionalProperties is a schema
+	PatternProperties    []*PatternProperty `json:"-"`
+	AdditionalProperties *SchemaNode        `json:"-"`
+
+	// if/then/else on an object, including those combined through allOf
+	Conditionals []*Conditional `json:"-"`
+
+	// Properties that must be present, or schemas that must hold, when the
+	// keyed property is present
+	DependentRequired map[string][]string    `json:"dependentRequired,omitempty"`
+	DependentSchemas  map[string]*Dependency `json:"-"`
+
+	// Exclusive numeric bounds
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
 
 	// SpecMint extensions
-	LLMEnhanced     bool             `json:"x-llm,omitempty"`
-	CrossFieldRules []CrossFieldRule `json:"x-cross-field-rules,omitempty"`
+	LLMEnhanced     bool                `json:"x-llm,omitempty"`
+	LLMPrompt       *template.Template  `json:"-"` // from x-llm-prompt, renders the field's enrichment prompt from the record
+	WMI             string              `json:"x-wmi,omitempty"`
+	Generator       string              `json:"x-generator,omitempty"`    // named synthetic generator for string values
+	Reference       string              `json:"x-ref,omitempty"`          // record_type.field whose generated values this field samples
+	EnumWeights     []float64           `json:"x-enum-weights,omitempty"` // relative likelihood of each enum value, normalized to sum to 1
+	DefaultProb     float64             `json:"x-default-prob,omitempty"` // chance of emitting Default instead of a generated value
+	GeoIP           *GeoIPSpec          `json:"x-geoip,omitempty"`
+	Distribution    *Distribution       `json:"x-distribution,omitempty"`
+	LengthDist      *LengthDistribution `json:"x-length-distribution,omitempty"` // shapes array lengths within minItems and maxItems
+	Location        *time.Location      `json:"-"`                               // from x-timezone, overrides the configured timezone for dates
+	TimeRange       *TimeRange          `json:"x-time-range,omitempty"`          // bounds the wall-clock times of a time field
+	DurationRange   *DurationRange      `json:"x-duration-range,omitempty"`      // bounds the length of a duration field
+	Locale          string              `json:"x-locale,omitempty"`              // overrides the configured locale for names, addresses and phones
+	SeedSalt        string              `json:"x-seed-salt,omitempty"`           // perturbs the field's seed so it alone is re-randomized
+	Nullable        bool                `json:"x-nullable,omitempty"`            // the field may be null even if its type does not say so
+	NullRate        *float64            `json:"x-null-rate,omitempty"`           // chance of null for a field that allows it, overrides the configured rate
+	OptionalWhen    []OptionalCondition `json:"x-optional-when,omitempty"`       // overrides OptionalProb depending on a sibling's value
+	CrossFieldRules []CrossFieldRule    `json:"x-cross-field-rules,omitempty"`
 
 	// Internal metadata
 	Path         string  `json:"-"`
 	IsRequired   bool    `json:"-"`
 	OptionalProb float64 `json:"-"`
+
+	// truncated marks a recursive $ref that was cut off at maxRefDepth
+	truncated bool
+}
+
+// HasType reports whether the node allows values of type t
+func (n *SchemaNode) HasType(t string) bool {
+	if len(n.Types) == 0 {
+		return n.Type == t
+	}
+	for _, allowed := range n.Types {
+		if allowed == t {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsNull reports whether null may be generated for the node, because
+// its type list includes "null" or it has x-nullable
+func (n *SchemaNode) AllowsNull() bool {
+	return n.Nullable || n.HasType("null")
+}
+
+// ItemAt returns the schema for the array element at index i: the matching
+// tuple schema, or Items for elements past the tuple
+func (n *SchemaNode) ItemAt(i int) *SchemaNode {
+	if i < len(n.TupleItems) {
+		return n.TupleItems[i]
+	}
+	return n.Items
+}
+
+// RecordNode returns the schema of a single dataset record. For an array
+// root each record is one item; any other root describes the record itself.
+func (n *SchemaNode) RecordNode() *SchemaNode {
+	if n.Type == "array" && len(n.Types) == 0 && n.Items != nil {
+		return n.Items
+	}
+	return n
+}
+
+// PropertyNode returns the schema that applies to the value of key: the
+// declared property, else the first matching pattern property, else
+// AdditionalProperties. It returns nil if nothing describes the key.
+func (n *SchemaNode) PropertyNode(key string) *SchemaNode {
+	if prop, ok := n.Properties[key]; ok {
+		return prop
+	}
+	for _, pp := range n.PatternProperties {
+		if pp.Matches(key) {
+			return pp.Node
+		}
+	}
+	return n.AdditionalProperties
+}
+
+// PatternProperty is a patternProperties entry: values of keys matching
+// Pattern follow Node
+type PatternProperty struct {
+	Pattern string
+	Node    *SchemaNode
+	re      *regexp.Regexp
+}
+
+// Matches reports whether key matches the pattern
+func (pp *PatternProperty) Matches(key string) bool {
+	return pp.re.MatchString(key)
+}
+
+// Conditional is an if/then/else: an object that satisfies the if schema
+// must also satisfy then, otherwise else. Then and Else are the object's own
+// schema with the branch merged in; either is nil when the branch is absent.
+type Conditional struct {
+	Then     *SchemaNode
+	Else     *SchemaNode
+	ThenKeys []string // properties then constrains or requires, sorted
+	ElseKeys []string
+	test     *jsonschema.Schema
+}
+
+// Matches reports whether value satisfies the if schema
+func (c *Conditional) Matches(value interface{}) bool {
+	return c.test.Validate(exactNumbers(value)) == nil
+}
+
+// Branch returns the schema value must also satisfy and the properties that
+// schema constrains, or nil if the branch that applies is absent
+func (c *Conditional) Branch(value interface{}) (*SchemaNode, []string) {
+	if c.Matches(value) {
+		return c.Then, c.ThenKeys
+	}
+	return c.Else, c.ElseKeys
+}
+
+// Dependency is a dependentSchemas entry. Schema is the object's own schema
+// with the dependent schema merged in.
+type Dependency struct {
+	Schema *SchemaNode
+	Keys   []string // properties the dependent schema constrains or requires, sorted
 }
 
 // CrossFieldRule represents a cross-field validation rule
@@ -51,86 +239,509 @@ type CrossFieldRule struct {
 	Description string     `json:"description"`
 	Fields      []string   `json:"fields"`
 	Rule        string     `json:"rule"`
-	Constraint  string     `json:"constraint,omitempty"` // for comparison rules
+	Constraint  string     `json:"constraint,omitempty"` // for comparison and regex_match rules
 	Severity    string     `json:"severity"`             // error, warning
 	Patch       *PatchRule `json:"patch,omitempty"`
 }
 
+// GeoIPSpec names the object fields that receive a coherent IP address and
+// location, so the IP always falls in a range assigned to the stated country
+type GeoIPSpec struct {
+	IPField          string   `json:"ip"`
+	CountryField     string   `json:"country,omitempty"`      // ISO 3166-1 alpha-2 code
+	CountryNameField string   `json:"country_name,omitempty"` // English country name
+	CityField        string   `json:"city,omitempty"`
+	Weighted         bool     `json:"weighted,omitempty"`  // pick countries by population
+	Countries        []string `json:"countries,omitempty"` // restrict to these country codes
+}
+
+// Distribution shapes numeric values within their range. Mean and StdDev
+// describe the value for normal; for lognormal they are the mean and
+// standard deviation of the value's logarithm. Exponential uses Mean only.
+type Distribution struct {
+	Type   string   `json:"type"` // uniform, normal, lognormal, exponential
+	Mean   *float64 `json:"mean,omitempty"`
+	StdDev *float64 `json:"stddev,omitempty"`
+}
+
+// LengthDistribution shapes the lengths of an array within minItems and
+// maxItems. Poisson draws a count with mean Lambda; geometric counts the
+// trials until the first success with probability P, so short arrays are
+// the most common. Draws outside the bounds are clamped to them.
+type LengthDistribution struct {
+	Type   string   `json:"type"` // uniform, poisson, geometric
+	Lambda *float64 `json:"lambda,omitempty"`
+	P      *float64 `json:"p,omitempty"`
+}
+
+// OptionalCondition overrides the chance that an optional property is
+// generated when a sibling property has a given value, such as a
+// discharge_date that is almost always present when status is discharged
+type OptionalCondition struct {
+	Field       string      `json:"field"`
+	Equals      interface{} `json:"equals"`
+	Probability float64     `json:"probability"`
+}
+
+// Matches reports whether the condition holds for an object's properties.
+// Values are compared as JSON, so a generated integer 3 equals the 3 parsed
+// from the schema.
+func (c OptionalCondition) Matches(properties map[string]interface{}) bool {
+	value, ok := properties[c.Field]
+	if !ok {
+		return false
+	}
+	actual, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	expected, err := json.Marshal(c.Equals)
+	return err == nil && bytes.Equal(actual, expected)
+}
+
+// TimeRange bounds the times of day generated for a format time field, such
+// as business hours. Start and End are seconds since midnight, inclusive,
+// in the field's own UTC offset.
+type TimeRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// DurationRange bounds the length of the ISO 8601 durations generated for a
+// format duration field, both inclusive
+type DurationRange struct {
+	Min time.Duration `json:"min"`
+	Max time.Duration `json:"max"`
+}
+
+// DefaultDurationMax is the longest duration generated for a duration
+// field without a max
+const DefaultDurationMax = 30 * 24 * time.Hour
+
 // PatchRule defines how to fix a constraint violation
 type PatchRule struct {
-	Strategy string                 `json:"strategy"` // set_value, adjust_field, remove_field
+	Strategy string                 `json:"strategy"` // set_value, adjust_field, remove_field, regenerate, pick_member, clamp
 	Target   string                 `json:"target"`
 	Value    interface{}            `json:"value,omitempty"`
 	Params   map[string]interface{} `json:"params,omitempty"`
 }
 
+// ValidationIssue is a single schema violation at a location in the instance
+type ValidationIssue struct {
+	Path    string `json:"path"`    // JSON Pointer of the failing value
+	Keyword string `json:"keyword"` // schema keyword that failed
+	Message string `json:"message"`
+}
+
+// ValidationFailure is returned by Validate and lists every violation found
+type ValidationFailure struct {
+	Issues []ValidationIssue
+}
+
+func (e *ValidationFailure) Error() string {
+	messages := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		messages[i] = fmt.Sprintf("%s: %s", displayPointer(issue.Path), issue.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// schemaLocation is the resource URL used for schemas parsed from bytes
+const schemaLocation = "specmint://schema.json"
+
 // New creates a new schema parser
 func NewParser() *Parser {
-	compiler := jsonschema.NewCompiler()
-
 	return &Parser{
-		compiler: compiler,
+		compiler: newCompiler(),
 	}
 }
 
-// ParseFile loads and parses a JSON Schema from file
+// newCompiler returns a compiler that asserts formats, so format violations
+// are reported like any other constraint
+func newCompiler() *jsonschema.Compiler {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat()
+	return compiler
+}
+
+// ParseFile loads and parses a JSON Schema from file. Files ending in .yaml
+// or .yml are read as YAML.
 func (p *Parser) ParseFile(filename string) error {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return fmt.Errorf("failed to read schema file: %w", err)
 	}
 
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return p.ParseYAML(data)
+	}
+	return p.ParseBytes(data)
+}
+
+// ParseYAML parses a JSON Schema written as YAML. The document is converted
+// to JSON and parsed exactly as a JSON schema would be.
+func (p *Parser) ParseYAML(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse schema YAML: %w", err)
+	}
+	keepTimestamps(&doc)
+
+	var value interface{}
+	if err := doc.Decode(&value); err != nil {
+		return fmt.Errorf("failed to parse schema YAML: %w", err)
+	}
+	value = normalizeYAML(value)
+	if _, ok := value.(map[string]interface{}); !ok {
+		return fmt.Errorf("failed to parse schema YAML: the document must be a mapping")
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to convert schema YAML to JSON: %w", err)
+	}
 	return p.ParseBytes(data)
 }
 
+// keepTimestamps retags unquoted dates and times as strings. YAML would
+// otherwise decode them as timestamps, and JSON Schema has none, so a
+// default of 2024-01-01 must stay exactly that string.
+func keepTimestamps(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!timestamp" {
+		node.Tag = "!!str"
+	}
+	for _, child := range node.Content {
+		keepTimestamps(child)
+	}
+}
+
+// normalizeYAML converts decoded YAML into the shapes encoding/json
+// produces, turning mappings with non-string keys, such as HTTP status
+// codes, into map[string]interface{}
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeYAML(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = normalizeYAML(item)
+		}
+		return result
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYAML(item)
+		}
+		return v
+	}
+	return value
+}
+
 // ParseBytes parses a JSON Schema from bytes
 func (p *Parser) ParseBytes(data []byte) error {
 	// Parse raw schema for extensions
+	p.raw = nil
 	if err := json.Unmarshal(data, &p.raw); err != nil {
+		if line, column, ok := errorLocation(data, err); ok {
+			return fmt.Errorf("failed to parse schema JSON at line %d, column %d: %w", line, column, err)
+		}
+		return fmt.Errorf("failed to parse schema JSON: %w", err)
+	}
+
+	// Fields with x-nullable must accept the nulls generated for them
+	if nullable, changed := allowNullable(p.raw); changed {
+		encoded, err := json.Marshal(nullable)
+		if err != nil {
+			return fmt.Errorf("failed to encode schema: %w", err)
+		}
+		data = encoded
+	}
+
+	// Compile the schema for validation
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
 		return fmt.Errorf("failed to parse schema JSON: %w", err)
 	}
 
-	// For now, skip JSON Schema validation and just use the raw schema
-	// This allows us to process the schema structure without validation library issues
-	p.schema = nil // We'll work directly with p.raw
+	p.compiler = newCompiler()
+	if err := p.compiler.AddResource(schemaLocation, doc); err != nil {
+		return fmt.Errorf("failed to load schema: %w", err)
+	}
+
+	compiled, err := p.compiler.Compile(schemaLocation)
+	if err != nil {
+		return fmt.Errorf("failed to compile schema: %w", err)
+	}
+	p.schema = compiled
+
+	// An array root describes a dataset whose records are its items
+	p.item = nil
+	if _, ok := p.raw["items"].(map[string]interface{}); ok && p.raw["type"] == "array" {
+		item, err := p.compiler.Compile(schemaLocation + "#/items")
+		if err != nil {
+			return fmt.Errorf("failed to compile array items schema: %w", err)
+		}
+		p.item = item
+	}
+
 	return nil
 }
 
+// CanonicalHash returns the SHA-256 of the loaded schema in a canonical
+// form, compact JSON with sorted keys, so formatting changes and YAML or
+// JSON spelling of the same schema give the same hash
+func (p *Parser) CanonicalHash() (string, error) {
+	if p.raw == nil {
+		return "", fmt.Errorf("no schema loaded")
+	}
+	canonical, err := json.Marshal(p.raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode schema: %w", err)
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// errorLocation returns the 1-based line and column of a JSON decoding error
+// in data, if the error carries an offset
+func errorLocation(data []byte, err error) (line, column int, ok bool) {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+		if syntaxErr.Error() != "unexpected end of JSON input" {
+			// The offset is just past the offending byte
+			offset--
+		}
+	case errors.As(err, &typeErr):
+		// The offset is just past the first byte of the value
+		offset = typeErr.Offset - 1
+	default:
+		return 0, 0, false
+	}
+	if offset < 0 || offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	line, column = 1, 1
+	for _, b := range data[:offset] {
+		if b == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column, true
+}
+
 // GetRootNode returns the parsed root schema node
 func (p *Parser) GetRootNode() (*SchemaNode, error) {
 	if p.raw == nil {
 		return nil, fmt.Errorf("no schema loaded")
 	}
 
+	p.warnings = nil
+	p.refDepth = make(map[string]int)
 	return p.buildNode(p.raw, "", false, 0.9)
 }
 
-// Validate validates data against the loaded schema
+// Warnings returns the unsupported schema constructs found by the last call
+// to GetRootNode, ordered by path
+func (p *Parser) Warnings() []SchemaWarning {
+	warnings := make([]SchemaWarning, len(p.warnings))
+	copy(warnings, p.warnings)
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Path < warnings[j].Path
+	})
+	return warnings
+}
+
+// collectWarnings records keywords in raw that generation will ignore
+func (p *Parser) collectWarnings(raw map[string]interface{}, path string) {
+	keywords := make([]string, 0, len(raw))
+	for keyword := range raw {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+
+	for _, keyword := range keywords {
+		if unsupportedKeywords[keyword] {
+			p.warnings = append(p.warnings, SchemaWarning{
+				Path:    path,
+				Keyword: keyword,
+				Message: fmt.Sprintf("keyword %s is not supported and will be ignored during generation", keyword),
+			})
+		}
+	}
+
+	if ref, ok := raw["$ref"].(string); ok && !strings.HasPrefix(ref, "#") {
+		p.warnings = append(p.warnings, SchemaWarning{
+			Path:    path,
+			Keyword: "$ref",
+			Message: fmt.Sprintf("reference %s is not local; only references into this schema are resolved", ref),
+		})
+	}
+}
+
+// Validate validates data against the loaded schema. Violations are
+// returned as a *ValidationFailure.
 func (p *Parser) Validate(data interface{}) error {
-	// For now, skip validation since we're not using the compiled schema
-	// In a production version, we'd implement basic validation against p.raw
-	return nil
+	return p.validate(p.schema, data)
+}
+
+// ValidateItem validates a single record of an array-root schema against
+// the items schema. For other schemas it is the same as Validate.
+func (p *Parser) ValidateItem(data interface{}) error {
+	if p.item == nil {
+		return p.Validate(data)
+	}
+	return p.validate(p.item, data)
+}
+
+func (p *Parser) validate(compiled *jsonschema.Schema, data interface{}) error {
+	if compiled == nil {
+		return fmt.Errorf("no schema loaded")
+	}
+
+	err := compiled.Validate(exactNumbers(data))
+	if err == nil {
+		return nil
+	}
+
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return fmt.Errorf("schema validation error: %w", err)
+	}
+
+	failure := &ValidationFailure{}
+	collectIssues(validationErr, &failure.Issues)
+	return failure
+}
+
+// collectIssues flattens a validation error tree into its leaf violations
+func collectIssues(err *jsonschema.ValidationError, issues *[]ValidationIssue) {
+	if len(err.Causes) > 0 {
+		for _, cause := range err.Causes {
+			collectIssues(cause, issues)
+		}
+		return
+	}
+
+	keyword := ""
+	if keywordPath := err.ErrorKind.KeywordPath(); len(keywordPath) > 0 {
+		keyword = keywordPath[0]
+	}
+
+	*issues = append(*issues, ValidationIssue{
+		Path:    instancePointer(err.InstanceLocation),
+		Keyword: keyword,
+		Message: err.ErrorKind.LocalizedString(messagePrinter),
+	})
+}
+
+var messagePrinter = message.NewPrinter(language.English)
+
+// exactNumbers replaces float64 values with their shortest decimal form so
+// that constraints like multipleOf are checked against the number as written
+// rather than its binary approximation
+func exactNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64))
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = exactNumbers(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = exactNumbers(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// instancePointer renders instance location tokens as a JSON Pointer
+func instancePointer(tokens []string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "~", "~0")
+		sb.WriteByte('/')
+		sb.WriteString(strings.ReplaceAll(token, "/", "~1"))
+	}
+	return sb.String()
+}
+
+func displayPointer(pointer string) string {
+	if pointer == "" {
+		return "/"
+	}
+	return pointer
 }
 
 // buildNode recursively builds a SchemaNode from raw schema data
 func (p *Parser) buildNode(raw map[string]interface{}, path string, required bool, optionalProb float64) (*SchemaNode, error) {
+	if ref, ok := raw["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+		return p.buildRef(ref, raw, path, required, optionalProb)
+	}
+	if subschemas, ok := raw["allOf"].([]interface{}); ok {
+		return p.buildAllOf(subschemas, raw, path, required, optionalProb)
+	}
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if branches, ok := raw[keyword].([]interface{}); ok {
+			return p.buildVariants(keyword, branches, raw, path, required, optionalProb)
+		}
+	}
+
 	node := &SchemaNode{
 		Path:         path,
 		IsRequired:   required,
 		OptionalProb: optionalProb,
 	}
 
+	p.collectWarnings(raw, path)
+
 	// Extract basic type information
 	if typeVal, ok := raw["type"]; ok {
 		if typeStr, ok := typeVal.(string); ok {
 			node.Type = typeStr
 		}
+		if types := stringList(typeVal); len(types) > 0 {
+			// Type holds the first non-null type so single-type code keeps working
+			node.Types = types
+			node.Type = types[0]
+			for _, t := range types {
+				if t != "null" {
+					node.Type = t
+					break
+				}
+			}
+		}
 	}
 
 	// Extract constraints
 	if enum, ok := raw["enum"].([]interface{}); ok {
 		node.Enum = enum
 	}
+	if constVal, ok := raw["const"]; ok {
+		node.Const = constVal
+		node.HasConst = true
+	}
+	if defaultVal, ok := raw["default"]; ok {
+		node.Default = defaultVal
+		node.HasDefault = true
+	}
 	if examples, ok := raw["examples"].([]interface{}); ok {
 		node.Examples = examples
 	}
@@ -158,6 +769,20 @@ func (p *Parser) buildNode(raw map[string]interface{}, path string, required boo
 	if multiple, ok := raw["multipleOf"].(float64); ok {
 		node.MultipleOf = &multiple
 	}
+	if exMin, ok := raw["exclusiveMinimum"].(float64); ok {
+		node.ExclusiveMinimum = &exMin
+	}
+	if exMax, ok := raw["exclusiveMaximum"].(float64); ok {
+		node.ExclusiveMaximum = &exMax
+	}
+
+	// Draft 4 spells exclusive bounds as booleans modifying minimum/maximum
+	if exclusive, ok := raw["exclusiveMinimum"].(bool); ok && exclusive && node.Minimum != nil {
+		node.ExclusiveMinimum, node.Minimum = node.Minimum, nil
+	}
+	if exclusive, ok := raw["exclusiveMaximum"].(bool); ok && exclusive && node.Maximum != nil {
+		node.ExclusiveMaximum, node.Maximum = node.Maximum, nil
+	}
 
 	// Extract string constraints
 	if minLen, ok := raw["minLength"].(float64); ok {
@@ -178,11 +803,154 @@ func (p *Parser) buildNode(raw map[string]interface{}, path string, required boo
 		maxItemsInt := int(maxItems)
 		node.MaxItems = &maxItemsInt
 	}
+	if uniqueItems, ok := raw["uniqueItems"].(bool); ok {
+		node.UniqueItems = uniqueItems
+	}
+
+	// Extract object size constraints
+	if minProps, ok := raw["minProperties"].(float64); ok {
+		minPropsInt := int(minProps)
+		node.MinProperties = &minPropsInt
+	}
+	if maxProps, ok := raw["maxProperties"].(float64); ok {
+		maxPropsInt := int(maxProps)
+		node.MaxProperties = &maxPropsInt
+	}
 
 	// Extract SpecMint extensions
 	if llmFlag, ok := raw["x-llm"].(bool); ok {
 		node.LLMEnhanced = llmFlag
 	}
+	if promptRaw, ok := raw["x-llm-prompt"]; ok {
+		text, isString := promptRaw.(string)
+		if !isString || strings.TrimSpace(text) == "" {
+			return nil, fmt.Errorf("invalid x-llm-prompt at %s: must be a non-empty template string", path)
+		}
+		// A record missing a field the template uses fails execution, so
+		// enrichment falls back to the default prompt rather than sending
+		// "<no value>" to the model
+		prompt, err := template.New(path).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x-llm-prompt at %s: %w", path, err)
+		}
+		node.LLMPrompt = prompt
+		node.LLMEnhanced = true
+	}
+	if xFormat, ok := raw["x-format"].(string); ok {
+		node.Format = xFormat
+	}
+	if wmi, ok := raw["x-wmi"].(string); ok {
+		node.WMI = strings.ToUpper(wmi)
+	}
+	if generatorRaw, ok := raw["x-generator"]; ok {
+		name, _ := generatorRaw.(string)
+		if !isSyntheticGenerator(name) {
+			return nil, fmt.Errorf("unknown x-generator %v at %s: expected one of %s", generatorRaw, path, strings.Join(SyntheticGenerators, ", "))
+		}
+		if node.Type != "" && !node.HasType("string") {
+			return nil, fmt.Errorf("invalid x-generator at %s: %s generates strings", path, name)
+		}
+		node.Generator = name
+	}
+	if weightsRaw, ok := raw["x-enum-weights"]; ok {
+		weights, err := parseEnumWeights(weightsRaw, len(node.Enum))
+		if err != nil {
+			return nil, fmt.Errorf("invalid x-enum-weights at %s: %w", path, err)
+		}
+		node.EnumWeights = weights
+	}
+	if probRaw, ok := raw["x-default-prob"]; ok {
+		prob, isNumber := probRaw.(float64)
+		if !isNumber || prob < 0 || prob > 1 {
+			return nil, fmt.Errorf("invalid x-default-prob at %s: must be a number between 0 and 1", path)
+		}
+		if !node.HasDefault {
+			return nil, fmt.Errorf("invalid x-default-prob at %s: schema has no default", path)
+		}
+		node.DefaultProb = prob
+	}
+	if tz, ok := raw["x-timezone"].(string); ok {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x-timezone at %s: %w", path, err)
+		}
+		node.Location = loc
+	}
+	if whenRaw, ok := raw["x-optional-when"]; ok {
+		conditions, err := parseOptionalConditions(whenRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x-optional-when at %s: %w", path, err)
+		}
+		node.OptionalWhen = conditions
+	}
+	if rangeRaw, ok := raw["x-time-range"]; ok {
+		if node.Format != "time" {
+			return nil, fmt.Errorf("invalid x-time-range at %s: only format time fields take a time range", path)
+		}
+		timeRange, err := parseTimeRange(rangeRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x-time-range at %s: %w", path, err)
+		}
+		node.TimeRange = timeRange
+	}
+	if rangeRaw, ok := raw["x-duration-range"]; ok {
+		if node.Format != "duration" {
+			return nil, fmt.Errorf("invalid x-duration-range at %s: only format duration fields take a duration range", path)
+		}
+		durationRange, err := parseDurationRange(rangeRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x-duration-range at %s: %w", path, err)
+		}
+		node.DurationRange = durationRange
+	}
+	if locale, ok := raw["x-locale"].(string); ok {
+		if IsLocale(locale) {
+			node.Locale = locale
+		} else {
+			p.warnings = append(p.warnings, SchemaWarning{
+				Path:    path,
+				Keyword: "x-locale",
+				Message: fmt.Sprintf("locale %s is not supported; %s is used instead", locale, DefaultLocale),
+			})
+		}
+	}
+	if nullableRaw, ok := raw["x-nullable"]; ok {
+		nullable, ok := nullableRaw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid x-nullable at %s: must be a boolean", path)
+		}
+		node.Nullable = nullable
+	}
+	if rateRaw, ok := raw["x-null-rate"]; ok {
+		rate, ok := rateRaw.(float64)
+		if !ok || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid x-null-rate at %s: must be a number between 0 and 1", path)
+		}
+		node.NullRate = &rate
+		if !node.AllowsNull() {
+			p.warnings = append(p.warnings, SchemaWarning{
+				Path:    path,
+				Keyword: "x-null-rate",
+				Message: "the field does not allow null, so x-null-rate is ignored; add \"null\" to its type or set x-nullable",
+			})
+		}
+	}
+	if saltRaw, ok := raw["x-seed-salt"]; ok {
+		switch salt := saltRaw.(type) {
+		case string:
+			node.SeedSalt = salt
+		case float64:
+			node.SeedSalt = strconv.FormatFloat(salt, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("invalid x-seed-salt at %s: must be a string or number", path)
+		}
+	}
+	if ref, ok := raw["x-ref"].(string); ok {
+		if recordType, field, found := strings.Cut(ref, "."); !found || recordType == "" || field == "" {
+			return nil, fmt.Errorf("invalid x-ref %q at %s: expected record_type.field", ref, path)
+		}
+		node.Reference = ref
+	}
 
 	// Also check for "llm:" prefix in description
 	if desc, ok := raw["description"].(string); ok && strings.HasPrefix(desc, "llm:") {
@@ -216,13 +984,57 @@ func (p *Parser) buildNode(raw map[string]interface{}, path string, required boo
 				if severity, ok := ruleMap["severity"].(string); ok {
 					rule.Severity = severity
 				}
+				if patchMap, ok := ruleMap["patch"].(map[string]interface{}); ok {
+					patch := &PatchRule{Value: patchMap["value"]}
+					patch.Strategy, _ = patchMap["strategy"].(string)
+					patch.Target, _ = patchMap["target"].(string)
+					patch.Params, _ = patchMap["params"].(map[string]interface{})
+					rule.Patch = patch
+				}
+				if rule.Rule == "regex_match" {
+					if _, err := regexp.Compile(rule.Constraint); err != nil {
+						return nil, fmt.Errorf("invalid regex_match rule %q at %s: %w", rule.Name, path, err)
+					}
+				}
 				node.CrossFieldRules = append(node.CrossFieldRules, rule)
 			}
 		}
 	}
 
+	// Extract geo-IP field grouping
+	if geoRaw, ok := raw["x-geoip"].(map[string]interface{}); ok {
+		geo, err := parseGeoIPSpec(geoRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x-geoip at %s: %w", path, err)
+		}
+		node.GeoIP = geo
+	}
+
+	// Extract numeric distribution
+	if distRaw, ok := raw["x-distribution"]; ok {
+		dist, err := parseDistribution(distRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x-distribution at %s: %w", path, err)
+		}
+		node.Distribution = dist
+	}
+	if lengthRaw, ok := raw["x-length-distribution"]; ok {
+		dist, err := parseLengthDistribution(lengthRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x-length-distribution at %s: %w", path, err)
+		}
+		node.LengthDist = dist
+		if !node.HasType("array") {
+			p.warnings = append(p.warnings, SchemaWarning{
+				Path:    path,
+				Keyword: "x-length-distribution",
+				Message: "the field is not an array, so x-length-distribution is ignored",
+			})
+		}
+	}
+
 	// Handle object properties
-	if node.Type == "object" {
+	if node.HasType("object") {
 		if props, ok := raw["properties"].(map[string]interface{}); ok {
 			node.Properties = make(map[string]*SchemaNode)
 
@@ -250,14 +1062,83 @@ func (p *Parser) buildNode(raw map[string]interface{}, path string, required boo
 					if err != nil {
 						return nil, fmt.Errorf("failed to parse property %s: %w", propName, err)
 					}
+					if propNode.truncated && !propNode.IsRequired {
+						// Leave out optional recursion that has bottomed out
+						continue
+					}
 					node.Properties[propName] = propNode
 				}
 			}
+
+			if err := p.checkOptionalConditions(node); err != nil {
+				return nil, err
+			}
+		}
+
+		extraPath := "*"
+		if path != "" {
+			extraPath = path + ".*"
+		}
+
+		if patterns, ok := raw["patternProperties"].(map[string]interface{}); ok {
+			for pattern, patternRaw := range patterns {
+				patternMap, ok := patternRaw.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return nil, fmt.Errorf("invalid patternProperties regex %q at %s: %w", pattern, path, err)
+				}
+				patternNode, err := p.buildNode(patternMap, extraPath, true, optionalProb)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse patternProperties %q: %w", pattern, err)
+				}
+				if !patternNode.truncated {
+					node.PatternProperties = append(node.PatternProperties, &PatternProperty{Pattern: pattern, Node: patternNode, re: re})
+				}
+			}
+			// Map order is random, so sort to keep generation reproducible
+			sort.Slice(node.PatternProperties, func(i, j int) bool {
+				return node.PatternProperties[i].Pattern < node.PatternProperties[j].Pattern
+			})
+		}
+
+		if extra, ok := raw["additionalProperties"].(map[string]interface{}); ok {
+			extraNode, err := p.buildNode(extra, extraPath, true, optionalProb)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse additionalProperties: %w", err)
+			}
+			if !extraNode.truncated {
+				node.AdditionalProperties = extraNode
+			}
 		}
+		if err := checkPropertyCounts(node, raw, path); err != nil {
+			return nil, err
+		}
+
+		base := unconditional(raw)
+		if err := p.buildDependencies(node, base, raw, path, required, optionalProb); err != nil {
+			return nil, err
+		}
+		if _, ok := raw["if"]; ok {
+			if err := p.buildConditional(node, base, raw, path, required, optionalProb); err != nil {
+				return nil, err
+			}
+		}
+	} else if _, ok := raw["if"]; ok {
+		p.warnings = append(p.warnings, SchemaWarning{
+			Path:    path,
+			Keyword: "if",
+			Message: "if/then/else is only supported on objects and will be ignored during generation",
+		})
 	}
 
 	// Handle array items
-	if node.Type == "array" {
+	if node.HasType("array") {
+		if err := p.buildTuple(node, raw, path, optionalProb); err != nil {
+			return nil, err
+		}
 		if items, ok := raw["items"].(map[string]interface{}); ok {
 			itemPath := path + "[]"
 			itemNode, err := p.buildNode(items, itemPath, true, optionalProb)
@@ -265,12 +1146,1050 @@ func (p *Parser) buildNode(raw map[string]interface{}, path string, required boo
 				return nil, fmt.Errorf("failed to parse array items: %w", err)
 			}
 			node.Items = itemNode
+			if itemNode.truncated && (node.MinItems == nil || *node.MinItems == 0) {
+				// Recursion has bottomed out, so keep the array empty
+				zero := 0
+				node.MinItems = &zero
+				node.MaxItems = &zero
+			}
+		}
+		if err := p.buildContains(node, raw, path, optionalProb); err != nil {
+			return nil, err
 		}
 	}
 
 	return node, nil
 }
 
+// buildConditional adds the if/then/else in raw to node. Each branch is
+// built as the branch merged with base, the object's schema without the
+// conditional, so generation can fill in what the branch adds.
+func (p *Parser) buildConditional(node *SchemaNode, base, raw map[string]interface{}, path string, required bool, optionalProb float64) error {
+	ifSchema, ok := raw["if"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	test, err := p.compileSubschema(ifSchema)
+	if err != nil {
+		return fmt.Errorf("invalid if at %s: %w", path, err)
+	}
+
+	// Properties if looks at are never regenerated by a branch, so the
+	// branch that applies stays the same
+	ifKeys := make(map[string]bool)
+	if props, ok := ifSchema["properties"].(map[string]interface{}); ok {
+		for name := range props {
+			ifKeys[name] = true
+		}
+	}
+
+	conditional := &Conditional{test: test}
+	for _, branch := range []struct {
+		keyword string
+		node    **SchemaNode
+		keys    *[]string
+	}{
+		{"then", &conditional.Then, &conditional.ThenKeys},
+		{"else", &conditional.Else, &conditional.ElseKeys},
+	} {
+		branchRaw, ok := raw[branch.keyword].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		branchNode, keys, err := p.buildBranch(base, branchRaw, path, required, optionalProb)
+		if err != nil {
+			return fmt.Errorf("invalid %s at %s: %w", branch.keyword, path, err)
+		}
+		*branch.node = branchNode
+		for _, name := range keys {
+			if !ifKeys[name] {
+				*branch.keys = append(*branch.keys, name)
+			}
+		}
+	}
+
+	node.Conditionals = append(node.Conditionals, conditional)
+	return nil
+}
+
+// buildDependencies parses dependentRequired, dependentSchemas and the
+// draft-07 dependencies keyword that combines both
+func (p *Parser) buildDependencies(node *SchemaNode, base, raw map[string]interface{}, path string, required bool, optionalProb float64) error {
+	requiredLists, _ := raw["dependentRequired"].(map[string]interface{})
+	schemas, _ := raw["dependentSchemas"].(map[string]interface{})
+	if legacy, ok := raw["dependencies"].(map[string]interface{}); ok {
+		requiredLists = copySchema(requiredLists)
+		schemas = copySchema(schemas)
+		for trigger, dependency := range legacy {
+			switch dependency.(type) {
+			case []interface{}:
+				requiredLists[trigger] = dependency
+			case map[string]interface{}:
+				schemas[trigger] = dependency
+			}
+		}
+	}
+
+	for trigger, names := range requiredLists {
+		if node.DependentRequired == nil {
+			node.DependentRequired = make(map[string][]string)
+		}
+		node.DependentRequired[trigger] = stringList(names)
+	}
+	for trigger, dependentRaw := range schemas {
+		dependentMap, ok := dependentRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		dependentNode, keys, err := p.buildBranch(base, dependentMap, path, required, optionalProb)
+		if err != nil {
+			return fmt.Errorf("invalid dependent schema for %s at %s: %w", trigger, path, err)
+		}
+		if node.DependentSchemas == nil {
+			node.DependentSchemas = make(map[string]*Dependency)
+		}
+		node.DependentSchemas[trigger] = &Dependency{Schema: dependentNode, Keys: keys}
+	}
+	return nil
+}
+
+// buildBranch builds a schema that applies on top of base, such as then or
+// a dependent schema, merged with base so generation has the complete
+// schema of each property. It also returns the properties the branch
+// requires or constrains, sorted.
+func (p *Parser) buildBranch(base, branchRaw map[string]interface{}, path string, required bool, optionalProb float64) (*SchemaNode, []string, error) {
+	// The branch is merged into base rather than the other way round so its
+	// keywords win, as both must hold
+	subschemas := []interface{}{base}
+	if allOf, ok := branchRaw["allOf"].([]interface{}); ok {
+		subschemas = append(subschemas, allOf...)
+	}
+	node, err := p.buildAllOf(subschemas, branchRaw, path, required, optionalProb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := stringList(branchRaw["required"])
+	if props, ok := branchRaw["properties"].(map[string]interface{}); ok {
+		for name := range props {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var keys []string
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			keys = append(keys, name)
+		}
+	}
+	return node, keys, nil
+}
+
+// unconditional returns raw without the keywords that apply schemas
+// depending on the value, the base that such schemas are merged with
+func unconditional(raw map[string]interface{}) map[string]interface{} {
+	base := copySchema(raw)
+	for _, keyword := range []string{"if", "then", "else", "dependentSchemas", "dependencies"} {
+		delete(base, keyword)
+	}
+	return base
+}
+
+// compileSubschema compiles part of the schema on its own, so a value can be
+// tested against it. Definitions of the root are carried along so local
+// references still resolve.
+func (p *Parser) compileSubschema(raw map[string]interface{}) (*jsonschema.Schema, error) {
+	standalone := copySchema(raw)
+	for _, keyword := range []string{"$defs", "definitions"} {
+		if defs, ok := p.raw[keyword]; ok {
+			if _, exists := standalone[keyword]; !exists {
+				standalone[keyword] = defs
+			}
+		}
+	}
+
+	nullable, _ := allowNullable(standalone)
+	data, err := json.Marshal(nullable)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	compiler := newCompiler()
+	if err := compiler.AddResource(schemaLocation, doc); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(schemaLocation)
+}
+
+// buildTuple parses positional item schemas, given either as an items array
+// with additionalItems for the rest (draft-07) or as prefixItems with items
+// for the rest (2020-12)
+func (p *Parser) buildTuple(node *SchemaNode, raw map[string]interface{}, path string, optionalProb float64) error {
+	positional, isPrefixForm := raw["prefixItems"].([]interface{})
+	rest := raw["additionalItems"]
+	if isPrefixForm {
+		rest = raw["items"]
+	} else {
+		var ok bool
+		if positional, ok = raw["items"].([]interface{}); !ok {
+			return nil
+		}
+	}
+
+	for i, item := range positional {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			itemMap = map[string]interface{}{}
+		}
+		itemNode, err := p.buildNode(itemMap, fmt.Sprintf("%s[%d]", path, i), true, optionalProb)
+		if err != nil {
+			return fmt.Errorf("failed to parse tuple item %d: %w", i, err)
+		}
+		node.TupleItems = append(node.TupleItems, itemNode)
+	}
+
+	switch restSchema := rest.(type) {
+	case bool:
+		node.ClosedTuple = !restSchema
+	case map[string]interface{}:
+		// In the prefixItems form the caller builds items as usual
+		if !isPrefixForm {
+			itemNode, err := p.buildNode(restSchema, path+"[]", true, optionalProb)
+			if err != nil {
+				return fmt.Errorf("failed to parse additional items: %w", err)
+			}
+			node.Items = itemNode
+		}
+	}
+
+	return nil
+}
+
+// buildContains parses contains with minContains and maxContains, and
+// rejects bounds no array can meet
+func (p *Parser) buildContains(node *SchemaNode, raw map[string]interface{}, path string, optionalProb float64) error {
+	contains, ok := raw["contains"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if len(node.TupleItems) > 0 {
+		p.warnings = append(p.warnings, SchemaWarning{
+			Path:    path,
+			Keyword: "contains",
+			Message: "contains is not supported on tuple arrays and will be ignored during generation",
+		})
+		return nil
+	}
+
+	// Matching items must satisfy items as well, so generate them from both
+	subschemas := []interface{}{contains}
+	if items, ok := raw["items"].(map[string]interface{}); ok {
+		subschemas = append(subschemas, items)
+	}
+	containsNode, err := p.buildAllOf(subschemas, map[string]interface{}{}, path+"[]", true, optionalProb)
+	if err != nil {
+		return fmt.Errorf("failed to parse contains: %w", err)
+	}
+	node.Contains = containsNode
+	node.MinContains = 1
+	if minContains, ok := raw["minContains"].(float64); ok {
+		node.MinContains = int(minContains)
+	}
+	if maxContains, ok := raw["maxContains"].(float64); ok {
+		maxContainsInt := int(maxContains)
+		node.MaxContains = &maxContainsInt
+	}
+
+	if node.MaxItems != nil && *node.MaxItems < node.MinContains {
+		return fmt.Errorf("invalid contains at %s: minContains %d exceeds maxItems %d", path, node.MinContains, *node.MaxItems)
+	}
+	if node.MaxContains != nil && *node.MaxContains < node.MinContains {
+		return fmt.Errorf("invalid contains at %s: minContains %d exceeds maxContains %d", path, node.MinContains, *node.MaxContains)
+	}
+	return nil
+}
+
+// SyntheticGenerators are the names x-generator accepts. Each produces
+// text without an LLM, in the node's locale where that matters.
+var SyntheticGenerators = []string{
+	"full_name", "first_name", "last_name", "street_address", "city", "postal_code", "phone", "company", "lorem",
+}
+
+// Locales are the locales synthetic names, addresses and phone numbers can
+// be generated for
+var Locales = []string{"en-US", "en-GB", "de-DE", "fr-FR"}
+
+// DefaultLocale is used when no locale is set or the one set is unknown
+const DefaultLocale = "en-US"
+
+// IsLocale reports whether locale is supported
+func IsLocale(locale string) bool {
+	for _, known := range Locales {
+		if locale == known {
+			return true
+		}
+	}
+	return false
+}
+
+// isSyntheticGenerator reports whether name is a built-in generator
+func isSyntheticGenerator(name string) bool {
+	for _, known := range SyntheticGenerators {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEnumWeights checks that weights line up with the enum values and
+// normalizes them to sum to 1
+func parseEnumWeights(raw interface{}, enumCount int) ([]float64, error) {
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array of numbers")
+	}
+	if len(list) != enumCount {
+		return nil, fmt.Errorf("got %d weights for %d enum values", len(list), enumCount)
+	}
+
+	weights := make([]float64, len(list))
+	total := 0.0
+	for i, item := range list {
+		weight, ok := item.(float64)
+		if !ok || weight < 0 {
+			return nil, fmt.Errorf("weight %d must be a non-negative number", i)
+		}
+		weights[i] = weight
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("weights must not all be zero")
+	}
+
+	for i := range weights {
+		weights[i] /= total
+	}
+	return weights, nil
+}
+
+// maxRefDepth bounds how many times a reference may be expanded inside its
+// own expansion, so recursive definitions terminate
+const maxRefDepth = 3
+
+// buildRef resolves a local $ref against the root schema and builds the
+// target at the current path. Keywords next to $ref override the target's.
+func (p *Parser) buildRef(ref string, raw map[string]interface{}, path string, required bool, optionalProb float64) (*SchemaNode, error) {
+	target, err := p.resolveRef(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve $ref at %s: %w", path, err)
+	}
+
+	if p.refDepth[ref] >= maxRefDepth {
+		p.warnings = append(p.warnings, SchemaWarning{
+			Path:    path,
+			Keyword: "$ref",
+			Message: fmt.Sprintf("recursive reference %s truncated after %d levels", ref, maxRefDepth),
+		})
+		node := &SchemaNode{
+			Path:         path,
+			IsRequired:   required,
+			OptionalProb: optionalProb,
+			truncated:    true,
+		}
+		if typeStr, ok := target["type"].(string); ok {
+			node.Type = typeStr
+		}
+		return node, nil
+	}
+
+	merged := make(map[string]interface{}, len(target)+len(raw))
+	for key, value := range target {
+		merged[key] = value
+	}
+	for key, value := range raw {
+		if key != "$ref" {
+			merged[key] = value
+		}
+	}
+
+	p.refDepth[ref]++
+	defer func() { p.refDepth[ref]-- }()
+
+	return p.buildNode(merged, path, required, optionalProb)
+}
+
+// buildAllOf merges every allOf subschema into the node before building it
+func (p *Parser) buildAllOf(subschemas []interface{}, raw map[string]interface{}, path string, required bool, optionalProb float64) (*SchemaNode, error) {
+	merged := withoutKeyword(raw, "allOf")
+
+	var conditions []map[string]interface{}
+	for _, sub := range subschemas {
+		subMap, ok := sub.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		expanded, ref, err := p.expandRef(subMap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve allOf at %s: %w", path, err)
+		}
+		if ref != "" {
+			if p.refDepth[ref] >= maxRefDepth {
+				continue
+			}
+			p.refDepth[ref]++
+			defer func() { p.refDepth[ref]-- }()
+		}
+
+		// Each subschema may carry its own if/then/else, which cannot be
+		// merged, so they are built separately
+		if _, ok := expanded["if"]; ok {
+			conditions = append(conditions, expanded)
+			expanded = withoutKeyword(withoutKeyword(withoutKeyword(expanded, "if"), "then"), "else")
+		}
+		mergeSchemas(merged, expanded)
+	}
+
+	node, err := p.buildNode(merged, path, required, optionalProb)
+	if err != nil {
+		return nil, err
+	}
+	base := unconditional(merged)
+	for _, condition := range conditions {
+		if err := p.buildConditional(node, base, condition, path, required, optionalProb); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// buildVariants builds one node per anyOf/oneOf branch, each combined with
+// the keywords next to the composition. The generator picks a single branch
+// per value so output stays reproducible for a given seed.
+func (p *Parser) buildVariants(keyword string, branches []interface{}, raw map[string]interface{}, path string, required bool, optionalProb float64) (*SchemaNode, error) {
+	base := withoutKeyword(raw, keyword)
+
+	expanded := make([]map[string]interface{}, 0, len(branches))
+	refs := make([]string, 0, len(branches))
+	for _, branch := range branches {
+		branchMap, ok := branch.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		branchMap, ref, err := p.expandRef(branchMap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s at %s: %w", keyword, path, err)
+		}
+		if ref != "" && p.refDepth[ref] >= maxRefDepth {
+			continue
+		}
+		expanded = append(expanded, branchMap)
+		refs = append(refs, ref)
+	}
+
+	if len(expanded) == 0 {
+		return p.buildNode(base, path, required, optionalProb)
+	}
+
+	node := &SchemaNode{
+		Path:         path,
+		IsRequired:   required,
+		OptionalProb: optionalProb,
+	}
+
+	for i, branch := range expanded {
+		merged := copySchema(base)
+		mergeSchemas(merged, branch)
+
+		if keyword == "oneOf" {
+			// A property another branch requires could make the value match
+			// that branch as well, so leave it out unless this branch uses it
+			dropExclusiveProperties(merged, branch, expanded, i)
+		}
+
+		if refs[i] != "" {
+			p.refDepth[refs[i]]++
+		}
+		variant, err := p.buildNode(merged, path, required, optionalProb)
+		if refs[i] != "" {
+			p.refDepth[refs[i]]--
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s branch %d: %w", keyword, i, err)
+		}
+		node.Variants = append(node.Variants, variant)
+	}
+
+	return node, nil
+}
+
+// dropExclusiveProperties removes properties that other branches require
+// but the given branch neither requires nor declares
+func dropExclusiveProperties(merged, branch map[string]interface{}, branches []map[string]interface{}, index int) {
+	props, ok := merged["properties"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	own := make(map[string]bool)
+	for _, name := range stringList(branch["required"]) {
+		own[name] = true
+	}
+	if branchProps, ok := branch["properties"].(map[string]interface{}); ok {
+		for name := range branchProps {
+			own[name] = true
+		}
+	}
+
+	props = copySchema(props)
+	for i, other := range branches {
+		if i == index {
+			continue
+		}
+		for _, name := range stringList(other["required"]) {
+			if !own[name] {
+				delete(props, name)
+			}
+		}
+	}
+	merged["properties"] = props
+}
+
+// expandRef inlines a local $ref, keeping any keywords written next to it.
+// It returns the reference followed, or "" when there was none.
+func (p *Parser) expandRef(raw map[string]interface{}) (map[string]interface{}, string, error) {
+	ref, ok := raw["$ref"].(string)
+	if !ok || !strings.HasPrefix(ref, "#") {
+		return raw, "", nil
+	}
+
+	target, err := p.resolveRef(ref)
+	if err != nil {
+		return nil, "", err
+	}
+
+	expanded := copySchema(target)
+	for key, value := range raw {
+		if key != "$ref" {
+			expanded[key] = value
+		}
+	}
+	return expanded, ref, nil
+}
+
+// mergeSchemas folds src into dst so that dst describes values satisfying
+// both. Properties are merged recursively, required lists are combined and
+// numeric bounds keep the tighter value. For other keywords dst wins.
+func mergeSchemas(dst, src map[string]interface{}) {
+	for key, value := range src {
+		existing, exists := dst[key]
+		if !exists {
+			dst[key] = value
+			continue
+		}
+
+		switch key {
+		case "properties", "$defs", "definitions":
+			dstProps, ok1 := existing.(map[string]interface{})
+			srcProps, ok2 := value.(map[string]interface{})
+			if !ok1 || !ok2 {
+				continue
+			}
+			props := copySchema(dstProps)
+			for name, prop := range srcProps {
+				dstProp, ok1 := props[name].(map[string]interface{})
+				srcProp, ok2 := prop.(map[string]interface{})
+				if ok1 && ok2 {
+					combined := copySchema(dstProp)
+					mergeSchemas(combined, srcProp)
+					props[name] = combined
+				} else {
+					props[name] = prop
+				}
+			}
+			dst[key] = props
+		case "additionalProperties":
+			// Undeclared keys are only allowed if both sides allow them
+			if value == false {
+				dst[key] = false
+			}
+		case "items":
+			dstItems, ok1 := existing.(map[string]interface{})
+			srcItems, ok2 := value.(map[string]interface{})
+			if ok1 && ok2 {
+				combined := copySchema(dstItems)
+				mergeSchemas(combined, srcItems)
+				dst[key] = combined
+			}
+		case "required":
+			names := stringList(existing)
+			seen := make(map[string]bool, len(names))
+			for _, name := range names {
+				seen[name] = true
+			}
+			for _, name := range stringList(value) {
+				if !seen[name] {
+					names = append(names, name)
+					seen[name] = true
+				}
+			}
+			list := make([]interface{}, len(names))
+			for i, name := range names {
+				list[i] = name
+			}
+			dst[key] = list
+		case "minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties", "minContains":
+			if a, ok := existing.(float64); ok {
+				if b, ok := value.(float64); ok && b > a {
+					dst[key] = b
+				}
+			}
+		case "maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties", "maxContains":
+			if a, ok := existing.(float64); ok {
+				if b, ok := value.(float64); ok && b < a {
+					dst[key] = b
+				}
+			}
+		case "enum":
+			dstEnum, ok1 := existing.([]interface{})
+			srcEnum, ok2 := value.([]interface{})
+			if !ok1 || !ok2 {
+				continue
+			}
+			weights, weighted := dst["x-enum-weights"].([]interface{})
+			weighted = weighted && len(weights) == len(dstEnum)
+			var common, commonWeights []interface{}
+			for i, a := range dstEnum {
+				for _, b := range srcEnum {
+					if reflect.DeepEqual(a, b) {
+						common = append(common, a)
+						if weighted {
+							commonWeights = append(commonWeights, weights[i])
+						}
+						break
+					}
+				}
+			}
+			dst[key] = common
+			if weighted {
+				// Keep the weights lined up with the remaining values
+				dst["x-enum-weights"] = commonWeights
+			}
+		case "type":
+			// integer is the narrower of the two numeric types
+			if existing == "number" && value == "integer" {
+				dst[key] = value
+			}
+		}
+	}
+}
+
+// copySchema returns a shallow copy of a raw schema map
+func copySchema(raw map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(raw))
+	for key, value := range raw {
+		result[key] = value
+	}
+	return result
+}
+
+// allowNullable returns a copy of a raw schema in which every subschema with
+// x-nullable also accepts null: null joins its type and any enum, and a
+// const becomes an enum of the constant and null. It reports whether
+// anything changed; if not, value itself is returned.
+func allowNullable(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var result map[string]interface{}
+		for key, item := range v {
+			if rewritten, changed := allowNullable(item); changed {
+				if result == nil {
+					result = copySchema(v)
+				}
+				result[key] = rewritten
+			}
+		}
+		if nullable, _ := v["x-nullable"].(bool); !nullable {
+			if result == nil {
+				return v, false
+			}
+			return result, true
+		}
+		if result == nil {
+			result = copySchema(v)
+		}
+
+		switch t := result["type"].(type) {
+		case string:
+			if t != "null" {
+				result["type"] = []interface{}{t, "null"}
+			}
+		case []interface{}:
+			if !containsValue(t, "null") {
+				result["type"] = append(append([]interface{}{}, t...), "null")
+			}
+		}
+		if enum, ok := result["enum"].([]interface{}); ok && !containsValue(enum, nil) {
+			result["enum"] = append(append([]interface{}{}, enum...), nil)
+		}
+		if constant, ok := result["const"]; ok && constant != nil {
+			delete(result, "const")
+			result["enum"] = []interface{}{constant, nil}
+		}
+		return result, true
+	case []interface{}:
+		var result []interface{}
+		for i, item := range v {
+			if rewritten, changed := allowNullable(item); changed {
+				if result == nil {
+					result = append([]interface{}{}, v...)
+				}
+				result[i] = rewritten
+			}
+		}
+		if result == nil {
+			return v, false
+		}
+		return result, true
+	}
+	return value, false
+}
+
+// containsValue reports whether items holds want
+func containsValue(items []interface{}, want interface{}) bool {
+	for _, item := range items {
+		if item == want {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutKeyword returns a shallow copy of raw without the given keyword
+func withoutKeyword(raw map[string]interface{}, keyword string) map[string]interface{} {
+	result := copySchema(raw)
+	delete(result, keyword)
+	return result
+}
+
+// checkPropertyCounts rejects minProperties and maxProperties that no object
+// matching the rest of the schema can meet
+func checkPropertyCounts(node *SchemaNode, raw map[string]interface{}, path string) error {
+	if node.MinProperties == nil && node.MaxProperties == nil {
+		return nil
+	}
+	if node.MinProperties != nil && node.MaxProperties != nil && *node.MinProperties > *node.MaxProperties {
+		return fmt.Errorf("minProperties %d exceeds maxProperties %d at %s", *node.MinProperties, *node.MaxProperties, path)
+	}
+
+	if node.MaxProperties != nil {
+		required := make(map[string]bool)
+		for _, name := range stringList(raw["required"]) {
+			required[name] = true
+		}
+		if len(required) > *node.MaxProperties {
+			return fmt.Errorf("%d properties are required at %s but maxProperties is %d", len(required), path, *node.MaxProperties)
+		}
+	}
+
+	// With additionalProperties false and no patternProperties, only the
+	// declared properties can be present
+	allowed, isBool := raw["additionalProperties"].(bool)
+	closed := isBool && !allowed && raw["patternProperties"] == nil
+	if closed && node.MinProperties != nil && len(node.Properties) < *node.MinProperties {
+		return fmt.Errorf("minProperties %d at %s exceeds the %d declared properties, and additionalProperties is false", *node.MinProperties, path, len(node.Properties))
+	}
+	return nil
+}
+
+// stringList extracts the strings from a raw JSON array
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	var result []string
+	for _, item := range items {
+		if str, ok := item.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
+// resolveRef looks up a local reference such as #/$defs/Address in the raw schema
+func (p *Parser) resolveRef(ref string) (map[string]interface{}, error) {
+	fragment, err := url.PathUnescape(strings.TrimPrefix(ref, "#"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %s: %w", ref, err)
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		return nil, fmt.Errorf("unsupported reference %s: only JSON Pointer fragments are supported", ref)
+	}
+
+	var current interface{} = p.raw
+	if fragment != "" {
+		for _, token := range strings.Split(fragment[1:], "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			switch value := current.(type) {
+			case map[string]interface{}:
+				next, ok := value[token]
+				if !ok {
+					return nil, fmt.Errorf("reference %s not found", ref)
+				}
+				current = next
+			case []interface{}:
+				index, err := strconv.Atoi(token)
+				if err != nil || index < 0 || index >= len(value) {
+					return nil, fmt.Errorf("reference %s not found", ref)
+				}
+				current = value[index]
+			default:
+				return nil, fmt.Errorf("reference %s not found", ref)
+			}
+		}
+	}
+
+	target, ok := current.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("reference %s does not point to a schema object", ref)
+	}
+	return target, nil
+}
+
+// parseGeoIPSpec builds a GeoIPSpec from its raw extension value
+func parseGeoIPSpec(raw map[string]interface{}) (*GeoIPSpec, error) {
+	spec := &GeoIPSpec{}
+	if ip, ok := raw["ip"].(string); ok {
+		spec.IPField = ip
+	}
+	if country, ok := raw["country"].(string); ok {
+		spec.CountryField = country
+	}
+	if countryName, ok := raw["country_name"].(string); ok {
+		spec.CountryNameField = countryName
+	}
+	if city, ok := raw["city"].(string); ok {
+		spec.CityField = city
+	}
+	if weighted, ok := raw["weighted"].(bool); ok {
+		spec.Weighted = weighted
+	}
+	if countries, ok := raw["countries"].([]interface{}); ok {
+		for _, country := range countries {
+			if code, ok := country.(string); ok {
+				spec.Countries = append(spec.Countries, strings.ToUpper(code))
+			}
+		}
+	}
+
+	if spec.IPField == "" {
+		return nil, fmt.Errorf("the ip field name is required")
+	}
+
+	return spec, nil
+}
+
+// parseDistribution builds a Distribution from either a bare type name such
+// as "normal" or an object with type, mean and stddev
+func parseDistribution(raw interface{}) (*Distribution, error) {
+	dist := &Distribution{}
+	switch v := raw.(type) {
+	case string:
+		dist.Type = v
+	case map[string]interface{}:
+		dist.Type, _ = v["type"].(string)
+		if mean, ok := v["mean"].(float64); ok {
+			dist.Mean = &mean
+		}
+		if stddev, ok := v["stddev"].(float64); ok {
+			if stddev <= 0 {
+				return nil, fmt.Errorf("stddev must be positive")
+			}
+			dist.StdDev = &stddev
+		}
+	default:
+		return nil, fmt.Errorf("expected a distribution name or object")
+	}
+
+	switch dist.Type {
+	case "uniform", "normal", "lognormal", "exponential":
+	default:
+		return nil, fmt.Errorf("unknown distribution %q", dist.Type)
+	}
+
+	return dist, nil
+}
+
+// parseLengthDistribution builds a LengthDistribution from either a bare
+// type name such as "poisson" or an object with type and lambda or p
+func parseLengthDistribution(raw interface{}) (*LengthDistribution, error) {
+	dist := &LengthDistribution{}
+	switch v := raw.(type) {
+	case string:
+		dist.Type = v
+	case map[string]interface{}:
+		dist.Type, _ = v["type"].(string)
+		if lambda, ok := v["lambda"].(float64); ok {
+			if lambda <= 0 {
+				return nil, fmt.Errorf("lambda must be positive")
+			}
+			dist.Lambda = &lambda
+		}
+		if p, ok := v["p"].(float64); ok {
+			if p <= 0 || p > 1 {
+				return nil, fmt.Errorf("p must be above 0 and at most 1")
+			}
+			dist.P = &p
+		}
+	default:
+		return nil, fmt.Errorf("expected a distribution name or object")
+	}
+
+	switch dist.Type {
+	case "uniform", "poisson", "geometric":
+	default:
+		return nil, fmt.Errorf("unknown length distribution %q", dist.Type)
+	}
+
+	return dist, nil
+}
+
+// parseOptionalConditions reads an x-optional-when list such as
+// [{"field": "status", "equals": "discharged", "probability": 0.98}]
+func parseOptionalConditions(raw interface{}) ([]OptionalCondition, error) {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list of conditions")
+	}
+
+	conditions := make([]OptionalCondition, 0, len(items))
+	for i, item := range items {
+		v, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("condition %d: expected an object with field, equals and probability", i)
+		}
+		field, _ := v["field"].(string)
+		if field == "" {
+			return nil, fmt.Errorf("condition %d: field must name a sibling property", i)
+		}
+		equals, ok := v["equals"]
+		if !ok {
+			return nil, fmt.Errorf("condition %d: equals is required", i)
+		}
+		probability, ok := v["probability"].(float64)
+		if !ok || probability < 0 || probability > 1 {
+			return nil, fmt.Errorf("condition %d: probability must be a number between 0 and 1", i)
+		}
+		conditions = append(conditions, OptionalCondition{Field: field, Equals: equals, Probability: probability})
+	}
+	return conditions, nil
+}
+
+// checkOptionalConditions rejects x-optional-when conditions that do not
+// name a sibling, and warns about those on required properties, which are
+// always generated
+func (p *Parser) checkOptionalConditions(node *SchemaNode) error {
+	names := make([]string, 0, len(node.Properties))
+	for name := range node.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop := node.Properties[name]
+		if len(prop.OptionalWhen) == 0 {
+			continue
+		}
+		for _, condition := range prop.OptionalWhen {
+			if _, ok := node.Properties[condition.Field]; !ok || condition.Field == name {
+				return fmt.Errorf("invalid x-optional-when at %s: %s is not a sibling property", prop.Path, condition.Field)
+			}
+		}
+		if prop.IsRequired {
+			p.warnings = append(p.warnings, SchemaWarning{
+				Path:    prop.Path,
+				Keyword: "x-optional-when",
+				Message: "the property is required, so it is always generated",
+			})
+		}
+	}
+	return nil
+}
+
+// parseTimeRange reads an x-time-range such as
+// {"start": "09:00", "end": "17:30:00"}
+func parseTimeRange(raw interface{}) (*TimeRange, error) {
+	v, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object with start and end")
+	}
+
+	bounds := make([]int, 2)
+	for i, key := range []string{"start", "end"} {
+		text, _ := v[key].(string)
+		seconds, err := parseTimeOfDay(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		bounds[i] = seconds
+	}
+	if bounds[0] > bounds[1] {
+		return nil, fmt.Errorf("start %s is after end %s", v["start"], v["end"])
+	}
+
+	return &TimeRange{Start: bounds[0], End: bounds[1]}, nil
+}
+
+// parseDurationRange reads an x-duration-range such as
+// {"min": "PT15M", "max": "P2D"}. Either bound may be left out; the min
+// defaults to zero and the max to 30 days.
+func parseDurationRange(raw interface{}) (*DurationRange, error) {
+	v, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object with min and max")
+	}
+
+	durationRange := &DurationRange{Max: DefaultDurationMax}
+	bounds := []*time.Duration{&durationRange.Min, &durationRange.Max}
+	for i, key := range []string{"min", "max"} {
+		boundRaw, ok := v[key]
+		if !ok {
+			continue
+		}
+		text, _ := boundRaw.(string)
+		duration, err := ParseISODuration(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		length, fixed := duration.Fixed()
+		if !fixed {
+			return nil, fmt.Errorf("%s %s has years or months, which have no fixed length", key, text)
+		}
+		*bounds[i] = length
+	}
+	if _, hasMax := v["max"]; !hasMax && durationRange.Min > durationRange.Max {
+		durationRange.Max = durationRange.Min
+	}
+	if durationRange.Min > durationRange.Max {
+		return nil, fmt.Errorf("min %s is longer than max %s", v["min"], v["max"])
+	}
+
+	return durationRange, nil
+}
+
+// parseTimeOfDay returns the seconds since midnight of a time written as
+// hh:mm or hh:mm:ss
+func parseTimeOfDay(text string) (int, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t.Hour()*3600 + t.Minute()*60 + t.Second(), nil
+		}
+	}
+	return 0, fmt.Errorf("expected a time as hh:mm or hh:mm:ss, got %q", text)
+}
+
 // GetLLMFields returns all fields marked for LLM enhancement
 func (p *Parser) GetLLMFields(node *SchemaNode) []string {
 	var fields []string
@@ -304,6 +2223,10 @@ func (p *Parser) collectLLMFields(node *SchemaNode, prefix string, fields *[]str
 		}
 	}
 
+	for i, item := range node.TupleItems {
+		p.collectLLMFields(item, fmt.Sprintf("%s[%d]", prefix, i), fields)
+	}
+
 	if node.Items != nil {
 		itemPath := prefix + "[]"
 		p.collectLLMFields(node.Items, itemPath, fields)
@@ -326,6 +2249,10 @@ func (p *Parser) collectCrossFieldRules(node *SchemaNode, rules *[]CrossFieldRul
 		}
 	}
 
+	for _, item := range node.TupleItems {
+		p.collectCrossFieldRules(item, rules)
+	}
+
 	if node.Items != nil {
 		p.collectCrossFieldRules(node.Items, rules)
 	}
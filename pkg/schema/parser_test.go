@@ -0,0 +1,1052 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRefResolution verifies that local $ref pointers are spliced in at the
+// referencing path
+func TestRefResolution(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"properties": {
+			"home": {"$ref": "#/$defs/Address"},
+			"work": {"$ref": "#/$defs/Address", "description": "Work address"}
+		},
+		"$defs": {
+			"Address": {
+				"type": "object",
+				"properties": {
+					"street": {"type": "string"},
+					"zip": {"type": "string", "pattern": "^[0-9]{5}$"}
+				},
+				"required": ["street"]
+			}
+		}
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	for _, name := range []string{"home", "work"} {
+		node := root.Properties[name]
+		if node.Type != "object" {
+			t.Fatalf("Expected %s to resolve to an object, got type '%s'", name, node.Type)
+		}
+		if node.Path != name {
+			t.Errorf("Expected path '%s', got '%s'", name, node.Path)
+		}
+		zip := node.Properties["zip"]
+		if zip == nil || zip.Pattern != "^[0-9]{5}$" {
+			t.Errorf("Expected %s.zip to carry the referenced pattern", name)
+		}
+		if zip != nil && zip.Path != name+".zip" {
+			t.Errorf("Expected path '%s.zip', got '%s'", name, zip.Path)
+		}
+		if !node.Properties["street"].IsRequired {
+			t.Errorf("Expected %s.street to be required", name)
+		}
+	}
+
+	if desc := root.Properties["work"].Description; desc != "Work address" {
+		t.Errorf("Expected sibling description to override the target, got '%s'", desc)
+	}
+	if len(parser.Warnings()) != 0 {
+		t.Errorf("Expected no warnings, got %v", parser.Warnings())
+	}
+}
+
+// TestRefResolution_Recursive verifies that self-referencing definitions
+// terminate instead of recursing forever
+func TestRefResolution_Recursive(t *testing.T) {
+	schemaJSON := `{
+		"$ref": "#/$defs/Category",
+		"$defs": {
+			"Category": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"children": {"type": "array", "items": {"$ref": "#/$defs/Category"}}
+				},
+				"required": ["name", "children"]
+			}
+		}
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	depth := 0
+	for node := root; node.Properties != nil; node = node.Properties["children"].Items {
+		depth++
+	}
+	if depth != maxRefDepth {
+		t.Errorf("Expected recursion to stop after %d levels, got %d", maxRefDepth, depth)
+	}
+
+	leaf := root
+	for leaf.Properties["children"].Items.Properties != nil {
+		leaf = leaf.Properties["children"].Items
+	}
+	if maxItems := leaf.Properties["children"].MaxItems; maxItems == nil || *maxItems != 0 {
+		t.Errorf("Expected the innermost children array to be kept empty")
+	}
+
+	warnings := parser.Warnings()
+	if len(warnings) != 1 || warnings[0].Keyword != "$ref" {
+		t.Errorf("Expected a single truncation warning, got %v", warnings)
+	}
+}
+
+// TestRefResolution_Missing verifies that dangling references are reported
+func TestRefResolution_Missing(t *testing.T) {
+	parser := NewParser()
+	parser.raw = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"owner": map[string]interface{}{"$ref": "#/$defs/Person"},
+		},
+	}
+
+	_, err := parser.GetRootNode()
+	if err == nil || !strings.Contains(err.Error(), "#/$defs/Person") {
+		t.Errorf("Expected an error naming the missing reference, got %v", err)
+	}
+}
+
+// TestAllOfMerge verifies that allOf subschemas are folded into one node
+func TestAllOfMerge(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"allOf": [
+			{"$ref": "#/$defs/Named"},
+			{
+				"properties": {
+					"age": {"type": "integer", "minimum": 18},
+					"name": {"maxLength": 20}
+				},
+				"required": ["age"]
+			}
+		],
+		"$defs": {
+			"Named": {
+				"properties": {"name": {"type": "string", "minLength": 2, "maxLength": 50}},
+				"required": ["name"]
+			}
+		}
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	name, age := root.Properties["name"], root.Properties["age"]
+	if name == nil || age == nil {
+		t.Fatalf("Expected properties from every allOf branch, got %v", root.Properties)
+	}
+	if !name.IsRequired || !age.IsRequired {
+		t.Errorf("Expected required lists from every branch to be combined")
+	}
+	if name.MinLength == nil || *name.MinLength != 2 || name.MaxLength == nil || *name.MaxLength != 20 {
+		t.Errorf("Expected name to keep minLength 2 and the tighter maxLength 20")
+	}
+	if age.Minimum == nil || *age.Minimum != 18 {
+		t.Errorf("Expected age minimum of 18")
+	}
+	if len(parser.Warnings()) != 0 {
+		t.Errorf("Expected no warnings, got %v", parser.Warnings())
+	}
+}
+
+// TestOneOfExclusiveRequired verifies that a oneOf branch does not carry
+// properties required only by its siblings
+func TestOneOfExclusiveRequired(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"properties": {
+			"amount": {"type": "number"},
+			"card_number": {"type": "string"},
+			"iban": {"type": "string"}
+		},
+		"required": ["amount"],
+		"oneOf": [
+			{"required": ["card_number"]},
+			{"required": ["iban"]}
+		]
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	if len(root.Variants) != 2 {
+		t.Fatalf("Expected 2 variants, got %d", len(root.Variants))
+	}
+
+	for i, exclusive := range [][2]string{{"card_number", "iban"}, {"iban", "card_number"}} {
+		variant := root.Variants[i]
+		if prop := variant.Properties[exclusive[0]]; prop == nil || !prop.IsRequired {
+			t.Errorf("Variant %d: expected %s to be required", i, exclusive[0])
+		}
+		if _, ok := variant.Properties[exclusive[1]]; ok {
+			t.Errorf("Variant %d: expected %s to be left out", i, exclusive[1])
+		}
+		if _, ok := variant.Properties["amount"]; !ok {
+			t.Errorf("Variant %d: expected shared property amount", i)
+		}
+	}
+}
+
+// TestTypeArray verifies that type arrays are kept and that nullable objects
+// still have their properties parsed
+func TestTypeArray(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"properties": {
+			"note": {"type": ["null", "string"]},
+			"meta": {"type": ["object", "null"], "properties": {"k": {"type": "string"}}}
+		}
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	note := root.Properties["note"]
+	if note.Type != "string" || len(note.Types) != 2 || !note.HasType("null") {
+		t.Errorf("Expected note to be a nullable string, got type %s and types %v", note.Type, note.Types)
+	}
+	if meta := root.Properties["meta"]; meta.Properties["k"] == nil {
+		t.Errorf("Expected properties of the nullable object to be parsed")
+	}
+	if len(parser.Warnings()) != 0 {
+		t.Errorf("Expected no warnings, got %v", parser.Warnings())
+	}
+}
+
+// TestEnumWeights verifies that weights are normalized and must match the
+// enum length
+func TestEnumWeights(t *testing.T) {
+	parser := NewParser()
+	parser.raw = map[string]interface{}{
+		"type":           "string",
+		"enum":           []interface{}{"completed", "pending", "cancelled"},
+		"x-enum-weights": []interface{}{80.0, 15.0, 5.0},
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	expected := []float64{0.8, 0.15, 0.05}
+	for i, weight := range root.EnumWeights {
+		if diff := weight - expected[i]; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("Expected weight %d to be %v, got %v", i, expected[i], weight)
+		}
+	}
+
+	parser.raw["x-enum-weights"] = []interface{}{0.5, 0.5}
+	if _, err := parser.GetRootNode(); err == nil || !strings.Contains(err.Error(), "2 weights for 3 enum values") {
+		t.Errorf("Expected a length mismatch error, got %v", err)
+	}
+}
+
+// TestConstValidation verifies that const is parsed and enforced
+func TestConstValidation(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"properties": {
+			"resourceType": {"const": "Patient"},
+			"version": {"const": 2}
+		}
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	if node := root.Properties["resourceType"]; !node.HasConst || node.Const != "Patient" {
+		t.Errorf("Expected resourceType to be pinned to Patient")
+	}
+
+	if err := parser.Validate(map[string]interface{}{"resourceType": "Patient", "version": 2.0}); err != nil {
+		t.Errorf("Expected matching record to pass, got %v", err)
+	}
+	err = parser.Validate(map[string]interface{}{"resourceType": "Observation", "version": 3.0})
+	failure, ok := err.(*ValidationFailure)
+	if !ok || len(failure.Issues) != 2 {
+		t.Fatalf("Expected two const violations, got %v", err)
+	}
+	for _, issue := range failure.Issues {
+		if issue.Keyword != "const" {
+			t.Errorf("Expected const violation, got %+v", issue)
+		}
+	}
+}
+
+// TestDistribution verifies both forms of x-distribution and rejects
+// unknown distributions
+func TestDistribution(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"properties": {
+			"age": {"type": "integer", "x-distribution": {"type": "normal", "mean": 40, "stddev": 12}},
+			"price": {"type": "number", "x-distribution": "lognormal"}
+		}
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	age := root.Properties["age"].Distribution
+	if age == nil || age.Type != "normal" || *age.Mean != 40 || *age.StdDev != 12 {
+		t.Errorf("Expected normal(40, 12) for age, got %+v", age)
+	}
+	if price := root.Properties["price"].Distribution; price == nil || price.Type != "lognormal" {
+		t.Errorf("Expected lognormal for price, got %+v", price)
+	}
+
+	parser.raw = map[string]interface{}{"type": "number", "x-distribution": "zipf"}
+	if _, err := parser.GetRootNode(); err == nil {
+		t.Errorf("Expected an error for an unknown distribution")
+	}
+}
+
+// TestLengthDistribution verifies both forms of x-length-distribution,
+// rejects impossible parameters and warns when the field is not an array
+func TestLengthDistribution(t *testing.T) {
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"properties": {
+			"cart": {"type": "array", "items": {"type": "string"}, "x-length-distribution": {"type": "poisson", "lambda": 2}},
+			"visits": {"type": "array", "items": {"type": "string"}, "x-length-distribution": "geometric"},
+			"name": {"type": "string", "x-length-distribution": "poisson"}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	if cart := root.Properties["cart"].LengthDist; cart == nil || cart.Type != "poisson" || *cart.Lambda != 2 {
+		t.Errorf("Expected poisson(2) for cart, got %+v", cart)
+	}
+	if visits := root.Properties["visits"].LengthDist; visits == nil || visits.Type != "geometric" || visits.P != nil {
+		t.Errorf("Expected geometric for visits, got %+v", visits)
+	}
+	if warnings := parser.Warnings(); len(warnings) != 1 || warnings[0].Path != "name" || warnings[0].Keyword != "x-length-distribution" {
+		t.Errorf("Expected a warning for the string field, got %+v", warnings)
+	}
+
+	for _, dist := range []string{`"normal"`, `{"type": "poisson", "lambda": 0}`, `{"type": "geometric", "p": 1.5}`} {
+		parser.raw = map[string]interface{}{}
+		json.Unmarshal([]byte(`{"type": "array", "x-length-distribution": `+dist+`}`), &parser.raw)
+		if _, err := parser.GetRootNode(); err == nil {
+			t.Errorf("Expected an error for x-length-distribution %s", dist)
+		}
+	}
+}
+
+// TestTupleItems verifies both tuple forms and how trailing items are
+// described
+func TestTupleItems(t *testing.T) {
+	schemaJSON := `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"properties": {
+			"point": {
+				"type": "array",
+				"items": [{"type": "string"}, {"type": "number"}],
+				"additionalItems": false
+			},
+			"row": {
+				"type": "array",
+				"prefixItems": [{"type": "integer"}],
+				"items": {"type": "boolean"}
+			}
+		}
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	point := root.Properties["point"]
+	if len(point.TupleItems) != 2 || point.TupleItems[1].Type != "number" || !point.ClosedTuple {
+		t.Errorf("Expected a closed string/number tuple, got %d items (closed: %v)", len(point.TupleItems), point.ClosedTuple)
+	}
+	if point.TupleItems[1].Path != "point[1]" {
+		t.Errorf("Expected path 'point[1]', got '%s'", point.TupleItems[1].Path)
+	}
+
+	row := root.Properties["row"]
+	if len(row.TupleItems) != 1 || row.ItemAt(0).Type != "integer" || row.ItemAt(3).Type != "boolean" {
+		t.Errorf("Expected an integer followed by booleans for row")
+	}
+	if len(parser.Warnings()) != 0 {
+		t.Errorf("Expected no warnings, got %v", parser.Warnings())
+	}
+}
+
+// TestAdditionalProperties verifies the schema form is parsed and that
+// additionalProperties: false rejects undeclared keys
+func TestAdditionalProperties(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"properties": {
+			"labels": {
+				"type": "object",
+				"additionalProperties": {"type": "string"}
+			},
+			"address": {
+				"type": "object",
+				"properties": {"city": {"type": "string"}},
+				"additionalProperties": false
+			}
+		}
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	labels := root.Properties["labels"]
+	if labels.AdditionalProperties == nil || labels.AdditionalProperties.Type != "string" {
+		t.Fatalf("Expected string additionalProperties on labels")
+	}
+	if labels.AdditionalProperties.Path != "labels.*" {
+		t.Errorf("Expected path 'labels.*', got '%s'", labels.AdditionalProperties.Path)
+	}
+	if root.Properties["address"].AdditionalProperties != nil {
+		t.Errorf("Expected no additionalProperties schema on address")
+	}
+
+	valid := map[string]interface{}{
+		"labels":  map[string]interface{}{"team": "billing"},
+		"address": map[string]interface{}{"city": "Lyon"},
+	}
+	if err := parser.Validate(valid); err != nil {
+		t.Errorf("Expected record to pass, got %v", err)
+	}
+
+	invalid := map[string]interface{}{
+		"address": map[string]interface{}{"city": "Lyon", "zip": "69001"},
+	}
+	failure, ok := parser.Validate(invalid).(*ValidationFailure)
+	if !ok || len(failure.Issues) != 1 || failure.Issues[0].Keyword != "additionalProperties" {
+		t.Fatalf("Expected an additionalProperties violation, got %v", failure)
+	}
+}
+
+// TestValidate verifies each kind of violation is reported as a
+// ValidationFailure with the failing value's path, keyword and message
+func TestValidate(t *testing.T) {
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["id", "name"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"name": {"type": "string", "minLength": 3},
+			"address": {"type": "object", "properties": {"zip": {"type": "string"}}}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	if err := parser.Validate(map[string]interface{}{"id": 1.0, "name": "Ada"}); err != nil {
+		t.Fatalf("Expected a valid record to pass, got %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		data    map[string]interface{}
+		path    string
+		keyword string
+		message string
+	}{
+		{"type mismatch", map[string]interface{}{"id": "one", "name": "Ada"}, "/id", "type", "got string, want integer"},
+		{"nested type mismatch", map[string]interface{}{"id": 1.0, "name": "Ada", "address": map[string]interface{}{"zip": 75001.0}}, "/address/zip", "type", "got number, want string"},
+		{"missing required field", map[string]interface{}{"id": 1.0}, "", "required", "missing property 'name'"},
+		{"minLength", map[string]interface{}{"id": 1.0, "name": "Al"}, "/name", "minLength", "minLength: got 2, want 3"},
+		{"minimum", map[string]interface{}{"id": 0.0, "name": "Ada"}, "/id", "minimum", "minimum: got 0, want 1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			failure, ok := parser.Validate(tc.data).(*ValidationFailure)
+			if !ok || len(failure.Issues) != 1 {
+				t.Fatalf("Expected one violation, got %v", failure)
+			}
+			issue := failure.Issues[0]
+			if issue.Path != tc.path || issue.Keyword != tc.keyword || issue.Message != tc.message {
+				t.Errorf("Expected %q at %q (%s), got %q at %q (%s)", tc.message, tc.path, tc.keyword, issue.Message, issue.Path, issue.Keyword)
+			}
+			if want := displayPointer(tc.path) + ": " + tc.message; failure.Error() != want {
+				t.Errorf("Expected error %q, got %q", want, failure.Error())
+			}
+		})
+	}
+}
+
+// TestPatternProperties verifies patternProperties are parsed in a stable
+// order and invalid regexes are rejected
+func TestPatternProperties(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"properties": {"name": {"type": "string"}},
+		"patternProperties": {
+			"^x-": {"type": "string"},
+			"^[0-9]+$": {"type": "integer"}
+		}
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	if len(root.PatternProperties) != 2 || root.PatternProperties[0].Pattern != "^[0-9]+$" {
+		t.Fatalf("Expected two sorted pattern properties, got %d", len(root.PatternProperties))
+	}
+	if node := root.PropertyNode("x-trace"); node == nil || node.Type != "string" {
+		t.Errorf("Expected x-trace to use the ^x- schema")
+	}
+	if node := root.PropertyNode("42"); node == nil || node.Type != "integer" {
+		t.Errorf("Expected 42 to use the ^[0-9]+$ schema")
+	}
+	if node := root.PropertyNode("other"); node != nil {
+		t.Errorf("Expected no schema for an unmatched key")
+	}
+
+	invalid := NewParser()
+	if err := invalid.ParseBytes([]byte(`{"type": "object", "patternProperties": {"(?<=a)b": {}}}`)); err == nil {
+		if _, err := invalid.GetRootNode(); err == nil {
+			t.Errorf("Expected an unsupported regex to be rejected")
+		}
+	}
+}
+
+// TestArrayRoot verifies that records of an array-root schema are its items
+func TestArrayRoot(t *testing.T) {
+	schemaJSON := `{
+		"type": "array",
+		"minItems": 3,
+		"items": {
+			"type": "object",
+			"required": ["id"],
+			"properties": {"id": {"type": "integer"}}
+		}
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	if record := root.RecordNode(); record != root.Items {
+		t.Errorf("Expected the items schema to describe records")
+	}
+
+	if err := parser.ValidateItem(map[string]interface{}{"id": 1.0}); err != nil {
+		t.Errorf("Expected a single item to pass, got %v", err)
+	}
+	if err := parser.ValidateItem(map[string]interface{}{"id": "one"}); err == nil {
+		t.Errorf("Expected an invalid item to fail")
+	}
+
+	objectParser := NewParser()
+	if err := objectParser.ParseBytes([]byte(`{"type": "object"}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	objectRoot, _ := objectParser.GetRootNode()
+	if objectRoot.RecordNode() != objectRoot {
+		t.Errorf("Expected an object root to describe records itself")
+	}
+}
+
+// TestDefault verifies default values and x-default-prob are parsed and
+// that an out-of-range probability is rejected
+func TestDefault(t *testing.T) {
+	schemaJSON := `{
+		"type": "object",
+		"properties": {
+			"status": {"type": "string", "default": "active", "x-default-prob": 0.6},
+			"settings": {"type": "object", "default": {"theme": "dark", "tabs": [1, 2]}},
+			"note": {"type": ["string", "null"], "default": null}
+		}
+	}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	status := root.Properties["status"]
+	if !status.HasDefault || status.Default != "active" || status.DefaultProb != 0.6 {
+		t.Errorf("Expected status default 'active' with probability 0.6")
+	}
+	if settings := root.Properties["settings"]; !settings.HasDefault || settings.DefaultProb != 0 {
+		t.Errorf("Expected settings default with probability 0")
+	}
+	if note := root.Properties["note"]; !note.HasDefault || note.Default != nil {
+		t.Errorf("Expected a null default for note")
+	}
+
+	for _, invalid := range []string{
+		`{"type": "string", "default": "a", "x-default-prob": 1.5}`,
+		`{"type": "string", "x-default-prob": 0.5}`,
+	} {
+		parser := NewParser()
+		if err := parser.ParseBytes([]byte(invalid)); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		if _, err := parser.GetRootNode(); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+// TestRegexMatchRule verifies regex_match patterns are checked when the
+// schema is loaded and that rule patches are kept
+func TestRegexMatchRule(t *testing.T) {
+	rule := `{"name": "mrn_format", "rule": "regex_match", "fields": ["mrn"], "constraint": %q,
+		"patch": {"strategy": "regenerate"}}`
+
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(`{"type": "object", "x-cross-field-rules": [` + fmt.Sprintf(rule, `^MRN-\d{6}$`) + `]}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	rules := parser.GetCrossFieldRules(root)
+	if len(rules) != 1 || rules[0].Constraint != `^MRN-\d{6}$` {
+		t.Fatalf("Expected the regex_match rule to keep its pattern, got %+v", rules)
+	}
+	if rules[0].Patch == nil || rules[0].Patch.Strategy != "regenerate" {
+		t.Errorf("Expected the rule's patch to be parsed, got %+v", rules[0].Patch)
+	}
+
+	parser = NewParser()
+	if err := parser.ParseBytes([]byte(`{"type": "object", "x-cross-field-rules": [` + fmt.Sprintf(rule, `^MRN-(\d+$`) + `]}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	if _, err := parser.GetRootNode(); err == nil {
+		t.Errorf("Expected an invalid regex_match pattern to be rejected")
+	}
+}
+
+// TestParseErrorLocation verifies malformed schemas are reported with the
+// line and column of the error
+func TestParseErrorLocation(t *testing.T) {
+	tests := []struct {
+		schema   string
+		location string
+	}{
+		{"{\n  \"type\": \"object\",\n  \"properties\": {\n    \"id\": {\"type\": \"string\"},\n  }\n}", "line 5, column 3"},
+		{"{\"type\": \"object\"", "line 1, column 18"},
+		{"[1, 2]", "line 1, column 1"},
+	}
+
+	for _, tt := range tests {
+		err := NewParser().ParseBytes([]byte(tt.schema))
+		if err == nil {
+			t.Errorf("Expected %q to fail to parse", tt.schema)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.location) {
+			t.Errorf("Expected error for %q at %s, got %v", tt.schema, tt.location, err)
+		}
+	}
+}
+
+// TestContains_Impossible verifies contains bounds no array can meet are
+// rejected
+func TestContains_Impossible(t *testing.T) {
+	schemas := map[string]string{
+		"maxItems":    `{"type": "array", "maxItems": 1, "contains": {"type": "string"}, "minContains": 2}`,
+		"maxContains": `{"type": "array", "contains": {"type": "string"}, "minContains": 3, "maxContains": 2}`,
+	}
+	for bound, schemaJSON := range schemas {
+		parser := NewParser()
+		if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		_, err := parser.GetRootNode()
+		if err == nil || !strings.Contains(err.Error(), "exceeds "+bound) {
+			t.Errorf("Expected minContains exceeding %s to be rejected, got %v", bound, err)
+		}
+	}
+}
+
+func TestDependencies_Legacy(t *testing.T) {
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"properties": {
+			"credit_card": {"type": "string"},
+			"billing_address": {"type": "string"},
+			"country": {"type": "string"},
+			"postal_code": {"type": "string"}
+		},
+		"dependencies": {
+			"credit_card": ["billing_address"],
+			"country": {"required": ["postal_code"]}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	if got := root.DependentRequired["credit_card"]; !reflect.DeepEqual(got, []string{"billing_address"}) {
+		t.Errorf("Expected credit_card to require billing_address, got %v", got)
+	}
+	dependency := root.DependentSchemas["country"]
+	if dependency == nil {
+		t.Fatal("Expected a dependent schema for country")
+	}
+	if !reflect.DeepEqual(dependency.Keys, []string{"postal_code"}) {
+		t.Errorf("Expected the dependent schema to constrain postal_code, got %v", dependency.Keys)
+	}
+	for _, warning := range parser.Warnings() {
+		if warning.Keyword == "dependencies" {
+			t.Errorf("Expected dependencies to be supported, got warning %+v", warning)
+		}
+	}
+}
+
+// TestParseYAML verifies a YAML schema builds the same tree as its JSON
+// equivalent, extensions included
+func TestParseYAML(t *testing.T) {
+	jsonSchema := `{
+		"type": "object",
+		"required": ["mrn"],
+		"properties": {
+			"mrn": {"type": "string", "pattern": "^MRN-[0-9]{6}$"},
+			"admitted": {"type": "string", "format": "date", "default": "2024-01-01"},
+			"notes": {"type": "string", "x-llm": true, "description": "Free text notes\nabout the visit"},
+			"responses": {"type": "object", "properties": {"200": {"type": "string"}}}
+		},
+		"x-cross-field-rules": [
+			{"name": "mrn_format", "rule": "regex_match", "fields": ["mrn"], "constraint": "^MRN-"}
+		]
+	}`
+	yamlSchema := `
+type: object
+required: [mrn]
+properties:
+  mrn:
+    type: string
+    pattern: "^MRN-[0-9]{6}$"
+  admitted:
+    type: string
+    format: date
+    default: 2024-01-01 # stays a string
+  notes:
+    type: string
+    x-llm: true
+    description: |-
+      Free text notes
+      about the visit
+  responses:
+    type: object
+    properties:
+      200: {type: string}
+x-cross-field-rules:
+  - name: mrn_format
+    rule: regex_match
+    fields: [mrn]
+    constraint: "^MRN-"
+`
+
+	dir := t.TempDir()
+	trees := make(map[string]string)
+	for name, content := range map[string]string{"schema.json": jsonSchema, "schema.yaml": yamlSchema} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		parser := NewParser()
+		if err := parser.ParseFile(path); err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+		root, err := parser.GetRootNode()
+		if err != nil {
+			t.Fatalf("Failed to build root node of %s: %v", name, err)
+		}
+		if fields := parser.GetLLMFields(root); len(fields) == 0 || fields[0] != "notes" {
+			t.Errorf("Expected notes to be the LLM field of %s, got %v", name, fields)
+		}
+		if rules := parser.GetCrossFieldRules(root); len(rules) != 1 || rules[0].Name != "mrn_format" {
+			t.Errorf("Expected the cross-field rule of %s, got %+v", name, rules)
+		}
+		if err := parser.Validate(map[string]interface{}{"mrn": "MRN-12345"}); err == nil {
+			t.Errorf("Expected %s to reject an invalid mrn", name)
+		}
+
+		tree, err := json.Marshal(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trees[name] = string(tree)
+	}
+	if trees["schema.json"] != trees["schema.yaml"] {
+		t.Errorf("Expected the YAML schema to match the JSON one:\n%s\n%s", trees["schema.json"], trees["schema.yaml"])
+	}
+
+	parser := NewParser()
+	if err := parser.ParseYAML([]byte("- type: string\n")); err == nil {
+		t.Error("Expected a YAML schema that is not a mapping to be rejected")
+	}
+}
+
+// TestGeneratorExtension verifies x-generator names are checked when the
+// schema is loaded
+func TestGeneratorExtension(t *testing.T) {
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(`{"type": "object", "properties": {"name": {"type": "string", "x-generator": "full_name"}}}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	if got := root.PropertyNode("name").Generator; got != "full_name" {
+		t.Errorf("Expected the full_name generator, got %q", got)
+	}
+
+	for _, invalid := range []string{
+		`{"type": "string", "x-generator": "favorite_color"}`,
+		`{"type": "string", "x-generator": 3}`,
+		`{"type": "integer", "x-generator": "city"}`,
+	} {
+		parser := NewParser()
+		if err := parser.ParseBytes([]byte(invalid)); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		if _, err := parser.GetRootNode(); err == nil || !strings.Contains(err.Error(), "x-generator") {
+			t.Errorf("Expected %s to be rejected, got %v", invalid, err)
+		}
+	}
+}
+
+// TestLLMPromptExtension verifies x-llm-prompt templates are parsed when the
+// schema is loaded and mark their field for enrichment
+func TestLLMPromptExtension(t *testing.T) {
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(`{"type": "object", "properties": {
+		"diagnosis": {"type": "string", "x-llm-prompt": "Describe a diagnosis for a {{.age}} year old patient"}
+	}}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	node := root.PropertyNode("diagnosis")
+	if node.LLMPrompt == nil || !node.LLMEnhanced {
+		t.Errorf("Expected diagnosis to carry a prompt template and be marked for enrichment")
+	}
+
+	for _, invalid := range []string{
+		`{"type": "string", "x-llm-prompt": "Describe {{.age"}`,
+		`{"type": "string", "x-llm-prompt": ""}`,
+		`{"type": "string", "x-llm-prompt": 3}`,
+	} {
+		parser := NewParser()
+		if err := parser.ParseBytes([]byte(invalid)); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		if _, err := parser.GetRootNode(); err == nil || !strings.Contains(err.Error(), "x-llm-prompt") {
+			t.Errorf("Expected %s to be rejected, got %v", invalid, err)
+		}
+	}
+}
+
+// TestLocaleExtension verifies supported x-locale values are kept and
+// unsupported ones fall back with a warning
+func TestLocaleExtension(t *testing.T) {
+	parser := NewParser()
+	if err := parser.ParseBytes([]byte(`{"type": "object", "properties": {
+		"name": {"type": "string", "x-generator": "full_name", "x-locale": "fr-FR"},
+		"city": {"type": "string", "x-generator": "city", "x-locale": "pt-BR"}
+	}}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	if got := root.PropertyNode("name").Locale; got != "fr-FR" {
+		t.Errorf("Expected the fr-FR locale, got %q", got)
+	}
+	if got := root.PropertyNode("city").Locale; got != "" {
+		t.Errorf("Expected an unsupported locale to be dropped, got %q", got)
+	}
+
+	warnings := parser.Warnings()
+	if len(warnings) != 1 || warnings[0].Keyword != "x-locale" || warnings[0].Path != "city" {
+		t.Errorf("Expected one x-locale warning for city, got %+v", warnings)
+	}
+}
+
+// TestCanonicalHash verifies formatting and key order do not change the
+// schema hash, while content does
+func TestCanonicalHash(t *testing.T) {
+	hash := func(parse func(*Parser) error) string {
+		t.Helper()
+		parser := NewParser()
+		if err := parse(parser); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		sum, err := parser.CanonicalHash()
+		if err != nil {
+			t.Fatalf("Failed to hash schema: %v", err)
+		}
+		return sum
+	}
+	fromJSON := func(schemaJSON string) func(*Parser) error {
+		return func(p *Parser) error { return p.ParseBytes([]byte(schemaJSON)) }
+	}
+
+	base := hash(fromJSON(`{"type": "object", "properties": {"id": {"type": "integer", "maximum": 10}}}`))
+	same := []string{
+		hash(fromJSON("{\n  \"properties\": {\"id\": {\"maximum\": 10, \"type\": \"integer\"}},\n  \"type\": \"object\"\n}\n")),
+		hash(func(p *Parser) error {
+			return p.ParseYAML([]byte("type: object\nproperties:\n  id:\n    type: integer\n    maximum: 10\n"))
+		}),
+	}
+	for i, sum := range same {
+		if sum != base {
+			t.Errorf("Expected equivalent schema %d to hash the same", i)
+		}
+	}
+
+	if changed := hash(fromJSON(`{"type": "object", "properties": {"id": {"type": "integer", "maximum": 11}}}`)); changed == base {
+		t.Error("Expected a changed schema to hash differently")
+	}
+}
+
+// TestSchemaWarnings verifies unsupported keywords and non-local references
+// are reported once each, ordered by path, however often the root node is
+// built
+func TestSchemaWarnings(t *testing.T) {
+	parser := NewParser()
+	parser.raw = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"status": map[string]interface{}{"type": "string", "not": map[string]interface{}{"const": "deleted"}},
+			"payload": map[string]interface{}{
+				"type":             "string",
+				"contentMediaType": "application/json",
+				"contentSchema":    map[string]interface{}{"type": "object"},
+			},
+			"address": map[string]interface{}{"$ref": "https://example.com/schemas/address.json"},
+		},
+	}
+
+	expected := []SchemaWarning{
+		{Path: "address", Keyword: "$ref"},
+		{Path: "payload", Keyword: "contentMediaType"},
+		{Path: "payload", Keyword: "contentSchema"},
+		{Path: "status", Keyword: "not"},
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := parser.GetRootNode(); err != nil {
+			t.Fatalf("Failed to build root node: %v", err)
+		}
+
+		warnings := parser.Warnings()
+		if len(warnings) != len(expected) {
+			t.Fatalf("Build %d: expected %d warnings, got %v", i+1, len(expected), warnings)
+		}
+		for j, warning := range warnings {
+			if warning.Path != expected[j].Path || warning.Keyword != expected[j].Keyword {
+				t.Errorf("Build %d: expected warning %d at %s for %s, got %+v", i+1, j, expected[j].Path, expected[j].Keyword, warning)
+			}
+		}
+		if !strings.Contains(warnings[0].Message, "https://example.com/schemas/address.json is not local") {
+			t.Errorf("Expected the non-local reference to be named, got %q", warnings[0].Message)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package stats provides streaming accumulators for summarizing dataset
+// fields in bounded memory.
+package stats
+
+import (
+	"math"
+	mathrand "math/rand"
+	"sort"
+)
+
+// NumericSummary describes the distribution of a numeric field
+type NumericSummary struct {
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	P50    float64 `json:"p50"`
+	P90    float64 `json:"p90"`
+	P99    float64 `json:"p99"`
+}
+
+// Numeric accumulates min, max, mean and variance exactly and estimates
+// percentiles from a fixed-size reservoir sample
+type Numeric struct {
+	count     int
+	min       float64
+	max       float64
+	mean      float64
+	m2        float64
+	reservoir []float64
+	size      int
+	rng       *mathrand.Rand
+}
+
+// NewNumeric creates a numeric accumulator keeping at most reservoirSize
+// samples for percentiles. The sample is seeded so results are reproducible.
+func NewNumeric(reservoirSize int) *Numeric {
+	return &Numeric{
+		size: reservoirSize,
+		rng:  mathrand.New(mathrand.NewSource(1)),
+	}
+}
+
+// Add records a value
+func (n *Numeric) Add(value float64) {
+	n.count++
+	if n.count == 1 || value < n.min {
+		n.min = value
+	}
+	if n.count == 1 || value > n.max {
+		n.max = value
+	}
+
+	// Welford's online algorithm
+	delta := value - n.mean
+	n.mean += delta / float64(n.count)
+	n.m2 += delta * (value - n.mean)
+
+	// Reservoir sampling (Algorithm R)
+	if len(n.reservoir) < n.size {
+		n.reservoir = append(n.reservoir, value)
+	} else if j := n.rng.Intn(n.count); j < n.size {
+		n.reservoir[j] = value
+	}
+}
+
+// Summary returns the accumulated statistics
+func (n *Numeric) Summary() NumericSummary {
+	summary := NumericSummary{
+		Count: n.count,
+		Min:   n.min,
+		Max:   n.max,
+		Mean:  n.mean,
+	}
+	if n.count > 1 {
+		summary.StdDev = math.Sqrt(n.m2 / float64(n.count-1))
+	}
+
+	sample := make([]float64, len(n.reservoir))
+	copy(sample, n.reservoir)
+	sort.Float64s(sample)
+	summary.P50 = percentile(sample, 0.50)
+	summary.P90 = percentile(sample, 0.90)
+	summary.P99 = percentile(sample, 0.99)
+
+	return summary
+}
+
+// percentile interpolates the p-th quantile of a sorted sample
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// ValueCount is a categorical value and how often it was seen
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Categorical counts value frequencies with at most capacity counters.
+// Counts are exact while the field has no more distinct values than the
+// capacity; beyond that the Misra-Gries algorithm keeps the frequent values
+// and counts become lower bounds.
+type Categorical struct {
+	total       int
+	counts      map[string]int
+	capacity    int
+	approximate bool
+}
+
+// NewCategorical creates a categorical accumulator with the given number
+// of counters
+func NewCategorical(capacity int) *Categorical {
+	return &Categorical{
+		counts:   make(map[string]int),
+		capacity: capacity,
+	}
+}
+
+// Add records a value
+func (c *Categorical) Add(value string) {
+	c.total++
+
+	if _, ok := c.counts[value]; ok || len(c.counts) < c.capacity {
+		c.counts[value]++
+		return
+	}
+
+	// Out of counters: decrement every counter instead of adding one
+	c.approximate = true
+	for key := range c.counts {
+		c.counts[key]--
+		if c.counts[key] == 0 {
+			delete(c.counts, key)
+		}
+	}
+}
+
+// Total returns how many values were recorded
+func (c *Categorical) Total() int {
+	return c.total
+}
+
+// Approximate reports whether the counts are lower bounds rather than exact
+func (c *Categorical) Approximate() bool {
+	return c.approximate
+}
+
+// Top returns the n most frequent values, most frequent first
+func (c *Categorical) Top(n int) []ValueCount {
+	values := make([]ValueCount, 0, len(c.counts))
+	for value, count := range c.counts {
+		values = append(values, ValueCount{Value: value, Count: count})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+
+	if len(values) > n {
+		values = values[:n]
+	}
+	return values
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestNumeric(t *testing.T) {
+	acc := NewNumeric(10000)
+	for i := 1; i <= 1000; i++ {
+		acc.Add(float64(i))
+	}
+
+	s := acc.Summary()
+	if s.Count != 1000 || s.Min != 1 || s.Max != 1000 {
+		t.Errorf("Expected count 1000, min 1, max 1000, got %+v", s)
+	}
+	if s.Mean != 500.5 {
+		t.Errorf("Expected mean 500.5, got %v", s.Mean)
+	}
+	if math.Abs(s.StdDev-288.8194) > 0.001 {
+		t.Errorf("Expected stddev ~288.8194, got %v", s.StdDev)
+	}
+	if s.P50 != 500.5 || math.Abs(s.P90-900.1) > 1e-9 {
+		t.Errorf("Expected p50 500.5 and p90 900.1, got %v and %v", s.P50, s.P90)
+	}
+}
+
+// TestNumeric_Reservoir verifies that memory stays bounded and percentiles
+// are still estimated from the sample
+func TestNumeric_Reservoir(t *testing.T) {
+	acc := NewNumeric(500)
+	for i := 0; i < 100000; i++ {
+		acc.Add(float64(i % 100))
+	}
+
+	if len(acc.reservoir) != 500 {
+		t.Errorf("Expected reservoir of 500, got %d", len(acc.reservoir))
+	}
+	if s := acc.Summary(); math.Abs(s.P50-49.5) > 10 {
+		t.Errorf("Expected p50 near 49.5, got %v", s.P50)
+	}
+}
+
+func TestCategorical(t *testing.T) {
+	acc := NewCategorical(10)
+	for i := 0; i < 100; i++ {
+		acc.Add([]string{"a", "a", "a", "b", "b", "c"}[i%6])
+	}
+
+	top := acc.Top(2)
+	if len(top) != 2 || top[0].Value != "a" || top[1].Value != "b" {
+		t.Errorf("Expected a then b, got %v", top)
+	}
+	if top[0].Count != 51 || acc.Approximate() {
+		t.Errorf("Expected exact count 51 for a, got %d (approximate: %v)", top[0].Count, acc.Approximate())
+	}
+}
+
+// TestCategorical_Bounded verifies that high-cardinality fields keep at most
+// capacity counters and still surface the dominant value
+func TestCategorical_Bounded(t *testing.T) {
+	acc := NewCategorical(10)
+	for i := 0; i < 10000; i++ {
+		if i%2 == 0 {
+			acc.Add("common")
+		} else {
+			acc.Add(fmt.Sprintf("id-%d", i))
+		}
+	}
+
+	if len(acc.counts) > 10 {
+		t.Errorf("Expected at most 10 counters, got %d", len(acc.counts))
+	}
+	if !acc.Approximate() {
+		t.Errorf("Expected counts to be marked approximate")
+	}
+	if top := acc.Top(1); len(top) != 1 || top[0].Value != "common" {
+		t.Errorf("Expected common to be the top value, got %v", top)
+	}
+	if acc.Total() != 10000 {
+		t.Errorf("Expected total 10000, got %d", acc.Total())
+	}
+}
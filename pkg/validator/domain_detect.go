@@ -0,0 +1,161 @@
+package validator
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// minDomainScore is the score a domain needs before its rules are applied,
+// so one generic field such as currency is not enough
+const minDomainScore = 3
+
+// domainSignals weighs the field names that point to each domain. Fields the
+// domain rules check weigh the most. Signals match whole words of a field
+// name, so npi matches provider_npi and routing_number matches
+// routingNumber.
+var domainSignals = map[string]map[string]int{
+	"healthcare": {
+		"icd10": 3, "icd10_code": 3, "icd10_pcs_code": 3, "loinc_code": 3, "ndc_code": 3, "cvx_code": 3, "npi": 3,
+		"patient": 2, "diagnosis": 2, "diagnoses": 2, "encounter": 2, "immunizations": 2, "prescriptions": 2,
+		"systolic": 1, "diastolic": 1, "date_of_birth": 1, "total_charges": 1, "service_date": 1,
+	},
+	"fintech": {
+		"routing_number": 3, "iban": 3, "bic": 3, "swift": 3,
+		"account_number": 2, "transaction_amount": 2, "risk_score": 2, "merchant": 2, "card_number": 2,
+		"currency": 1, "approval_status": 1, "transaction": 1, "balance": 1,
+	},
+	"ecommerce": {
+		"sku": 3, "base_price": 2, "sale_price": 2, "stock_quantity": 2, "warehouse_location": 2, "cart": 2,
+		"product": 1, "inventory": 1, "order": 1, "shipping": 1,
+	},
+	"telecom": {
+		"imei": 3, "msisdn": 3, "imsi": 3,
+		"called_number": 2, "call_duration": 2, "data_bytes": 2, "subscriber": 2, "cell_id": 2,
+		"roaming": 1, "carrier": 1,
+	},
+}
+
+// DomainDetection is the domain inferred from the field names of a schema
+type DomainDetection struct {
+	Domain string
+	Score  int
+	Fields []string // names of the fields that point to Domain, sorted
+}
+
+// DetectDomain infers which domain's rules apply to records of root from
+// its field names, weighing each field by its strongest signal. Domain is
+// empty when no domain reaches minDomainScore or the top two tie.
+func DetectDomain(root *schema.SchemaNode) DomainDetection {
+	names := make(map[string]bool)
+	collectFieldNames(root, names)
+
+	scores := make(map[string]*DomainDetection, len(domainSignals))
+	for name := range names {
+		words := nameWords(name)
+		for domain, signals := range domainSignals {
+			weight := 0
+			for signal, w := range signals {
+				if w > weight && containsWords(words, strings.Split(signal, "_")) {
+					weight = w
+				}
+			}
+			if weight == 0 {
+				continue
+			}
+			if scores[domain] == nil {
+				scores[domain] = &DomainDetection{Domain: domain}
+			}
+			scores[domain].Score += weight
+			scores[domain].Fields = append(scores[domain].Fields, name)
+		}
+	}
+
+	var best, runnerUp DomainDetection
+	for _, detection := range scores {
+		switch {
+		case detection.Score > best.Score:
+			best, runnerUp = *detection, best
+		case detection.Score > runnerUp.Score:
+			runnerUp = *detection
+		}
+	}
+	if best.Score < minDomainScore || best.Score == runnerUp.Score {
+		return DomainDetection{}
+	}
+	sort.Strings(best.Fields)
+	return best
+}
+
+// collectFieldNames adds the property names declared anywhere under node
+func collectFieldNames(node *schema.SchemaNode, names map[string]bool) {
+	if node == nil {
+		return
+	}
+	for name, property := range node.Properties {
+		names[name] = true
+		collectFieldNames(property, names)
+	}
+	collectFieldNames(node.Items, names)
+	for _, item := range node.TupleItems {
+		collectFieldNames(item, names)
+	}
+	for _, variant := range node.Variants {
+		collectFieldNames(variant, names)
+	}
+	for _, pp := range node.PatternProperties {
+		collectFieldNames(pp.Node, names)
+	}
+	collectFieldNames(node.AdditionalProperties, names)
+}
+
+// nameWords splits a field name into lowercase words at punctuation and
+// camelCase boundaries, keeping letters and digits together as in icd10
+func nameWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = word[:0]
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// containsWords reports whether want appears as consecutive words of words
+func containsWords(words, want []string) bool {
+	for i := 0; i+len(want) <= len(words); i++ {
+		match := true
+		for j, w := range want {
+			if words[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// HasDomain reports whether there are built-in rules for domain
+func (dv *DomainValidator) HasDomain(domain string) bool {
+	_, ok := dv.rules[domain]
+	return ok
+}
@@ -0,0 +1,42 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// TestDetectDomain verifies the domain is inferred from field names in any
+// case style, and that weak or tied evidence detects nothing
+func TestDetectDomain(t *testing.T) {
+	testCases := []struct {
+		schema string
+		domain string
+		fields []string
+	}{
+		{`{"type": "object", "properties": {"id": {"type": "string"}, "encounter": {"type": "object", "properties": {"providerNPI": {"type": "string"}, "ICD10Code": {"type": "string"}}}}}`,
+			"healthcare", []string{"ICD10Code", "encounter", "providerNPI"}},
+		{`{"type": "array", "items": {"type": "object", "properties": {"routingNumber": {"type": "string"}, "currency": {"type": "string"}}}}`,
+			"fintech", []string{"currency", "routingNumber"}},
+		{`{"type": "object", "properties": {"sku": {"type": "string"}, "skull_size": {"type": "integer"}}}`,
+			"ecommerce", []string{"sku"}},
+		{`{"type": "object", "properties": {"currency": {"type": "string"}, "shipping": {"type": "string"}}}`, "", nil},
+		{`{"type": "object", "properties": {"imei": {"type": "string"}, "npi": {"type": "string"}}}`, "", nil},
+	}
+
+	for _, tc := range testCases {
+		parser := schema.NewParser()
+		if err := parser.ParseBytes([]byte(tc.schema)); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		root, err := parser.GetRootNode()
+		if err != nil {
+			t.Fatalf("Failed to build root node: %v", err)
+		}
+		detection := DetectDomain(root)
+		if detection.Domain != tc.domain || !reflect.DeepEqual(detection.Fields, tc.fields) {
+			t.Errorf("Expected %q from %v for %s, got %q from %v", tc.domain, tc.fields, tc.schema, detection.Domain, detection.Fields)
+		}
+	}
+}
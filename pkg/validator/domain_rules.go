@@ -2,13 +2,37 @@ package validator
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"time"
 )
 
 // DomainValidator provides domain-specific validation rules
 type DomainValidator struct {
-	rules map[string][]ValidationRule
+	rules      map[string][]ValidationRule
+	fieldPaths map[string]string
+}
+
+// Severities of domain rules. Rules with any other severity count as
+// errors.
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// DomainError is a domain rule that a record failed
+type DomainError struct {
+	Rule     string
+	Severity string
+	Err      error
+}
+
+func (e *DomainError) Error() string {
+	return fmt.Sprintf("[%s] %s: %v", e.Severity, e.Rule, e.Err)
+}
+
+func (e *DomainError) Unwrap() error {
+	return e.Err
 }
 
 // ValidationRule represents a domain-specific validation rule
@@ -22,31 +46,74 @@ type ValidationRule struct {
 // NewDomainValidator creates a new domain validator with built-in rules
 func NewDomainValidator() *DomainValidator {
 	dv := &DomainValidator{
-		rules: make(map[string][]ValidationRule),
+		rules:      make(map[string][]ValidationRule),
+		fieldPaths: make(map[string]string, len(defaultFieldPaths)),
+	}
+
+	for field, pointer := range defaultFieldPaths {
+		dv.fieldPaths[field] = pointer
 	}
 
 	dv.registerHealthcareRules()
 	dv.registerFintechRules()
 	dv.registerEcommerceRules()
+	dv.registerTelecomRules()
 
 	return dv
 }
 
-// ValidateDomain validates data against domain-specific rules
+// RegisterRule adds a rule to a domain, after the rules it already has, so
+// built-in rules keep applying. A rule without a severity is an error.
+// Rules must be registered before validation starts.
+func (dv *DomainValidator) RegisterRule(domain string, rule ValidationRule) {
+	if rule.Severity == "" {
+		rule.Severity = SeverityError
+	}
+	dv.rules[domain] = append(dv.rules[domain], rule)
+}
+
+// RegisterDomain adds rules to a domain, creating it when it is new. Rules
+// of an existing domain, built-in or registered, are kept.
+func (dv *DomainValidator) RegisterDomain(domain string, rules []ValidationRule) {
+	if _, exists := dv.rules[domain]; !exists {
+		dv.rules[domain] = nil
+	}
+	for _, rule := range rules {
+		dv.RegisterRule(domain, rule)
+	}
+}
+
+// ValidateDomain validates data against domain-specific rules, returning a
+// *DomainError for each rule it fails
 func (dv *DomainValidator) ValidateDomain(domain string, data map[string]interface{}) []error {
+	return dv.ValidateDomainSeverity(domain, data, SeverityWarning)
+}
+
+// ValidateDomainSeverity validates data against the domain rules at least
+// as severe as minSeverity, so SeverityError skips warnings
+func (dv *DomainValidator) ValidateDomainSeverity(domain string, data map[string]interface{}, minSeverity string) []error {
 	var errors []error
 
-	if rules, exists := dv.rules[domain]; exists {
-		for _, rule := range rules {
-			if err := rule.Validator(data); err != nil {
-				errors = append(errors, fmt.Errorf("[%s] %s: %v", rule.Severity, rule.Name, err))
-			}
+	for _, rule := range dv.rules[domain] {
+		if severityRank(rule.Severity) < severityRank(minSeverity) {
+			continue
+		}
+		if err := rule.Validator(data); err != nil {
+			errors = append(errors, &DomainError{Rule: rule.Name, Severity: rule.Severity, Err: err})
 		}
 	}
 
 	return errors
 }
 
+// severityRank orders severities, warnings below everything else
+func severityRank(severity string) int {
+	if severity == SeverityWarning {
+		return 0
+	}
+	return 1
+}
+
 // Healthcare domain validation rules
 func (dv *DomainValidator) registerHealthcareRules() {
 	dv.rules["healthcare"] = []ValidationRule{
@@ -55,16 +122,85 @@ func (dv *DomainValidator) registerHealthcareRules() {
 			Description: "Validate ICD-10 diagnosis codes format",
 			Severity:    "error",
 			Validator: func(data map[string]interface{}) error {
-				if clinical, ok := data["clinical_data"].(map[string]interface{}); ok {
-					if diagnoses, ok := clinical["diagnoses"].([]interface{}); ok {
-						for _, diag := range diagnoses {
-							if diagMap, ok := diag.(map[string]interface{}); ok {
-								if code, ok := diagMap["icd10_code"].(string); ok {
-									if !isValidICD10(code) {
-										return fmt.Errorf("invalid ICD-10 code: %s", code)
-									}
-								}
-							}
+				for _, value := range dv.fieldValues(data, "icd10_code") {
+					if code, ok := value.(string); ok {
+						if !isValidICD10(code) {
+							return fmt.Errorf("invalid ICD-10 code: %s", code)
+						}
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "loinc_format",
+			Description: "Validate LOINC lab codes and their check digit",
+			Severity:    "error",
+			Validator: func(data map[string]interface{}) error {
+				for _, value := range dv.fieldValues(data, "loinc_code") {
+					if code, ok := value.(string); ok {
+						if err := ValidateLOINC(code); err != nil {
+							return fmt.Errorf("invalid LOINC code %s: %v", code, err)
+						}
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "ndc_format",
+			Description: "Validate National Drug Codes on prescriptions",
+			Severity:    "error",
+			Validator: func(data map[string]interface{}) error {
+				for _, value := range dv.fieldValues(data, "ndc_code") {
+					if code, ok := value.(string); ok {
+						if err := ValidateNDC(code); err != nil {
+							return fmt.Errorf("invalid NDC %s: %v", code, err)
+						}
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "icd10_pcs_format",
+			Description: "Validate ICD-10-PCS procedure code structure",
+			Severity:    "error",
+			Validator: func(data map[string]interface{}) error {
+				for _, value := range dv.fieldValues(data, "icd10_pcs_code") {
+					if code, ok := value.(string); ok {
+						if err := ValidateICD10PCS(code); err != nil {
+							return fmt.Errorf("invalid ICD-10-PCS code %s: %v", code, err)
+						}
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "cvx_format",
+			Description: "Validate CVX vaccine codes on immunizations",
+			Severity:    "error",
+			Validator: func(data map[string]interface{}) error {
+				for _, value := range dv.fieldValues(data, "cvx_code") {
+					if code, ok := value.(string); ok {
+						if err := ValidateCVXCode(code, false); err != nil {
+							return fmt.Errorf("invalid CVX code %s: %v", code, err)
+						}
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "cvx_known",
+			Description: "Flag well-formed CVX codes missing from the common code table",
+			Severity:    "warning",
+			Validator: func(data map[string]interface{}) error {
+				for _, value := range dv.fieldValues(data, "cvx_code") {
+					if code, ok := value.(string); ok && ValidateCVXCode(code, false) == nil {
+						if err := ValidateCVXCode(code, true); err != nil {
+							return err
 						}
 					}
 				}
@@ -76,11 +212,9 @@ func (dv *DomainValidator) registerHealthcareRules() {
 			Description: "Ensure realistic charge amounts ($10 - $50,000)",
 			Severity:    "error",
 			Validator: func(data map[string]interface{}) error {
-				if billing, ok := data["billing"].(map[string]interface{}); ok {
-					if total, ok := billing["total_charges"].(float64); ok {
-						if total < 10 || total > 50000 {
-							return fmt.Errorf("unrealistic charge amount: $%.2f", total)
-						}
+				if total, ok := dv.numericField(data, "total_charges"); ok {
+					if total < 10 || total > 50000 {
+						return fmt.Errorf("unrealistic charge amount: $%.2f", total)
 					}
 				}
 				return nil
@@ -95,29 +229,22 @@ func (dv *DomainValidator) registerHealthcareRules() {
 				var err error
 
 				// Extract dates
-				if patient, ok := data["patient"].(map[string]interface{}); ok {
-					if demo, ok := patient["demographics"].(map[string]interface{}); ok {
-						if dobStr, ok := demo["date_of_birth"].(string); ok {
-							dob, err = time.Parse("2006-01-02", dobStr)
-							if err != nil {
-								return fmt.Errorf("invalid DOB format: %s", dobStr)
-							}
-						}
+				if dobStr, ok := dv.stringField(data, "date_of_birth"); ok {
+					dob, err = time.Parse("2006-01-02", dobStr)
+					if err != nil {
+						return fmt.Errorf("invalid DOB format: %s", dobStr)
 					}
 				}
-
-				if billing, ok := data["billing"].(map[string]interface{}); ok {
-					if serviceStr, ok := billing["service_date"].(string); ok {
-						serviceDate, err = time.Parse("2006-01-02", serviceStr)
-						if err != nil {
-							return fmt.Errorf("invalid service date format: %s", serviceStr)
-						}
+				if serviceStr, ok := dv.stringField(data, "service_date"); ok {
+					serviceDate, err = time.Parse("2006-01-02", serviceStr)
+					if err != nil {
+						return fmt.Errorf("invalid service date format: %s", serviceStr)
 					}
-					if subStr, ok := billing["submitted_date"].(string); ok {
-						submittedDate, err = time.Parse("2006-01-02", subStr)
-						if err != nil {
-							return fmt.Errorf("invalid submitted date format: %s", subStr)
-						}
+				}
+				if subStr, ok := dv.stringField(data, "submitted_date"); ok {
+					submittedDate, err = time.Parse("2006-01-02", subStr)
+					if err != nil {
+						return fmt.Errorf("invalid submitted date format: %s", subStr)
 					}
 				}
 
@@ -138,13 +265,9 @@ func (dv *DomainValidator) registerHealthcareRules() {
 			Description: "Validate NPI numbers format (10 digits)",
 			Severity:    "error",
 			Validator: func(data map[string]interface{}) error {
-				if encounter, ok := data["encounter"].(map[string]interface{}); ok {
-					if provider, ok := encounter["provider"].(map[string]interface{}); ok {
-						if npi, ok := provider["npi"].(string); ok {
-							if !isValidNPI(npi) {
-								return fmt.Errorf("invalid NPI format: %s", npi)
-							}
-						}
+				if npi, ok := dv.stringField(data, "npi"); ok {
+					if !isValidNPI(npi) {
+						return fmt.Errorf("invalid NPI format: %s", npi)
 					}
 				}
 				return nil
@@ -155,19 +278,28 @@ func (dv *DomainValidator) registerHealthcareRules() {
 			Description: "Blood pressure values should be medically plausible",
 			Severity:    "warning",
 			Validator: func(data map[string]interface{}) error {
-				if clinical, ok := data["clinical_data"].(map[string]interface{}); ok {
-					if vitals, ok := clinical["vital_signs"].(map[string]interface{}); ok {
-						if bp, ok := vitals["blood_pressure"].(map[string]interface{}); ok {
-							if sys, ok := bp["systolic"].(float64); ok {
-								if dia, ok := bp["diastolic"].(float64); ok {
-									if sys <= dia {
-										return fmt.Errorf("systolic (%v) must be greater than diastolic (%v)", sys, dia)
-									}
-									if (sys - dia) < 20 {
-										return fmt.Errorf("pulse pressure too narrow: %v", sys-dia)
-									}
-								}
-							}
+				if sys, ok := dv.numericField(data, "systolic"); ok {
+					if dia, ok := dv.numericField(data, "diastolic"); ok {
+						if sys <= dia {
+							return fmt.Errorf("systolic (%v) must be greater than diastolic (%v)", sys, dia)
+						}
+						if (sys - dia) < 20 {
+							return fmt.Errorf("pulse pressure too narrow: %v", sys-dia)
+						}
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "lab_values_correlation",
+			Description: "Hematocrit should be about three times hemoglobin (within 3 points)",
+			Severity:    "warning",
+			Validator: func(data map[string]interface{}) error {
+				if hgb, ok := dv.numericField(data, "hemoglobin"); ok {
+					if hct, ok := dv.numericField(data, "hematocrit"); ok {
+						if math.Abs(hct-hgb*3) > 3 {
+							return fmt.Errorf("hematocrit (%v) does not correlate with hemoglobin (%v), expected %v ± 3", hct, hgb, hgb*3)
 						}
 					}
 				}
@@ -185,11 +317,9 @@ func (dv *DomainValidator) registerFintechRules() {
 			Description: "Validate ABA routing numbers (9 digits with checksum)",
 			Severity:    "error",
 			Validator: func(data map[string]interface{}) error {
-				if account, ok := data["account"].(map[string]interface{}); ok {
-					if routing, ok := account["routing_number"].(string); ok {
-						if !isValidRoutingNumber(routing) {
-							return fmt.Errorf("invalid routing number: %s", routing)
-						}
+				if routing, ok := dv.stringField(data, "routing_number"); ok {
+					if !isValidRoutingNumber(routing) {
+						return fmt.Errorf("invalid routing number: %s", routing)
 					}
 				}
 				return nil
@@ -200,11 +330,9 @@ func (dv *DomainValidator) registerFintechRules() {
 			Description: "Validate currency codes (ISO 4217)",
 			Severity:    "error",
 			Validator: func(data map[string]interface{}) error {
-				if txn, ok := data["transaction_details"].(map[string]interface{}); ok {
-					if currency, ok := txn["currency"].(string); ok {
-						if !isValidCurrencyCode(currency) {
-							return fmt.Errorf("invalid currency code: %s", currency)
-						}
+				if currency, ok := dv.stringField(data, "currency"); ok {
+					if !isValidCurrencyCode(currency) {
+						return fmt.Errorf("invalid currency code: %s", currency)
 					}
 				}
 				return nil
@@ -215,11 +343,9 @@ func (dv *DomainValidator) registerFintechRules() {
 			Description: "Risk scoring logic (0-100 scale)",
 			Severity:    "error",
 			Validator: func(data map[string]interface{}) error {
-				if risk, ok := data["risk_assessment"].(map[string]interface{}); ok {
-					if score, ok := risk["risk_score"].(float64); ok {
-						if score < 0 || score > 100 {
-							return fmt.Errorf("risk score out of range: %v", score)
-						}
+				if score, ok := dv.numericField(data, "risk_score"); ok {
+					if score < 0 || score > 100 {
+						return fmt.Errorf("risk score out of range: %v", score)
 					}
 				}
 				return nil
@@ -230,16 +356,10 @@ func (dv *DomainValidator) registerFintechRules() {
 			Description: "Large transactions (>$10K) must require approval",
 			Severity:    "error",
 			Validator: func(data map[string]interface{}) error {
-				if txn, ok := data["transaction_details"].(map[string]interface{}); ok {
-					if amount, ok := txn["amount"].(float64); ok {
-						if amount > 10000 {
-							if risk, ok := data["risk_assessment"].(map[string]interface{}); ok {
-								if status, ok := risk["approval_status"].(string); ok {
-									if status != "manual_review" && status != "approved" {
-										return fmt.Errorf("large transaction ($%.2f) requires approval, got status: %s", amount, status)
-									}
-								}
-							}
+				if amount, ok := dv.numericField(data, "transaction_amount"); ok && amount > 10000 {
+					if status, ok := dv.stringField(data, "approval_status"); ok {
+						if status != "manual_review" && status != "approved" {
+							return fmt.Errorf("large transaction ($%.2f) requires approval, got status: %s", amount, status)
 						}
 					}
 				}
@@ -257,7 +377,7 @@ func (dv *DomainValidator) registerEcommerceRules() {
 			Description: "Validate SKU formats (e.g., AB123456)",
 			Severity:    "error",
 			Validator: func(data map[string]interface{}) error {
-				if sku, ok := data["sku"].(string); ok {
+				if sku, ok := dv.stringField(data, "sku"); ok {
 					if !isValidSKU(sku) {
 						return fmt.Errorf("invalid SKU format: %s", sku)
 					}
@@ -270,15 +390,11 @@ func (dv *DomainValidator) registerEcommerceRules() {
 			Description: "Ensure price-inventory consistency",
 			Severity:    "warning",
 			Validator: func(data map[string]interface{}) error {
-				if pricing, ok := data["pricing"].(map[string]interface{}); ok {
-					if inventory, ok := data["inventory"].(map[string]interface{}); ok {
-						if basePrice, ok := pricing["base_price"].(float64); ok {
-							if stock, ok := inventory["stock_quantity"].(float64); ok {
-								// High-value items should have lower inventory
-								if basePrice > 1000 && stock > 1000 {
-									return fmt.Errorf("high-value item ($%.2f) should have lower inventory (%v)", basePrice, stock)
-								}
-							}
+				if basePrice, ok := dv.numericField(data, "base_price"); ok {
+					if stock, ok := dv.numericField(data, "stock_quantity"); ok {
+						// High-value items should have lower inventory
+						if basePrice > 1000 && stock > 1000 {
+							return fmt.Errorf("high-value item ($%.2f) should have lower inventory (%v)", basePrice, stock)
 						}
 					}
 				}
@@ -290,12 +406,10 @@ func (dv *DomainValidator) registerEcommerceRules() {
 			Description: "Sale price must be less than base price",
 			Severity:    "error",
 			Validator: func(data map[string]interface{}) error {
-				if pricing, ok := data["pricing"].(map[string]interface{}); ok {
-					if basePrice, ok := pricing["base_price"].(float64); ok {
-						if salePrice, ok := pricing["sale_price"].(float64); ok {
-							if salePrice >= basePrice {
-								return fmt.Errorf("sale price ($%.2f) must be less than base price ($%.2f)", salePrice, basePrice)
-							}
+				if basePrice, ok := dv.numericField(data, "base_price"); ok {
+					if salePrice, ok := dv.numericField(data, "sale_price"); ok {
+						if salePrice >= basePrice {
+							return fmt.Errorf("sale price ($%.2f) must be less than base price ($%.2f)", salePrice, basePrice)
 						}
 					}
 				}
@@ -307,16 +421,74 @@ func (dv *DomainValidator) registerEcommerceRules() {
 			Description: "Validate warehouse location codes",
 			Severity:    "error",
 			Validator: func(data map[string]interface{}) error {
-				if inventory, ok := data["inventory"].(map[string]interface{}); ok {
-					if location, ok := inventory["warehouse_location"].(string); ok {
-						if !isValidWarehouseLocation(location) {
-							return fmt.Errorf("invalid warehouse location format: %s", location)
+				if location, ok := dv.stringField(data, "warehouse_location"); ok {
+					if !isValidWarehouseLocation(location) {
+						return fmt.Errorf("invalid warehouse location format: %s", location)
+					}
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Telecom domain validation rules
+func (dv *DomainValidator) registerTelecomRules() {
+	dv.rules["telecom"] = []ValidationRule{
+		{
+			Name:        "e164_format",
+			Description: "Validate subscriber and called numbers as E.164 (+ and up to 15 digits)",
+			Severity:    "error",
+			Validator: func(data map[string]interface{}) error {
+				for _, field := range []string{"msisdn", "called_number"} {
+					if number, ok := dv.stringField(data, field); ok {
+						if !isValidE164(number) {
+							return fmt.Errorf("invalid E.164 number in %s: %s", field, number)
 						}
 					}
 				}
 				return nil
 			},
 		},
+		{
+			Name:        "imei_checksum",
+			Description: "Validate IMEIs (15 digits with Luhn check digit)",
+			Severity:    "error",
+			Validator: func(data map[string]interface{}) error {
+				if imei, ok := dv.stringField(data, "imei"); ok {
+					if !isValidIMEI(imei) {
+						return fmt.Errorf("invalid IMEI: %s", imei)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "call_duration_plausible",
+			Description: "Call durations should be between 0 seconds and 24 hours",
+			Severity:    "warning",
+			Validator: func(data map[string]interface{}) error {
+				if duration, ok := dv.numericField(data, "call_duration"); ok {
+					if duration < 0 || duration > 86400 {
+						return fmt.Errorf("implausible call duration: %v seconds", duration)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "data_bytes_plausible",
+			Description: "Data session volumes should be between 0 and 100 GB",
+			Severity:    "warning",
+			Validator: func(data map[string]interface{}) error {
+				if bytes, ok := dv.numericField(data, "data_bytes"); ok {
+					if bytes < 0 || bytes > 100e9 {
+						return fmt.Errorf("implausible data session volume: %.0f bytes", bytes)
+					}
+				}
+				return nil
+			},
+		},
 	}
 }
 
@@ -365,6 +537,21 @@ func isValidRoutingNumber(routing string) bool {
 	return sum%10 == 0
 }
 
+func isValidE164(number string) bool {
+	// E.164: a plus sign, a non-zero country code digit, at most 15 digits
+	matched, _ := regexp.MatchString(`^\+[1-9][0-9]{1,14}$`, number)
+	return matched
+}
+
+func isValidIMEI(imei string) bool {
+	// IMEI: 14 digits followed by a Luhn check digit
+	if len(imei) != 15 {
+		return false
+	}
+	checkDigit, err := LuhnCheckDigit(imei[:14])
+	return err == nil && imei[14] == checkDigit
+}
+
 func isValidCurrencyCode(currency string) bool {
 	// ISO 4217 currency codes
 	validCurrencies := map[string]bool{
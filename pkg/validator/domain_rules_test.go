@@ -0,0 +1,135 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRegisterDomainRules verifies registered rules run after the built-in
+// rules of a domain and can define new domains
+func TestRegisterDomainRules(t *testing.T) {
+	dv := NewDomainValidator()
+	mrn := ValidationRule{
+		Name: "mrn_present",
+		Validator: func(data map[string]interface{}) error {
+			if _, ok := data["mrn"]; !ok {
+				return fmt.Errorf("missing mrn")
+			}
+			return nil
+		},
+	}
+	dv.RegisterRule("healthcare", mrn)
+
+	errs := dv.ValidateDomain("healthcare", map[string]interface{}{
+		"immunizations": []interface{}{map[string]interface{}{"cvx_code": "327"}},
+	})
+	if len(errs) != 2 || !strings.HasPrefix(errs[0].Error(), "[warning] cvx_known") || errs[1].Error() != "[error] mrn_present: missing mrn" {
+		t.Errorf("Expected the built-in warning then the registered error, got %v", errs)
+	}
+
+	if dv.HasDomain("logistics") {
+		t.Fatalf("Expected no logistics rules before registering them")
+	}
+	dv.RegisterDomain("logistics", []ValidationRule{{
+		Name:     "weight_positive",
+		Severity: "warning",
+		Validator: func(data map[string]interface{}) error {
+			if weight, _ := data["weight"].(float64); weight <= 0 {
+				return fmt.Errorf("weight %v is not positive", data["weight"])
+			}
+			return nil
+		},
+	}})
+	dv.RegisterDomain("logistics", []ValidationRule{mrn})
+	errs = dv.ValidateDomain("logistics", map[string]interface{}{"weight": 0.0})
+	if len(errs) != 2 || errs[0].Error() != "[warning] weight_positive: weight 0 is not positive" {
+		t.Errorf("Expected both registered logistics rules to apply, got %v", errs)
+	}
+}
+
+// TestDomainSeverity verifies domain failures carry their rule's severity
+// and that warnings can be left out
+func TestDomainSeverity(t *testing.T) {
+	dv := NewDomainValidator()
+	record := map[string]interface{}{
+		"immunizations": []interface{}{map[string]interface{}{"cvx_code": "327"}, map[string]interface{}{"cvx_code": "9999"}},
+	}
+
+	errs := dv.ValidateDomain("healthcare", record)
+	severities := map[string]string{}
+	for _, err := range errs {
+		var domainErr *DomainError
+		if !errors.As(err, &domainErr) {
+			t.Fatalf("Expected a DomainError, got %T", err)
+		}
+		severities[domainErr.Rule] = domainErr.Severity
+	}
+	if !reflect.DeepEqual(severities, map[string]string{"cvx_format": SeverityError, "cvx_known": SeverityWarning}) {
+		t.Errorf("Unexpected severities %v", severities)
+	}
+
+	errs = dv.ValidateDomainSeverity("healthcare", record, SeverityError)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "[error] cvx_format") {
+		t.Errorf("Expected only the cvx_format error, got %v", errs)
+	}
+}
+
+// TestRegisterRuleKeepsBuiltins verifies a rule registered on a built-in
+// domain runs alongside its built-in rules, each at its own severity, and
+// that severity filtering applies to registered rules too
+func TestRegisterRuleKeepsBuiltins(t *testing.T) {
+	dv := NewDomainValidator()
+	dv.RegisterRule("healthcare", ValidationRule{
+		Name:     "mrn_present",
+		Severity: SeverityWarning,
+		Validator: func(data map[string]interface{}) error {
+			if _, ok := data["mrn"]; !ok {
+				return fmt.Errorf("missing mrn")
+			}
+			return nil
+		},
+	})
+	dv.RegisterRule("healthcare", ValidationRule{
+		Name: "payer_present",
+		Validator: func(data map[string]interface{}) error {
+			if _, ok := data["payer"]; !ok {
+				return fmt.Errorf("missing payer")
+			}
+			return nil
+		},
+	})
+
+	record := map[string]interface{}{
+		"encounter":     map[string]interface{}{"provider": map[string]interface{}{"npi": "12345"}},
+		"immunizations": []interface{}{map[string]interface{}{"cvx_code": "327"}},
+	}
+	severities := func(errs []error) map[string]string {
+		found := make(map[string]string, len(errs))
+		for _, err := range errs {
+			var domainErr *DomainError
+			if !errors.As(err, &domainErr) {
+				t.Fatalf("Expected a DomainError, got %T", err)
+			}
+			found[domainErr.Rule] = domainErr.Severity
+		}
+		return found
+	}
+
+	want := map[string]string{
+		"npi_format":    SeverityError,
+		"cvx_known":     SeverityWarning,
+		"mrn_present":   SeverityWarning,
+		"payer_present": SeverityError,
+	}
+	if got := severities(dv.ValidateDomain("healthcare", record)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected built-in and registered failures %v, got %v", want, got)
+	}
+
+	want = map[string]string{"npi_format": SeverityError, "payer_present": SeverityError}
+	if got := severities(dv.ValidateDomainSeverity("healthcare", record, SeverityError)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected only errors %v, got %v", want, got)
+	}
+}
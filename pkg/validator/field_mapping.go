@@ -0,0 +1,187 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFieldPaths maps the logical field names used by the built-in domain
+// rules to their JSON Pointer locations in the reference schemas. A "*" token
+// matches every element of an array.
+var defaultFieldPaths = map[string]string{
+	// Healthcare
+	"icd10_code":     "/clinical_data/diagnoses/*/icd10_code",
+	"loinc_code":     "/clinical_data/lab_results/*/loinc_code",
+	"ndc_code":       "/prescriptions/*/ndc_code",
+	"icd10_pcs_code": "/clinical_data/procedures/*/icd10_pcs_code",
+	"cvx_code":       "/immunizations/*/cvx_code",
+	"total_charges":  "/billing/total_charges",
+	"date_of_birth":  "/patient/demographics/date_of_birth",
+	"service_date":   "/billing/service_date",
+	"submitted_date": "/billing/submitted_date",
+	"npi":            "/encounter/provider/npi",
+	"systolic":       "/clinical_data/vital_signs/blood_pressure/systolic",
+	"diastolic":      "/clinical_data/vital_signs/blood_pressure/diastolic",
+	"hemoglobin":     "/clinical_data/laboratory_results/complete_blood_count/hemoglobin/value",
+	"hematocrit":     "/clinical_data/laboratory_results/complete_blood_count/hematocrit/value",
+
+	// Fintech
+	"routing_number":     "/account/routing_number",
+	"currency":           "/transaction_details/currency",
+	"risk_score":         "/risk_assessment/risk_score",
+	"transaction_amount": "/transaction_details/amount",
+	"approval_status":    "/risk_assessment/approval_status",
+
+	// E-commerce
+	"sku":                "/sku",
+	"base_price":         "/pricing/base_price",
+	"sale_price":         "/pricing/sale_price",
+	"stock_quantity":     "/inventory/stock_quantity",
+	"warehouse_location": "/inventory/warehouse_location",
+
+	// Telecom
+	"msisdn":        "/subscriber/msisdn",
+	"called_number": "/called_number",
+	"imei":          "/device/imei",
+	"call_duration": "/call_duration_seconds",
+	"data_bytes":    "/data_bytes",
+}
+
+// LoadFieldMapping reads a field mapping file (JSON, or YAML by extension)
+// mapping logical field names to JSON Pointer paths
+func LoadFieldMapping(filename string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read field mapping file: %w", err)
+	}
+
+	mapping := make(map[string]string)
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &mapping)
+	default:
+		err = json.Unmarshal(data, &mapping)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse field mapping file: %w", err)
+	}
+
+	return mapping, nil
+}
+
+// SetFieldMapping overrides the locations of logical fields used by the
+// domain rules. Fields not present in the mapping keep their default paths.
+func (dv *DomainValidator) SetFieldMapping(mapping map[string]string) error {
+	for field, pointer := range mapping {
+		if pointer != "" && !strings.HasPrefix(pointer, "/") {
+			return fmt.Errorf("invalid JSON pointer for field %s: %q must start with '/'", field, pointer)
+		}
+	}
+
+	for field, pointer := range mapping {
+		dv.fieldPaths[field] = pointer
+	}
+
+	return nil
+}
+
+// fieldValues resolves a logical field to every value found at its location
+func (dv *DomainValidator) fieldValues(data map[string]interface{}, field string) []interface{} {
+	pointer, ok := dv.fieldPaths[field]
+	if !ok || pointer == "" {
+		return nil
+	}
+
+	return resolvePointer(data, splitPointer(pointer))
+}
+
+// fieldValue returns the first value found at a logical field's location
+func (dv *DomainValidator) fieldValue(data map[string]interface{}, field string) (interface{}, bool) {
+	values := dv.fieldValues(data, field)
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values[0], true
+}
+
+// stringField returns a logical field as a string
+func (dv *DomainValidator) stringField(data map[string]interface{}, field string) (string, bool) {
+	value, ok := dv.fieldValue(data, field)
+	if !ok {
+		return "", false
+	}
+	str, ok := value.(string)
+	return str, ok
+}
+
+// numericField returns a logical field as a float64
+func (dv *DomainValidator) numericField(data map[string]interface{}, field string) (float64, bool) {
+	value, ok := dv.fieldValue(data, field)
+	if !ok {
+		return 0, false
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// splitPointer splits a JSON Pointer into unescaped reference tokens. The
+// empty pointer is the whole document; "/" is its empty-named key
+func splitPointer(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, "~1", "/")
+		tokens[i] = strings.ReplaceAll(token, "~0", "~")
+	}
+	return tokens
+}
+
+// resolvePointer walks value along tokens, fanning out over arrays for "*"
+func resolvePointer(value interface{}, tokens []string) []interface{} {
+	if len(tokens) == 0 {
+		return []interface{}{value}
+	}
+
+	token, rest := tokens[0], tokens[1:]
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		child, ok := v[token]
+		if !ok {
+			return nil
+		}
+		return resolvePointer(child, rest)
+	case []interface{}:
+		if token == "*" {
+			var results []interface{}
+			for _, item := range v {
+				results = append(results, resolvePointer(item, rest)...)
+			}
+			return results
+		}
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || index >= len(v) {
+			return nil
+		}
+		return resolvePointer(v[index], rest)
+	default:
+		return nil
+	}
+}
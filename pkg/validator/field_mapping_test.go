@@ -0,0 +1,139 @@
+package validator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordWith builds a record holding each value at its JSON pointer
+func recordWith(values map[string]interface{}) map[string]interface{} {
+	record := make(map[string]interface{})
+	for pointer, value := range values {
+		tokens := splitPointer(pointer)
+		current := record
+		for _, token := range tokens[:len(tokens)-1] {
+			next, ok := current[token].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				current[token] = next
+			}
+			current = next
+		}
+		current[tokens[len(tokens)-1]] = value
+	}
+	return record
+}
+
+// TestSplitPointer verifies pointers split into unescaped tokens, with only
+// the empty pointer naming the whole document
+func TestSplitPointer(t *testing.T) {
+	testCases := []struct {
+		pointer string
+		tokens  []string
+	}{
+		{pointer: "", tokens: nil},
+		{pointer: "/", tokens: []string{""}},
+		{pointer: "/a//b", tokens: []string{"a", "", "b"}},
+		{pointer: "/size~1unit/~0home", tokens: []string{"size/unit", "~home"}},
+		{pointer: "/~01", tokens: []string{"~1"}},
+	}
+
+	for _, tc := range testCases {
+		if tokens := splitPointer(tc.pointer); !reflect.DeepEqual(tokens, tc.tokens) {
+			t.Errorf("splitPointer(%q) = %q, expected %q", tc.pointer, tokens, tc.tokens)
+		}
+	}
+
+	record := map[string]interface{}{"": "root key"}
+	if values := resolvePointer(record, splitPointer("/")); len(values) != 1 || values[0] != "root key" {
+		t.Errorf("Expected \"/\" to resolve the empty-named key, got %v", values)
+	}
+}
+
+// TestHL7CrossFieldRules verifies the cross-field constraints of the HL7
+// reference schema fire at their default paths and, through a field
+// mapping, at the paths of another layout
+func TestHL7CrossFieldRules(t *testing.T) {
+	mapping := map[string]string{
+		"date_of_birth":  "/member/dob",
+		"service_date":   "/claim/dates/service",
+		"submitted_date": "/claim/dates/submitted",
+		"total_charges":  "/claim/amount",
+		"systolic":       "/vitals/bp_sys",
+		"diastolic":      "/vitals/bp_dia",
+		"hemoglobin":     "/labs/hgb",
+		"hematocrit":     "/labs/hct",
+	}
+
+	testCases := []struct {
+		name     string
+		rule     string
+		severity string
+		values   map[string]interface{} // logical field to value
+	}{
+		{"birth after service", "date_ordering", SeverityError, map[string]interface{}{"date_of_birth": "2024-05-02", "service_date": "2024-05-01"}},
+		{"service after submission", "date_ordering", SeverityError, map[string]interface{}{"service_date": "2024-05-02", "submitted_date": "2024-05-01"}},
+		{"unrealistic charges", "charge_amount_realistic", SeverityError, map[string]interface{}{"total_charges": 75000.0}},
+		{"narrow pulse pressure", "vital_signs_plausible", SeverityWarning, map[string]interface{}{"systolic": 110.0, "diastolic": 100.0}},
+		{"uncorrelated labs", "lab_values_correlation", SeverityWarning, map[string]interface{}{"hemoglobin": 14.0, "hematocrit": 30.0}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defaults := make(map[string]interface{}, len(tc.values))
+			mapped := make(map[string]interface{}, len(tc.values))
+			for field, value := range tc.values {
+				defaults[defaultFieldPaths[field]] = value
+				mapped[mapping[field]] = value
+			}
+
+			check := func(dv *DomainValidator, record map[string]interface{}, fires bool) {
+				t.Helper()
+				var found *DomainError
+				for _, err := range dv.ValidateDomain("healthcare", record) {
+					var domainErr *DomainError
+					if errors.As(err, &domainErr) && domainErr.Rule == tc.rule {
+						found = domainErr
+					}
+				}
+				if !fires {
+					if found != nil {
+						t.Errorf("Expected %s not to fire for %v, got %v", tc.rule, record, found)
+					}
+					return
+				}
+				if found == nil || found.Severity != tc.severity {
+					t.Errorf("Expected %s to fire as %s for %v, got %v", tc.rule, tc.severity, record, found)
+				}
+			}
+
+			dv := NewDomainValidator()
+			check(dv, recordWith(defaults), true)
+			check(dv, recordWith(mapped), false)
+
+			if err := dv.SetFieldMapping(mapping); err != nil {
+				t.Fatalf("Failed to set field mapping: %v", err)
+			}
+			check(dv, recordWith(mapped), true)
+		})
+	}
+
+	// Correlated values at the mapped paths pass
+	dv := NewDomainValidator()
+	if err := dv.SetFieldMapping(mapping); err != nil {
+		t.Fatalf("Failed to set field mapping: %v", err)
+	}
+	valid := recordWith(map[string]interface{}{
+		"/member/dob": "1980-01-01", "/claim/dates/service": "2024-05-01", "/claim/dates/submitted": "2024-05-03",
+		"/claim/amount": 420.0, "/vitals/bp_sys": 120.0, "/vitals/bp_dia": 80.0, "/labs/hgb": 14.0, "/labs/hct": 42.5,
+	})
+	if errs := dv.ValidateDomain("healthcare", valid); len(errs) != 0 {
+		t.Errorf("Expected a plausible record to pass, got %v", errs)
+	}
+
+	if err := dv.SetFieldMapping(map[string]string{"hemoglobin": "labs/hgb"}); err == nil || !strings.Contains(err.Error(), "must start with '/'") {
+		t.Errorf("Expected a relative pointer to be rejected, got %v", err)
+	}
+}
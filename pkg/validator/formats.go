@@ -0,0 +1,513 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// formatCheckers maps SpecMint-specific string formats to their validators.
+// Standard JSON Schema formats are left to the schema validator.
+var formatCheckers = map[string]func(string) error{
+	"vin":         ValidateVIN,
+	"credit-card": ValidateCreditCard,
+	"iban":        ValidateIBAN,
+	"bic":         ValidateBIC,
+	"loinc":       ValidateLOINC,
+	"phone-e164":  ValidatePhoneE164,
+}
+
+// validateFormats walks a record alongside its schema and checks every string
+// value whose node declares a SpecMint-specific format
+func (v *Validator) validateFormats(node *schema.SchemaNode, value interface{}, path string, errors *[]ValidationError) {
+	if node == nil || value == nil {
+		return
+	}
+
+	switch typed := value.(type) {
+	case string:
+		check, ok := formatCheckers[node.Format]
+		// The schema validator checks times and durations themselves, but
+		// not their ranges
+		switch {
+		case node.Format == "time" && node.TimeRange != nil:
+			check, ok = func(value string) error { return ValidateTime(value, node.TimeRange) }, true
+		case node.Format == "duration" && node.DurationRange != nil:
+			check, ok = func(value string) error { return ValidateDuration(value, node.DurationRange) }, true
+		}
+		if ok {
+			if err := check(typed); err != nil {
+				*errors = append(*errors, ValidationError{
+					Field:   path,
+					Rule:    node.Format,
+					Source:  SourceFormat,
+					Message: err.Error(),
+					Value:   typed,
+				})
+			}
+		}
+	case map[string]interface{}:
+		for name, propValue := range typed {
+			v.validateFormats(node.PropertyNode(name), propValue, joinPointer(path, name), errors)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			v.validateFormats(node.ItemAt(i), item, joinPointer(path, strconv.Itoa(i)), errors)
+		}
+	}
+}
+
+// joinPointer appends a reference token to a JSON Pointer
+func joinPointer(pointer, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	return pointer + "/" + strings.ReplaceAll(token, "/", "~1")
+}
+
+// VIN helpers
+
+// vinTransliteration maps VIN characters to their check-digit values.
+// I, O and Q are not part of the VIN alphabet.
+var vinTransliteration = map[rune]int{
+	'A': 1, 'B': 2, 'C': 3, 'D': 4, 'E': 5, 'F': 6, 'G': 7, 'H': 8,
+	'J': 1, 'K': 2, 'L': 3, 'M': 4, 'N': 5, 'P': 7, 'R': 9,
+	'S': 2, 'T': 3, 'U': 4, 'V': 5, 'W': 6, 'X': 7, 'Y': 8, 'Z': 9,
+	'0': 0, '1': 1, '2': 2, '3': 3, '4': 4, '5': 5, '6': 6, '7': 7, '8': 8, '9': 9,
+}
+
+var vinWeights = []int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// VINCheckDigit computes the check digit (position 9) for a 17-character VIN.
+// The character currently in position 9 is ignored.
+func VINCheckDigit(vin string) (byte, error) {
+	if len(vin) != 17 {
+		return 0, fmt.Errorf("VIN must be 17 characters, got %d", len(vin))
+	}
+
+	sum := 0
+	for i, char := range vin {
+		value, ok := vinTransliteration[char]
+		if !ok {
+			return 0, fmt.Errorf("invalid VIN character %q at position %d", char, i+1)
+		}
+		sum += value * vinWeights[i]
+	}
+
+	remainder := sum % 11
+	if remainder == 10 {
+		return 'X', nil
+	}
+	return byte('0' + remainder), nil
+}
+
+// ValidateVIN checks VIN length, alphabet, and check digit
+func ValidateVIN(vin string) error {
+	checkDigit, err := VINCheckDigit(vin)
+	if err != nil {
+		return err
+	}
+	if vin[8] != checkDigit {
+		return fmt.Errorf("invalid VIN check digit: expected %c, got %c", checkDigit, vin[8])
+	}
+	return nil
+}
+
+// Credit card helpers
+
+// LuhnCheckDigit computes the Luhn check digit to append to a digit string
+func LuhnCheckDigit(digits string) (byte, error) {
+	sum := 0
+	double := true // the rightmost payload digit is doubled
+	for i := len(digits) - 1; i >= 0; i-- {
+		char := digits[i]
+		if char < '0' || char > '9' {
+			return 0, fmt.Errorf("invalid digit %q at position %d", char, i+1)
+		}
+		digit := int(char - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+// cardNetworkLength returns the required length for numbers of the card
+// networks we generate, or 0 for other networks
+func cardNetworkLength(number string) int {
+	switch {
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return 15 // American Express
+	case strings.HasPrefix(number, "4"):
+		return 16 // Visa
+	case number[0] == '5' && number[1] >= '1' && number[1] <= '5':
+		return 16 // Mastercard
+	}
+	if prefix, err := strconv.Atoi(number[:4]); err == nil && prefix >= 2221 && prefix <= 2720 {
+		return 16 // Mastercard 2-series
+	}
+	return 0
+}
+
+// ValidateCreditCard checks card number length and Luhn check digit
+func ValidateCreditCard(number string) error {
+	if len(number) < 13 || len(number) > 19 {
+		return fmt.Errorf("card number must be 13 to 19 digits, got %d", len(number))
+	}
+	if want := cardNetworkLength(number); want != 0 && len(number) != want {
+		return fmt.Errorf("card number must be %d digits for its network, got %d", want, len(number))
+	}
+
+	checkDigit, err := LuhnCheckDigit(number[:len(number)-1])
+	if err != nil {
+		return err
+	}
+	if number[len(number)-1] != checkDigit {
+		return fmt.Errorf("invalid Luhn check digit: expected %c, got %c", checkDigit, number[len(number)-1])
+	}
+	return nil
+}
+
+// IBAN and BIC helpers
+
+// ibanLengths holds the full IBAN length for each country
+var ibanLengths = map[string]int{
+	"AT": 20, "BE": 16, "CH": 21, "CZ": 24, "DE": 22, "DK": 18, "ES": 24,
+	"FI": 18, "FR": 27, "GB": 22, "IE": 22, "IT": 27, "LU": 20, "NL": 18,
+	"NO": 15, "PL": 28, "PT": 25, "SE": 24,
+}
+
+// IBANCheckDigits computes the two mod-97 check digits for a country code
+// and BBAN
+func IBANCheckDigits(country, bban string) (string, error) {
+	remainder, err := ibanMod97(bban + country + "00")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d", 98-remainder), nil
+}
+
+// ibanMod97 interprets an IBAN-style string as a number, with letters
+// standing for 10 to 35, and returns it modulo 97
+func ibanMod97(value string) (int, error) {
+	remainder := 0
+	for i, char := range value {
+		switch {
+		case char >= '0' && char <= '9':
+			remainder = (remainder*10 + int(char-'0')) % 97
+		case char >= 'A' && char <= 'Z':
+			remainder = (remainder*100 + int(char-'A') + 10) % 97
+		default:
+			return 0, fmt.Errorf("invalid IBAN character %q at position %d", char, i+1)
+		}
+	}
+	return remainder, nil
+}
+
+// ValidateIBAN checks IBAN country, length, and mod-97 check digits
+func ValidateIBAN(iban string) error {
+	if len(iban) < 4 {
+		return fmt.Errorf("IBAN too short")
+	}
+	if want, ok := ibanLengths[iban[:2]]; ok && len(iban) != want {
+		return fmt.Errorf("IBAN for %s must be %d characters, got %d", iban[:2], want, len(iban))
+	}
+
+	remainder, err := ibanMod97(iban[4:] + iban[:4])
+	if err != nil {
+		return err
+	}
+	if remainder != 1 {
+		return fmt.Errorf("invalid IBAN check digits %s", iban[2:4])
+	}
+	return nil
+}
+
+var bicPattern = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+// ValidateBIC checks the shape of a SWIFT/BIC code
+func ValidateBIC(bic string) error {
+	if !bicPattern.MatchString(bic) {
+		return fmt.Errorf("BIC must be 8 or 11 characters: bank, country, location and optional branch code")
+	}
+	return nil
+}
+
+// Phone helpers
+
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// e164NationalLengths holds the shortest and longest national number for
+// country calling codes whose lengths are checked
+var e164NationalLengths = map[string][2]int{
+	"1": {10, 10}, "33": {9, 9}, "34": {9, 9}, "39": {6, 11}, "44": {9, 10}, "49": {6, 13},
+	"52": {10, 10}, "55": {10, 11}, "61": {9, 9}, "81": {9, 10}, "86": {10, 11}, "91": {10, 10},
+}
+
+// ValidatePhoneE164 checks that a phone number is in E.164 form: a plus
+// sign and at most 15 digits starting with the country calling code. For
+// the countries in e164NationalLengths the national number length is
+// checked too, and for +1 the North American area and exchange codes.
+func ValidatePhoneE164(phone string) error {
+	if !e164Pattern.MatchString(phone) {
+		return fmt.Errorf("E.164 phone number must be + and up to 15 digits, without spaces or separators")
+	}
+
+	// Country calling codes are prefix-free, so at most one prefix matches
+	digits := phone[1:]
+	for size := 1; size <= 3 && size < len(digits); size++ {
+		bounds, ok := e164NationalLengths[digits[:size]]
+		if !ok {
+			continue
+		}
+		national := digits[size:]
+		if len(national) < bounds[0] || len(national) > bounds[1] {
+			return fmt.Errorf("national number for +%s must be %d to %d digits, got %d", digits[:size], bounds[0], bounds[1], len(national))
+		}
+		if size == 1 && (national[0] < '2' || national[3] < '2') {
+			return fmt.Errorf("North American area and exchange codes must start with 2-9, got %s", national)
+		}
+		return nil
+	}
+	return nil
+}
+
+// LOINC helpers
+
+var loincPattern = regexp.MustCompile(`^([0-9]{1,5})-([0-9])$`)
+
+// ValidateLOINC checks the shape of a LOINC code, a number of up to five
+// digits, a hyphen and a check digit, and its mod-10 check digit. LOINC
+// computes the check digit with the Luhn algorithm.
+func ValidateLOINC(code string) error {
+	parts := loincPattern.FindStringSubmatch(code)
+	if parts == nil {
+		return fmt.Errorf("LOINC code must be 1 to 5 digits, a hyphen and a check digit")
+	}
+
+	checkDigit, err := LuhnCheckDigit(parts[1])
+	if err != nil {
+		return err
+	}
+	if parts[2][0] != checkDigit {
+		return fmt.Errorf("invalid LOINC check digit: expected %c, got %c", checkDigit, parts[2][0])
+	}
+	return nil
+}
+
+// NDC helpers
+
+// ndcLayouts are the segment lengths of the hyphenated National Drug Code
+// forms: the 11-digit billing form and the 10-digit labeler layouts
+var ndcLayouts = [][3]int{{5, 4, 2}, {5, 3, 2}, {4, 4, 2}}
+
+// ValidateNDC checks that a National Drug Code has labeler, product and
+// package segments of digits in the 5-4-2, 5-3-2 or 4-4-2 layout
+func ValidateNDC(code string) error {
+	_, err := ndcSegments(code)
+	return err
+}
+
+// ndcSegments splits a hyphenated NDC and returns its segments
+func ndcSegments(code string) ([]string, error) {
+	segments := strings.Split(code, "-")
+	if len(segments) != 3 {
+		return nil, fmt.Errorf("NDC must have three hyphenated segments, got %q", code)
+	}
+	for i, segment := range segments {
+		for _, char := range segment {
+			if char < '0' || char > '9' {
+				return nil, fmt.Errorf("NDC segment %d must be digits, got %q", i+1, segment)
+			}
+		}
+	}
+
+	for _, layout := range ndcLayouts {
+		if len(segments[0]) == layout[0] && len(segments[1]) == layout[1] && len(segments[2]) == layout[2] {
+			return segments, nil
+		}
+	}
+	return nil, fmt.Errorf("NDC segments must be 5-4-2, 5-3-2 or 4-4-2 digits, got %d-%d-%d",
+		len(segments[0]), len(segments[1]), len(segments[2]))
+}
+
+// NormalizeNDC converts an NDC to the 11-digit 5-4-2 form by zero-padding
+// its short segment. Codes without hyphens are accepted as 11 digits, or as
+// 10 digits when layout ("5-3-2" or "4-4-2") says where the segments split,
+// since the digits alone are ambiguous.
+func NormalizeNDC(code, layout string) (string, error) {
+	if !strings.Contains(code, "-") {
+		switch {
+		case len(code) == 11:
+			code = code[:5] + "-" + code[5:9] + "-" + code[9:]
+		case len(code) == 10 && layout == "5-3-2":
+			code = code[:5] + "-" + code[5:8] + "-" + code[8:]
+		case len(code) == 10 && layout == "4-4-2":
+			code = code[:4] + "-" + code[4:8] + "-" + code[8:]
+		case len(code) == 10:
+			return "", fmt.Errorf("10-digit NDC %q needs a 5-3-2 or 4-4-2 layout to normalize", code)
+		default:
+			return "", fmt.Errorf("NDC without hyphens must be 10 or 11 digits, got %d", len(code))
+		}
+	}
+
+	segments, err := ndcSegments(code)
+	if err != nil {
+		return "", err
+	}
+	for i, width := range ndcLayouts[0] {
+		segments[i] = strings.Repeat("0", width-len(segments[i])) + segments[i]
+	}
+	return strings.Join(segments, "-"), nil
+}
+
+// ICD-10-PCS helpers
+
+// icd10PCSSections are the first characters of ICD-10-PCS codes
+const icd10PCSSections = "0123456789BCDFGHX"
+
+// ValidateICD10PCS checks the structure of an ICD-10-PCS procedure code:
+// seven characters, each a digit or an uppercase letter other than I and O,
+// which are left out so they cannot be confused with 1 and 0. The first
+// character, the section, is always 0-9 or B-D, F-H or X. Whether the code
+// exists in the code tables is not checked.
+func ValidateICD10PCS(code string) error {
+	if len(code) != 7 {
+		return fmt.Errorf("ICD-10-PCS code must be 7 characters, got %d", len(code))
+	}
+
+	if !strings.ContainsRune(icd10PCSSections, rune(code[0])) {
+		return fmt.Errorf("invalid ICD-10-PCS section %q", code[0])
+	}
+	for i := 1; i < len(code); i++ {
+		char := code[i]
+		if !(char >= '0' && char <= '9') && !(char >= 'A' && char <= 'Z' && char != 'I' && char != 'O') {
+			return fmt.Errorf("invalid ICD-10-PCS character %q at position %d", char, i+1)
+		}
+	}
+	return nil
+}
+
+// CVX helpers
+
+// cvxCodes are common CVX vaccine codes, keyed by number so zero-padded
+// forms such as 03 match. Any code in the administered range can be valid;
+// the table only catches likely typos.
+var cvxCodes = map[int]string{
+	3:   "MMR",
+	8:   "Hep B, adolescent or pediatric",
+	10:  "IPV",
+	20:  "DTaP",
+	21:  "varicella",
+	33:  "pneumococcal polysaccharide PPV23",
+	43:  "Hep B, adult",
+	62:  "HPV, quadrivalent",
+	83:  "Hep A, pediatric/adolescent, 2 dose",
+	88:  "influenza, unspecified formulation",
+	94:  "MMRV",
+	106: "DTaP, 5 pertussis antigens",
+	110: "DTaP-Hep B-IPV",
+	113: "Td, preservative free",
+	114: "meningococcal MCV4P",
+	115: "Tdap",
+	116: "rotavirus, pentavalent",
+	119: "rotavirus, monovalent",
+	120: "DTaP-Hib-IPV",
+	121: "zoster, live",
+	133: "pneumococcal conjugate PCV13",
+	140: "influenza, seasonal, injectable, preservative free",
+	141: "influenza, seasonal, injectable",
+	150: "influenza, injectable, quadrivalent, preservative free",
+	165: "HPV9",
+	187: "zoster recombinant",
+	207: "COVID-19, mRNA, LNP-S, PF, 100 mcg/0.5mL dose",
+	208: "COVID-19, mRNA, LNP-S, PF, 30 mcg/0.3mL dose",
+	212: "COVID-19 vaccine, vector-nr, rS-Ad26, PF, 0.5mL",
+	213: "SARS-COV-2 (COVID-19) vaccine, unspecified formulation",
+}
+
+// cvxMaxAdministered is the highest CVX code for a vaccine that was given.
+// 998 (no vaccine administered) and 999 (unknown) record its absence.
+const cvxMaxAdministered = 997
+
+// ValidateCVXCode checks that a CVX vaccine code is 1 to 3 digits naming an
+// administered vaccine. When strict is set, a code missing from the table of
+// common codes is an error too; otherwise a well-formed code passes, since
+// CDC adds codes regularly.
+func ValidateCVXCode(code string, strict bool) error {
+	if len(code) < 1 || len(code) > 3 {
+		return fmt.Errorf("CVX code must be 1 to 3 digits, got %q", code)
+	}
+	for _, char := range code {
+		if char < '0' || char > '9' {
+			return fmt.Errorf("CVX code must be 1 to 3 digits, got %q", code)
+		}
+	}
+
+	number, _ := strconv.Atoi(code)
+	if number < 1 || number > cvxMaxAdministered {
+		return fmt.Errorf("CVX code %s does not record an administered vaccine", code)
+	}
+	if strict {
+		if _, ok := cvxCodes[number]; !ok {
+			return fmt.Errorf("CVX code %s is not a common vaccine code", code)
+		}
+	}
+	return nil
+}
+
+// Time of day and duration helpers
+
+// ValidateTime checks an RFC 3339 full-time such as 14:30:00Z or
+// 09:05:30.250+02:00 and, when bounds are given, that its wall-clock time
+// falls within them
+func ValidateTime(value string, bounds *schema.TimeRange) error {
+	t, err := time.Parse("15:04:05Z07:00", value)
+	if err != nil {
+		return fmt.Errorf("time must be hh:mm:ss with optional fractional seconds and a UTC offset, got %q", value)
+	}
+	if bounds == nil {
+		return nil
+	}
+
+	seconds := t.Hour()*3600 + t.Minute()*60 + t.Second()
+	if seconds < bounds.Start || seconds > bounds.End {
+		return fmt.Errorf("time %s is outside %s to %s", value, formatTimeOfDay(bounds.Start), formatTimeOfDay(bounds.End))
+	}
+	return nil
+}
+
+// ValidateDuration checks an ISO 8601 duration such as P3DT4H or P2W and,
+// when bounds are given, that its length falls within them. Years and
+// months have no fixed length, so a bounded duration may not use them.
+func ValidateDuration(value string, bounds *schema.DurationRange) error {
+	duration, err := schema.ParseISODuration(value)
+	if err != nil {
+		return err
+	}
+	if bounds == nil {
+		return nil
+	}
+
+	length, fixed := duration.Fixed()
+	if !fixed {
+		return fmt.Errorf("duration %s has years or months, so its length cannot be checked against %s to %s", value, bounds.Min, bounds.Max)
+	}
+	if length < bounds.Min || length > bounds.Max {
+		return fmt.Errorf("duration %s is outside %s to %s", value, bounds.Min, bounds.Max)
+	}
+	return nil
+}
+
+// formatTimeOfDay writes seconds since midnight as hh:mm:ss
+func formatTimeOfDay(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
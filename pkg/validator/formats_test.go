@@ -0,0 +1,371 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// newTestValidator builds a Validator for schemaJSON
+func newTestValidator(t *testing.T, schemaJSON string) *Validator {
+	t.Helper()
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(schemaJSON)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	if _, err := parser.GetRootNode(); err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+	return New(parser)
+}
+
+// TestVINValidation checks the validator against known VINs
+func TestVINValidation(t *testing.T) {
+	testCases := []struct {
+		vin   string
+		valid bool
+	}{
+		{vin: "1M8GDM9AXKP042788", valid: true},
+		{vin: "11111111111111111", valid: true},
+		{vin: "1M8GDM9A1KP042788", valid: false}, // wrong check digit
+		{vin: "1M8GDM9AXKP04278", valid: false},  // too short
+		{vin: "1M8GDM9AXKP0427O8", valid: false}, // contains O
+	}
+
+	for _, tc := range testCases {
+		err := ValidateVIN(tc.vin)
+		if tc.valid && err != nil {
+			t.Errorf("Expected VIN '%s' to be valid, got: %v", tc.vin, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Expected VIN '%s' to be invalid", tc.vin)
+		}
+	}
+}
+
+// TestCreditCardValidation checks the validator against known card numbers
+func TestCreditCardValidation(t *testing.T) {
+	testCases := []struct {
+		number string
+		valid  bool
+	}{
+		{number: "4111111111111111", valid: true},
+		{number: "5555555555554444", valid: true},
+		{number: "378282246310005", valid: true},
+		{number: "4111111111111112", valid: false}, // wrong check digit
+		{number: "3782822463100050", valid: false}, // Amex with 16 digits
+		{number: "41111111111111a1", valid: false}, // not a digit
+		{number: "411111111111", valid: false},     // too short
+	}
+
+	for _, tc := range testCases {
+		err := ValidateCreditCard(tc.number)
+		if tc.valid && err != nil {
+			t.Errorf("Expected card number '%s' to be valid, got: %v", tc.number, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Expected card number '%s' to be invalid", tc.number)
+		}
+	}
+}
+
+// TestIBANValidation checks the validator against known IBANs
+func TestIBANValidation(t *testing.T) {
+	testCases := []struct {
+		iban  string
+		valid bool
+	}{
+		{iban: "GB82WEST12345698765432", valid: true},
+		{iban: "DE89370400440532013000", valid: true},
+		{iban: "GB82WEST12345698765433", valid: false}, // wrong check digits
+		{iban: "DE8937040044053201300", valid: false},  // too short for DE
+		{iban: "DE89370400440532013-00", valid: false}, // invalid character
+	}
+
+	for _, tc := range testCases {
+		err := ValidateIBAN(tc.iban)
+		if tc.valid && err != nil {
+			t.Errorf("Expected IBAN '%s' to be valid, got: %v", tc.iban, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Expected IBAN '%s' to be invalid", tc.iban)
+		}
+	}
+}
+
+// TestPhoneE164Validation checks the validator against known numbers
+func TestPhoneE164Validation(t *testing.T) {
+	testCases := []struct {
+		phone string
+		valid bool
+	}{
+		{phone: "+14155552671", valid: true},
+		{phone: "+447911123456", valid: true},
+		{phone: "+8613912345678", valid: true},
+		{phone: "+3725123456", valid: true},        // country without length checks
+		{phone: "14155552671", valid: false},       // no plus sign
+		{phone: "+1 415 555 2671", valid: false},   // separators
+		{phone: "+0123456789", valid: false},       // country code starting with 0
+		{phone: "+1415555267", valid: false},       // 9-digit North American number
+		{phone: "+11155552671", valid: false},      // area code starting with 1
+		{phone: "+4915112345678901", valid: false}, // over 15 digits
+		{phone: "+33612345678", valid: true},       // French mobile
+		{phone: "+336123456789", valid: false},     // 10-digit French number
+	}
+
+	for _, tc := range testCases {
+		err := ValidatePhoneE164(tc.phone)
+		if tc.valid && err != nil {
+			t.Errorf("Expected phone number '%s' to be valid, got: %v", tc.phone, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Expected phone number '%s' to be invalid", tc.phone)
+		}
+	}
+}
+
+// TestLOINCValidation checks the validator against published LOINC codes
+func TestLOINCValidation(t *testing.T) {
+	testCases := []struct {
+		code  string
+		valid bool
+	}{
+		{code: "2345-7", valid: true},  // Glucose
+		{code: "718-7", valid: true},   // Hemoglobin
+		{code: "4548-4", valid: true},  // Hemoglobin A1c
+		{code: "2345-8", valid: false}, // wrong check digit
+		{code: "2345", valid: false},   // no check digit
+		{code: "123456-7", valid: false},
+		{code: "23a5-7", valid: false},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateLOINC(tc.code)
+		if tc.valid && err != nil {
+			t.Errorf("Expected LOINC code '%s' to be valid, got: %v", tc.code, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Expected LOINC code '%s' to be invalid", tc.code)
+		}
+	}
+}
+
+// TestNDCValidation verifies the three NDC layouts are accepted and that
+// codes normalize to the 11-digit form
+func TestNDCValidation(t *testing.T) {
+	testCases := []struct {
+		code  string
+		valid bool
+	}{
+		{code: "12345-6789-01", valid: true}, // 5-4-2
+		{code: "12345-678-01", valid: true},  // 5-3-2
+		{code: "1234-5678-01", valid: true},  // 4-4-2
+		{code: "123-45678-01", valid: false},
+		{code: "12345-67a9-01", valid: false},
+		{code: "1234567890", valid: false},
+		{code: "12345-6789-01-2", valid: false},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateNDC(tc.code)
+		if tc.valid && err != nil {
+			t.Errorf("Expected NDC '%s' to be valid, got: %v", tc.code, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Expected NDC '%s' to be invalid", tc.code)
+		}
+	}
+
+	normalized := []struct {
+		code, layout, want string
+	}{
+		{code: "12345-678-01", want: "12345-0678-01"},
+		{code: "1234-5678-01", want: "01234-5678-01"},
+		{code: "12345678901", want: "12345-6789-01"},
+		{code: "1234567801", layout: "5-3-2", want: "12345-0678-01"},
+		{code: "1234567801", layout: "4-4-2", want: "01234-5678-01"},
+	}
+	for _, tc := range normalized {
+		got, err := NormalizeNDC(tc.code, tc.layout)
+		if err != nil || got != tc.want {
+			t.Errorf("Expected %s (%s) to normalize to %s, got %s (%v)", tc.code, tc.layout, tc.want, got, err)
+		}
+	}
+	if _, err := NormalizeNDC("1234567801", ""); err == nil {
+		t.Errorf("Expected a 10-digit NDC without a layout to be rejected")
+	}
+}
+
+// TestICD10PCSValidation checks procedure code structure
+func TestICD10PCSValidation(t *testing.T) {
+	testCases := []struct {
+		code  string
+		valid bool
+	}{
+		{code: "0DTJ4ZZ", valid: true}, // laparoscopic appendectomy
+		{code: "BW03ZZZ", valid: true},
+		{code: "XW033E5", valid: true},
+		{code: "0DTJ4Z", valid: false},   // too short
+		{code: "0DTJ4ZZZ", valid: false}, // too long
+		{code: "0DTI4ZZ", valid: false},  // I is not used
+		{code: "0DTJ4ZO", valid: false},  // O is not used
+		{code: "EDTJ4ZZ", valid: false},  // no section E
+		{code: "0dtj4zz", valid: false},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateICD10PCS(tc.code)
+		if tc.valid && err != nil {
+			t.Errorf("Expected ICD-10-PCS code '%s' to be valid, got: %v", tc.code, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Expected ICD-10-PCS code '%s' to be invalid", tc.code)
+		}
+	}
+}
+
+// TestCVXValidation checks vaccine code format and the strict table lookup
+func TestCVXValidation(t *testing.T) {
+	testCases := []struct {
+		code   string
+		valid  bool // in the administered range
+		common bool // in the table of common codes
+	}{
+		{code: "208", valid: true, common: true}, // Pfizer COVID-19
+		{code: "03", valid: true, common: true},  // MMR, zero-padded
+		{code: "3", valid: true, common: true},
+		{code: "327", valid: true, common: false},
+		{code: "0", valid: false},
+		{code: "998", valid: false}, // no vaccine administered
+		{code: "999", valid: false}, // unknown
+		{code: "1000", valid: false},
+		{code: "", valid: false},
+		{code: "2O8", valid: false},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateCVXCode(tc.code, false)
+		if tc.valid && err != nil {
+			t.Errorf("Expected CVX code '%s' to be valid, got: %v", tc.code, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Expected CVX code '%s' to be invalid", tc.code)
+		}
+
+		err = ValidateCVXCode(tc.code, true)
+		if tc.common && err != nil {
+			t.Errorf("Expected CVX code '%s' to pass strict validation, got: %v", tc.code, err)
+		}
+		if !tc.common && err == nil {
+			t.Errorf("Expected CVX code '%s' to fail strict validation", tc.code)
+		}
+	}
+
+	dv := NewDomainValidator()
+	errs := dv.ValidateDomain("healthcare", map[string]interface{}{
+		"immunizations": []interface{}{map[string]interface{}{"cvx_code": "327"}},
+	})
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "[warning] cvx_known") {
+		t.Errorf("Expected an unknown CVX code to be a warning only, got %v", errs)
+	}
+}
+
+func TestTimeValidation(t *testing.T) {
+	businessHours := &schema.TimeRange{Start: 9 * 3600, End: 17 * 3600}
+	testCases := []struct {
+		value   string
+		valid   bool // a well-formed full-time
+		inRange bool // within business hours
+	}{
+		{value: "14:30:00Z", valid: true, inRange: true},
+		{value: "09:00:00+02:00", valid: true, inRange: true},
+		{value: "17:00:00.999-05:00", valid: true, inRange: true},
+		{value: "17:00:01Z", valid: true, inRange: false},
+		{value: "08:59:59.5Z", valid: true, inRange: false},
+		{value: "14:30:00", valid: false},
+		{value: "14:30Z", valid: false},
+		{value: "25:00:00Z", valid: false},
+		{value: "2024-01-01T14:30:00Z", valid: false},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateTime(tc.value, nil)
+		if tc.valid && err != nil {
+			t.Errorf("Expected time '%s' to be valid, got: %v", tc.value, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Expected time '%s' to be invalid", tc.value)
+		}
+
+		err = ValidateTime(tc.value, businessHours)
+		if tc.inRange && err != nil {
+			t.Errorf("Expected time '%s' to be within business hours, got: %v", tc.value, err)
+		}
+		if !tc.inRange && err == nil {
+			t.Errorf("Expected time '%s' to be outside business hours", tc.value)
+		}
+	}
+
+	v := newTestValidator(t, `{
+		"type": "object",
+		"properties": {
+			"slot": {"type": "string", "format": "time", "x-time-range": {"start": "09:00", "end": "17:30:00"}, "x-timezone": "Asia/Kolkata"},
+			"any": {"type": "string", "format": "time"}
+		}
+	}`)
+	errs := v.ValidateRecord(map[string]interface{}{"slot": "08:15:00+05:30", "any": "23:00:00Z"})
+	if len(errs) != 1 || errs[0].Source != SourceFormat || errs[0].Field != "/slot" {
+		t.Errorf("Expected the early slot to fail its time range, got %v", errs)
+	}
+}
+
+func TestDurationValidation(t *testing.T) {
+	shift := &schema.DurationRange{Min: time.Hour, Max: 12 * time.Hour}
+	testCases := []struct {
+		value   string
+		valid   bool // a well-formed duration
+		inRange bool // between one and twelve hours
+	}{
+		{value: "PT8H", valid: true, inRange: true},
+		{value: "PT1H", valid: true, inRange: true},
+		{value: "PT11H59M60S", valid: true, inRange: true},
+		{value: "PT0S", valid: true, inRange: false},
+		{value: "P1D", valid: true, inRange: false},
+		{value: "P1M", valid: true, inRange: false}, // no fixed length
+		{value: "P1W", valid: true, inRange: false},
+		{value: "8h", valid: false},
+		{value: "PT", valid: false},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateDuration(tc.value, nil)
+		if tc.valid && err != nil {
+			t.Errorf("Expected duration '%s' to be valid, got: %v", tc.value, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("Expected duration '%s' to be invalid", tc.value)
+		}
+
+		err = ValidateDuration(tc.value, shift)
+		if tc.inRange && err != nil {
+			t.Errorf("Expected duration '%s' to be within the shift, got: %v", tc.value, err)
+		}
+		if !tc.inRange && err == nil {
+			t.Errorf("Expected duration '%s' to be outside the shift", tc.value)
+		}
+	}
+
+	v := newTestValidator(t, `{
+		"type": "object",
+		"properties": {
+			"slot": {"type": "string", "format": "duration", "x-duration-range": {"min": "PT15M", "max": "P2DT12H"}},
+			"any": {"type": "string", "format": "duration"}
+		}
+	}`)
+	errs := v.ValidateRecord(map[string]interface{}{"slot": "PT5M", "any": "P1Y"})
+	if len(errs) != 1 || errs[0].Source != SourceFormat || errs[0].Field != "/slot" {
+		t.Errorf("Expected the short slot to fail its duration range, got %v", errs)
+	}
+}
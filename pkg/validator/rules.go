@@ -0,0 +1,158 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// ruleFieldCounts gives the number of fields each cross-field rule type
+// takes. A max of 0 means no upper limit.
+var ruleFieldCounts = map[string]struct{ min, max int }{
+	"date_ordering":        {2, 0},
+	"amount_range":         {3, 3}, // amount, min, max
+	"comparison":           {2, 0},
+	"conditional_required": {2, 2}, // condition, required
+	"mutual_exclusion":     {2, 0},
+	"sum_constraint":       {3, 0}, // field1, field2, ..., target_sum
+	"regex_match":          {1, 1}, // pattern in the constraint
+	"set_membership":       {2, 2}, // value, set
+}
+
+// rulesFile is the object form of a rules file
+type rulesFile struct {
+	Rules []json.RawMessage `json:"rules"`
+}
+
+// LoadRules reads cross-field rules from a JSON file holding either a list
+// of rules or an object with a "rules" list. Each rule has the same shape as
+// an x-cross-field-rules entry:
+//
+//	{"name": "discharge_after_admission", "rule": "comparison",
+//	 "fields": ["discharge_day", "admission_day"],
+//	 "constraint": "discharge_day >= admission_day", "severity": "error"}
+func LoadRules(filename string) ([]schema.CrossFieldRule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	var rawRules []json.RawMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(data, &rawRules)
+	} else {
+		var file rulesFile
+		err = json.Unmarshal(data, &file)
+		rawRules = file.Rules
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rules file %s: %w", filename, err)
+	}
+
+	rules := make([]schema.CrossFieldRule, 0, len(rawRules))
+	names := make(map[string]bool, len(rawRules))
+	for i, rawRule := range rawRules {
+		var rule schema.CrossFieldRule
+		decoder := json.NewDecoder(bytes.NewReader(rawRule))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("invalid rule %s in %s: %w", ruleLabel(i, rawRule), filename, err)
+		}
+		if err := checkRule(rule); err != nil {
+			return nil, fmt.Errorf("invalid rule %s in %s: %w", ruleLabel(i, rawRule), filename, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("invalid rule %s in %s: duplicate rule name", ruleLabel(i, rawRule), filename)
+		}
+		names[rule.Name] = true
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// ruleLabel names a rule in errors by its position and, when it can be
+// read, its name
+func ruleLabel(i int, rawRule json.RawMessage) string {
+	var named struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(rawRule, &named) == nil && named.Name != "" {
+		return fmt.Sprintf("#%d (%s)", i+1, named.Name)
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// checkRule verifies a rule can be evaluated
+func checkRule(rule schema.CrossFieldRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+
+	counts, ok := ruleFieldCounts[rule.Rule]
+	if !ok {
+		return fmt.Errorf("unknown rule type %q", rule.Rule)
+	}
+	if len(rule.Fields) < counts.min {
+		return fmt.Errorf("%s needs at least %d fields, got %d", rule.Rule, counts.min, len(rule.Fields))
+	}
+	if counts.max > 0 && len(rule.Fields) > counts.max {
+		return fmt.Errorf("%s takes at most %d fields, got %d", rule.Rule, counts.max, len(rule.Fields))
+	}
+
+	if rule.Rule == "comparison" && !strings.ContainsAny(rule.Constraint, "<>") {
+		return fmt.Errorf("comparison needs a constraint such as \"a >= b\", got %q", rule.Constraint)
+	}
+	if rule.Rule == "regex_match" {
+		if _, err := regexp.Compile(rule.Constraint); err != nil {
+			return fmt.Errorf("regex_match constraint is not a valid pattern: %w", err)
+		}
+	}
+
+	switch rule.Severity {
+	case "", "error", "warning":
+	default:
+		return fmt.Errorf("unknown severity %q", rule.Severity)
+	}
+
+	if rule.Patch != nil {
+		switch rule.Patch.Strategy {
+		case "set_value", "adjust_field", "remove_field":
+		case "clamp":
+			if rule.Patch.Target == "" && rule.Rule != "amount_range" {
+				return fmt.Errorf("patch strategy clamp needs a target")
+			}
+		case "regenerate":
+			if rule.Rule != "regex_match" {
+				return fmt.Errorf("patch strategy regenerate only applies to regex_match rules")
+			}
+		case "pick_member":
+			if rule.Rule != "set_membership" {
+				return fmt.Errorf("patch strategy pick_member only applies to set_membership rules")
+			}
+		default:
+			return fmt.Errorf("unknown patch strategy %q", rule.Patch.Strategy)
+		}
+	}
+
+	return nil
+}
+
+// AddRules applies rules in addition to those embedded in the schema. The
+// patterns of regex_match rules are compiled here, once.
+func (v *Validator) AddRules(rules []schema.CrossFieldRule) {
+	for _, rule := range rules {
+		if rule.Rule != "regex_match" {
+			continue
+		}
+		if re, err := regexp.Compile(rule.Constraint); err == nil {
+			v.patterns[rule.Constraint] = re
+		}
+	}
+	v.rules = append(v.rules, rules...)
+}
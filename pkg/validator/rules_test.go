@@ -0,0 +1,87 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestLoadRules verifies rules load from either file form and that an
+// invalid rule is reported by its position and name
+func TestLoadRules(t *testing.T) {
+	const admission = `{"name": "discharge_after_admission", "rule": "comparison", "fields": ["discharge_day", "admission_day"], "constraint": "discharge_day >= admission_day", "severity": "error"}`
+	const mrn = `{"name": "mrn_format", "rule": "regex_match", "fields": ["mrn"], "constraint": "^MRN-[0-9]{6}$", "severity": "warning", "patch": {"strategy": "regenerate"}}`
+
+	testCases := []struct {
+		name  string
+		file  string
+		rules []string // names of the loaded rules
+		err   string
+	}{
+		{name: "array form", file: "[" + admission + ", " + mrn + "]", rules: []string{"discharge_after_admission", "mrn_format"}},
+		{name: "object form", file: `{"rules": [` + admission + ", " + mrn + "]}", rules: []string{"discharge_after_admission", "mrn_format"}},
+		{name: "empty list", file: "[]", rules: []string{}},
+		{
+			name: "bad rule named",
+			file: "[" + admission + `, {"name": "payer_present", "rule": "conditional_required", "fields": ["payer"]}]`,
+			err:  "invalid rule #2 (payer_present) in rules.json: conditional_required needs at least 2 fields, got 1",
+		},
+		{
+			name: "unknown field",
+			file: `[{"name": "mrn_format", "rule": "regex_match", "fields": ["mrn"], "pattern": "^MRN"}]`,
+			err:  `invalid rule #1 (mrn_format) in rules.json: json: unknown field "pattern"`,
+		},
+		{
+			name: "unknown rule type",
+			file: `{"rules": [{"name": "ages", "rule": "between", "fields": ["age", "min_age", "max_age"]}]}`,
+			err:  `invalid rule #1 (ages) in rules.json: unknown rule type "between"`,
+		},
+		{
+			name: "unnamed rule",
+			file: `[{"rule": "mutual_exclusion", "fields": ["a", "b"]}]`,
+			err:  "invalid rule #1 in rules.json: missing name",
+		},
+		{
+			name: "duplicate name",
+			file: "[" + admission + ", " + admission + "]",
+			err:  "invalid rule #2 (discharge_after_admission) in rules.json: duplicate rule name",
+		},
+		{
+			name: "patch for another rule type",
+			file: `[{"name": "plan", "rule": "comparison", "fields": ["a", "b"], "constraint": "a > b", "patch": {"strategy": "pick_member"}}]`,
+			err:  "patch strategy pick_member only applies to set_membership rules",
+		},
+		{name: "not JSON", file: "rules:\n  - name: a\n", err: "failed to parse rules file"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.json")
+			if err := os.WriteFile(path, []byte(tc.file), 0644); err != nil {
+				t.Fatalf("Failed to write rules file: %v", err)
+			}
+
+			rules, err := LoadRules(path)
+			if tc.err != "" {
+				want := strings.ReplaceAll(tc.err, "rules.json", path)
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected an error containing %q, got %v", want, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to load rules: %v", err)
+			}
+
+			names := make([]string, len(rules))
+			for i, rule := range rules {
+				names[i] = rule.Name
+			}
+			if !reflect.DeepEqual(names, tc.rules) {
+				t.Errorf("Expected rules %v, got %v", tc.rules, names)
+			}
+		})
+	}
+}
@@ -2,25 +2,64 @@ package validator
 
 import (
 	"fmt"
+	"hash/fnv"
+	"math"
+	mathrand "math/rand"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/specmint/specmint/pkg/regexgen"
 	"github.com/specmint/specmint/pkg/schema"
 )
 
 // Validator handles record validation and patching
 type Validator struct {
 	parser *schema.Parser
+	root   *schema.SchemaNode
 	rules  []schema.CrossFieldRule
+
+	// patterns holds the compiled constraint of each regex_match rule
+	patterns map[string]*regexp.Regexp
 }
 
 // ValidationError represents a validation failure
 type ValidationError struct {
-	Field   string      `json:"field"`
+	Field   string      `json:"field"` // JSON Pointer of the failing value
 	Rule    string      `json:"rule"`
+	Source  string      `json:"source"` // schema, format or cross_field
 	Message string      `json:"message"`
-	Value   interface{} `json:"value"`
+	Value   interface{} `json:"value,omitempty"`
+
+	// Severity is warning or error for domain rules; other errors have none
+	// and always count as errors
+	Severity string `json:"severity,omitempty"`
+}
+
+// Sources of validation errors
+const (
+	SourceSchema     = "schema"
+	SourceFormat     = "format"
+	SourceCrossField = "cross_field"
+)
+
+// String formats the error as a single human-readable line
+func (e ValidationError) String() string {
+	switch e.Source {
+	case SourceFormat:
+		return fmt.Sprintf("Format '%s' failed for field %s: %s", e.Rule, e.Field, e.Message)
+	case SourceCrossField:
+		return fmt.Sprintf("Cross-field rule '%s' failed: %s", e.Rule, e.Message)
+	}
+	if e.Rule == "" {
+		return fmt.Sprintf("Schema validation failed: %s", e.Message)
+	}
+	field := e.Field
+	if field == "" {
+		field = "/"
+	}
+	return fmt.Sprintf("Schema validation failed at %s (%s): %s", field, e.Rule, e.Message)
 }
 
 // New creates a new validator instance
@@ -28,42 +67,84 @@ func New(parser *schema.Parser) *Validator {
 	rootNode, _ := parser.GetRootNode()
 	rules := parser.GetCrossFieldRules(rootNode)
 
-	return &Validator{
-		parser: parser,
-		rules:  rules,
+	v := &Validator{
+		parser:   parser,
+		root:     rootNode,
+		patterns: make(map[string]*regexp.Regexp),
 	}
+	v.AddRules(rules)
+	return v
 }
 
-// ValidateRecord validates a record against the schema and cross-field rules
-func (v *Validator) ValidateRecord(data map[string]interface{}) []string {
-	var errors []string
+// ValidateRecord validates a record against the schema and cross-field rules.
+// For an array-root schema the record is a single item of the array. Each
+// error names the failing field as a JSON Pointer into the record.
+func (v *Validator) ValidateRecord(data interface{}) []ValidationError {
+	var errors []ValidationError
 
 	// Schema validation
-	if err := v.parser.Validate(data); err != nil {
-		errors = append(errors, fmt.Sprintf("Schema validation failed: %s", err.Error()))
+	if err := v.parser.ValidateItem(data); err != nil {
+		if failure, ok := err.(*schema.ValidationFailure); ok {
+			for _, issue := range failure.Issues {
+				errors = append(errors, ValidationError{
+					Field:   issue.Path,
+					Rule:    issue.Keyword,
+					Source:  SourceSchema,
+					Message: issue.Message,
+				})
+			}
+		} else {
+			errors = append(errors, ValidationError{Source: SourceSchema, Message: err.Error()})
+		}
 	}
 
-	// Cross-field rule validation
+	// SpecMint format validation
+	var recordNode *schema.SchemaNode
+	if v.root != nil {
+		recordNode = v.root.RecordNode()
+	}
+	v.validateFormats(recordNode, data, "", &errors)
+
+	// Cross-field rules relate fields of an object record
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return errors
+	}
 	for _, rule := range v.rules {
-		if err := v.validateCrossFieldRule(data, rule); err != nil {
-			errors = append(errors, fmt.Sprintf("Cross-field rule '%s' failed: %s", rule.Name, err.Error()))
+		if err := v.validateCrossFieldRule(object, rule); err != nil {
+			var field string
+			if len(rule.Fields) > 0 {
+				field = joinPointer("", rule.Fields[0])
+			}
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Rule:    rule.Name,
+				Source:  SourceCrossField,
+				Message: err.Error(),
+			})
 		}
 	}
 
 	return errors
 }
 
-// PatchRecord attempts to fix validation errors in a record
-func (v *Validator) PatchRecord(data map[string]interface{}, errors []string) (map[string]interface{}, error) {
+// PatchRecord attempts to fix validation errors in a record. Only object
+// records can be patched; other records are returned unchanged.
+func (v *Validator) PatchRecord(data interface{}, errors []ValidationError) (interface{}, error) {
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+
 	patched := make(map[string]interface{})
-	for k, v := range data {
+	for k, v := range object {
 		patched[k] = v
 	}
 
 	// Apply patches for cross-field rule violations
 	for _, rule := range v.rules {
 		if rule.Patch != nil && v.ruleViolated(errors, rule.Name) {
-			if err := v.applyPatch(patched, rule.Patch); err != nil {
+			if err := v.applyPatch(patched, rule); err != nil {
 				return nil, fmt.Errorf("failed to apply patch for rule %s: %w", rule.Name, err)
 			}
 		}
@@ -87,6 +168,10 @@ func (v *Validator) validateCrossFieldRule(data map[string]interface{}, rule sch
 		return v.validateMutualExclusion(data, rule.Fields)
 	case "sum_constraint":
 		return v.validateSumConstraint(data, rule.Fields)
+	case "regex_match":
+		return v.validateRegexMatch(data, rule.Fields, v.patterns[rule.Constraint])
+	case "set_membership":
+		return v.validateSetMembership(data, rule.Fields)
 	default:
 		return fmt.Errorf("unknown rule type: %s", rule.Rule)
 	}
@@ -199,8 +284,81 @@ func (v *Validator) validateSumConstraint(data map[string]interface{}, fields []
 	return nil
 }
 
-// applyPatch applies a patch rule to fix a validation error
-func (v *Validator) applyPatch(data map[string]interface{}, patch *schema.PatchRule) error {
+func (v *Validator) validateRegexMatch(data map[string]interface{}, fields []string, pattern *regexp.Regexp) error {
+	if len(fields) != 1 {
+		return fmt.Errorf("regex_match requires exactly 1 field")
+	}
+	if pattern == nil {
+		return fmt.Errorf("regex_match requires a pattern constraint")
+	}
+
+	val, exists := data[fields[0]]
+	if !exists {
+		return nil
+	}
+	str, ok := val.(string)
+	if !ok {
+		return fmt.Errorf("field %s is not a string", fields[0])
+	}
+	if !pattern.MatchString(str) {
+		return fmt.Errorf("field %s (%s) does not match %s", fields[0], str, pattern)
+	}
+
+	return nil
+}
+
+func (v *Validator) validateSetMembership(data map[string]interface{}, fields []string) error {
+	if len(fields) != 2 { // value, set
+		return fmt.Errorf("set_membership requires exactly 2 fields: value, set")
+	}
+
+	val, exists := data[fields[0]]
+	if !exists {
+		return nil
+	}
+	setVal, exists := data[fields[1]]
+	if !exists || setVal == nil {
+		return nil
+	}
+	set, ok := setVal.([]interface{})
+	if !ok {
+		return fmt.Errorf("field %s is not an array", fields[1])
+	}
+
+	for _, member := range set {
+		if sameValue(val, member) {
+			return nil
+		}
+	}
+	return fmt.Errorf("field %s (%v) is not in %s", fields[0], val, fields[1])
+}
+
+// sameValue compares set members: numbers by value whatever their Go type,
+// everything else by deep equality
+func sameValue(a, b interface{}) bool {
+	x, aNumeric := toFloat(a)
+	y, bNumeric := toFloat(b)
+	if aNumeric || bNumeric {
+		return aNumeric && bNumeric && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(val interface{}) (float64, bool) {
+	switch n := val.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// applyPatch applies a rule's patch to fix a validation error
+func (v *Validator) applyPatch(data map[string]interface{}, rule schema.CrossFieldRule) error {
+	patch := rule.Patch
 	switch patch.Strategy {
 	case "set_value":
 		data[patch.Target] = patch.Value
@@ -208,12 +366,165 @@ func (v *Validator) applyPatch(data map[string]interface{}, patch *schema.PatchR
 		return v.adjustField(data, patch)
 	case "remove_field":
 		delete(data, patch.Target)
+	case "regenerate":
+		return v.regenerateField(data, rule)
+	case "pick_member":
+		return v.pickMember(data, rule)
+	case "clamp":
+		return v.clampField(data, rule)
 	default:
 		return fmt.Errorf("unknown patch strategy: %s", patch.Strategy)
 	}
 	return nil
 }
 
+// regenerateField replaces the field of a regex_match rule with a new value
+// matching its pattern
+func (v *Validator) regenerateField(data map[string]interface{}, rule schema.CrossFieldRule) error {
+	if rule.Rule != "regex_match" {
+		return fmt.Errorf("regenerate requires a regex_match rule, got %s", rule.Rule)
+	}
+
+	target := rule.Patch.Target
+	if target == "" {
+		target = rule.Fields[0]
+	}
+
+	value, err := regexgen.Generate(rule.Constraint, patchRand(rule, target, data[target]))
+	if err != nil {
+		return err
+	}
+	data[target] = value
+	return nil
+}
+
+// pickMember replaces the value of a set_membership rule with a random
+// member of its set
+func (v *Validator) pickMember(data map[string]interface{}, rule schema.CrossFieldRule) error {
+	if rule.Rule != "set_membership" {
+		return fmt.Errorf("pick_member requires a set_membership rule, got %s", rule.Rule)
+	}
+
+	target := rule.Patch.Target
+	if target == "" {
+		target = rule.Fields[0]
+	}
+
+	set, _ := data[rule.Fields[1]].([]interface{})
+	if len(set) == 0 {
+		return fmt.Errorf("field %s has no members to pick from", rule.Fields[1])
+	}
+	data[target] = set[patchRand(rule, target, data[target]).Intn(len(set))]
+	return nil
+}
+
+// patchRand returns a random source seeded from the rule and the rejected
+// value, so patching the same record twice gives the same result
+func patchRand(rule schema.CrossFieldRule, target string, current interface{}) *mathrand.Rand {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\x00%s\x00%v", rule.Name, target, current)
+	return mathrand.New(mathrand.NewSource(int64(h.Sum64())))
+}
+
+// clampField snaps a numeric field into [min, max]. The bounds come from the
+// patch params, either as numbers or as names of fields holding them; an
+// amount_range rule falls back to its own amount, min and max fields. The
+// value keeps its Go type, and bounds are rounded inward for integer fields.
+func (v *Validator) clampField(data map[string]interface{}, rule schema.CrossFieldRule) error {
+	patch := rule.Patch
+	target := patch.Target
+	minBound, maxBound := patch.Params["min"], patch.Params["max"]
+	if rule.Rule == "amount_range" {
+		if target == "" {
+			target = rule.Fields[0]
+		}
+		if minBound == nil {
+			minBound = rule.Fields[1]
+		}
+		if maxBound == nil {
+			maxBound = rule.Fields[2]
+		}
+	}
+	if target == "" {
+		return fmt.Errorf("clamp requires a target field")
+	}
+
+	min, hasMin, err := v.clampBound(data, minBound, "min")
+	if err != nil {
+		return err
+	}
+	max, hasMax, err := v.clampBound(data, maxBound, "max")
+	if err != nil {
+		return err
+	}
+	if !hasMin && !hasMax {
+		return fmt.Errorf("clamp requires a 'min' or 'max' parameter")
+	}
+
+	integer := false
+	if v.root != nil {
+		if node := v.root.RecordNode().PropertyNode(target); node != nil {
+			integer = node.Type == "integer"
+		}
+	}
+	switch data[target].(type) {
+	case int, int64:
+		integer = true
+	}
+	if integer {
+		min, max = math.Ceil(min), math.Floor(max)
+	}
+	if hasMin && hasMax && min > max {
+		return fmt.Errorf("clamp range [%v, %v] is empty", min, max)
+	}
+
+	var value float64
+	switch current := data[target].(type) {
+	case float64:
+		value = current
+	case int:
+		value = float64(current)
+	case int64:
+		value = float64(current)
+	default:
+		return fmt.Errorf("field %s is not a number", target)
+	}
+	if hasMin && value < min {
+		value = min
+	}
+	if hasMax && value > max {
+		value = max
+	}
+
+	switch data[target].(type) {
+	case int:
+		data[target] = int(value)
+	case int64:
+		data[target] = int64(value)
+	default:
+		data[target] = value
+	}
+	return nil
+}
+
+// clampBound resolves a clamp bound given as a number or a field name
+func (v *Validator) clampBound(data map[string]interface{}, bound interface{}, name string) (float64, bool, error) {
+	switch b := bound.(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		return b, true, nil
+	case int:
+		return float64(b), true, nil
+	case string:
+		if _, exists := data[b]; !exists {
+			return 0, false, nil
+		}
+		return v.getNumericValue(data, b), true, nil
+	}
+	return 0, false, fmt.Errorf("clamp '%s' must be a number or a field name", name)
+}
+
 func (v *Validator) adjustField(data map[string]interface{}, patch *schema.PatchRule) error {
 	currentVal := v.getNumericValue(data, patch.Target)
 
@@ -265,15 +576,51 @@ func (v *Validator) isTruthy(val interface{}) bool {
 	}
 }
 
-func (v *Validator) ruleViolated(errors []string, ruleName string) bool {
+func (v *Validator) ruleViolated(errors []ValidationError, ruleName string) bool {
 	for _, err := range errors {
-		if strings.Contains(err, fmt.Sprintf("rule '%s'", ruleName)) {
+		if err.Source == SourceCrossField && err.Rule == ruleName {
 			return true
 		}
 	}
 	return false
 }
 
+// stringOperands returns the values of two comparison operands when both
+// are fields holding non-numeric strings
+func (v *Validator) stringOperands(data map[string]interface{}, left, right string) (string, string, bool) {
+	leftStr, leftOk := data[left].(string)
+	rightStr, rightOk := data[right].(string)
+	if !leftOk || !rightOk {
+		return "", "", false
+	}
+	if _, err := strconv.ParseFloat(leftStr, 64); err == nil {
+		return "", "", false
+	}
+	if _, err := strconv.ParseFloat(rightStr, 64); err == nil {
+		return "", "", false
+	}
+	return leftStr, rightStr, true
+}
+
+func compareStrings(leftSide, leftValue, operator, rightSide, rightValue string) error {
+	cmp := strings.Compare(leftValue, rightValue)
+	var ok bool
+	switch operator {
+	case ">=":
+		ok = cmp >= 0
+	case "<=":
+		ok = cmp <= 0
+	case ">":
+		ok = cmp > 0
+	case "<":
+		ok = cmp < 0
+	}
+	if !ok {
+		return fmt.Errorf("constraint violation: %s (%s) should be %s %s (%s)", leftSide, leftValue, operator, rightSide, rightValue)
+	}
+	return nil
+}
+
 func abs(x float64) float64 {
 	if x < 0 {
 		return -x
@@ -329,6 +676,12 @@ func (v *Validator) validateComparison(data map[string]interface{}, fields []str
 		return fmt.Errorf("unsupported comparison operator in constraint: %s", constraint)
 	}
 
+	// Dates and other non-numeric strings compare as strings, which orders
+	// ISO 8601 values chronologically
+	if leftStr, rightStr, ok := v.stringOperands(data, leftSide, rightSide); ok {
+		return compareStrings(leftSide, leftStr, operator, rightSide, rightStr)
+	}
+
 	// Evaluate left side
 	leftValue := v.evaluateExpression(data, leftSide)
 
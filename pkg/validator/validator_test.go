@@ -0,0 +1,321 @@
+package validator
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// crossFieldErrors returns the messages of the cross-field errors of rule
+func crossFieldErrors(errors []ValidationError, rule string) []string {
+	var messages []string
+	for _, err := range errors {
+		if err.Source == SourceCrossField && err.Rule == rule {
+			messages = append(messages, err.Message)
+		}
+	}
+	return messages
+}
+
+// TestSetMembership verifies numbers are members by value whatever their Go
+// type, strings only by equal strings, and that a missing set passes while
+// a set that is not an array fails
+func TestSetMembership(t *testing.T) {
+	v := newTestValidator(t, `{"type": "object"}`)
+	v.AddRules([]schema.CrossFieldRule{{Name: "plan_offered", Rule: "set_membership", Fields: []string{"plan", "offered"}}})
+
+	testCases := []struct {
+		name   string
+		record map[string]interface{}
+		err    string // empty when the record passes
+	}{
+		{"string member", map[string]interface{}{"plan": "gold", "offered": []interface{}{"silver", "gold"}}, ""},
+		{"string not a member", map[string]interface{}{"plan": "bronze", "offered": []interface{}{"silver", "gold"}}, "field plan (bronze) is not in offered"},
+		{"int matches float", map[string]interface{}{"plan": 3, "offered": []interface{}{1.0, 3.0}}, ""},
+		{"float matches int64", map[string]interface{}{"plan": 3.0, "offered": []interface{}{int64(3)}}, ""},
+		{"numeric string is not a number", map[string]interface{}{"plan": "3", "offered": []interface{}{3.0}}, "field plan (3) is not in offered"},
+		{"number is not a numeric string", map[string]interface{}{"plan": 3.0, "offered": []interface{}{"3"}}, "field plan (3) is not in offered"},
+		{"missing value", map[string]interface{}{"offered": []interface{}{"gold"}}, ""},
+		{"missing set", map[string]interface{}{"plan": "gold"}, ""},
+		{"null set", map[string]interface{}{"plan": "gold", "offered": nil}, ""},
+		{"empty set", map[string]interface{}{"plan": "gold", "offered": []interface{}{}}, "field plan (gold) is not in offered"},
+		{"set not an array", map[string]interface{}{"plan": "gold", "offered": "gold"}, "field offered is not an array"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			messages := crossFieldErrors(v.ValidateRecord(tc.record), "plan_offered")
+			if tc.err == "" {
+				if len(messages) != 0 {
+					t.Errorf("Expected %v to pass, got %v", tc.record, messages)
+				}
+				return
+			}
+			if len(messages) != 1 || messages[0] != tc.err {
+				t.Errorf("Expected %q, got %v", tc.err, messages)
+			}
+		})
+	}
+}
+
+// TestPatchPickMember verifies pick_member replaces a value outside the set
+// with a member, the same one each time the record is patched
+func TestPatchPickMember(t *testing.T) {
+	v := newTestValidator(t, `{"type": "object"}`)
+	v.AddRules([]schema.CrossFieldRule{{
+		Name:   "plan_offered",
+		Rule:   "set_membership",
+		Fields: []string{"plan", "offered"},
+		Patch:  &schema.PatchRule{Strategy: "pick_member"},
+	}})
+
+	record := map[string]interface{}{"plan": "bronze", "offered": []interface{}{"silver", "gold", "platinum"}, "id": 7.0}
+	errors := v.ValidateRecord(record)
+	patched, err := v.PatchRecord(record, errors)
+	if err != nil {
+		t.Fatalf("Failed to patch: %v", err)
+	}
+	again, err := v.PatchRecord(record, errors)
+	if err != nil {
+		t.Fatalf("Failed to patch: %v", err)
+	}
+	if !reflect.DeepEqual(patched, again) {
+		t.Errorf("Expected patching to be reproducible, got %v and %v", patched, again)
+	}
+
+	plan := patched.(map[string]interface{})["plan"]
+	if plan != "silver" && plan != "gold" && plan != "platinum" {
+		t.Errorf("Expected plan to be an offered member, got %v", plan)
+	}
+	if messages := crossFieldErrors(v.ValidateRecord(patched), "plan_offered"); len(messages) != 0 {
+		t.Errorf("Expected the patched record to pass, got %v", messages)
+	}
+	if record["plan"] != "bronze" || patched.(map[string]interface{})["id"] != 7.0 {
+		t.Errorf("Expected the original record to be untouched and other fields kept, got %v and %v", record, patched)
+	}
+
+	// An empty set has no member to pick
+	empty := map[string]interface{}{"plan": "bronze", "offered": []interface{}{}}
+	if _, err := v.PatchRecord(empty, v.ValidateRecord(empty)); err == nil || !strings.Contains(err.Error(), "has no members to pick from") {
+		t.Errorf("Expected an empty set to fail patching, got %v", err)
+	}
+}
+
+// TestPatchClamp verifies clamp snaps a field into its bounds, keeping its
+// Go type, rounding the bounds inward for integer fields and leaving the
+// other fields alone
+func TestPatchClamp(t *testing.T) {
+	v := newTestValidator(t, `{
+		"type": "object",
+		"properties": {
+			"age": {"type": "integer"},
+			"price": {"type": "number"}
+		}
+	}`)
+
+	clamp := func(target string, params map[string]interface{}) schema.CrossFieldRule {
+		return schema.CrossFieldRule{
+			Name:   "clamped",
+			Rule:   "comparison",
+			Fields: []string{target, "limit"},
+			Patch:  &schema.PatchRule{Strategy: "clamp", Target: target, Params: params},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		rule     schema.CrossFieldRule
+		record   map[string]interface{}
+		expected map[string]interface{}
+		err      string
+	}{
+		{
+			name:     "integer field above max",
+			rule:     clamp("age", map[string]interface{}{"min": 0.5, "max": 120.5}),
+			record:   map[string]interface{}{"age": 130.0, "name": "Ada", "price": 5.0},
+			expected: map[string]interface{}{"age": 120.0, "name": "Ada", "price": 5.0},
+		},
+		{
+			name:     "integer field below min",
+			rule:     clamp("age", map[string]interface{}{"min": 0.5, "max": 120.5}),
+			record:   map[string]interface{}{"age": -2.0, "name": "Ada"},
+			expected: map[string]interface{}{"age": 1.0, "name": "Ada"},
+		},
+		{
+			name:     "Go int keeps its type",
+			rule:     clamp("visits", map[string]interface{}{"max": 10.7}),
+			record:   map[string]interface{}{"visits": 15, "age": 40},
+			expected: map[string]interface{}{"visits": 10, "age": 40},
+		},
+		{
+			name:     "Go int64 keeps its type",
+			rule:     clamp("visits", map[string]interface{}{"min": 0}),
+			record:   map[string]interface{}{"visits": int64(-4)},
+			expected: map[string]interface{}{"visits": int64(0)},
+		},
+		{
+			name:     "float field is not rounded",
+			rule:     clamp("price", map[string]interface{}{"min": 0.5, "max": 9.99}),
+			record:   map[string]interface{}{"price": 12.345, "age": 40.0},
+			expected: map[string]interface{}{"price": 9.99, "age": 40.0},
+		},
+		{
+			name:     "bound from a field",
+			rule:     clamp("price", map[string]interface{}{"max": "budget"}),
+			record:   map[string]interface{}{"price": 50.0, "budget": 42.5},
+			expected: map[string]interface{}{"price": 42.5, "budget": 42.5},
+		},
+		{
+			name:     "value in range",
+			rule:     clamp("price", map[string]interface{}{"min": 1.0, "max": 10.0}),
+			record:   map[string]interface{}{"price": 4.25},
+			expected: map[string]interface{}{"price": 4.25},
+		},
+		{
+			name: "amount_range fallback",
+			rule: schema.CrossFieldRule{
+				Name:   "clamped",
+				Rule:   "amount_range",
+				Fields: []string{"paid", "min_paid", "max_paid"},
+				Patch:  &schema.PatchRule{Strategy: "clamp"},
+			},
+			record:   map[string]interface{}{"paid": 500.0, "min_paid": 10.0, "max_paid": 250.0},
+			expected: map[string]interface{}{"paid": 250.0, "min_paid": 10.0, "max_paid": 250.0},
+		},
+		{
+			name:   "integer range empty after rounding",
+			rule:   clamp("age", map[string]interface{}{"min": 3.2, "max": 3.8}),
+			record: map[string]interface{}{"age": 5.0},
+			err:    "clamp range [4, 3] is empty",
+		},
+		{
+			name:   "not a number",
+			rule:   clamp("price", map[string]interface{}{"max": 10.0}),
+			record: map[string]interface{}{"price": "12"},
+			err:    "field price is not a number",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v.rules = []schema.CrossFieldRule{tc.rule}
+			violated := []ValidationError{{Source: SourceCrossField, Rule: tc.rule.Name}}
+
+			patched, err := v.PatchRecord(tc.record, violated)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("Expected an error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to patch: %v", err)
+			}
+			if !reflect.DeepEqual(patched, tc.expected) {
+				t.Errorf("Expected %#v, got %#v", tc.expected, patched)
+			}
+		})
+	}
+}
+
+// TestValidateRecord_Pointers verifies every error names its field by the
+// full JSON Pointer into the record: nested schema and format failures,
+// array items, escaped names and cross-field rules
+func TestValidateRecord_Pointers(t *testing.T) {
+	v := newTestValidator(t, `{
+		"type": "object",
+		"properties": {
+			"patient": {
+				"type": "object",
+				"required": ["demographics"],
+				"properties": {
+					"demographics": {
+						"type": "object",
+						"required": ["mrn"],
+						"properties": {
+							"date_of_birth": {"type": "string"},
+							"mrn": {"type": "string"}
+						}
+					}
+				}
+			},
+			"vehicles": {"type": "array", "items": {"type": "object", "properties": {"vin": {"type": "string", "format": "vin"}}}},
+			"size/unit": {"type": "string", "maxLength": 2},
+			"admission_day": {"type": "integer"},
+			"discharge_day": {"type": "integer"}
+		},
+		"x-cross-field-rules": [
+			{"name": "discharge_after_admission", "rule": "comparison", "fields": ["discharge_day", "admission_day"], "constraint": "discharge_day >= admission_day", "severity": "error"}
+		]
+	}`)
+
+	record := map[string]interface{}{
+		"patient":       map[string]interface{}{"demographics": map[string]interface{}{"date_of_birth": 19800101.0}},
+		"vehicles":      []interface{}{map[string]interface{}{"vin": "1M8GDM9AXKP042788"}, map[string]interface{}{"vin": "1M8GDM9A1KP042788"}},
+		"size/unit":     "kg/m",
+		"admission_day": 5.0,
+		"discharge_day": 3.0,
+	}
+
+	expected := map[string]string{
+		"/patient/demographics/date_of_birth": SourceSchema + ":type",
+		"/patient/demographics":               SourceSchema + ":required",
+		"/vehicles/1/vin":                     SourceFormat + ":vin",
+		"/size~1unit":                         SourceSchema + ":maxLength",
+		"/discharge_day":                      SourceCrossField + ":discharge_after_admission",
+	}
+	got := make(map[string]string)
+	for _, err := range v.ValidateRecord(record) {
+		got[err.Field] = err.Source + ":" + err.Rule
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected errors at\n%v\ngot\n%v", expected, got)
+	}
+}
+
+// TestPatchRegenerate verifies the regenerate patch replaces a field that
+// violates a regex_match rule with a value matching the pattern, the same
+// value each time the record is patched
+func TestPatchRegenerate(t *testing.T) {
+	v := newTestValidator(t, `{
+		"type": "object",
+		"x-cross-field-rules": [
+			{"name": "mrn_format", "rule": "regex_match", "fields": ["mrn"], "constraint": "^MRN-[0-9]{6}$", "severity": "error", "patch": {"strategy": "regenerate"}}
+		]
+	}`)
+	pattern := regexp.MustCompile(`^MRN-[0-9]{6}$`)
+
+	for _, mrn := range []string{"12345", "MRN-12", "mrn-123456"} {
+		record := map[string]interface{}{"mrn": mrn, "name": "Ada"}
+		errors := v.ValidateRecord(record)
+		if messages := crossFieldErrors(errors, "mrn_format"); len(messages) != 1 {
+			t.Fatalf("Expected '%s' to violate mrn_format, got %v", mrn, messages)
+		}
+
+		patched, err := v.PatchRecord(record, errors)
+		if err != nil {
+			t.Fatalf("Failed to patch: %v", err)
+		}
+		again, err := v.PatchRecord(record, errors)
+		if err != nil {
+			t.Fatalf("Failed to patch: %v", err)
+		}
+		if !reflect.DeepEqual(patched, again) {
+			t.Errorf("Expected patching '%s' to be reproducible, got %v and %v", mrn, patched, again)
+		}
+
+		fields := patched.(map[string]interface{})
+		if value, _ := fields["mrn"].(string); !pattern.MatchString(value) {
+			t.Errorf("Expected '%s' to be regenerated to match the pattern, got %v", mrn, fields["mrn"])
+		}
+		if fields["name"] != "Ada" {
+			t.Errorf("Expected other fields to be kept, got %v", fields)
+		}
+		if errs := v.ValidateRecord(patched); len(errs) != 0 {
+			t.Errorf("Expected the patched record to pass, got %v", errs)
+		}
+	}
+}
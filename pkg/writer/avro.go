@@ -0,0 +1,587 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// avroBlockRecords is how many records each block of an Avro object
+// container file holds
+const avroBlockRecords = 1000
+
+// avroMagic starts every Avro object container file
+var avroMagic = []byte{'O', 'b', 'j', 1}
+
+// Avro types a schema node maps to. avroJSON is an Avro string holding the
+// value as JSON text, for values Avro cannot type, such as a field that may
+// be a string or a number, or an object with undeclared keys.
+const (
+	avroNull    = "null"
+	avroBoolean = "boolean"
+	avroLong    = "long"
+	avroDouble  = "double"
+	avroString  = "string"
+	avroJSON    = "json"
+	avroRecord  = "record"
+	avroArray   = "array"
+	avroMap     = "map"
+	avroUnion   = "union"
+)
+
+// avroType is the Avro schema derived from a schema node, kept in a form
+// that both renders the .avsc and drives the encoder
+type avroType struct {
+	kind     string
+	name     string      // record name
+	fields   []avroField // record fields, in key order
+	items    *avroType   // array items and map values
+	branches []*avroType // union branches, null first
+}
+
+// avroField is a record field. Name is the key made a valid Avro name.
+type avroField struct {
+	key  string
+	name string
+	typ  *avroType
+}
+
+// SetSchema declares the schema of a single record, which Avro output
+// derives its schema from
+func (w *Writer) SetSchema(recordNode *schema.SchemaNode) {
+	w.recordNode = recordNode
+}
+
+// avroSchemaPath returns the path of the .avsc written next to the dataset
+func (w *Writer) avroSchemaPath() string {
+	return strings.TrimSuffix(w.GetOutputPath(), ".avro") + ".avsc"
+}
+
+// writeAvro writes records as an Avro object container file, with the Avro
+// schema derived from the record schema also written to dataset.avsc.
+// Compression uses Avro's deflate codec, so the file stays readable by
+// Avro tools.
+func (w *Writer) writeAvro(records []interface{}) error {
+	if w.recordNode == nil {
+		return fmt.Errorf("avro output needs the record schema")
+	}
+	root := newAvroSchema(w.recordNode)
+	schemaJSON, err := json.Marshal(root.schema())
+	if err != nil {
+		return fmt.Errorf("failed to encode Avro schema: %w", err)
+	}
+
+	if !w.config.Stdout() {
+		indented, err := json.MarshalIndent(root.schema(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode Avro schema: %w", err)
+		}
+		if err := os.WriteFile(w.avroSchemaPath(), append(indented, '\n'), 0600); err != nil {
+			return fmt.Errorf("failed to write Avro schema: %w", err)
+		}
+	}
+
+	file, err := w.openUncompressed()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	out := bufio.NewWriter(file)
+
+	codec := "null"
+	if w.config.Compress {
+		codec = "deflate"
+	}
+	// The sync marker comes from the schema, not a random source, so the
+	// same run writes the same bytes
+	sum := sha256.Sum256(schemaJSON)
+	sync := sum[:16]
+
+	var header bytes.Buffer
+	header.Write(avroMagic)
+	appendLong(&header, 2)
+	appendString(&header, "avro.codec")
+	appendBytes(&header, []byte(codec))
+	appendString(&header, "avro.schema")
+	appendBytes(&header, schemaJSON)
+	appendLong(&header, 0)
+	header.Write(sync)
+	out.Write(header.Bytes())
+
+	var block bytes.Buffer
+	for start := 0; start < len(records); start += avroBlockRecords {
+		end := start + avroBlockRecords
+		if end > len(records) {
+			end = len(records)
+		}
+
+		block.Reset()
+		for i := start; i < end; i++ {
+			if err := root.encode(&block, records[i], ""); err != nil {
+				return fmt.Errorf("failed to encode record %d: %w", i, err)
+			}
+		}
+		data := block.Bytes()
+		if codec == "deflate" {
+			if data, err = deflate(data); err != nil {
+				return fmt.Errorf("failed to compress records: %w", err)
+			}
+		}
+
+		var prefix bytes.Buffer
+		appendLong(&prefix, int64(end-start))
+		appendLong(&prefix, int64(len(data)))
+		out.Write(prefix.Bytes())
+		out.Write(data)
+		out.Write(sync)
+	}
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	return file.Close()
+}
+
+// deflate compresses an Avro block with raw deflate, as the deflate codec
+// requires
+func deflate(data []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	fw, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := fw.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// newAvroSchema derives the Avro schema of a record. Record types are named
+// after their path, starting with Record for the record itself.
+func newAvroSchema(recordNode *schema.SchemaNode) *avroType {
+	names := make(map[string]bool)
+	return avroTypeOf(recordNode, "Record", names)
+}
+
+// avroTypeOf maps a schema node to an Avro type. Optional properties and
+// nodes that allow null become unions with null, objects with declared
+// properties become records, and objects with only additionalProperties or
+// a single patternProperties entry become maps. Values Avro cannot type
+// are kept as JSON text.
+func avroTypeOf(node *schema.SchemaNode, name string, names map[string]bool) *avroType {
+	if node == nil {
+		return &avroType{kind: avroJSON}
+	}
+
+	kind, nullable := avroKind(node)
+	var typ *avroType
+	switch kind {
+	case avroRecord:
+		typ = &avroType{kind: avroRecord, name: uniqueAvroName(name, names)}
+		keys := make([]string, 0, len(node.Properties))
+		for key := range node.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		required := make(map[string]bool, len(node.Required))
+		for _, key := range node.Required {
+			required[key] = true
+		}
+		fieldNames := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			fieldName := uniqueAvroName(avroName(key), fieldNames)
+			fieldType := avroTypeOf(node.Properties[key], name+avroTypeName(key), names)
+			if !required[key] {
+				fieldType = nullableAvroType(fieldType)
+			}
+			typ.fields = append(typ.fields, avroField{key: key, name: fieldName, typ: fieldType})
+		}
+	case avroArray:
+		typ = &avroType{kind: avroArray, items: avroTypeOf(node.Items, name+"Item", names)}
+	case avroMap:
+		values := node.AdditionalProperties
+		if values == nil && len(node.PatternProperties) == 1 {
+			values = node.PatternProperties[0].Node
+		}
+		typ = &avroType{kind: avroMap, items: avroTypeOf(values, name+"Value", names)}
+	default:
+		typ = &avroType{kind: kind}
+	}
+
+	if nullable {
+		typ = nullableAvroType(typ)
+	}
+	return typ
+}
+
+// avroKind returns the Avro kind of a node and whether it also allows null
+func avroKind(node *schema.SchemaNode) (string, bool) {
+	if len(node.Variants) > 0 {
+		return avroJSON, false
+	}
+	if node.HasConst {
+		return avroValueKind([]interface{}{node.Const}, node.Nullable)
+	}
+	if len(node.Enum) > 0 {
+		return avroValueKind(node.Enum, node.Nullable)
+	}
+
+	types := node.Types
+	if len(types) == 0 && node.Type != "" {
+		types = []string{node.Type}
+	}
+	var nonNull []string
+	for _, t := range types {
+		if t != "null" {
+			nonNull = append(nonNull, t)
+		}
+	}
+	nullable := node.AllowsNull()
+	if len(nonNull) == 0 {
+		if nullable {
+			return avroNull, false
+		}
+		return avroJSON, false
+	}
+	if len(nonNull) > 1 {
+		return avroJSON, false
+	}
+
+	switch nonNull[0] {
+	case "string":
+		return avroString, nullable
+	case "integer":
+		return avroLong, nullable
+	case "number":
+		return avroDouble, nullable
+	case "boolean":
+		return avroBoolean, nullable
+	case "array":
+		if node.Items == nil || len(node.TupleItems) > 0 {
+			return avroJSON, false
+		}
+		return avroArray, nullable
+	case "object":
+		// Keys not in properties have no record field to go in
+		undeclared := node.AdditionalProperties != nil || len(node.PatternProperties) > 0 ||
+			len(node.Conditionals) > 0 || len(node.DependentSchemas) > 0
+		switch {
+		case len(node.Properties) > 0 && !undeclared:
+			return avroRecord, nullable
+		case len(node.Properties) == 0 && (node.AdditionalProperties != nil) != (len(node.PatternProperties) == 1) &&
+			len(node.Conditionals) == 0 && len(node.DependentSchemas) == 0:
+			return avroMap, nullable
+		}
+	}
+	return avroJSON, false
+}
+
+// avroValueKind returns the Avro kind that holds every value of an enum or
+// const, and whether one of them is null
+func avroValueKind(values []interface{}, nullable bool) (string, bool) {
+	kind := ""
+	for _, value := range values {
+		var valueKind string
+		switch v := value.(type) {
+		case nil:
+			nullable = true
+			continue
+		case string:
+			valueKind = avroString
+		case bool:
+			valueKind = avroBoolean
+		case float64:
+			valueKind = avroLong
+			if v != math.Trunc(v) {
+				valueKind = avroDouble
+			}
+		default:
+			return avroJSON, false
+		}
+
+		switch {
+		case kind == "" || kind == valueKind:
+			kind = valueKind
+		case (kind == avroLong && valueKind == avroDouble) || (kind == avroDouble && valueKind == avroLong):
+			kind = avroDouble
+		default:
+			return avroJSON, false
+		}
+	}
+	if kind == "" {
+		return avroNull, false
+	}
+	return kind, nullable
+}
+
+// nullableAvroType returns a union of null and typ, which typ is already if
+// it allows null
+func nullableAvroType(typ *avroType) *avroType {
+	if typ.kind == avroNull || typ.kind == avroUnion || typ.kind == avroJSON {
+		return typ
+	}
+	return &avroType{kind: avroUnion, branches: []*avroType{{kind: avroNull}, typ}}
+}
+
+// avroName turns a key into a valid Avro name by replacing characters names
+// cannot contain with underscores and prefixing an underscore when the
+// first character cannot start a name
+func avroName(key string) string {
+	if key == "" {
+		return "_"
+	}
+
+	var name strings.Builder
+	for i, r := range key {
+		switch {
+		case r == '_' || (r < unicode.MaxASCII && unicode.IsLetter(r)):
+		case r < unicode.MaxASCII && unicode.IsDigit(r):
+			if i == 0 {
+				name.WriteByte('_')
+			}
+		default:
+			r = '_'
+		}
+		name.WriteRune(r)
+	}
+	return name.String()
+}
+
+// avroTypeName turns a key into the part of a record name that a nested
+// record adds, such as Address for address
+func avroTypeName(key string) string {
+	name := avroName(key)
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// uniqueAvroName returns name, or name with a numeric suffix if it is taken
+func uniqueAvroName(name string, taken map[string]bool) string {
+	unique := name
+	for n := 2; taken[unique]; n++ {
+		unique = fmt.Sprintf("%s_%d", name, n)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// schema renders the type as Avro schema JSON
+func (t *avroType) schema() interface{} {
+	switch t.kind {
+	case avroJSON:
+		return avroString
+	case avroRecord:
+		fields := make([]interface{}, len(t.fields))
+		for i, field := range t.fields {
+			rendered := map[string]interface{}{"name": field.name, "type": field.typ.schema()}
+			if field.typ.kind == avroUnion {
+				rendered["default"] = nil
+			}
+			var doc []string
+			if field.name != field.key {
+				doc = append(doc, fmt.Sprintf("the %q field", field.key))
+			}
+			if field.typ.kind == avroJSON {
+				doc = append(doc, "encoded as JSON text")
+			}
+			if len(doc) > 0 {
+				rendered["doc"] = strings.Join(doc, ", ")
+			}
+			fields[i] = rendered
+		}
+		return map[string]interface{}{"type": avroRecord, "name": t.name, "fields": fields}
+	case avroArray:
+		return map[string]interface{}{"type": avroArray, "items": t.items.schema()}
+	case avroMap:
+		return map[string]interface{}{"type": avroMap, "values": t.items.schema()}
+	case avroUnion:
+		branches := make([]interface{}, len(t.branches))
+		for i, branch := range t.branches {
+			branches[i] = branch.schema()
+		}
+		return branches
+	}
+	return t.kind
+}
+
+// encode appends value in Avro binary encoding. Path names the value in
+// errors.
+func (t *avroType) encode(buf *bytes.Buffer, value interface{}, path string) error {
+	switch t.kind {
+	case avroNull:
+		if value != nil {
+			return fmt.Errorf("%s: expected null, got %T", avroPath(path), value)
+		}
+	case avroBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("%s: expected a boolean, got %T", avroPath(path), value)
+		}
+		if b {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case avroLong:
+		n, ok := avroInteger(value)
+		if !ok {
+			return fmt.Errorf("%s: expected an integer, got %v", avroPath(path), value)
+		}
+		appendLong(buf, n)
+	case avroDouble:
+		f, ok := avroFloat(value)
+		if !ok {
+			return fmt.Errorf("%s: expected a number, got %T", avroPath(path), value)
+		}
+		var bits [8]byte
+		binary.LittleEndian.PutUint64(bits[:], math.Float64bits(f))
+		buf.Write(bits[:])
+	case avroString:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a string, got %T", avroPath(path), value)
+		}
+		appendString(buf, s)
+	case avroJSON:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", avroPath(path), err)
+		}
+		appendBytes(buf, encoded)
+	case avroRecord:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %T", avroPath(path), value)
+		}
+		for _, field := range t.fields {
+			if err := field.typ.encode(buf, obj[field.key], joinAvroPath(path, field.key)); err != nil {
+				return err
+			}
+		}
+	case avroArray:
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %T", avroPath(path), value)
+		}
+		if len(items) > 0 {
+			appendLong(buf, int64(len(items)))
+			for i, item := range items {
+				if err := t.items.encode(buf, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+		appendLong(buf, 0)
+	case avroMap:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %T", avroPath(path), value)
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if len(keys) > 0 {
+			appendLong(buf, int64(len(keys)))
+			for _, key := range keys {
+				appendString(buf, key)
+				if err := t.items.encode(buf, obj[key], joinAvroPath(path, key)); err != nil {
+					return err
+				}
+			}
+		}
+		appendLong(buf, 0)
+	case avroUnion:
+		// Unions are always null and one other type
+		if value == nil {
+			appendLong(buf, 0)
+			return nil
+		}
+		appendLong(buf, 1)
+		return t.branches[1].encode(buf, value, path)
+	}
+	return nil
+}
+
+// avroInteger returns a JSON integer as an int64
+func avroInteger(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// avroFloat returns a JSON number as a float64
+func avroFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// avroPath names a value in errors, the record itself being the root
+func avroPath(path string) string {
+	if path == "" {
+		return "record"
+	}
+	return path
+}
+
+func joinAvroPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// appendLong appends n as a zigzag varint
+func appendLong(buf *bytes.Buffer, n int64) {
+	var encoded [binary.MaxVarintLen64]byte
+	buf.Write(encoded[:binary.PutVarint(encoded[:], n)])
+}
+
+// appendBytes appends data prefixed with its length
+func appendBytes(buf *bytes.Buffer, data []byte) {
+	appendLong(buf, int64(len(data)))
+	buf.Write(data)
+}
+
+// appendString appends s prefixed with its length
+func appendString(buf *bytes.Buffer, s string) {
+	appendLong(buf, int64(len(s)))
+	buf.WriteString(s)
+}
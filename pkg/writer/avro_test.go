@@ -0,0 +1,275 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/specmint/specmint/internal/config"
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// avroReader decodes an Avro object container file using only the schema
+// embedded in it, the way a standard Avro reader does
+type avroReader struct {
+	t   *testing.T
+	in  *bufio.Reader
+	err error
+}
+
+func (r *avroReader) long() int64 {
+	n, err := binary.ReadVarint(r.in)
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	return n
+}
+
+func (r *avroReader) bytes() []byte {
+	data := make([]byte, r.long())
+	if _, err := io.ReadFull(r.in, data); err != nil && r.err == nil {
+		r.err = err
+	}
+	return data
+}
+
+// value decodes a value of the given Avro schema, with named types looked
+// up in names
+func (r *avroReader) value(avroSchema interface{}, names map[string]interface{}) interface{} {
+	switch s := avroSchema.(type) {
+	case string:
+		switch s {
+		case "null":
+			return nil
+		case "boolean":
+			b, _ := r.in.ReadByte()
+			return b == 1
+		case "long":
+			return r.long()
+		case "double":
+			var bits [8]byte
+			io.ReadFull(r.in, bits[:])
+			return math.Float64frombits(binary.LittleEndian.Uint64(bits[:]))
+		case "string":
+			return string(r.bytes())
+		}
+		return r.value(names[s], names)
+	case []interface{}:
+		return r.value(s[r.long()], names)
+	case map[string]interface{}:
+		switch s["type"] {
+		case "record":
+			names[s["name"].(string)] = s
+			record := make(map[string]interface{})
+			for _, field := range s["fields"].([]interface{}) {
+				field := field.(map[string]interface{})
+				record[field["name"].(string)] = r.value(field["type"], names)
+			}
+			return record
+		case "array":
+			items := []interface{}{}
+			for n := r.long(); n != 0; n = r.long() {
+				for i := int64(0); i < n; i++ {
+					items = append(items, r.value(s["items"], names))
+				}
+			}
+			return items
+		case "map":
+			values := make(map[string]interface{})
+			for n := r.long(); n != 0; n = r.long() {
+				for i := int64(0); i < n; i++ {
+					key := string(r.bytes())
+					values[key] = r.value(s["values"], names)
+				}
+			}
+			return values
+		}
+	}
+	r.t.Fatalf("Unexpected Avro schema %v", avroSchema)
+	return nil
+}
+
+// readAvro decodes every record of an Avro object container file and
+// returns them with the embedded schema
+func readAvro(t *testing.T, path string) ([]interface{}, map[string]interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read dataset: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("Obj\x01")) {
+		t.Fatalf("Missing Avro magic in %q", data[:4])
+	}
+
+	r := &avroReader{t: t, in: bufio.NewReader(bytes.NewReader(data[4:]))}
+	meta := make(map[string]string)
+	for n := r.long(); n != 0; n = r.long() {
+		for i := int64(0); i < n; i++ {
+			key := string(r.bytes())
+			meta[key] = string(r.bytes())
+		}
+	}
+	var avroSchema map[string]interface{}
+	if err := json.Unmarshal([]byte(meta["avro.schema"]), &avroSchema); err != nil {
+		t.Fatalf("Failed to decode the embedded schema: %v", err)
+	}
+	sync := make([]byte, 16)
+	io.ReadFull(r.in, sync)
+
+	var records []interface{}
+	for {
+		if _, err := r.in.Peek(1); err == io.EOF {
+			break
+		}
+		count := r.long()
+		block := r.bytes()
+		if meta["avro.codec"] == "deflate" {
+			if block, err = io.ReadAll(flate.NewReader(bytes.NewReader(block))); err != nil {
+				t.Fatalf("Failed to inflate block: %v", err)
+			}
+		}
+		blockReader := &avroReader{t: t, in: bufio.NewReader(bytes.NewReader(block))}
+		for i := int64(0); i < count; i++ {
+			records = append(records, blockReader.value(avroSchema, make(map[string]interface{})))
+		}
+		marker := make([]byte, 16)
+		io.ReadFull(r.in, marker)
+		if !bytes.Equal(marker, sync) {
+			t.Fatalf("Block does not end with the sync marker")
+		}
+		if r.err != nil || blockReader.err != nil {
+			t.Fatalf("Failed to decode block: %v %v", r.err, blockReader.err)
+		}
+	}
+	return records, avroSchema
+}
+
+// TestWriteAvro verifies records, nested records, arrays and maps survive a
+// round trip through an Avro container file, compressed or not, and that the
+// .avsc matches the embedded schema
+func TestWriteAvro(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["id", "name", "address", "lines", "status"],
+		"properties": {
+			"id": {"type": "integer"},
+			"name": {"type": "string"},
+			"score": {"type": "number"},
+			"active": {"type": "boolean"},
+			"nickname": {"type": ["string", "null"]},
+			"status": {"enum": ["open", "closed"]},
+			"first-visit": {"type": "string", "format": "date"},
+			"address": {"type": "object", "required": ["city"], "properties": {"city": {"type": "string"}, "zip": {"type": "string"}}},
+			"lines": {"type": "array", "items": {"type": "object", "required": ["sku", "qty"], "properties": {"sku": {"type": "string"}, "qty": {"type": "integer"}}}},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"extra": {"type": ["string", "integer"]}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	records := []interface{}{
+		map[string]interface{}{
+			"id": int64(1), "name": "Ada", "score": 9.5, "active": true, "nickname": nil, "status": "open",
+			"first-visit": "2024-01-02",
+			"address":     map[string]interface{}{"city": "Oslo", "zip": "0150"},
+			"lines":       []interface{}{map[string]interface{}{"sku": "A-1", "qty": int64(2)}, map[string]interface{}{"sku": "B-2", "qty": 3.0}},
+			"labels":      map[string]interface{}{"tier": "gold"},
+			"extra":       int64(7),
+		},
+		map[string]interface{}{
+			"id": int64(-2), "name": "Grace", "status": "closed",
+			"address": map[string]interface{}{"city": "Bergen"},
+			"lines":   []interface{}{},
+			"extra":   "x",
+		},
+	}
+	expected := []interface{}{
+		map[string]interface{}{
+			"id": int64(1), "name": "Ada", "score": 9.5, "active": true, "nickname": nil, "status": "open",
+			"first_visit": "2024-01-02",
+			"address":     map[string]interface{}{"city": "Oslo", "zip": "0150"},
+			"lines":       []interface{}{map[string]interface{}{"sku": "A-1", "qty": int64(2)}, map[string]interface{}{"sku": "B-2", "qty": int64(3)}},
+			"labels":      map[string]interface{}{"tier": "gold"},
+			"extra":       "7",
+		},
+		map[string]interface{}{
+			"id": int64(-2), "name": "Grace", "score": nil, "active": nil, "nickname": nil, "status": "closed",
+			"first_visit": nil,
+			"address":     map[string]interface{}{"city": "Bergen", "zip": nil},
+			"lines":       []interface{}{},
+			"labels":      nil,
+			"extra":       `"x"`,
+		},
+	}
+
+	for _, compress := range []bool{false, true} {
+		dir := t.TempDir()
+		w, err := New(config.Output{Directory: dir, Format: "avro", Compress: compress})
+		if err != nil {
+			t.Fatalf("Failed to create writer: %v", err)
+		}
+		w.SetSchema(root)
+		if err := w.WriteRecords(records); err != nil {
+			t.Fatalf("Failed to write records: %v", err)
+		}
+		if w.GetOutputPath() != filepath.Join(dir, "dataset.avro") {
+			t.Errorf("Unexpected output path %s", w.GetOutputPath())
+		}
+
+		decoded, embedded := readAvro(t, filepath.Join(dir, "dataset.avro"))
+		if !reflect.DeepEqual(decoded, expected) {
+			t.Errorf("Expected records:\n%v\ngot:\n%v", expected, decoded)
+		}
+
+		avsc, err := os.ReadFile(filepath.Join(dir, "dataset.avsc"))
+		if err != nil {
+			t.Fatalf("Failed to read Avro schema: %v", err)
+		}
+		var written map[string]interface{}
+		if err := json.Unmarshal(avsc, &written); err != nil {
+			t.Fatalf("Failed to decode Avro schema: %v", err)
+		}
+		if !reflect.DeepEqual(written, embedded) {
+			t.Errorf("Expected dataset.avsc to match the embedded schema")
+		}
+		if embedded["name"] != "Record" || len(embedded["fields"].([]interface{})) != 11 {
+			t.Errorf("Unexpected record schema %v", embedded)
+		}
+	}
+}
+
+// TestWriteAvro_Invalid verifies a value of the wrong type names its field
+func TestWriteAvro_Invalid(t *testing.T) {
+	root := &schema.SchemaNode{
+		Type:     "object",
+		Required: []string{"address"},
+		Properties: map[string]*schema.SchemaNode{
+			"address": {Type: "object", Required: []string{"zip"}, Properties: map[string]*schema.SchemaNode{"zip": {Type: "integer"}}},
+		},
+	}
+	w, err := New(config.Output{Directory: t.TempDir(), Format: "avro"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	w.SetSchema(root)
+
+	err = w.WriteRecords([]interface{}{map[string]interface{}{"address": map[string]interface{}{"zip": "0150"}}})
+	if err == nil || !strings.Contains(err.Error(), "address.zip") {
+		t.Errorf("Expected an error naming address.zip, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package writer
+
+import "sort"
+
+// Flatten walks a record and calls visit for every leaf path in dotted
+// form, e.g. patient.demographics.date_of_birth. Arrays are reported at
+// their own path and their elements are walked under path[], so an array
+// of objects yields paths like claims[].amount. Keys are visited in sorted
+// order.
+func Flatten(record map[string]interface{}, visit func(path string, value interface{})) {
+	flattenValue("", record, visit)
+}
+
+func flattenValue(path string, value interface{}, visit func(path string, value interface{})) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			flattenValue(child, v[key], visit)
+		}
+	case []interface{}:
+		visit(path, v)
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				flattenValue(path+"[]", item, visit)
+			}
+		}
+	default:
+		visit(path, v)
+	}
+}
@@ -0,0 +1,42 @@
+package writer
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFlatten verifies that nested objects and arrays are expanded into
+// dotted leaf paths
+func TestFlatten(t *testing.T) {
+	record := map[string]interface{}{
+		"patient": map[string]interface{}{
+			"demographics": map[string]interface{}{
+				"date_of_birth": "1980-01-01",
+				"middle_name":   nil,
+			},
+		},
+		"claims": []interface{}{
+			map[string]interface{}{"amount": 12.5},
+			map[string]interface{}{"amount": 3.0, "code": "A1"},
+		},
+		"tags": []interface{}{"a", "b"},
+	}
+
+	var paths []string
+	Flatten(record, func(path string, value interface{}) {
+		paths = append(paths, path)
+	})
+
+	expected := []string{
+		"claims",
+		"claims[].amount",
+		"claims[].amount",
+		"claims[].code",
+		"patient.demographics.date_of_birth",
+		"patient.demographics.middle_name",
+		"tags",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected paths %v, got %v", expected, paths)
+	}
+}
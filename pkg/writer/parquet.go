@@ -0,0 +1,561 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// parquetRowGroupRecords is how many records each row group holds. Only
+// one row group is buffered at a time.
+const parquetRowGroupRecords = 10000
+
+// parquetMagic starts and ends every Parquet file
+var parquetMagic = []byte("PAR1")
+
+// Parquet physical types, repetitions, converted types, encodings, codecs
+// and page types used here, as numbered in parquet.thrift
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetConvertedUTF8  = 0
+	parquetConvertedInt64 = 18
+	parquetConvertedJSON  = 19
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetCodecUncompressed = 0
+	parquetCodecGzip         = 2
+
+	parquetDataPage = 0
+)
+
+// parquetColumn is a leaf column of the flattened record. Nested objects
+// give dotted names such as address.city; values that are neither numbers,
+// booleans nor strings, such as arrays, are stored as JSON text.
+type parquetColumn struct {
+	name     string
+	keys     []string // path to the value in a record
+	kind     string   // avroLong, avroDouble, avroBoolean, avroString or avroJSON
+	optional bool
+}
+
+// parquetColumns flattens the record schema into columns, in key order.
+// Records that are not objects get a single value column.
+func parquetColumns(recordNode *schema.SchemaNode) []parquetColumn {
+	if !flattensToColumns(recordNode) {
+		kind, _ := avroKind(recordNode)
+		return []parquetColumn{parquetLeaf("value", nil, kind, true)}
+	}
+	var columns []parquetColumn
+	appendParquetColumns(&columns, "", nil, recordNode, false)
+	return columns
+}
+
+// flattensToColumns reports whether a node is an object whose declared
+// properties become columns of their own
+func flattensToColumns(node *schema.SchemaNode) bool {
+	return node != nil && node.HasType("object") && len(node.Properties) > 0 && len(node.Variants) == 0
+}
+
+func appendParquetColumns(columns *[]parquetColumn, prefix string, keys []string, node *schema.SchemaNode, optional bool) {
+	required := make(map[string]bool, len(node.Required))
+	for _, key := range node.Required {
+		required[key] = true
+	}
+	names := make([]string, 0, len(node.Properties))
+	for name := range node.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop := node.Properties[name]
+		column := name
+		if prefix != "" {
+			column = prefix + "." + name
+		}
+		propKeys := append(append([]string(nil), keys...), name)
+		propOptional := optional || !required[name] || prop.AllowsNull()
+
+		if flattensToColumns(prop) {
+			appendParquetColumns(columns, column, propKeys, prop, propOptional)
+			continue
+		}
+		kind, nullable := avroKind(prop)
+		*columns = append(*columns, parquetLeaf(column, propKeys, kind, propOptional || nullable))
+	}
+}
+
+// parquetLeaf returns the column for a value of the given Avro kind. Kinds
+// Parquet has no scalar type for are kept as JSON text.
+func parquetLeaf(name string, keys []string, kind string, optional bool) parquetColumn {
+	switch kind {
+	case avroLong, avroDouble, avroBoolean, avroString:
+	default:
+		kind = avroJSON
+		optional = true
+	}
+	return parquetColumn{name: name, keys: keys, kind: kind, optional: optional}
+}
+
+// physicalType returns the Parquet type the column's values are stored as
+func (c *parquetColumn) physicalType() int32 {
+	switch c.kind {
+	case avroBoolean:
+		return parquetBoolean
+	case avroLong:
+		return parquetInt64
+	case avroDouble:
+		return parquetDouble
+	}
+	return parquetByteArray
+}
+
+// value returns the column's value in a record, nil if it or an object
+// above it is missing
+func (c *parquetColumn) value(record interface{}) interface{} {
+	value := record
+	for _, key := range c.keys {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = obj[key]
+	}
+	return value
+}
+
+// writeParquet writes records as a Parquet file with one column per leaf
+// field, in row groups of parquetRowGroupRecords. Keys the schema does not
+// declare are left out. Compression gzips each page, so the file stays
+// readable by Parquet tools.
+func (w *Writer) writeParquet(records []interface{}) error {
+	if w.recordNode == nil {
+		return fmt.Errorf("parquet output needs the record schema")
+	}
+	columns := parquetColumns(w.recordNode)
+
+	file, err := w.openUncompressed()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	out := &countingWriter{w: bufio.NewWriter(file)}
+	out.Write(parquetMagic)
+
+	codec := parquetCodecUncompressed
+	if w.config.Compress {
+		codec = parquetCodecGzip
+	}
+
+	var rowGroups []parquetRowGroup
+	for start := 0; start < len(records); start += parquetRowGroupRecords {
+		end := start + parquetRowGroupRecords
+		if end > len(records) {
+			end = len(records)
+		}
+		group, err := writeParquetRowGroup(out, columns, records[start:end], codec)
+		if err != nil {
+			return fmt.Errorf("failed to write records %d to %d: %w", start, end-1, err)
+		}
+		rowGroups = append(rowGroups, group)
+	}
+
+	footer := parquetFileMetaData(columns, rowGroups, len(records), codec)
+	out.Write(footer)
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	out.Write(length[:])
+	out.Write(parquetMagic)
+
+	if err := out.w.Flush(); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	return file.Close()
+}
+
+// countingWriter tracks the file offset, which column chunks record
+type countingWriter struct {
+	w      *bufio.Writer
+	offset int64
+}
+
+func (c *countingWriter) Write(data []byte) {
+	n, _ := c.w.Write(data)
+	c.offset += int64(n)
+}
+
+// parquetRowGroup records where each column chunk of a row group was
+// written
+type parquetRowGroup struct {
+	rows   int
+	chunks []parquetChunk
+}
+
+type parquetChunk struct {
+	offset           int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+// writeParquetRowGroup writes one data page per column for records
+func writeParquetRowGroup(out *countingWriter, columns []parquetColumn, records []interface{}, codec int) (parquetRowGroup, error) {
+	group := parquetRowGroup{rows: len(records)}
+	for _, column := range columns {
+		page, err := encodeParquetPage(&column, records)
+		if err != nil {
+			return group, err
+		}
+		body := page
+		if codec == parquetCodecGzip {
+			var compressed bytes.Buffer
+			gz := gzip.NewWriter(&compressed)
+			gz.Write(page)
+			if err := gz.Close(); err != nil {
+				return group, err
+			}
+			body = compressed.Bytes()
+		}
+
+		header := parquetPageHeader(len(records), len(page), len(body))
+		chunk := parquetChunk{
+			offset:           out.offset,
+			uncompressedSize: int64(len(header) + len(page)),
+			compressedSize:   int64(len(header) + len(body)),
+		}
+		out.Write(header)
+		out.Write(body)
+		group.chunks = append(group.chunks, chunk)
+	}
+	return group, nil
+}
+
+// encodeParquetPage encodes a column of records as the body of a version 1
+// data page: definition levels for an optional column, then the present
+// values in plain encoding
+func encodeParquetPage(column *parquetColumn, records []interface{}) ([]byte, error) {
+	var values bytes.Buffer
+	levels := make([]byte, len(records))
+	var bits []bool
+	for i, record := range records {
+		value := column.value(record)
+		if value == nil {
+			if !column.optional {
+				return nil, fmt.Errorf("record %d: %s is null but the column is required", i, column.name)
+			}
+			continue
+		}
+		levels[i] = 1
+
+		switch column.kind {
+		case avroBoolean:
+			b, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("record %d: %s: expected a boolean, got %T", i, column.name, value)
+			}
+			bits = append(bits, b)
+		case avroLong:
+			n, ok := avroInteger(value)
+			if !ok {
+				return nil, fmt.Errorf("record %d: %s: expected an integer, got %v", i, column.name, value)
+			}
+			binary.Write(&values, binary.LittleEndian, n)
+		case avroDouble:
+			f, ok := avroFloat(value)
+			if !ok {
+				return nil, fmt.Errorf("record %d: %s: expected a number, got %T", i, column.name, value)
+			}
+			binary.Write(&values, binary.LittleEndian, math.Float64bits(f))
+		case avroString:
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("record %d: %s: expected a string, got %T", i, column.name, value)
+			}
+			appendParquetBytes(&values, []byte(s))
+		default:
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %s: %w", i, column.name, err)
+			}
+			appendParquetBytes(&values, encoded)
+		}
+	}
+
+	// Booleans are bit-packed, least significant bit first
+	if column.kind == avroBoolean {
+		packed := make([]byte, (len(bits)+7)/8)
+		for i, b := range bits {
+			if b {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		values.Write(packed)
+	}
+
+	var page bytes.Buffer
+	if column.optional {
+		encoded := encodeLevels(levels)
+		var length [4]byte
+		binary.LittleEndian.PutUint32(length[:], uint32(len(encoded)))
+		page.Write(length[:])
+		page.Write(encoded)
+	}
+	page.Write(values.Bytes())
+	return page.Bytes(), nil
+}
+
+// encodeLevels encodes definition levels of bit width 1 as RLE runs of the
+// RLE/bit-packing hybrid encoding
+func encodeLevels(levels []byte) []byte {
+	var encoded []byte
+	var varint [binary.MaxVarintLen64]byte
+	for start := 0; start < len(levels); {
+		end := start
+		for end < len(levels) && levels[end] == levels[start] {
+			end++
+		}
+		n := binary.PutUvarint(varint[:], uint64(end-start)<<1)
+		encoded = append(encoded, varint[:n]...)
+		encoded = append(encoded, levels[start])
+		start = end
+	}
+	return encoded
+}
+
+// appendParquetBytes appends a plain-encoded byte array: its length as four
+// little-endian bytes, then the bytes
+func appendParquetBytes(buf *bytes.Buffer, data []byte) {
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(data)))
+	buf.Write(length[:])
+	buf.Write(data)
+}
+
+// parquetPageHeader encodes the PageHeader of a version 1 data page
+func parquetPageHeader(rows, uncompressed, compressed int) []byte {
+	var t thriftWriter
+	t.i32(1, parquetDataPage)
+	t.i32(2, int32(uncompressed))
+	t.i32(3, int32(compressed))
+	t.structField(5)
+	t.i32(1, int32(rows))
+	t.i32(2, parquetEncodingPlain)
+	t.i32(3, parquetEncodingRLE)
+	t.i32(4, parquetEncodingRLE)
+	t.endStruct()
+	t.endStruct()
+	return t.buf.Bytes()
+}
+
+// parquetFileMetaData encodes the FileMetaData footer
+func parquetFileMetaData(columns []parquetColumn, rowGroups []parquetRowGroup, rows int, codec int) []byte {
+	var t thriftWriter
+	t.i32(1, 1)
+
+	t.listField(2, thriftStruct, len(columns)+1)
+	t.beginStruct()
+	t.binary(4, []byte("schema"))
+	t.i32(5, int32(len(columns)))
+	t.endStruct()
+	for _, column := range columns {
+		t.beginStruct()
+		t.i32(1, column.physicalType())
+		repetition := int32(parquetRequired)
+		if column.optional {
+			repetition = parquetOptional
+		}
+		t.i32(3, repetition)
+		t.binary(4, []byte(column.name))
+		switch column.kind {
+		case avroLong:
+			t.i32(6, parquetConvertedInt64)
+			t.structField(10)
+			t.structField(10) // IntType
+			t.byteField(1, 64)
+			t.boolean(2, true)
+			t.endStruct()
+			t.endStruct()
+		case avroString:
+			t.i32(6, parquetConvertedUTF8)
+			t.structField(10)
+			t.structField(1) // StringType
+			t.endStruct()
+			t.endStruct()
+		case avroJSON:
+			t.i32(6, parquetConvertedJSON)
+			t.structField(10)
+			t.structField(12) // JsonType
+			t.endStruct()
+			t.endStruct()
+		}
+		t.endStruct()
+	}
+
+	t.i64(3, int64(rows))
+
+	t.listField(4, thriftStruct, len(rowGroups))
+	for _, group := range rowGroups {
+		t.beginStruct()
+		t.listField(1, thriftStruct, len(group.chunks))
+		var total, totalCompressed int64
+		for i, chunk := range group.chunks {
+			column := columns[i]
+			total += chunk.uncompressedSize
+			totalCompressed += chunk.compressedSize
+
+			t.beginStruct()
+			t.i64(2, chunk.offset)
+			t.structField(3)
+			t.i32(1, column.physicalType())
+			t.listField(2, thriftI32, 2)
+			t.varint(parquetEncodingPlain)
+			t.varint(parquetEncodingRLE)
+			t.listField(3, thriftBinary, 1)
+			t.listBinary([]byte(column.name))
+			t.i32(4, int32(codec))
+			t.i64(5, int64(group.rows))
+			t.i64(6, chunk.uncompressedSize)
+			t.i64(7, chunk.compressedSize)
+			t.i64(9, chunk.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64(2, total)
+		t.i64(3, int64(group.rows))
+		if len(group.chunks) > 0 {
+			t.i64(5, group.chunks[0].offset)
+		}
+		t.i64(6, totalCompressed)
+		t.endStruct()
+	}
+
+	t.binary(6, []byte("specmint"))
+	t.endStruct()
+	return t.buf.Bytes()
+}
+
+// Thrift compact protocol field types
+const (
+	thriftBoolTrue  = 1
+	thriftBoolFalse = 2
+	thriftByte      = 3
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftStruct    = 12
+)
+
+// thriftWriter encodes structs in the Thrift compact protocol, which
+// Parquet uses for its page headers and footer. The outermost struct is
+// open from the start and closed by the last endStruct.
+type thriftWriter struct {
+	buf    bytes.Buffer
+	lastID int16
+	stack  []int16
+}
+
+func (t *thriftWriter) varint(n int64) {
+	var encoded [binary.MaxVarintLen64]byte
+	t.buf.Write(encoded[:binary.PutVarint(encoded[:], n)])
+}
+
+func (t *thriftWriter) uvarint(n uint64) {
+	var encoded [binary.MaxVarintLen64]byte
+	t.buf.Write(encoded[:binary.PutUvarint(encoded[:], n)])
+}
+
+// field writes a field header, with the id as a delta from the last field
+// of the struct when it fits
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.lastID; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	t.lastID = id
+}
+
+func (t *thriftWriter) i32(id int16, n int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(n))
+}
+
+func (t *thriftWriter) i64(id int16, n int64) {
+	t.field(id, thriftI64)
+	t.varint(n)
+}
+
+func (t *thriftWriter) byteField(id int16, b int8) {
+	t.field(id, thriftByte)
+	t.buf.WriteByte(byte(b))
+}
+
+func (t *thriftWriter) boolean(id int16, b bool) {
+	if b {
+		t.field(id, thriftBoolTrue)
+	} else {
+		t.field(id, thriftBoolFalse)
+	}
+}
+
+func (t *thriftWriter) binary(id int16, data []byte) {
+	t.field(id, thriftBinary)
+	t.listBinary(data)
+}
+
+// listBinary writes a binary value without a field header, as list
+// elements are
+func (t *thriftWriter) listBinary(data []byte) {
+	t.uvarint(uint64(len(data)))
+	t.buf.Write(data)
+}
+
+// listField writes the header of a list field; its size elements follow
+func (t *thriftWriter) listField(id int16, elemType byte, size int) {
+	t.field(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xF0 | elemType)
+		t.uvarint(uint64(size))
+	}
+}
+
+// structField opens a struct-valued field, closed by endStruct
+func (t *thriftWriter) structField(id int16) {
+	t.field(id, thriftStruct)
+	t.beginStruct()
+}
+
+// beginStruct opens a struct, such as a list element, whose field ids
+// start over
+func (t *thriftWriter) beginStruct() {
+	t.stack = append(t.stack, t.lastID)
+	t.lastID = 0
+}
+
+// endStruct writes the stop byte of the innermost open struct
+func (t *thriftWriter) endStruct() {
+	t.buf.WriteByte(0)
+	if n := len(t.stack); n > 0 {
+		t.lastID = t.stack[n-1]
+		t.stack = t.stack[:n-1]
+	}
+}
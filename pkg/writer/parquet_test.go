@@ -0,0 +1,343 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/specmint/specmint/internal/config"
+	"github.com/specmint/specmint/pkg/schema"
+)
+
+// thriftReader decodes Thrift compact protocol structs into maps from field
+// id to value, without knowing the struct definitions
+type thriftReader struct {
+	in  *bufio.Reader
+	err error
+}
+
+func (r *thriftReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *thriftReader) varint() int64 {
+	n, err := binary.ReadVarint(r.in)
+	r.fail(err)
+	return n
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	n, err := binary.ReadUvarint(r.in)
+	r.fail(err)
+	return n
+}
+
+func (r *thriftReader) byte() byte {
+	b, err := r.in.ReadByte()
+	r.fail(err)
+	return b
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case 3:
+		return int64(int8(r.byte()))
+	case 4, 5, 6:
+		return r.varint()
+	case 8:
+		data := make([]byte, r.uvarint())
+		_, err := io.ReadFull(r.in, data)
+		r.fail(err)
+		return string(data)
+	case 9:
+		header := r.byte()
+		size, elemType := uint64(header>>4), header&0x0F
+		if size == 15 {
+			size = r.uvarint()
+		}
+		items := make([]interface{}, size)
+		for i := range items {
+			if elemType == 1 || elemType == 2 {
+				items[i] = r.byte() == 1
+				continue
+			}
+			items[i] = r.value(elemType)
+		}
+		return items
+	case 12:
+		return r.structValue()
+	}
+	r.fail(io.ErrUnexpectedEOF)
+	return nil
+}
+
+func (r *thriftReader) structValue() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for r.err == nil {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.varint())
+		}
+		fields[id] = r.value(header & 0x0F)
+		last = id
+	}
+	return fields
+}
+
+// readParquet decodes every row of a Parquet file written with plain
+// encoding, one data page per column chunk, and returns them keyed by column
+// name with the footer's schema elements
+func readParquet(t *testing.T, path string) ([]map[string]interface{}, []map[int16]interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read dataset: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatalf("Missing Parquet magic")
+	}
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footerReader := &thriftReader{in: bufio.NewReader(bytes.NewReader(data[len(data)-8-footerLength : len(data)-8]))}
+	footer := footerReader.structValue()
+	if footerReader.err != nil {
+		t.Fatalf("Failed to decode footer: %v", footerReader.err)
+	}
+
+	var elements []map[int16]interface{}
+	for _, element := range footer[2].([]interface{}) {
+		elements = append(elements, element.(map[int16]interface{}))
+	}
+
+	var rows []map[string]interface{}
+	for _, group := range footer[4].([]interface{}) {
+		group := group.(map[int16]interface{})
+		start := len(rows)
+		for i := int64(0); i < group[3].(int64); i++ {
+			rows = append(rows, make(map[string]interface{}))
+		}
+
+		for c, chunk := range group[1].([]interface{}) {
+			meta := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+			element := elements[c+1]
+			name := meta[3].([]interface{})[0].(string)
+			if name != element[4] {
+				t.Fatalf("Column %d is %s in the schema but %s in the row group", c, element[4], name)
+			}
+
+			in := bufio.NewReader(bytes.NewReader(data[meta[9].(int64):]))
+			page := (&thriftReader{in: in}).structValue()
+			body := make([]byte, page[3].(int64))
+			io.ReadFull(in, body)
+			if meta[4] == int64(parquetCodecGzip) {
+				gz, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatalf("Failed to read gzip page: %v", err)
+				}
+				body, _ = io.ReadAll(gz)
+			}
+			values := bytes.NewReader(body)
+
+			count := int(page[5].(map[int16]interface{})[1].(int64))
+			levels := make([]bool, count)
+			if element[3] == int64(parquetOptional) {
+				var length uint32
+				binary.Read(values, binary.LittleEndian, &length)
+				encoded := make([]byte, length)
+				values.Read(encoded)
+				levelReader := bufio.NewReader(bytes.NewReader(encoded))
+				for i := 0; i < count; {
+					header, _ := binary.ReadUvarint(levelReader)
+					level, _ := levelReader.ReadByte()
+					for n := 0; n < int(header>>1); n++ {
+						levels[i] = level == 1
+						i++
+					}
+				}
+			} else {
+				for i := range levels {
+					levels[i] = true
+				}
+			}
+
+			var booleans byte
+			present := 0
+			for i := 0; i < count; i++ {
+				if !levels[i] {
+					rows[start+i][name] = nil
+					continue
+				}
+				var value interface{}
+				switch element[1] {
+				case int64(parquetBoolean):
+					if present%8 == 0 {
+						booleans, _ = values.ReadByte()
+					}
+					value = booleans&(1<<(present%8)) != 0
+				case int64(parquetInt64):
+					var n int64
+					binary.Read(values, binary.LittleEndian, &n)
+					value = n
+				case int64(parquetDouble):
+					var bits uint64
+					binary.Read(values, binary.LittleEndian, &bits)
+					value = math.Float64frombits(bits)
+				default:
+					var length uint32
+					binary.Read(values, binary.LittleEndian, &length)
+					text := make([]byte, length)
+					values.Read(text)
+					value = string(text)
+				}
+				rows[start+i][name] = value
+				present++
+			}
+		}
+	}
+	if footer[3] != int64(len(rows)) {
+		t.Errorf("Footer has %v rows, row groups hold %d", footer[3], len(rows))
+	}
+	return rows, elements
+}
+
+// TestWriteParquet verifies nested fields become dotted columns with the
+// right types, optional fields hold nulls, and values survive a round trip
+// whether pages are compressed or not
+func TestWriteParquet(t *testing.T) {
+	parser := schema.NewParser()
+	if err := parser.ParseBytes([]byte(`{
+		"type": "object",
+		"required": ["id", "name", "address", "tags"],
+		"properties": {
+			"id": {"type": "integer"},
+			"name": {"type": "string"},
+			"score": {"type": "number"},
+			"active": {"type": "boolean"},
+			"address": {"type": "object", "required": ["city"], "properties": {"city": {"type": "string"}, "zip": {"type": "string"}}},
+			"billing": {"type": "object", "required": ["city"], "properties": {"city": {"type": "string"}}},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		}
+	}`)); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	root, err := parser.GetRootNode()
+	if err != nil {
+		t.Fatalf("Failed to build root node: %v", err)
+	}
+
+	records := []interface{}{
+		map[string]interface{}{
+			"id": int64(1), "name": "Ada", "score": 9.5, "active": true,
+			"address": map[string]interface{}{"city": "Oslo", "zip": "0150"},
+			"billing": map[string]interface{}{"city": "Bergen"},
+			"tags":    []interface{}{"a", "b"},
+		},
+		map[string]interface{}{
+			"id": int64(-2), "name": "Grace", "active": false,
+			"address": map[string]interface{}{"city": "Bergen"},
+			"tags":    []interface{}{},
+		},
+	}
+	expected := []map[string]interface{}{
+		{
+			"id": int64(1), "name": "Ada", "score": 9.5, "active": true,
+			"address.city": "Oslo", "address.zip": "0150", "billing.city": "Bergen", "tags": `["a","b"]`,
+		},
+		{
+			"id": int64(-2), "name": "Grace", "score": nil, "active": false,
+			"address.city": "Bergen", "address.zip": nil, "billing.city": nil, "tags": `[]`,
+		},
+	}
+
+	for _, compress := range []bool{false, true} {
+		dir := t.TempDir()
+		w, err := New(config.Output{Directory: dir, Format: "parquet", Compress: compress})
+		if err != nil {
+			t.Fatalf("Failed to create writer: %v", err)
+		}
+		w.SetSchema(root)
+		if err := w.WriteRecords(records); err != nil {
+			t.Fatalf("Failed to write records: %v", err)
+		}
+		if w.GetOutputPath() != filepath.Join(dir, "dataset.parquet") {
+			t.Errorf("Unexpected output path %s", w.GetOutputPath())
+		}
+
+		rows, elements := readParquet(t, filepath.Join(dir, "dataset.parquet"))
+		if !reflect.DeepEqual(rows, expected) {
+			t.Errorf("Expected rows:\n%v\ngot:\n%v", expected, rows)
+		}
+
+		var columns []string
+		for _, element := range elements[1:] {
+			columns = append(columns, element[4].(string))
+		}
+		if strings.Join(columns, ",") != "active,address.city,address.zip,billing.city,id,name,score,tags" {
+			t.Errorf("Unexpected columns %v", columns)
+		}
+		// address.city is required all the way down; billing.city is not
+		// because billing is optional
+		if elements[2][3] != int64(parquetRequired) || elements[4][3] != int64(parquetOptional) {
+			t.Errorf("Unexpected repetition of address.city or billing.city: %v %v", elements[2], elements[4])
+		}
+		// Strings are UTF8, integers INT_64 and arrays JSON
+		if elements[6][6] != int64(parquetConvertedUTF8) || elements[5][6] != int64(parquetConvertedInt64) || elements[8][6] != int64(parquetConvertedJSON) {
+			t.Errorf("Unexpected converted types: %v %v %v", elements[6], elements[5], elements[8])
+		}
+	}
+}
+
+// TestWriteParquet_RowGroups verifies records are split into row groups
+func TestWriteParquet_RowGroups(t *testing.T) {
+	root := &schema.SchemaNode{
+		Type:       "object",
+		Required:   []string{"n"},
+		Properties: map[string]*schema.SchemaNode{"n": {Type: "integer"}},
+	}
+	records := make([]interface{}, parquetRowGroupRecords+5)
+	for i := range records {
+		records[i] = map[string]interface{}{"n": int64(i)}
+	}
+
+	dir := t.TempDir()
+	w, err := New(config.Output{Directory: dir, Format: "parquet"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	w.SetSchema(root)
+	if err := w.WriteRecords(records); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+
+	rows, _ := readParquet(t, filepath.Join(dir, "dataset.parquet"))
+	if len(rows) != len(records) {
+		t.Fatalf("Expected %d rows, got %d", len(records), len(rows))
+	}
+	for i, row := range rows {
+		if row["n"] != int64(i) {
+			t.Fatalf("Row %d holds %v", i, row["n"])
+		}
+	}
+
+	if err := w.WriteRecords([]interface{}{map[string]interface{}{}}); err == nil || !strings.Contains(err.Error(), "required") {
+		t.Errorf("Expected a missing required value to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,291 @@
+package writer
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/specmint/specmint/internal/config"
+)
+
+// Shard describes one file of a sharded dataset
+type Shard struct {
+	Path    string `json:"path"` // relative to the output directory
+	Records int    `json:"records"`
+}
+
+// shardState tracks the shards written so far. Boundaries depend only on
+// the sequence of records and their encoded size before compression, so a
+// given config always produces the same layout.
+type shardState struct {
+	shards []Shard
+	bytes  int64 // uncompressed bytes in the last shard
+}
+
+// sharded reports whether records are split across multiple files
+func sharded(cfg config.Output) bool {
+	return cfg.ShardMaxRecords > 0 || cfg.ShardMaxBytes > 0
+}
+
+// shardName returns the file name of shard n, counting from 1
+func shardName(cfg config.Output, n int) string {
+	name := fmt.Sprintf("dataset-%05d.jsonl", n)
+	if cfg.Compress {
+		name += ".gz"
+	}
+	return name
+}
+
+// Shards returns the shards of the dataset, or nil when it is not sharded
+func (w *Writer) Shards() []Shard {
+	if !sharded(w.config) {
+		return nil
+	}
+	return append([]Shard(nil), w.shards.shards...)
+}
+
+// writeShards writes records across shards, continuing the last shard when
+// appending and starting over otherwise
+func (w *Writer) writeShards(records []interface{}, appending bool) error {
+	if !appending {
+		if err := removeShards(w.config); err != nil {
+			return err
+		}
+		w.shards = shardState{}
+	}
+
+	var out io.WriteCloser
+	closeOut := func() error {
+		if out == nil {
+			return nil
+		}
+		err := out.Close()
+		out = nil
+		return err
+	}
+	defer closeOut()
+
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+		line = append(line, '\n')
+
+		// Roll over to a new shard when this record would overflow the
+		// current one. A record larger than shard_max_bytes gets a shard
+		// to itself.
+		s := &w.shards
+		full := len(s.shards) == 0
+		if !full {
+			last := s.shards[len(s.shards)-1]
+			full = (w.config.ShardMaxRecords > 0 && last.Records >= w.config.ShardMaxRecords) ||
+				(w.config.ShardMaxBytes > 0 && last.Records > 0 && s.bytes+int64(len(line)) > w.config.ShardMaxBytes)
+		}
+		if full {
+			if err := closeOut(); err != nil {
+				return err
+			}
+			s.shards = append(s.shards, Shard{Path: shardName(w.config, len(s.shards)+1)})
+			s.bytes = 0
+		}
+
+		last := &s.shards[len(s.shards)-1]
+		if out == nil {
+			if out, err = w.openFile(filepath.Join(w.outputDir, last.Path), os.O_CREATE|os.O_WRONLY|os.O_APPEND); err != nil {
+				return err
+			}
+		}
+		if _, err := out.Write(line); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+		last.Records++
+		s.bytes += int64(len(line))
+	}
+
+	return closeOut()
+}
+
+// listShards returns the shard files in the output directory in order
+func listShards(cfg config.Output) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(cfg.Directory, "dataset-[0-9]*.jsonl*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var shards []string
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if name == shardName(cfg, shardNumber(name)) {
+			shards = append(shards, path)
+		}
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		return shardNumber(filepath.Base(shards[i])) < shardNumber(filepath.Base(shards[j]))
+	})
+	return shards, nil
+}
+
+// shardNumber parses the number out of a shard file name, or returns 0
+func shardNumber(name string) int {
+	var n int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(name, "dataset-"), "%d", &n); err != nil {
+		return 0
+	}
+	return n
+}
+
+// removeShards deletes the shards of an earlier run, so a smaller rerun
+// leaves no stale files behind
+func removeShards(cfg config.Output) error {
+	shards, err := listShards(cfg)
+	if err != nil {
+		return err
+	}
+	for _, path := range shards {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove old shard: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadShards reads the layout of an existing sharded dataset so appended
+// records continue where it left off
+func loadShards(cfg config.Output) (shardState, error) {
+	var state shardState
+	paths, err := listShards(cfg)
+	if err != nil {
+		return state, err
+	}
+	for _, path := range paths {
+		records, size, err := countRecords(path)
+		if err != nil {
+			return state, err
+		}
+		state.shards = append(state.shards, Shard{Path: filepath.Base(path), Records: records})
+		state.bytes = size
+	}
+	return state, nil
+}
+
+// countRecords counts the lines of a dataset file and their uncompressed size
+func countRecords(path string) (int, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	records := 0
+	var size int64
+	buffered := bufio.NewReader(reader)
+	for {
+		line, err := buffered.ReadBytes('\n')
+		size += int64(len(line))
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			records++
+		}
+		if err == io.EOF {
+			return records, size, nil
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+}
+
+// CountRecords returns the number of records in the jsonl dataset of an
+// output directory, summed across shards when it is sharded
+func CountRecords(cfg config.Output) (int, error) {
+	if !sharded(cfg) {
+		records, _, err := countRecords(filepath.Join(cfg.Directory, "dataset.jsonl"))
+		return records, err
+	}
+
+	state, err := loadShards(cfg)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, shard := range state.shards {
+		total += shard.Records
+	}
+	return total, nil
+}
+
+// TruncateRecords cuts the uncompressed jsonl dataset of an output directory
+// down to its first n records, removing whole shards past the cut. It fails
+// if the dataset has fewer records.
+func TruncateRecords(cfg config.Output, n int) error {
+	paths := []string{filepath.Join(cfg.Directory, "dataset.jsonl")}
+	if sharded(cfg) {
+		var err error
+		if paths, err = listShards(cfg); err != nil {
+			return err
+		}
+	}
+
+	remaining := n
+	for _, path := range paths {
+		if remaining == 0 {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove shard: %w", err)
+			}
+			continue
+		}
+		kept, err := truncateLines(path, remaining)
+		if err != nil {
+			return err
+		}
+		remaining -= kept
+	}
+	if remaining > 0 {
+		return fmt.Errorf("dataset has %d complete records, expected at least %d", n-remaining, n)
+	}
+	return nil
+}
+
+// truncateLines cuts a file down to at most its first n lines and returns
+// how many it kept
+func truncateLines(path string, n int) (int, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var size int64
+	lines := 0
+	for lines < n {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			// A partial last line is dropped
+			break
+		}
+		size += int64(len(line))
+		lines++
+	}
+
+	if err := file.Truncate(size); err != nil {
+		return 0, fmt.Errorf("failed to truncate dataset: %w", err)
+	}
+	return lines, file.Close()
+}
@@ -0,0 +1,122 @@
+package writer
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/specmint/specmint/internal/config"
+)
+
+func shardRecords(n int) []interface{} {
+	records := make([]interface{}, n)
+	for i := range records {
+		records[i] = map[string]interface{}{"id": float64(i), "name": strings.Repeat("x", i%7)}
+	}
+	return records
+}
+
+// TestShards verifies records roll over by count and that a dataset written
+// in batches has the same layout as one written at once
+func TestShards(t *testing.T) {
+	records := shardRecords(25)
+
+	whole, err := New(config.Output{Directory: t.TempDir(), Format: "jsonl", ShardMaxRecords: 10})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	if err := whole.WriteRecords(records); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+	want := []Shard{{"dataset-00001.jsonl", 10}, {"dataset-00002.jsonl", 10}, {"dataset-00003.jsonl", 5}}
+	if got := whole.Shards(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected shards %v, got %v", want, got)
+	}
+
+	batched, _ := New(config.Output{Directory: t.TempDir(), Format: "jsonl", ShardMaxRecords: 10})
+	if err := batched.WriteRecords(records[:12]); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+	if err := batched.AppendRecords(records[12:]); err != nil {
+		t.Fatalf("Failed to append records: %v", err)
+	}
+	if got := batched.Shards(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected batched shards %v, got %v", want, got)
+	}
+	for _, shard := range want {
+		a, _ := os.ReadFile(filepath.Join(whole.outputDir, shard.Path))
+		b, _ := os.ReadFile(filepath.Join(batched.outputDir, shard.Path))
+		if string(a) != string(b) {
+			t.Errorf("Expected %s to match between whole and batched writes", shard.Path)
+		}
+	}
+
+	// A rerun that writes fewer shards removes the stale ones
+	if err := whole.WriteRecords(records[:5]); err != nil {
+		t.Fatalf("Failed to rewrite records: %v", err)
+	}
+	if shards, _ := listShards(whole.config); len(shards) != 1 {
+		t.Errorf("Expected stale shards to be removed, found %v", shards)
+	}
+}
+
+// TestShards_Bytes verifies the byte limit, compression and continuing an
+// existing dataset after truncating it
+func TestShards_Bytes(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Output{Directory: dir, Format: "jsonl", ShardMaxBytes: 100, Compress: true}
+	w, _ := New(cfg)
+	if err := w.WriteRecords(shardRecords(20)); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+	layout := w.Shards()
+
+	total := 0
+	for _, shard := range layout {
+		file, err := os.Open(filepath.Join(dir, shard.Path))
+		if err != nil {
+			t.Fatalf("Failed to open shard: %v", err)
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("Expected %s to be gzipped: %v", shard.Path, err)
+		}
+		data, _ := io.ReadAll(gz)
+		file.Close()
+		if len(data) > 100 {
+			t.Errorf("Expected %s to hold at most 100 bytes, got %d", shard.Path, len(data))
+		}
+		total += shard.Records
+	}
+	if total != 20 || len(layout) < 3 {
+		t.Errorf("Expected 20 records over several shards, got %v", layout)
+	}
+
+	// Resuming drops records past the cut and continues the same layout
+	plain := config.Output{Directory: t.TempDir(), Format: "jsonl", ShardMaxBytes: 100}
+	first, _ := New(plain)
+	if err := first.WriteRecords(shardRecords(20)); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+	if err := TruncateRecords(plain, 7); err != nil {
+		t.Fatalf("Failed to truncate: %v", err)
+	}
+	if count, _ := CountRecords(plain); count != 7 {
+		t.Errorf("Expected 7 records after truncating, got %d", count)
+	}
+	plain.Append = true
+	resumed, err := New(plain)
+	if err != nil {
+		t.Fatalf("Failed to reopen dataset: %v", err)
+	}
+	if err := resumed.WriteRecords(shardRecords(20)[7:]); err != nil {
+		t.Fatalf("Failed to append records: %v", err)
+	}
+	if got := resumed.Shards(); !reflect.DeepEqual(got, first.Shards()) {
+		t.Errorf("Expected the resumed layout %v to match %v", got, first.Shards())
+	}
+}
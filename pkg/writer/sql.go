@@ -0,0 +1,142 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// sqlRowsPerStatement is how many rows each INSERT statement carries.
+// Multi-row inserts load far faster than one statement per row.
+const sqlRowsPerStatement = 100
+
+// SetColumns declares the dataset's top-level fields, normally the schema's
+// properties, so SQL output has the same columns whatever the records hold
+func (w *Writer) SetColumns(columns []string) {
+	w.columns = append([]string(nil), columns...)
+	sort.Strings(w.columns)
+}
+
+// writeSQL writes records as INSERT statements into the configured table.
+// Each top-level field is a column; nested objects and arrays are stored as
+// JSON text. Columns are the declared ones plus any other field found in the
+// records, in sorted order. Values use standard SQL quoting, which is safe
+// for PostgreSQL and SQLite; MySQL needs NO_BACKSLASH_ESCAPES.
+func (w *Writer) writeSQL(records []interface{}) error {
+	if w.config.Table == "" {
+		return fmt.Errorf("sql output needs a table name")
+	}
+
+	rows := make([]map[string]interface{}, len(records))
+	columnSet := make(map[string]bool)
+	for _, column := range w.columns {
+		columnSet[column] = true
+	}
+	for i, record := range records {
+		row, ok := record.(map[string]interface{})
+		if !ok {
+			// Records of an array or primitive root get a single column
+			row = map[string]interface{}{"value": record}
+		}
+		for column := range row {
+			columnSet[column] = true
+		}
+		rows[i] = row
+	}
+
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES\n", quoteTable(w.config.Table), strings.Join(quoted, ", "))
+
+	file, err := w.openOutput(os.O_CREATE | os.O_WRONLY | os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	out := bufio.NewWriter(file)
+
+	values := make([]string, len(columns))
+	for i, row := range rows {
+		if i%sqlRowsPerStatement == 0 {
+			out.WriteString(insert)
+		} else {
+			out.WriteString(",\n")
+		}
+		for j, column := range columns {
+			if values[j], err = sqlValue(row[column]); err != nil {
+				return fmt.Errorf("failed to encode %s of record %d: %w", column, i, err)
+			}
+		}
+		out.WriteString("  (" + strings.Join(values, ", ") + ")")
+		if i%sqlRowsPerStatement == sqlRowsPerStatement-1 || i == len(rows)-1 {
+			out.WriteString(";\n")
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	return file.Close()
+}
+
+// sqlValue renders a value as an SQL literal: NULL, a bare number or
+// boolean, or a quoted string. Objects and arrays become quoted JSON.
+func sqlValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return quoteString(v), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return quoteString(string(encoded)), nil
+	}
+	if len(encoded) > 0 && (encoded[0] == '-' || (encoded[0] >= '0' && encoded[0] <= '9')) {
+		return string(encoded), nil
+	}
+	// Anything else that is not a number is stored as its JSON text
+	return quoteString(string(encoded)), nil
+}
+
+// quoteString quotes s as an SQL string literal, doubling embedded quotes
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteIdentifier quotes a column or table name, doubling embedded double
+// quotes, so any field name is a safe identifier
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteTable quotes a table name, keeping a schema qualifier such as
+// public.patients as a separate identifier
+func quoteTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = quoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
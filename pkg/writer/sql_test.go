@@ -0,0 +1,69 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/specmint/specmint/internal/config"
+)
+
+// TestWriteSQL verifies quoting of each value type, batching and that
+// declared columns appear even when no record has them
+func TestWriteSQL(t *testing.T) {
+	dir := t.TempDir()
+	w, err := New(config.Output{Directory: dir, Format: "sql", Table: "public.patients"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	w.SetColumns([]string{"name", "id", "notes"})
+
+	records := []interface{}{
+		map[string]interface{}{"id": 1.0, "name": "O'Brien'); DROP TABLE patients; --", "active": true},
+		map[string]interface{}{"id": -2.5, "name": nil, "address": map[string]interface{}{"city": "Oslo"}, "tags": []interface{}{"a"}},
+	}
+	if err := w.WriteRecords(records); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "dataset.sql"))
+	if err != nil {
+		t.Fatalf("Failed to read dataset: %v", err)
+	}
+	expected := `INSERT INTO "public"."patients" ("active", "address", "id", "name", "notes", "tags") VALUES
+  (TRUE, NULL, 1, 'O''Brien''); DROP TABLE patients; --', NULL, NULL),
+  (NULL, '{"city":"Oslo"}', -2.5, NULL, NULL, '["a"]');
+`
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+// TestWriteSQL_Batches verifies rows are split across INSERT statements
+func TestWriteSQL_Batches(t *testing.T) {
+	dir := t.TempDir()
+	w, err := New(config.Output{Directory: dir, Format: "sql", Table: "t"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+
+	records := make([]interface{}, sqlRowsPerStatement*2+1)
+	for i := range records {
+		records[i] = map[string]interface{}{"id": float64(i)}
+	}
+	if err := w.WriteRecords(records); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "dataset.sql"))
+	if err != nil {
+		t.Fatalf("Failed to read dataset: %v", err)
+	}
+	if got := strings.Count(string(data), "INSERT INTO"); got != 3 {
+		t.Errorf("Expected 3 statements, got %d", got)
+	}
+	if got := strings.Count(string(data), ";\n"); got != 3 {
+		t.Errorf("Expected 3 terminated statements, got %d", got)
+	}
+}
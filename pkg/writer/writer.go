@@ -1,47 +1,115 @@
 package writer
 
 import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/specmint/specmint/internal/config"
+	"github.com/specmint/specmint/pkg/schema"
 )
 
 // Writer handles output writing in various formats
 type Writer struct {
-	config    config.Output
-	outputDir string
+	config     config.Output
+	outputDir  string
+	shards     shardState
+	columns    []string           // declared top-level fields, see SetColumns
+	recordNode *schema.SchemaNode // schema of a record, see SetSchema
 }
 
 // New creates a new writer instance
 func New(config config.Output) (*Writer, error) {
+	if config.Format == "xml" {
+		if err := validateXMLNames(config); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Stdout() {
+		return &Writer{config: config, outputDir: config.Directory}, nil
+	}
+
 	// Ensure output directory exists
 	if err := os.MkdirAll(config.Directory, 0750); err != nil {
 		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	return &Writer{
+	w := &Writer{
 		config:    config,
 		outputDir: config.Directory,
-	}, nil
+	}
+
+	// Appended records continue the last shard of the existing dataset
+	if config.Append && sharded(config) {
+		state, err := loadShards(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read existing shards: %w", err)
+		}
+		w.shards = state
+	}
+
+	return w, nil
 }
 
 // WriteRecords writes the generated records to the output file
-func (w *Writer) WriteRecords(records []map[string]interface{}) error {
+func (w *Writer) WriteRecords(records []interface{}) error {
+	if w.config.Append && w.config.Format != "jsonl" && w.config.Format != "" {
+		return fmt.Errorf("appending is only supported for jsonl output, got %s", w.config.Format)
+	}
+	if sharded(w.config) {
+		if w.config.Format != "jsonl" && w.config.Format != "" {
+			return fmt.Errorf("sharding is only supported for jsonl output, got %s", w.config.Format)
+		}
+		return w.writeShards(records, w.config.Append)
+	}
+
 	switch w.config.Format {
 	case "json":
 		return w.writeJSON(records)
 	case "jsonl":
 		return w.writeJSONL(records)
+	case "csv":
+		return w.writeCSV(records)
+	case "sql":
+		return w.writeSQL(records)
+	case "xml":
+		return w.writeXML(records)
+	case "avro":
+		return w.writeAvro(records)
+	case "parquet":
+		return w.writeParquet(records)
 	default:
 		return w.writeJSONL(records) // Default to JSONL
 	}
 }
 
-// WriteManifest writes the generation manifest
+// AppendRecords appends records to a jsonl dataset, creating it if needed.
+// Runs that write their dataset in batches use it after the first batch.
+func (w *Writer) AppendRecords(records []interface{}) error {
+	if w.config.Format != "jsonl" && w.config.Format != "" {
+		return fmt.Errorf("appending is only supported for jsonl output, got %s", w.config.Format)
+	}
+	if sharded(w.config) {
+		return w.writeShards(records, true)
+	}
+	return w.encodeJSONL(records, os.O_CREATE|os.O_WRONLY|os.O_APPEND)
+}
+
+// WriteManifest writes the generation manifest. Nothing is written when the
+// dataset goes to stdout.
 func (w *Writer) WriteManifest(manifest map[string]interface{}) error {
+	if w.config.Stdout() {
+		return nil
+	}
+
 	manifestPath := filepath.Join(w.outputDir, "manifest.json")
 
 	file, err := os.Create(manifestPath)
@@ -60,13 +128,36 @@ func (w *Writer) WriteManifest(manifest map[string]interface{}) error {
 	return nil
 }
 
-// writeJSON writes records as a single JSON array
-func (w *Writer) writeJSON(records []map[string]interface{}) error {
-	outputPath := filepath.Join(w.outputDir, "dataset.json")
+// WriteRejects writes records that failed validation to rejects.jsonl in
+// the output directory, one per line, whatever the dataset's format. The
+// file is written even when there are none, so a stale one never remains.
+// Output to stdout has no directory to hold it, so it is an error.
+func (w *Writer) WriteRejects(rejects []interface{}) error {
+	if w.config.Stdout() {
+		return fmt.Errorf("rejected records need an output directory, not stdout")
+	}
 
-	file, err := os.Create(outputPath)
+	file, err := os.Create(filepath.Join(w.outputDir, "rejects.jsonl"))
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return fmt.Errorf("failed to create rejects file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, reject := range rejects {
+		if err := encoder.Encode(reject); err != nil {
+			return fmt.Errorf("failed to write reject: %w", err)
+		}
+	}
+
+	return file.Close()
+}
+
+// writeJSON writes records as a single JSON array
+func (w *Writer) writeJSON(records []interface{}) error {
+	file, err := w.openOutput(os.O_CREATE | os.O_WRONLY | os.O_TRUNC)
+	if err != nil {
+		return err
 	}
 	defer file.Close()
 
@@ -77,16 +168,23 @@ func (w *Writer) writeJSON(records []map[string]interface{}) error {
 		return fmt.Errorf("failed to write JSON: %w", err)
 	}
 
-	return nil
+	return file.Close()
 }
 
-// writeJSONL writes records as JSON Lines (one JSON object per line)
-func (w *Writer) writeJSONL(records []map[string]interface{}) error {
-	outputPath := filepath.Join(w.outputDir, "dataset.jsonl")
+// writeJSONL writes records as JSON Lines (one JSON value per line)
+func (w *Writer) writeJSONL(records []interface{}) error {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if w.config.Append {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	return w.encodeJSONL(records, flags)
+}
 
-	file, err := os.Create(outputPath)
+// encodeJSONL writes records one per line to the dataset opened with flags
+func (w *Writer) encodeJSONL(records []interface{}, flags int) error {
+	file, err := w.openOutput(flags)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return err
 	}
 	defer file.Close()
 
@@ -98,15 +196,211 @@ func (w *Writer) writeJSONL(records []map[string]interface{}) error {
 		}
 	}
 
-	return nil
+	return file.Close()
+}
+
+// writeCSV writes records as CSV, flattening nested objects into dotted
+// column names. The header is the sorted union of columns across all records.
+func (w *Writer) writeCSV(records []interface{}) error {
+	rows := make([]map[string]string, len(records))
+	columnSet := make(map[string]bool)
+	for i, record := range records {
+		row, err := csvRow(record)
+		if err != nil {
+			return fmt.Errorf("failed to flatten record %d: %w", i, err)
+		}
+		for column := range row {
+			columnSet[column] = true
+		}
+		rows[i] = row
+	}
+
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	file, err := w.openOutput(os.O_CREATE | os.O_WRONLY | os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	csvWriter := csv.NewWriter(file)
+	if err := csvWriter.Write(columns); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	line := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			line[i] = row[column]
+		}
+		if err := csvWriter.Write(line); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	return file.Close()
+}
+
+// csvRow flattens a record into cells keyed by column. Nested objects
+// become dotted columns as Flatten names them; arrays are kept whole and
+// serialized as JSON within a single cell, so the paths Flatten walks inside
+// them are skipped. Records that are not objects get a single value column.
+func csvRow(record interface{}) (map[string]string, error) {
+	row := make(map[string]string)
+	obj, ok := record.(map[string]interface{})
+	if !ok {
+		cell, err := csvCell("value", record)
+		if err != nil {
+			return nil, err
+		}
+		row["value"] = cell
+		return row, nil
+	}
+
+	var err error
+	Flatten(obj, func(path string, value interface{}) {
+		if err != nil || strings.Contains(path, "[]") {
+			return
+		}
+		row[path], err = csvCell(path, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return row, nil
 }
 
-// GetOutputPath returns the path where records were written
+// csvCell formats a leaf value for a CSV cell
+func csvCell(column string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", column, err)
+	}
+	return string(encoded), nil
+}
+
+// GetOutputPath returns the path where records were written. For a sharded
+// dataset or one XML file per record it is a glob matching every file.
 func (w *Writer) GetOutputPath() string {
+	if w.config.Stdout() {
+		return config.StdoutDirectory
+	}
+	if sharded(w.config) {
+		return filepath.Join(w.outputDir, strings.Replace(shardName(w.config, 0), "00000", "*", 1))
+	}
+
+	var name string
 	switch w.config.Format {
 	case "json":
-		return filepath.Join(w.outputDir, "dataset.json")
+		name = "dataset.json"
+	case "csv":
+		name = "dataset.csv"
+	case "sql":
+		name = "dataset.sql"
+	case "xml":
+		if w.config.XMLFilePerRecord {
+			name = strings.Replace(xmlRecordName(w.config, 0), "000000", "*", 1)
+		} else {
+			name = "dataset.xml"
+		}
+	case "avro":
+		// Avro compresses blocks inside the file
+		return filepath.Join(w.outputDir, "dataset.avro")
+	case "parquet":
+		// Parquet compresses pages inside the file
+		return filepath.Join(w.outputDir, "dataset.parquet")
 	default:
-		return filepath.Join(w.outputDir, "dataset.jsonl")
+		name = "dataset.jsonl"
+	}
+
+	if w.config.Compress {
+		name += ".gz"
+	}
+
+	return filepath.Join(w.outputDir, name)
+}
+
+// openOutput opens the dataset file, wrapping it in a gzip stream when
+// compression is enabled. Callers must check the error from Close, which
+// flushes any buffered compressed data.
+func (w *Writer) openOutput(flags int) (io.WriteCloser, error) {
+	if w.config.Stdout() {
+		return &stdoutFile{Writer: bufio.NewWriter(os.Stdout)}, nil
+	}
+	return w.openFile(w.GetOutputPath(), flags)
+}
+
+// openUncompressed truncates and opens the dataset for formats that
+// compress inside the file rather than as a whole, such as Avro
+func (w *Writer) openUncompressed() (io.WriteCloser, error) {
+	if w.config.Stdout() {
+		return &stdoutFile{Writer: bufio.NewWriter(os.Stdout)}, nil
+	}
+	file, err := os.OpenFile(w.GetOutputPath(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	return file, nil
+}
+
+// openFile opens a dataset file at path like openOutput
+func (w *Writer) openFile(path string, flags int) (io.WriteCloser, error) {
+	file, err := os.OpenFile(path, flags, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	if !w.config.Compress {
+		return file, nil
+	}
+
+	return &gzipFile{Writer: gzip.NewWriter(file), file: file}, nil
+}
+
+// stdoutFile buffers writes to stdout and flushes them on Close, leaving
+// stdout open for later writes
+type stdoutFile struct {
+	*bufio.Writer
+}
+
+func (s *stdoutFile) Close() error {
+	if err := s.Flush(); err != nil {
+		return fmt.Errorf("failed to write to stdout: %w", err)
+	}
+	return nil
+}
+
+// gzipFile closes the gzip stream before the underlying file
+type gzipFile struct {
+	*gzip.Writer
+	file   *os.File
+	closed bool
+}
+
+func (g *gzipFile) Close() error {
+	if g.closed {
+		return nil
+	}
+	g.closed = true
+
+	if err := g.Writer.Close(); err != nil {
+		g.file.Close()
+		return fmt.Errorf("failed to finish gzip stream: %w", err)
 	}
+	return g.file.Close()
 }
@@ -0,0 +1,95 @@
+package writer
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/specmint/specmint/internal/config"
+)
+
+// readCSV returns the rows of a CSV dataset, header first
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open dataset: %v", err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	return rows
+}
+
+// TestWriteCSV verifies nested objects become the dotted columns Flatten
+// names, arrays stay whole as JSON cells even when they hold objects, and
+// the header is the union of columns across records
+func TestWriteCSV(t *testing.T) {
+	dir := t.TempDir()
+	w, err := New(config.Output{Directory: dir, Format: "csv"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+
+	records := []interface{}{
+		map[string]interface{}{
+			"id":      1.0,
+			"name":    "Ada, Countess",
+			"patient": map[string]interface{}{"address": map[string]interface{}{"city": "Oslo"}, "active": true},
+			"claims":  []interface{}{map[string]interface{}{"amount": 12.5, "codes": []interface{}{"A1"}}},
+			"tags":    []interface{}{"a", "b"},
+		},
+		map[string]interface{}{
+			"id":      2.0,
+			"name":    nil,
+			"patient": map[string]interface{}{"address": map[string]interface{}{"zip": "0150"}},
+			"claims":  []interface{}{},
+		},
+	}
+	if err := w.WriteRecords(records); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+
+	expected := [][]string{
+		{"claims", "id", "name", "patient.active", "patient.address.city", "patient.address.zip", "tags"},
+		{`[{"amount":12.5,"codes":["A1"]}]`, "1", "Ada, Countess", "true", "Oslo", "", `["a","b"]`},
+		{`[]`, "2", "", "", "", "0150", ""},
+	}
+	if rows := readCSV(t, filepath.Join(dir, "dataset.csv")); !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected rows:\n%q\ngot:\n%q", expected, rows)
+	}
+}
+
+// TestWriteCSV_NonObjectRecords verifies records of an array or primitive
+// root get a single value column
+func TestWriteCSV_NonObjectRecords(t *testing.T) {
+	dir := t.TempDir()
+	w, err := New(config.Output{Directory: dir, Format: "csv"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	if err := w.WriteRecords([]interface{}{[]interface{}{1.0, "x"}, "plain", 3.0}); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+
+	expected := [][]string{{"value"}, {`[1,"x"]`}, {"plain"}, {"3"}}
+	if rows := readCSV(t, filepath.Join(dir, "dataset.csv")); !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected rows:\n%q\ngot:\n%q", expected, rows)
+	}
+}
+
+// TestWriteRejects_Stdout verifies rejects are refused rather than dropped
+// when the dataset goes to stdout
+func TestWriteRejects_Stdout(t *testing.T) {
+	w, err := New(config.Output{Directory: config.StdoutDirectory})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	if err := w.WriteRejects([]interface{}{map[string]interface{}{"id": 1.0}}); err == nil {
+		t.Errorf("Expected rejects to need an output directory")
+	}
+}
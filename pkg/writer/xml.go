@@ -0,0 +1,225 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/specmint/specmint/internal/config"
+)
+
+const (
+	defaultXMLRoot   = "records"
+	defaultXMLRecord = "record"
+
+	// xmlArrayItem names the elements of an array nested directly in another
+	// array, which has no key of its own
+	xmlArrayItem = "item"
+)
+
+// xmlNames returns the root and record element names, defaulting unset ones
+func xmlNames(cfg config.Output) (root, record string) {
+	root, record = cfg.XMLRoot, cfg.XMLRecord
+	if root == "" {
+		root = defaultXMLRoot
+	}
+	if record == "" {
+		record = defaultXMLRecord
+	}
+	return root, record
+}
+
+// validateXMLNames rejects root and record element names that are not XML
+// names, before any record is generated
+func validateXMLNames(cfg config.Output) error {
+	root, record := xmlNames(cfg)
+	for _, name := range []string{root, record} {
+		if xmlElementName(name) != name {
+			return fmt.Errorf("invalid XML element name %q", name)
+		}
+	}
+	return nil
+}
+
+// xmlRecordName returns the file name of the nth record, counting from 1,
+// when each record gets its own file
+func xmlRecordName(cfg config.Output, n int) string {
+	_, record := xmlNames(cfg)
+	name := fmt.Sprintf("%s-%06d.xml", record, n)
+	if cfg.Compress {
+		name += ".gz"
+	}
+	return name
+}
+
+// writeXML writes records as XML. Object keys become elements in sorted
+// order, array elements repeat the array's element, and scalars become
+// text. Records are wrapped in a single root element, or each is written to
+// its own file.
+func (w *Writer) writeXML(records []interface{}) error {
+	root, record := xmlNames(w.config)
+	if w.config.XMLFilePerRecord {
+		return w.writeXMLFiles(records, record)
+	}
+
+	file, err := w.openOutput(os.O_CREATE | os.O_WRONLY | os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	out := bufio.NewWriter(file)
+
+	out.WriteString(xml.Header)
+	out.WriteString("<" + root + ">\n")
+	for i, value := range records {
+		if err := writeXMLElement(out, record, value, 1); err != nil {
+			return fmt.Errorf("failed to write record %d: %w", i, err)
+		}
+	}
+	out.WriteString("</" + root + ">\n")
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	return file.Close()
+}
+
+// writeXMLFiles writes each record to its own document, replacing the files
+// of an earlier run
+func (w *Writer) writeXMLFiles(records []interface{}, record string) error {
+	old, err := filepath.Glob(filepath.Join(w.outputDir, record+"-[0-9]*.xml*"))
+	if err != nil {
+		return err
+	}
+	for _, path := range old {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove old record file: %w", err)
+		}
+	}
+
+	for i, value := range records {
+		path := filepath.Join(w.outputDir, xmlRecordName(w.config, i+1))
+		file, err := w.openFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC)
+		if err != nil {
+			return err
+		}
+		out := bufio.NewWriter(file)
+		out.WriteString(xml.Header)
+		err = writeXMLElement(out, record, value, 0)
+		if err == nil {
+			err = out.Flush()
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write record %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// writeXMLElement writes value as an element called name, indented by depth
+func writeXMLElement(out *bufio.Writer, name string, value interface{}, depth int) error {
+	indent := strings.Repeat("  ", depth)
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			out.WriteString(indent + "<" + name + "/>\n")
+			return nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		out.WriteString(indent + "<" + name + ">\n")
+		for _, key := range keys {
+			if err := writeXMLField(out, xmlElementName(key), v[key], depth+1); err != nil {
+				return err
+			}
+		}
+		out.WriteString(indent + "</" + name + ">\n")
+	case []interface{}:
+		// An array on its own, such as a record of an array root, wraps its
+		// elements
+		out.WriteString(indent + "<" + name + ">\n")
+		if err := writeXMLField(out, xmlArrayItem, v, depth+1); err != nil {
+			return err
+		}
+		out.WriteString(indent + "</" + name + ">\n")
+	case nil:
+		out.WriteString(indent + "<" + name + "/>\n")
+	default:
+		text, err := xmlText(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		out.WriteString(indent + "<" + name + ">" + text + "</" + name + ">\n")
+	}
+	return nil
+}
+
+// writeXMLField writes an object field. An array field is written as one
+// element per item, so an empty array leaves no element at all.
+func writeXMLField(out *bufio.Writer, name string, value interface{}, depth int) error {
+	items, ok := value.([]interface{})
+	if !ok {
+		return writeXMLElement(out, name, value, depth)
+	}
+	for _, item := range items {
+		if err := writeXMLElement(out, name, item, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xmlText renders a scalar as escaped element text
+func xmlText(value interface{}) (string, error) {
+	text, ok := value.(string)
+	if !ok {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		text = string(encoded)
+	}
+
+	var escaped strings.Builder
+	if err := xml.EscapeText(&escaped, []byte(text)); err != nil {
+		return "", err
+	}
+	return escaped.String(), nil
+}
+
+// xmlElementName turns a record key into a valid XML element name by
+// replacing characters names cannot contain with underscores and prefixing
+// an underscore when the first character cannot start a name
+func xmlElementName(key string) string {
+	if key == "" {
+		return "_"
+	}
+
+	var name strings.Builder
+	for i, r := range key {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		case i == 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+			name.WriteByte('_')
+		default:
+			r = '_'
+		}
+		name.WriteRune(r)
+	}
+	return name.String()
+}
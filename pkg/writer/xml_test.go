@@ -0,0 +1,113 @@
+package writer
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/specmint/specmint/internal/config"
+)
+
+// TestWriteXML verifies element order, repeated array elements, escaping
+// and renaming of keys that are not XML names
+func TestWriteXML(t *testing.T) {
+	dir := t.TempDir()
+	w, err := New(config.Output{Directory: dir, Format: "xml", XMLRoot: "patients", XMLRecord: "patient"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+
+	records := []interface{}{
+		map[string]interface{}{
+			"name":    `Tom & "Jerry" <MD>`,
+			"age":     42.0,
+			"tags":    []interface{}{"a", "b"},
+			"address": map[string]interface{}{"zip": "0150", "city": "Oslo"},
+			"1st key": true,
+			"note":    nil,
+			"empty":   []interface{}{},
+		},
+	}
+	if err := w.WriteRecords(records); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "dataset.xml"))
+	if err != nil {
+		t.Fatalf("Failed to read dataset: %v", err)
+	}
+	expected := xml.Header + `<patients>
+  <patient>
+    <_1st_key>true</_1st_key>
+    <address>
+      <city>Oslo</city>
+      <zip>0150</zip>
+    </address>
+    <age>42</age>
+    <name>Tom &amp; &#34;Jerry&#34; &lt;MD&gt;</name>
+    <note/>
+    <tags>a</tags>
+    <tags>b</tags>
+  </patient>
+</patients>
+`
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	// The output must be well-formed
+	decoder := xml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			if err != io.EOF {
+				t.Errorf("Output is not well-formed XML: %v", err)
+			}
+			break
+		}
+	}
+}
+
+// TestWriteXML_FilePerRecord verifies each record gets its own document and
+// a smaller rerun leaves no stale files
+func TestWriteXML_FilePerRecord(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Output{Directory: dir, Format: "xml", XMLFilePerRecord: true}
+	for _, count := range []int{3, 2} {
+		w, err := New(cfg)
+		if err != nil {
+			t.Fatalf("Failed to create writer: %v", err)
+		}
+		records := make([]interface{}, count)
+		for i := range records {
+			records[i] = map[string]interface{}{"id": float64(i)}
+		}
+		if err := w.WriteRecords(records); err != nil {
+			t.Fatalf("Failed to write records: %v", err)
+		}
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "record-*.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("Expected 2 record files, got %v", paths)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "record-000002.xml"))
+	if err != nil {
+		t.Fatalf("Failed to read record: %v", err)
+	}
+	if expected := xml.Header + "<record>\n  <id>1</id>\n</record>\n"; string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+}
+
+// TestWriteXML_InvalidName verifies element names are checked up front
+func TestWriteXML_InvalidName(t *testing.T) {
+	if _, err := New(config.Output{Directory: t.TempDir(), Format: "xml", XMLRecord: "my record"}); err == nil {
+		t.Error("Expected an invalid record element name to be rejected")
+	}
+}